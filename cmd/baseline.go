@@ -0,0 +1,314 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/compose-diff/internal/baseline"
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/parser"
+	"github.com/stackgen-cli/compose-diff/internal/reporter"
+	"github.com/stackgen-cli/compose-diff/pkg/composediff"
+)
+
+var baselineFormat string
+
+var baselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "Manage saved baselines",
+	Long: `List, inspect, delete and rename the baselines in .compose-diff/. Baselines
+are saved with "diff --save-baseline" and compared with "diff --baseline".
+
+Saving a baseline again keeps the previous snapshot in its history. Refer to
+earlier snapshots as name~N (N saves before the current one) or name@date
+(the latest snapshot saved by then), e.g. "diff --baseline prod~2" or
+"baseline show prod@2024-05-01".`,
+}
+
+var baselineListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved baselines",
+	Args:  cobra.NoArgs,
+	Run:   runBaselineList,
+}
+
+var baselineShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Show a baseline's details and the services it holds",
+	Long: `Show when a baseline was saved, from which file, whether it was resolved
+with docker compose config, and the services, volumes and networks it holds.
+With --format json the whole baseline is printed, including its data.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runBaselineShow,
+}
+
+var baselineHistoryCmd = &cobra.Command{
+	Use:   "history <name>",
+	Short: "Show the changes between successive snapshots of a baseline",
+	Long: `List the snapshots of a baseline, oldest first, with the changes each one
+made to the one before it.
+
+Examples:
+  compose-diff baseline history prod
+  compose-diff baseline history --format json prod > prod-history.json`,
+	Args: cobra.ExactArgs(1),
+	Run:  runBaselineHistory,
+}
+
+var baselineDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a baseline and its history",
+	Args:  cobra.ExactArgs(1),
+	Run:   runBaselineDelete,
+}
+
+var baselineRenameCmd = &cobra.Command{
+	Use:   "rename <old> <new>",
+	Short: "Rename a baseline",
+	Args:  cobra.ExactArgs(2),
+	Run:   runBaselineRename,
+}
+
+func init() {
+	baselineListCmd.Flags().StringVarP(&baselineFormat, "format", "f", "text", "Output format: text, json")
+	baselineShowCmd.Flags().StringVarP(&baselineFormat, "format", "f", "text", "Output format: text, json")
+	baselineHistoryCmd.Flags().StringVarP(&baselineFormat, "format", "f", "text", "Output format: text, json")
+
+	baselineCmd.AddCommand(baselineListCmd, baselineShowCmd, baselineHistoryCmd, baselineDeleteCmd, baselineRenameCmd)
+	rootCmd.AddCommand(baselineCmd)
+}
+
+// baselineInfo is a baseline without its data, for listings
+type baselineInfo struct {
+	Name      string            `json:"name"`
+	CreatedAt time.Time         `json:"created_at"`
+	Source    string            `json:"source"`
+	Resolved  bool              `json:"resolved"`
+	Services  []string          `json:"services"`
+	Snapshots int               `json:"snapshots,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+func newBaselineInfo(b *baseline.Baseline) baselineInfo {
+	return baselineInfo{
+		Name:      b.Name,
+		CreatedAt: b.CreatedAt,
+		Source:    b.Source,
+		Resolved:  b.Resolved,
+		Services:  sectionKeys(b.Data, "services"),
+		Metadata:  b.Metadata,
+	}
+}
+
+func runBaselineList(cmd *cobra.Command, args []string) {
+	baselines, err := baseline.NewManager(".compose-diff").List()
+	if err != nil {
+		color.Red("Error listing baselines: %v", err)
+		os.Exit(2)
+	}
+
+	mgr := baseline.NewManager(".compose-diff")
+	infos := make([]baselineInfo, 0, len(baselines))
+	for _, b := range baselines {
+		info := newBaselineInfo(b)
+		if history, err := mgr.History(b.Name); err == nil {
+			info.Snapshots = len(history)
+		}
+		infos = append(infos, info)
+	}
+
+	switch baselineFormat {
+	case "json":
+		printBaselineJSON(infos)
+	case "text":
+		if len(infos) == 0 {
+			fmt.Println("No baselines saved. Save one with: compose-diff diff --save-baseline <name> <compose-file>")
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tCREATED\tSOURCE\tRESOLVED\tSERVICES\tSNAPSHOTS")
+		for _, info := range infos {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\n", info.Name, info.CreatedAt.Local().Format("2006-01-02 15:04"), info.Source, yesNo(info.Resolved), len(info.Services), info.Snapshots)
+		}
+		w.Flush()
+	default:
+		color.Red("Unknown format: %s (use text or json)", baselineFormat)
+		os.Exit(2)
+	}
+}
+
+func runBaselineShow(cmd *cobra.Command, args []string) {
+	b := loadBaseline(args[0])
+
+	switch baselineFormat {
+	case "json":
+		printBaselineJSON(b)
+	case "text":
+		info := newBaselineInfo(b)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "Name:\t%s\n", info.Name)
+		fmt.Fprintf(w, "Created:\t%s\n", info.CreatedAt.Local().Format("2006-01-02 15:04:05 MST"))
+		fmt.Fprintf(w, "Source:\t%s\n", info.Source)
+		fmt.Fprintf(w, "Resolved:\t%s\n", yesNo(info.Resolved))
+		keys := make([]string, 0, len(info.Metadata))
+		for k := range info.Metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(w, "%s:\t%s\n", k, info.Metadata[k])
+		}
+		for _, section := range []string{"services", "volumes", "networks"} {
+			names := sectionKeys(b.Data, section)
+			if len(names) > 0 {
+				fmt.Fprintf(w, "%s%s (%d):\t%s\n", strings.ToUpper(section[:1]), section[1:], len(names), strings.Join(names, ", "))
+			}
+		}
+		w.Flush()
+	default:
+		color.Red("Unknown format: %s (use text or json)", baselineFormat)
+		os.Exit(2)
+	}
+}
+
+// baselineStep is one snapshot in a baseline's history and its changes from
+// the snapshot before
+type baselineStep struct {
+	Ref       string          `json:"ref"`
+	CreatedAt time.Time       `json:"created_at"`
+	Source    string          `json:"source"`
+	Resolved  bool            `json:"resolved"`
+	Changes   []models.Change `json:"changes"` // empty for the first snapshot
+}
+
+func runBaselineHistory(cmd *cobra.Command, args []string) {
+	name := args[0]
+	loadBaseline(name)
+	history, err := baseline.NewManager(".compose-diff").History(name)
+	if err != nil {
+		color.Red("Error loading history of '%s': %v", name, err)
+		os.Exit(2)
+	}
+
+	steps := make([]baselineStep, len(history))
+	var prev *models.ComposeIR
+	for i, b := range history {
+		ir, err := parser.ParseFromMap(b.Data)
+		if err != nil {
+			color.Red("Error parsing snapshot of %s: %v", b.CreatedAt.Local().Format("2006-01-02 15:04"), err)
+			os.Exit(2)
+		}
+		ref := name
+		if n := len(history) - 1 - i; n > 0 {
+			ref = fmt.Sprintf("%s~%d", name, n)
+		}
+		steps[i] = baselineStep{Ref: ref, CreatedAt: b.CreatedAt, Source: b.Source, Resolved: b.Resolved, Changes: []models.Change{}}
+		if prev != nil {
+			steps[i].Changes = composediff.Compare(prev, ir, composediff.Options{}).Changes
+		}
+		prev = ir
+	}
+
+	switch baselineFormat {
+	case "json":
+		printBaselineJSON(steps)
+	case "text":
+		for i, step := range steps {
+			summary := "first snapshot"
+			if i > 0 {
+				summary = fmt.Sprintf("%d changes", len(step.Changes))
+			}
+			fmt.Printf("%s  %s  %s (%s)\n", color.CyanString(step.Ref), step.CreatedAt.Local().Format("2006-01-02 15:04"), step.Source, summary)
+			for _, c := range step.Changes {
+				fmt.Printf("  %s (%s)\n", reporter.ChangeLine(c), severityLabel(c.Severity))
+			}
+			if len(step.Changes) > 0 {
+				fmt.Println()
+			}
+		}
+	default:
+		color.Red("Unknown format: %s (use text or json)", baselineFormat)
+		os.Exit(2)
+	}
+}
+
+func runBaselineDelete(cmd *cobra.Command, args []string) {
+	if err := requireOnline("baseline delete (writes to .compose-diff/)"); err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(2)
+	}
+	mgr := baseline.NewManager(".compose-diff")
+	if !mgr.Exists(args[0]) {
+		color.Red("No baseline named '%s' (see compose-diff baseline list)", args[0])
+		os.Exit(2)
+	}
+	if err := mgr.Delete(args[0]); err != nil {
+		color.Red("Error deleting baseline: %v", err)
+		os.Exit(2)
+	}
+	color.Green("Deleted baseline '%s'", args[0])
+}
+
+func runBaselineRename(cmd *cobra.Command, args []string) {
+	if err := requireOnline("baseline rename (writes to .compose-diff/)"); err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(2)
+	}
+	if !baseline.NewManager(".compose-diff").Exists(args[0]) {
+		color.Red("No baseline named '%s' (see compose-diff baseline list)", args[0])
+		os.Exit(2)
+	}
+	if err := baseline.NewManager(".compose-diff").Rename(args[0], args[1]); err != nil {
+		color.Red("Error renaming baseline: %v", err)
+		os.Exit(2)
+	}
+	color.Green("Renamed baseline '%s' to '%s'", args[0], args[1])
+}
+
+// loadBaseline loads the baseline snapshot a reference such as prod or
+// prod~2 names, or exits
+func loadBaseline(ref string) *baseline.Baseline {
+	b, err := baseline.NewManager(".compose-diff").Resolve(ref)
+	if os.IsNotExist(err) {
+		color.Red("No baseline named '%s' (see compose-diff baseline list)", ref)
+		os.Exit(2)
+	} else if err != nil {
+		color.Red("Error loading baseline '%s': %v", ref, err)
+		os.Exit(2)
+	}
+	return b
+}
+
+func printBaselineJSON(v any) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		color.Red("Error generating JSON: %v", err)
+		os.Exit(2)
+	}
+	fmt.Println(string(data))
+}
+
+// sectionKeys returns the sorted entry names of a top-level compose section
+func sectionKeys(data map[string]any, section string) []string {
+	entries, _ := data[section].(map[string]any)
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/stackgen-cli/compose-diff/internal/bundle"
+	"github.com/stackgen-cli/compose-diff/internal/cache"
+	"github.com/stackgen-cli/compose-diff/internal/hints"
+)
+
+// policyBundle is the --policy-bundle OCI reference shared by commands that load rules
+var policyBundle string
+
+// usePolicyBundle pulls the policy bundle, registers its hints and returns
+// the path of its rules file
+func usePolicyBundle(ref string) (string, error) {
+	parsed, err := bundle.ParseReference(ref)
+	if err != nil {
+		return "", err
+	}
+
+	httpClient, err := newHTTPClient()
+	if err != nil {
+		return "", err
+	}
+	client := bundle.NewClient(cache.BundlesDir())
+	client.HTTP = httpClient
+	client.Credentials = bundle.DockerCredentials(credHelper)
+	client.Offline = offline
+	task := newProgress().Start("Pulling policy bundle " + parsed.String())
+	b, err := client.Pull(parsed)
+	if err != nil {
+		task.Fail(err)
+		return "", err
+	}
+	task.Done()
+
+	// Status goes to stderr so JSON and markdown output stay clean
+	fmt.Fprintf(os.Stderr, "Using policy bundle %s (%s)\n", parsed, b.Digest)
+	if !parsed.Pinned() {
+		fmt.Fprintf(os.Stderr, "Pin it for reproducible results: --policy-bundle %s/%s@%s\n",
+			parsed.Registry, parsed.Repository, b.Digest)
+	}
+
+	if path := b.HintsPath(); path != "" {
+		hs, err := hints.LoadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("policy bundle hints: %w", err)
+		}
+		hints.Register(hs...)
+	}
+
+	path := b.RulesPath()
+	if path == "" {
+		return "", fmt.Errorf("policy bundle %s has no rules.yaml", parsed)
+	}
+	return path, nil
+}
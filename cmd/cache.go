@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/compose-diff/internal/cache"
+	"github.com/stackgen-cli/compose-diff/internal/parser"
+)
+
+var (
+	cacheWarmResolve []string
+	cacheWarmBundles []string
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Provision the cache used by --offline runs",
+	Long: `Manage the cache of resolved compose configs and policy bundles, so
+air-gapped runners can run with --offline.
+
+The cache lives in the user cache directory (override with $` + cache.EnvDir + `).
+
+Examples:
+  compose-diff cache warm --resolve docker-compose.yml --policy-bundle ghcr.io/org/policies@sha256:...
+  compose-diff cache export cache.tar.gz
+  compose-diff cache import cache.tar.gz`,
+}
+
+var cacheWarmCmd = &cobra.Command{
+	Use:   "warm",
+	Short: "Resolve compose files and pull policy bundles into the cache",
+	Args:  cobra.NoArgs,
+	Run:   runCacheWarm,
+}
+
+var cacheExportCmd = &cobra.Command{
+	Use:   "export <archive.tar.gz>",
+	Short: "Write the cache to a reproducible tarball",
+	Args:  cobra.ExactArgs(1),
+	Run:   runCacheExport,
+}
+
+var cacheImportCmd = &cobra.Command{
+	Use:   "import <archive.tar.gz>",
+	Short: "Load a tarball written by cache export",
+	Args:  cobra.ExactArgs(1),
+	Run:   runCacheImport,
+}
+
+var cacheDirCmd = &cobra.Command{
+	Use:   "dir",
+	Short: "Print the cache directory",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println(cache.Dir())
+	},
+}
+
+func init() {
+	cacheWarmCmd.Flags().StringArrayVar(&cacheWarmResolve, "resolve", nil, "Compose file to resolve with docker compose config (repeatable)")
+	cacheWarmCmd.Flags().StringArrayVar(&cacheWarmBundles, "policy-bundle", nil, "Policy bundle to pull (repeatable)")
+
+	cacheCmd.AddCommand(cacheWarmCmd)
+	cacheCmd.AddCommand(cacheExportCmd)
+	cacheCmd.AddCommand(cacheImportCmd)
+	cacheCmd.AddCommand(cacheDirCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+func runCacheWarm(cmd *cobra.Command, args []string) {
+	if err := requireOnline("cache warm"); err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(2)
+	}
+	if len(cacheWarmResolve) == 0 && len(cacheWarmBundles) == 0 {
+		color.Red("Nothing to warm: pass --resolve and/or --policy-bundle")
+		os.Exit(2)
+	}
+
+	for _, file := range cacheWarmResolve {
+		path, err := parser.ResolveComposePath(file)
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(2)
+		}
+		if _, err := resolveCompose(path); err != nil {
+			color.Red("Error resolving %s: %v", path, err)
+			os.Exit(2)
+		}
+		color.Green("Cached resolved config for %s", path)
+	}
+
+	for _, ref := range cacheWarmBundles {
+		if _, err := usePolicyBundle(ref); err != nil {
+			color.Red("Error pulling %s: %v", ref, err)
+			os.Exit(2)
+		}
+	}
+}
+
+func runCacheExport(cmd *cobra.Command, args []string) {
+	f, err := os.Create(args[0])
+	if err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(2)
+	}
+
+	count, err := cache.Export(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		color.Red("Error exporting cache: %v", err)
+		os.Exit(2)
+	}
+
+	color.Green("Exported %d cached files to %s", count, args[0])
+}
+
+func runCacheImport(cmd *cobra.Command, args []string) {
+	f, err := os.Open(args[0])
+	if err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(2)
+	}
+	defer f.Close()
+
+	count, err := cache.Import(f)
+	if err != nil {
+		color.Red("Error importing cache: %v", err)
+		os.Exit(2)
+	}
+
+	color.Green("Imported %d cached files into %s", count, cache.Dir())
+}
@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/compose-diff/internal/forge"
+)
+
+var (
+	commentPR     int
+	commentRepo   string
+	commentAPIURL string
+	commentKey    string
+	commentGitLab bool
+	forgePlatform string
+)
+
+// Largest comment bodies the platforms accept
+const (
+	githubCommentLimit = 65536
+	gitlabNoteLimit    = 1000000
+)
+
+var commentCmd = &cobra.Command{
+	Use:   "comment [report.md]",
+	Short: "Post a markdown report as a sticky pull request comment",
+	Long: `Post a markdown report (from a file, or stdin) as a pull request comment on
+GitHub or Bitbucket, a merge request note on GitLab, or a pull request thread
+on Azure DevOps. The comment is updated in place on later runs instead of
+adding a new one.
+
+The platform follows the CI system (GitLab CI, Bitbucket Pipelines, Azure
+Pipelines, otherwise GitHub) unless --platform is given. The token is read
+from GITHUB_TOKEN (or GH_TOKEN), GITLAB_TOKEN, BITBUCKET_TOKEN (or
+BITBUCKET_USERNAME and BITBUCKET_APP_PASSWORD), or SYSTEM_ACCESSTOKEN (or
+AZURE_DEVOPS_EXT_PAT). In CI pull request builds --repo, --pr and --api-url
+default to the current project and pull request.
+
+Examples:
+  compose-diff diff --format markdown --max-comment-bytes 65000 old.yml new.yml > report.md
+  compose-diff comment --repo acme/shop --pr 42 report.md
+
+  # One comment per compose file in a monorepo
+  compose-diff comment --key worker report-worker.md
+
+  # GitLab merge request note
+  compose-diff comment --gitlab --repo acme/shop --pr 42 report.md
+
+  # Azure DevOps pull request thread, --repo is project/repository
+  compose-diff comment --platform azure --repo Shop/shop --pr 42 report.md`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runComment,
+}
+
+func init() {
+	commentCmd.Flags().IntVar(&commentPR, "pr", 0, "Pull/merge request number (default: from GITHUB_REF, or CI_MERGE_REQUEST_IID)")
+	commentCmd.Flags().StringVar(&commentRepo, "repo", "", "Repository as owner/name, GitLab project path or ID, Bitbucket workspace/slug or Azure project/repository (default: from the CI environment)")
+	commentCmd.Flags().StringVar(&commentAPIURL, "api-url", "", "API URL, for GitHub Enterprise or self-managed GitLab, or the Azure DevOps organization URL (default: from the CI environment)")
+	commentCmd.Flags().StringVar(&commentKey, "key", "", "Keeps a separate sticky comment per key on the same pull request")
+	commentCmd.Flags().BoolVar(&commentGitLab, "gitlab", false, "Post a GitLab merge request note (same as --platform gitlab)")
+	commentCmd.Flags().StringVar(&forgePlatform, "platform", "", "github, gitlab, bitbucket or azure (default: detected from the CI environment)")
+
+	rootCmd.AddCommand(commentCmd)
+}
+
+func runComment(cmd *cobra.Command, args []string) {
+	if err := requireOnline("comment (posts to the code review platform's API)"); err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(2)
+	}
+
+	switch platform := detectPlatform(); platform {
+	case "github":
+		commentOnGitHub(args)
+	case "gitlab":
+		commentOnGitLab(args)
+	case "bitbucket":
+		commentOnBitbucket(args)
+	case "azure":
+		commentOnAzure(args)
+	default:
+		color.Red("Error: unknown --platform %q (use github, gitlab, bitbucket or azure)", platform)
+		os.Exit(2)
+	}
+}
+
+func commentOnGitHub(args []string) {
+	repo := valueOr(commentRepo, os.Getenv("GITHUB_REPOSITORY"))
+	pr := commentPR
+	if pr == 0 {
+		pr = prFromRef(os.Getenv("GITHUB_REF"))
+	}
+	if repo == "" || pr == 0 {
+		color.Red("Usage: compose-diff comment --repo owner/name --pr <number> [report.md]")
+		os.Exit(2)
+	}
+
+	token := valueOr(os.Getenv("GITHUB_TOKEN"), os.Getenv("GH_TOKEN"))
+	if token == "" {
+		color.Red("Error: set GITHUB_TOKEN (or GH_TOKEN) to a token that can write pull request comments")
+		os.Exit(2)
+	}
+
+	body := readCommentBody(args)
+	if len(body) > githubCommentLimit-100 {
+		color.Red("Error: report is %d bytes, over GitHub's comment limit; render it with --max-comment-bytes 65000", len(body))
+		os.Exit(2)
+	}
+
+	gh := &forge.GitHub{Client: commentClient(), BaseURL: valueOr(commentAPIURL, githubAPIURL()), Token: token}
+	url, err := gh.UpsertPRComment(repo, pr, commentKey, body)
+	if err != nil {
+		color.Red("Error posting comment: %v", err)
+		os.Exit(2)
+	}
+	color.Green("Posted %s", url)
+}
+
+func commentOnGitLab(args []string) {
+	project := valueOr(commentRepo, os.Getenv("CI_PROJECT_PATH"))
+	mr := commentPR
+	if mr == 0 {
+		mr, _ = strconv.Atoi(os.Getenv("CI_MERGE_REQUEST_IID"))
+	}
+	if project == "" || mr == 0 {
+		color.Red("Usage: compose-diff comment --gitlab --repo group/project --pr <number> [report.md]")
+		os.Exit(2)
+	}
+
+	token := os.Getenv("GITLAB_TOKEN")
+	if token == "" {
+		color.Red("Error: set GITLAB_TOKEN to an access token with api scope (CI_JOB_TOKEN cannot write notes)")
+		os.Exit(2)
+	}
+
+	body := readCommentBody(args)
+	if len(body) > gitlabNoteLimit-100 {
+		color.Red("Error: report is %d bytes, over GitLab's note limit; render it with --max-comment-bytes", len(body))
+		os.Exit(2)
+	}
+
+	gl := &forge.GitLab{Client: commentClient(), BaseURL: valueOr(commentAPIURL, gitlabAPIURL()), Token: token}
+	id, err := gl.UpsertMRNote(project, mr, commentKey, body)
+	if err != nil {
+		color.Red("Error posting note: %v", err)
+		os.Exit(2)
+	}
+	color.Green("Posted note %d on %s!%d", id, project, mr)
+}
+
+func commentOnBitbucket(args []string) {
+	repo := valueOr(commentRepo, os.Getenv("BITBUCKET_REPO_FULL_NAME"))
+	pr := commentPR
+	if pr == 0 {
+		pr, _ = strconv.Atoi(os.Getenv("BITBUCKET_PR_ID"))
+	}
+	if repo == "" || pr == 0 {
+		color.Red("Usage: compose-diff comment --platform bitbucket --repo workspace/slug --pr <number> [report.md]")
+		os.Exit(2)
+	}
+
+	bb := bitbucketClient()
+	url, err := bb.UpsertPRComment(repo, pr, commentKey, readCommentBody(args))
+	if err != nil {
+		color.Red("Error posting comment: %v", err)
+		os.Exit(2)
+	}
+	color.Green("Posted %s", url)
+}
+
+func commentOnAzure(args []string) {
+	project, repo := azureRepo()
+	pr := commentPR
+	if pr == 0 {
+		pr, _ = strconv.Atoi(os.Getenv("SYSTEM_PULLREQUEST_PULLREQUESTID"))
+	}
+	if project == "" || repo == "" || pr == 0 {
+		color.Red("Usage: compose-diff comment --platform azure --repo project/repository --pr <number> [report.md]")
+		os.Exit(2)
+	}
+
+	az := azureClient()
+	id, err := az.UpsertPRThread(project, repo, pr, commentKey, readCommentBody(args))
+	if err != nil {
+		color.Red("Error posting thread: %v", err)
+		os.Exit(2)
+	}
+	color.Green("Posted thread %d on %s/%s!%d", id, project, repo, pr)
+}
+
+// detectPlatform returns the --platform, or the code review platform of the
+// CI system we run in
+func detectPlatform() string {
+	switch {
+	case forgePlatform != "":
+		return forgePlatform
+	case commentGitLab, os.Getenv("GITLAB_CI") != "":
+		return "gitlab"
+	case os.Getenv("BITBUCKET_BUILD_NUMBER") != "":
+		return "bitbucket"
+	case os.Getenv("TF_BUILD") != "":
+		return "azure"
+	}
+	return "github"
+}
+
+func bitbucketClient() *forge.Bitbucket {
+	bb := &forge.Bitbucket{Client: commentClient(), BaseURL: valueOr(commentAPIURL, forge.DefaultBitbucketAPI)}
+	if user := os.Getenv("BITBUCKET_USERNAME"); user != "" {
+		bb.Username, bb.Token = user, os.Getenv("BITBUCKET_APP_PASSWORD")
+	} else {
+		bb.Token = os.Getenv("BITBUCKET_TOKEN")
+	}
+	if bb.Token == "" {
+		color.Red("Error: set BITBUCKET_TOKEN to a repository access token, or BITBUCKET_USERNAME and BITBUCKET_APP_PASSWORD")
+		os.Exit(2)
+	}
+	return bb
+}
+
+func azureClient() *forge.AzureDevOps {
+	org := valueOr(commentAPIURL, os.Getenv("SYSTEM_COLLECTIONURI"))
+	if org == "" {
+		color.Red("Error: set --api-url to the organization URL, e.g. https://dev.azure.com/acme")
+		os.Exit(2)
+	}
+	token := valueOr(os.Getenv("SYSTEM_ACCESSTOKEN"), os.Getenv("AZURE_DEVOPS_EXT_PAT"))
+	if token == "" {
+		color.Red("Error: set SYSTEM_ACCESSTOKEN (map it from $(System.AccessToken)) or AZURE_DEVOPS_EXT_PAT")
+		os.Exit(2)
+	}
+	return &forge.AzureDevOps{Client: commentClient(), BaseURL: org, Token: token}
+}
+
+// azureRepo returns the project and repository from --repo, or from the
+// Azure Pipelines environment
+func azureRepo() (project, repo string) {
+	if commentRepo != "" {
+		project, repo, _ = strings.Cut(commentRepo, "/")
+		return project, repo
+	}
+	return os.Getenv("SYSTEM_TEAMPROJECT"), os.Getenv("BUILD_REPOSITORY_NAME")
+}
+
+func readCommentBody(args []string) string {
+	body, err := readReport(args)
+	if err != nil {
+		color.Red("Error reading report: %v", err)
+		os.Exit(2)
+	}
+	return string(body)
+}
+
+func commentClient() *http.Client {
+	client, err := newHTTPClient()
+	if err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(2)
+	}
+	return client
+}
+
+// valueOr returns value, or fallback if value is empty
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// readReport reads the report from the named file, or stdin if none or "-"
+func readReport(args []string) ([]byte, error) {
+	if len(args) == 0 || args[0] == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(args[0])
+}
+
+var pullRefPattern = regexp.MustCompile(`^refs/pull/(\d+)/`)
+
+// prFromRef extracts the pull request number from a GitHub Actions ref
+// such as refs/pull/42/merge
+func prFromRef(ref string) int {
+	m := pullRefPattern.FindStringSubmatch(ref)
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+func githubAPIURL() string {
+	return valueOr(os.Getenv("GITHUB_API_URL"), forge.DefaultGitHubAPI)
+}
+
+func gitlabAPIURL() string {
+	return valueOr(os.Getenv("CI_API_V4_URL"), forge.DefaultGitLabAPI)
+}
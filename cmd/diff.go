@@ -6,15 +6,24 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"github.com/stackgen-cli/compose-diff/internal/baseline"
+	"github.com/stackgen-cli/compose-diff/internal/cache"
 	"github.com/stackgen-cli/compose-diff/internal/diff"
+	"github.com/stackgen-cli/compose-diff/internal/impact"
+	"github.com/stackgen-cli/compose-diff/internal/lint"
 	"github.com/stackgen-cli/compose-diff/internal/models"
 	"github.com/stackgen-cli/compose-diff/internal/parser"
+	"github.com/stackgen-cli/compose-diff/internal/recreate"
+	"github.com/stackgen-cli/compose-diff/internal/redact"
+	"github.com/stackgen-cli/compose-diff/internal/rego"
 	"github.com/stackgen-cli/compose-diff/internal/reporter"
+	"github.com/stackgen-cli/compose-diff/internal/risk"
 	"github.com/stackgen-cli/compose-diff/internal/rules"
+	"github.com/stackgen-cli/compose-diff/pkg/composediff"
 	"gopkg.in/yaml.v3"
 )
 
@@ -22,6 +31,7 @@ var (
 	formatFlag       string
 	serviceFilter    string
 	severityMin      string
+	kindFilter       []string
 	strictMode       bool
 	normalizeOn      bool
 	rulesFile        string
@@ -30,6 +40,15 @@ var (
 	resolveConfig    bool
 	categoryMode     bool
 	categoryDetail   bool
+	checklistMode    bool
+	maxCommentBytes  int
+	artifactURL      string
+	profileFlags     []string
+	expandEnvFiles   bool
+	lenientParse     bool
+	regoPolicy       string
+	showSecrets      bool
+	orderedLists     []string
 )
 
 var diffCmd = &cobra.Command{
@@ -40,8 +59,19 @@ var diffCmd = &cobra.Command{
 Examples:
   compose-diff diff docker-compose.old.yml docker-compose.new.yml
   compose-diff diff --format json old.yml new.yml
+  compose-diff diff --format html old.yml new.yml > report.html
+  compose-diff diff --format github old.yml new.yml   # inline PR annotations in Actions
+  compose-diff diff --format gitlab-codequality old.yml new.yml > gl-code-quality-report.json
   compose-diff diff --service api old.yml new.yml
+  compose-diff diff --kind removed --kind renamed old.yml new.yml
   compose-diff diff --strict old.yml new.yml
+  compose-diff diff --fail-on warning old.yml new.yml
+  compose-diff diff --fail-on-score 50 old.yml new.yml   # gate on the weighted risk score
+  compose-diff diff --exit-code old.yml new.yml   # 0 none, 1 changes, 2 breaking, 3 error
+  compose-diff diff --format markdown --checklist old.yml new.yml
+  compose-diff diff --output report.md --output report.json old.yml new.yml
+  compose-diff diff -o gitlab-codequality=gl-code-quality-report.json old.yml new.yml
+  compose-diff diff --format template --template slack.gotmpl old.yml new.yml
   
   # Use resolved config (interpolated)
   compose-diff diff --resolve old.yml new.yml
@@ -50,49 +80,104 @@ Examples:
   compose-diff diff --baseline production new.yml
   compose-diff diff --save-baseline production docker-compose.yml
   
+  # Baseline per commit, then compare against the latest one from main
+  compose-diff diff --save-baseline auto docker-compose.yml
+  compose-diff diff --baseline git:main docker-compose.yml
+  
+  # Skip unreadable entries of generated files instead of failing
+  compose-diff diff --lenient old.yml new.yml
+
+  # Only services docker compose would start with --profile debug
+  compose-diff diff --profile debug old.yml new.yml
+
   # Category summary
   compose-diff diff --category old.yml new.yml
   compose-diff diff --category-detail old.yml new.yml
   
   # Use custom rules file
-  compose-diff diff --rules .compose-diff.yaml old.yml new.yml`,
+  compose-diff diff --rules .compose-diff.yaml old.yml new.yml
+
+  # Let a Rego policy deny the diff or reclassify changes
+  compose-diff diff --policy policy.rego old.yml new.yml`,
 	Args: cobra.RangeArgs(1, 2),
 	Run:  runDiff,
 }
 
 func init() {
-	diffCmd.Flags().StringVarP(&formatFlag, "format", "f", "text", "Output format: text, json, markdown")
+	diffCmd.Flags().StringVarP(&formatFlag, "format", "f", "text", "Output format: text, json, markdown, html, github, gitlab-codequality, csv, tsv, patch, review-json, template")
+	diffCmd.Flags().StringVar(&templateFile, "template", "", "Go text/template file for --format template")
 	diffCmd.Flags().StringVarP(&serviceFilter, "service", "s", "", "Filter to specific service")
+	diffCmd.Flags().StringSliceVar(&kindFilter, "kind", nil, "Only report changes of these kinds: added, removed, modified, reordered, renamed (repeatable)")
 	diffCmd.Flags().StringVar(&severityMin, "severity", "info", "Minimum severity: info, warning, breaking")
 	diffCmd.Flags().BoolVar(&strictMode, "strict", false, "Exit 1 if breaking changes detected")
+	diffCmd.Flags().StringVar(&failOn, "fail-on", "", "Exit 1 if any change is at or above this severity: info, warning, breaking")
+	diffCmd.Flags().IntVar(&failOnScore, "fail-on-score", 0, "Exit 1 if the risk score is at or above N (0 to disable)")
+	diffCmd.Flags().BoolVar(&exitCodeMode, "exit-code", false, "Exit 0 for no changes, 1 for changes, 2 for breaking changes, 3 for errors")
+	diffCmd.Flags().StringVar(&nowFlag, "now", "", "Check freeze windows at this time (RFC 3339 or YYYY-MM-DD) instead of now, e.g. for emergency deploys")
 	diffCmd.Flags().BoolVar(&normalizeOn, "normalize", true, "Normalize configs before diff")
+	diffCmd.Flags().StringSliceVar(&orderedLists, "ordered-lists", nil, "List fields compared by position rather than as sets (default: command,entrypoint,env_file,dns_search)")
 
 	// New flags
 	diffCmd.Flags().StringVar(&rulesFile, "rules", "", "Path to rules file (default: .compose-diff.yaml)")
-	diffCmd.Flags().StringVar(&baselineFlag, "baseline", "", "Compare against saved baseline")
-	diffCmd.Flags().StringVar(&saveBaseline, "save-baseline", "", "Save current config as baseline")
+	diffCmd.Flags().StringVar(&policyBundle, "policy-bundle", "", "OCI reference of a rules/hints bundle (e.g. ghcr.io/org/policies:v3 or @sha256:...)")
+	diffCmd.Flags().StringVar(&regoPolicy, "policy", "", "Rego policy (package compose_diff) that may deny the diff, reclassify or annotate changes; needs the opa CLI")
+	diffCmd.Flags().StringVar(&baselineFlag, "baseline", "", "Compare against saved baseline (name, name~N, name@date, or git:branch for the latest auto baseline of a branch)")
+	diffCmd.Flags().StringVar(&saveBaseline, "save-baseline", "", "Save current config as baseline, keeping earlier snapshots in its history (auto: name it after the git branch and commit)")
 	diffCmd.Flags().BoolVar(&resolveConfig, "resolve", false, "Use docker compose config resolved output")
 	diffCmd.Flags().BoolVar(&categoryMode, "category", false, "Show category summary report")
 	diffCmd.Flags().BoolVar(&categoryDetail, "category-detail", false, "Show detailed category report")
+	diffCmd.Flags().BoolVar(&checklistMode, "checklist", false, "Append a review checklist for breaking changes (markdown/text)")
+	diffCmd.Flags().IntVar(&maxCommentBytes, "max-comment-bytes", 0, "Shrink markdown output to fit this many bytes (e.g. 65536 for GitHub)")
+	diffCmd.Flags().BoolVar(&expandEnvFiles, "expand-env-files", false, "Read env_file contents into environment so changes inside them are diffed")
+	diffCmd.Flags().BoolVar(&validateFirst, "validate", false, "Validate the files against the Compose Specification schema before diffing")
+	diffCmd.Flags().StringVar(&schemaFile, "schema", "", "JSON schema for --validate (default: bundled compose-spec schema)")
+	diffCmd.Flags().BoolVar(&lenientParse, "lenient", false, "Skip entries that cannot be read and list them in the report instead of failing")
+	diffCmd.Flags().StringVar(&maxMemory, "max-memory", "", "Soft memory limit (e.g. 512m); large change payloads are spilled to disk")
+	diffCmd.Flags().StringSliceVar(&profileFlags, "profile", nil, "Only compare services active under these profiles, like docker compose --profile (repeatable)")
+	diffCmd.Flags().StringVar(&artifactURL, "artifact-url", "", "URL of the full report, linked from truncated markdown tables")
+	diffCmd.Flags().StringArrayVarP(&outputFiles, "output", "o", nil, "Also write the report to this file, in the format its extension implies or format=path (repeatable)")
+	diffCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "Print secret-looking values (passwords, tokens, URL credentials) instead of masking them")
+	diffCmd.Flags().StringVar(&stderrSummary, "stderr-summary", "auto", "Print a one-line summary to stderr: auto (when stdout is not a terminal), always, never")
+	diffCmd.Flags().Lookup("stderr-summary").NoOptDefVal = "always"
+	diffCmd.MarkFlagsMutuallyExclusive("exit-code", "strict")
+	diffCmd.MarkFlagsMutuallyExclusive("exit-code", "fail-on")
+	diffCmd.MarkFlagsMutuallyExclusive("exit-code", "fail-on-score")
 
 	rootCmd.AddCommand(diffCmd)
 }
 
 func runDiff(cmd *cobra.Command, args []string) {
+	checkExitCodeFlags()
+	checkStderrSummaryFlag()
+	if err := parser.CheckOrderedLists(orderedLists); err != nil {
+		color.Red("Invalid --ordered-lists: %v", err)
+		os.Exit(exitCodeError)
+	}
+	if regoPolicy != "" {
+		if err := requireOnline("--policy (runs the opa CLI)"); err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(exitCodeError)
+		}
+	}
+	outputs := parseOutputs()
+	kinds := changeKinds(kindFilter)
+
 	var oldFile, newFile string
 	var oldIR, newIR *models.ComposeIR
-	var err error
+	var diagnostics []models.Diagnostic
 
 	// Load rules
-	var r *rules.Rules
-	if rulesFile != "" {
-		r, err = rules.LoadRules(rulesFile)
-		if err != nil {
-			color.Red("Error loading rules: %v", err)
-			os.Exit(2)
-		}
-	} else {
-		r, _ = rules.LoadRulesFromDir(".")
+	r, err := loadRules()
+	if err != nil {
+		color.Red("Error loading rules: %v", err)
+		os.Exit(exitCodeError)
+	}
+
+	if validateFirst {
+		validateBeforeDiff(args)
+	}
+	if maxMemory != "" {
+		applyMemoryLimit(args)
 	}
 
 	baselineMgr := baseline.NewManager(".compose-diff")
@@ -101,7 +186,11 @@ func runDiff(cmd *cobra.Command, args []string) {
 	if saveBaseline != "" {
 		if len(args) < 1 {
 			color.Red("Usage: compose-diff diff --save-baseline <name> <compose-file>")
-			os.Exit(2)
+			os.Exit(exitCodeError)
+		}
+		if err := requireOnline("--save-baseline (writes to .compose-diff/)"); err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(exitCodeError)
 		}
 		composeFile := args[0]
 		var data map[string]any
@@ -113,14 +202,25 @@ func runDiff(cmd *cobra.Command, args []string) {
 		}
 		if err != nil {
 			color.Red("Error: %v", err)
-			os.Exit(2)
+			os.Exit(exitCodeError)
+		}
+
+		name := saveBaseline
+		var metadata map[string]string
+		if saveBaseline == "auto" {
+			metadata, err = baseline.GitMetadata(filepath.Dir(composeFile))
+			if err != nil {
+				color.Red("Error: --save-baseline auto needs a git checkout: %v", err)
+				os.Exit(exitCodeError)
+			}
+			name = baseline.AutoName(metadata)
 		}
 
-		if err := baselineMgr.Save(saveBaseline, data, composeFile, resolveConfig); err != nil {
+		if err := baselineMgr.SaveWithMetadata(name, data, composeFile, resolveConfig, metadata); err != nil {
 			color.Red("Error saving baseline: %v", err)
-			os.Exit(2)
+			os.Exit(exitCodeError)
 		}
-		color.Green("Saved baseline '%s'", saveBaseline)
+		color.Green("Saved baseline '%s'", name)
 		return
 	}
 
@@ -128,37 +228,41 @@ func runDiff(cmd *cobra.Command, args []string) {
 	if baselineFlag != "" {
 		if len(args) < 1 {
 			color.Red("Usage: compose-diff diff --baseline <name> <compose-file>")
-			os.Exit(2)
+			os.Exit(exitCodeError)
 		}
 		newFile = args[0]
 		oldFile = "(baseline: " + baselineFlag + ")"
 
-		bl, err := baselineMgr.Load(baselineFlag)
+		if expandEnvFiles && !resolveConfig {
+			color.Red("--expand-env-files cannot be used with --baseline: baselines do not snapshot env files (save and compare with --resolve instead)")
+			os.Exit(exitCodeError)
+		}
+		bl, err := baselineMgr.Resolve(baselineFlag)
 		if err != nil {
 			color.Red("Error loading baseline '%s': %v", baselineFlag, err)
-			os.Exit(2)
+			os.Exit(exitCodeError)
 		}
 
 		oldIR, err = parser.ParseFromMap(bl.Data)
 		if err != nil {
 			color.Red("Error parsing baseline: %v", err)
-			os.Exit(2)
+			os.Exit(exitCodeError)
 		}
 
 		if resolveConfig {
 			newIR, err = parseResolvedToIR(newFile)
 		} else {
-			newIR, err = parser.ParseComposeFile(newFile)
+			newIR, err = loadCompose(newFile, composediff.Options{}, &diagnostics)
 		}
 		if err != nil {
 			color.Red("Error parsing %s: %v", newFile, err)
-			os.Exit(2)
+			os.Exit(exitCodeError)
 		}
 	} else {
 		// Standard two-file comparison
 		if len(args) < 2 {
 			color.Red("Usage: compose-diff diff <old-compose.yml> <new-compose.yml>")
-			os.Exit(2)
+			os.Exit(exitCodeError)
 		}
 		oldFile = args[0]
 		newFile = args[1]
@@ -168,48 +272,101 @@ func runDiff(cmd *cobra.Command, args []string) {
 			oldIR, err = parseResolvedToIR(oldFile)
 			if err != nil {
 				color.Red("Error resolving %s: %v", oldFile, err)
-				os.Exit(2)
+				os.Exit(exitCodeError)
 			}
 			newIR, err = parseResolvedToIR(newFile)
 			if err != nil {
 				color.Red("Error resolving %s: %v", newFile, err)
-				os.Exit(2)
+				os.Exit(exitCodeError)
 			}
 		} else {
-			oldIR, err = parser.ParseComposeFile(oldFile)
+			// Resolved output already has env_file contents inlined
+			loadOpts := composediff.Options{ExpandEnvFiles: expandEnvFiles}
+			oldIR, err = loadCompose(oldFile, loadOpts, &diagnostics)
 			if err != nil {
 				color.Red("Error parsing %s: %v", oldFile, err)
-				os.Exit(2)
+				os.Exit(exitCodeError)
 			}
-			newIR, err = parser.ParseComposeFile(newFile)
+			newIR, err = loadCompose(newFile, loadOpts, &diagnostics)
 			if err != nil {
 				color.Red("Error parsing %s: %v", newFile, err)
-				os.Exit(2)
+				os.Exit(exitCodeError)
 			}
 		}
 	}
 
-	// Normalize if enabled
-	if normalizeOn {
-		oldIR = parser.Normalize(oldIR)
-		newIR = parser.Normalize(newIR)
+	// Whole-file checks need the IRs, which --max-memory drops early
+	findings := lint.Findings(oldIR, newIR)
+	if newFile != "" {
+		findings = append(findings, healthDependencyFindings(oldFile, newFile)...)
+	}
+	if r != nil {
+		findings = append(findings, r.Conventions().Check(oldIR, newIR)...)
+		findings = append(findings, r.CheckRequirements(oldIR, newIR)...)
+	}
+	deps := impact.NewGraph(oldIR, newIR)
+	hashes := serviceHashes(oldIR, newIR)
+	planner := recreate.NewPlanner(newIR)
+	var policies *rules.PolicyEvaluator
+	if r != nil && r.HasPolicies() {
+		policies = r.Policies(oldIR, newIR)
 	}
 
 	// Compute diff
-	report := diff.Compare(oldIR, newIR)
+	opts := compareOptions(r, composediff.Options{IgnoreOrdering: normalizeOn, Profiles: profileFlags})
+	directives := newIR.Directives
+	var report *models.DiffReport
+	cleanup := func() {}
+	if maxMemory != "" {
+		report, cleanup = compareSpilled(oldIR, newIR, opts)
+		oldIR, newIR = nil, nil // let the IRs be collected while the report renders
+	} else {
+		report = composediff.Compare(oldIR, newIR, opts)
+	}
 
 	// Apply rules-based severity overrides and filtering
-	if r != nil {
-		report = applyRules(report, r)
-	}
+	report = reviewReport(report, r, policies, directives)
+	findings = append(findings, policies.Findings()...)
 
 	// Filter by service if specified
 	if serviceFilter != "" {
 		report = diff.FilterByService(report, serviceFilter)
 	}
+	if len(kinds) > 0 {
+		report = diff.FilterByKind(report, kinds)
+	}
 
 	// Filter by severity
 	report = diff.FilterBySeverity(report, severityMin)
+	report.Diagnostics = diagnostics
+	report.Findings = diff.FilterFindings(findings, severityMin)
+	weights := risk.DefaultWeights()
+	if r != nil {
+		weights = r.RiskWeights()
+	}
+	report.Summary.RiskScore = risk.Score(report, weights)
+	report.Impacts = deps.Analyze(report.Changes)
+	report.ServiceHashes = hashes
+	report.Runtime = recreate.Analyze(report.Changes)
+	report.Rollout = planner.Plan(report.Runtime)
+
+	// A Rego policy sees the finished report and may deny it, reclassify
+	// changes or annotate them
+	if regoPolicy != "" {
+		result, err := rego.Evaluate(regoPolicy, reporter.ToJSON(report, oldFile, newFile))
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(exitCodeError)
+		}
+		result.Apply(report)
+		report.Summary.RiskScore = risk.Score(report, weights)
+		report.Impacts = deps.Analyze(report.Changes)
+	}
+
+	// Policies above see the real values; every output sees them masked
+	if !showSecrets {
+		redact.Report(report)
+	}
 
 	// Output
 	var output string
@@ -218,25 +375,149 @@ func runDiff(cmd *cobra.Command, args []string) {
 		output = reporter.ToCategoryDetail(report, oldFile, newFile)
 	case categoryMode:
 		output = reporter.ToCategorySummary(report, oldFile, newFile)
-	case formatFlag == "json":
-		jsonBytes, err := json.MarshalIndent(reporter.ToJSON(report, oldFile, newFile), "", "  ")
+	default:
+		output, err = renderReport(formatFlag, report, oldFile, newFile)
 		if err != nil {
-			color.Red("Error generating JSON: %v", err)
-			os.Exit(2)
+			color.Red("Error: %v", err)
+			os.Exit(exitCodeError)
 		}
-		output = string(jsonBytes)
-	case formatFlag == "markdown":
-		output = reporter.ToMarkdown(report, oldFile, newFile)
-	default:
-		output = reporter.ToText(report, oldFile, newFile)
 	}
 
+	writeOutputs(outputs, report, oldFile, newFile)
 	fmt.Println(output)
+	cleanup()
+	printStderrSummary(report)
 
-	// Exit code handling
-	if strictMode && report.Summary.BreakingCount > 0 {
-		os.Exit(1)
+	os.Exit(diffExitCode(report))
+}
+
+// renderReport renders report in one of the --format formats; unknown
+// formats fall back to text
+func renderReport(format string, report *models.DiffReport, oldFile, newFile string) (string, error) {
+	switch format {
+	case "json":
+		jsonBytes, err := json.MarshalIndent(reporter.ToJSON(report, oldFile, newFile), "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("generating JSON: %w", err)
+		}
+		return string(jsonBytes), nil
+	case "markdown":
+		checklist := ""
+		if checklistMode {
+			checklist = reporter.ToChecklist(report)
+		}
+		return reporter.FitMarkdown(report, oldFile, newFile, checklist, maxCommentBytes, artifactURL), nil
+	case "gitlab-codequality":
+		jsonBytes, err := json.MarshalIndent(reporter.ToGitLabCodeQuality(report, oldFile, newFile), "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("generating JSON: %w", err)
+		}
+		return string(jsonBytes), nil
+	case "html":
+		return reporter.ToHTML(report, oldFile, newFile), nil
+	case "github":
+		return reporter.ToGitHubAnnotations(report, oldFile, newFile), nil
+	case "review-json":
+		jsonBytes, err := json.Marshal(reporter.ToReview(report, oldFile, newFile))
+		if err != nil {
+			return "", fmt.Errorf("generating JSON: %w", err)
+		}
+		return string(jsonBytes), nil
+	case "patch":
+		return strings.TrimSuffix(reporter.ToPatch(report, oldFile, newFile), "\n"), nil
+	case "csv":
+		return strings.TrimSuffix(reporter.ToCSV(report, ','), "\n"), nil
+	case "tsv":
+		return strings.TrimSuffix(reporter.ToCSV(report, '\t'), "\n"), nil
+	case "template":
+		if templateFile == "" {
+			return "", fmt.Errorf("--format template needs --template <file>")
+		}
+		tmpl, err := reporter.ParseTemplate(templateFile)
+		if err != nil {
+			return "", fmt.Errorf("reading template: %w", err)
+		}
+		return reporter.ToTemplate(tmpl, report, oldFile, newFile)
+	}
+	output := reporter.ToText(report, oldFile, newFile)
+	if checklistMode {
+		output = appendSection(output, reporter.ToChecklist(report))
 	}
+	return output, nil
+}
+
+// loadRules loads the --policy-bundle or --rules file, or the default rules
+// file in the current directory
+func loadRules() (*rules.Rules, error) {
+	if policyBundle != "" {
+		if rulesFile != "" {
+			return nil, fmt.Errorf("--rules and --policy-bundle cannot be combined")
+		}
+		path, err := usePolicyBundle(policyBundle)
+		if err != nil {
+			return nil, err
+		}
+		return rules.LoadRules(path)
+	}
+	if rulesFile != "" {
+		return rules.LoadRules(rulesFile)
+	}
+	return rules.LoadRulesFromDir(".")
+}
+
+// compareOptions adds --ordered-lists and the rules file's ordered_lists
+// and label namespaces to opts. r may be nil.
+func compareOptions(r *rules.Rules, opts composediff.Options) composediff.Options {
+	opts.OrderedLists = orderedLists
+	if r != nil {
+		opts.WarnLabelNamespaces = r.WarnLabelNamespaces()
+		if orderedLists == nil {
+			opts.OrderedLists = r.OrderedLists()
+		}
+	}
+	return opts
+}
+
+// reviewReport applies the rules file (ignores, severity policies, CEL
+// policies, overrides and image rules) and then the new file's directives to
+// a fresh report. r and policies may be nil.
+func reviewReport(report *models.DiffReport, r *rules.Rules, policies *rules.PolicyEvaluator, directives models.Directives) *models.DiffReport {
+	if r != nil {
+		report = applyRules(report, r, policies)
+	}
+	return applyDirectives(report, directives, r)
+}
+
+// changeKinds converts --kind values, exiting on unknown kinds
+func changeKinds(values []string) []models.ChangeKind {
+	kinds := make([]models.ChangeKind, 0, len(values))
+	for _, v := range values {
+		k := models.ChangeKind(v)
+		if !models.ValidChangeKind(k) {
+			color.Red("Invalid --kind %q: use added, removed, modified, reordered, renamed, dangling, cycle or orphaned", v)
+			os.Exit(exitCodeError)
+		}
+		kinds = append(kinds, k)
+	}
+	return kinds
+}
+
+// serviceHashes hashes the services of both files that run with the
+// --profile flags
+func serviceHashes(oldIR, newIR *models.ComposeIR) map[string]models.ServiceHash {
+	if profileFlags != nil {
+		oldIR = parser.FilterProfiles(oldIR, profileFlags)
+		newIR = parser.FilterProfiles(newIR, profileFlags)
+	}
+	return parser.ServiceHashes(oldIR, newIR)
+}
+
+// appendSection appends an optional report section separated by a blank line
+func appendSection(output, section string) string {
+	if section == "" {
+		return output
+	}
+	return strings.TrimRight(output, "\n") + "\n\n" + section
 }
 
 // parseRaw parses a compose file to raw map
@@ -252,8 +533,36 @@ func parseRaw(composeFile string) (map[string]any, error) {
 	return result, nil
 }
 
-// parseResolved uses docker compose config to get resolved output
+// parseResolved uses docker compose config to get resolved output.
+// The output is cached so --offline runs can reuse it.
 func parseResolved(composeFile string) (map[string]any, error) {
+	output, err := resolveCompose(composeFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]any
+	if err := yaml.Unmarshal(output, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// resolveCompose returns `docker compose config` output for a file, from the
+// cache when offline
+func resolveCompose(composeFile string) ([]byte, error) {
+	key, err := cache.ResolvedKey(composeFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if offline {
+		if output, ok := cache.LoadResolved(key); ok {
+			return output, nil
+		}
+		return nil, fmt.Errorf("no cached resolved config for %s (run `compose-diff cache warm --resolve %s` first)", composeFile, composeFile)
+	}
+
 	dir := filepath.Dir(composeFile)
 	if dir == "" {
 		dir = "."
@@ -262,16 +571,29 @@ func parseResolved(composeFile string) (map[string]any, error) {
 	cmd := exec.Command("docker", "compose", "-f", filepath.Base(composeFile), "config")
 	cmd.Dir = dir
 
+	task := newProgress().Start("Resolving " + composeFile + " with docker compose config")
 	output, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("docker compose config failed: %w", err)
+		err = fmt.Errorf("docker compose config failed: %w", err)
+		task.Fail(err)
+		return nil, err
 	}
+	task.Done()
 
-	var result map[string]any
-	if err := yaml.Unmarshal(output, &result); err != nil {
-		return nil, err
+	// A cache write failure shouldn't fail the diff
+	_ = cache.SaveResolved(key, output)
+	return output, nil
+}
+
+// loadCompose loads a compose file, or with --lenient loads what it can and
+// appends the skipped entries to diags
+func loadCompose(path string, opts composediff.Options, diags *[]models.Diagnostic) (*models.ComposeIR, error) {
+	if !lenientParse {
+		return composediff.LoadFile(path, opts)
 	}
-	return result, nil
+	ir, d, err := composediff.LoadFileLenient(path, opts)
+	*diags = append(*diags, d...)
+	return ir, err
 }
 
 // parseResolvedToIR parses resolved config to IR
@@ -283,26 +605,23 @@ func parseResolvedToIR(composeFile string) (*models.ComposeIR, error) {
 	return parser.ParseFromMap(data)
 }
 
-// applyRules applies rules-based modifications to the report
-func applyRules(report *models.DiffReport, r *rules.Rules) *models.DiffReport {
+// applyRules applies rules-based modifications to the report, running the CEL
+// policies through policies (nil if there are none), and records
+// the freeze window in effect, if any
+func applyRules(report *models.DiffReport, r *rules.Rules, policies *rules.PolicyEvaluator) *models.DiffReport {
 	var filtered []models.Change
 	var breakingCount, warningCount, infoCount int
+	freeze, frozen := r.ActiveFreeze(currentTime())
 
 	for _, c := range report.Changes {
-		// Check if should be ignored
-		if ignore, _ := r.ShouldIgnore(c.Path); ignore {
+		// Ignores, then the severity policy, CEL policies and severity
+		// overrides, except that a freeze keeps breaking changes breaking,
+		// then image rules; why and rules apply run the same chain
+		out := r.Apply(c, policies, frozen)
+		if out.Ignored {
 			continue
 		}
-
-		// Check service-specific ignores
-		if r.ShouldIgnoreServiceField(c.Name, extractFieldFromPath(c.Path)) {
-			continue
-		}
-
-		// Apply severity overrides
-		if severity, ok := r.GetSeverityOverride(c.Path); ok {
-			c.Severity = severity
-		}
+		c = out.Change
 
 		filtered = append(filtered, c)
 
@@ -322,6 +641,13 @@ func applyRules(report *models.DiffReport, r *rules.Rules) *models.DiffReport {
 	report.Summary.BreakingCount = breakingCount
 	report.Summary.WarningCount = warningCount
 	report.Summary.InfoCount = infoCount
+	report.Freeze = freeze
+
+	// A typo in a policy's answer would silently turn it off
+	if err := policies.Err(); err != nil {
+		color.Red("Error in rules: %v", err)
+		os.Exit(exitCodeError)
+	}
 
 	return report
 }
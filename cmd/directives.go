@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/rules"
+)
+
+// applyDirectives applies the "# compose-diff:" comments of the new file:
+// ignore drops a change, severity=... reclassifies it, and the reason is
+// kept as a note. Like the rules file, a comment cannot downgrade a breaking
+// change during a freeze, nor an image the rules disallow. r may be nil.
+func applyDirectives(report *models.DiffReport, directives models.Directives, r *rules.Rules) *models.DiffReport {
+	if len(directives) == 0 {
+		return report
+	}
+	var filtered []models.Change
+	var breakingCount, warningCount, infoCount int
+
+	for _, c := range report.Changes {
+		d, ok := directives.For(c.Path)
+		if ok && !(report.Freeze != nil && c.Severity == models.SeverityBreaking) && !imageViolation(r, c) {
+			if d.Ignore {
+				continue
+			}
+			c.Severity = d.Severity
+			if d.Reason != "" {
+				c.Notes = append(c.Notes, "acknowledged: "+d.Reason)
+			}
+		}
+
+		filtered = append(filtered, c)
+
+		switch c.Severity {
+		case models.SeverityBreaking:
+			breakingCount++
+		case models.SeverityWarning:
+			warningCount++
+		default:
+			infoCount++
+		}
+	}
+
+	report.Changes = filtered
+	report.Summary.TotalChanges = len(filtered)
+	report.Summary.BreakingCount = breakingCount
+	report.Summary.WarningCount = warningCount
+	report.Summary.InfoCount = infoCount
+	return report
+}
+
+func imageViolation(r *rules.Rules, c models.Change) bool {
+	if r == nil {
+		return false
+	}
+	_, ok := r.ImageViolation(c)
+	return ok
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/rego"
+)
+
+var (
+	failOn       string
+	failOnScore  int
+	exitCodeMode bool
+)
+
+// exitCodeError is the exit code for errors: 2, or 3 under --exit-code
+var exitCodeError = 2
+
+// Exit codes under --exit-code
+const (
+	exitNoChanges = 0
+	exitChanges   = 1
+	exitBreaking  = 2
+	exitError     = 3
+)
+
+// checkExitCodeFlags validates --fail-on and --fail-on-score and switches
+// error exits to 3 under --exit-code
+func checkExitCodeFlags() {
+	if exitCodeMode {
+		exitCodeError = exitError
+	}
+	switch models.Severity(failOn) {
+	case "", models.SeverityInfo, models.SeverityWarning, models.SeverityBreaking:
+	default:
+		color.Red("Invalid --fail-on %q: use info, warning or breaking", failOn)
+		os.Exit(exitCodeError)
+	}
+	if failOnScore < 0 {
+		color.Red("Invalid --fail-on-score %d: use a positive score, or 0 to disable", failOnScore)
+		os.Exit(exitCodeError)
+	}
+}
+
+// diffExitCode returns the exit code for a finished diff: with --exit-code
+// 0 for no changes, 1 for changes and 2 for breaking changes or a --policy
+// deny; otherwise 1 if --fail-on, --fail-on-score or --strict is tripped,
+// --policy denies the diff, or there are breaking changes during a freeze,
+// else 0
+func diffExitCode(report *models.DiffReport) int {
+	denied := rego.Denied(report)
+	if exitCodeMode {
+		switch {
+		case report.Summary.BreakingCount > 0 || denied:
+			return exitBreaking
+		case report.Summary.TotalChanges > 0:
+			return exitChanges
+		}
+		return exitNoChanges
+	}
+
+	if denied || report.Freeze != nil && report.Summary.BreakingCount > 0 {
+		return 1
+	}
+	if failOnScore > 0 && report.Summary.RiskScore >= failOnScore {
+		return 1
+	}
+	threshold := failOn
+	if threshold == "" && strictMode {
+		threshold = string(models.SeverityBreaking)
+	}
+	if threshold == "" {
+		return 0
+	}
+	level := models.SeverityLevel(models.Severity(threshold))
+	for _, c := range report.Changes {
+		if models.SeverityLevel(c.Severity) >= level {
+			return 1
+		}
+	}
+	for _, f := range report.Findings {
+		if models.SeverityLevel(f.Severity) >= level {
+			return 1
+		}
+	}
+	return 0
+}
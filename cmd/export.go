@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/compose-diff/internal/export"
+	"github.com/stackgen-cli/compose-diff/internal/history"
+)
+
+var (
+	exportURL       string
+	exportIndex     string
+	exportProject   string
+	exportTimestamp string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <report.json|dir>...",
+	Short: "Ship per-change documents to OpenSearch for org-wide dashboards",
+	Long: `Turn JSON reports (from --format json) into one document per change, with
+the project, timestamp, severity, path and values, and index them in
+OpenSearch or Elasticsearch with the bulk API. Without --opensearch the bulk
+request body (newline-delimited JSON) is written to stdout, to pipe into
+another tool or a log shipper.
+
+Each report's timestamp is the report file's modification time unless
+--timestamp is given. Document IDs are derived from the report, so exporting
+the same report again overwrites its documents rather than duplicating them.
+
+Credentials are read from OPENSEARCH_USERNAME and OPENSEARCH_PASSWORD, or
+OPENSEARCH_API_KEY.
+
+Examples:
+  compose-diff diff --format json old.yml new.yml > report.json
+  compose-diff export --opensearch https://search.example.com:9200 report.json
+
+  # Backfill a directory of past reports
+  compose-diff export --opensearch https://search.example.com:9200 --project acme/shop reports/
+
+  # Bulk NDJSON for curl or a log shipper
+  compose-diff export report.json > bulk.ndjson`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runExport,
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportURL, "opensearch", "", "OpenSearch or Elasticsearch URL to index into (default: write bulk NDJSON to stdout)")
+	exportCmd.Flags().StringVar(&exportIndex, "index", export.DefaultIndex, "Index to write documents to")
+	exportCmd.Flags().StringVar(&exportProject, "project", "", "Project name on every document (default: the CI repository, or the current directory name)")
+	exportCmd.Flags().StringVar(&exportTimestamp, "timestamp", "", "Timestamp for every document, RFC 3339 or YYYY-MM-DD (default: each report file's modification time)")
+
+	rootCmd.AddCommand(exportCmd)
+}
+
+func runExport(cmd *cobra.Command, args []string) {
+	if exportURL != "" {
+		if err := requireOnline("export --opensearch (posts to the search cluster)"); err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(2)
+		}
+	}
+
+	var ts time.Time
+	if exportTimestamp != "" {
+		t, err := time.Parse(time.RFC3339, exportTimestamp)
+		if err != nil {
+			if t, err = time.ParseInLocation("2006-01-02", exportTimestamp, time.Local); err != nil {
+				color.Red("Error: --timestamp %q is not an RFC 3339 time or YYYY-MM-DD date", exportTimestamp)
+				os.Exit(2)
+			}
+		}
+		ts = t
+	}
+
+	files, err := history.ReportFiles(args)
+	if err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(2)
+	}
+	if len(files) == 0 {
+		color.Red("No JSON reports found in %v", args)
+		os.Exit(2)
+	}
+
+	project := valueOr(exportProject, ciProject())
+	var docs []export.Document
+	for _, f := range files {
+		report, err := history.LoadReport(f)
+		if err != nil {
+			color.Red("Error: failed to load report %s: %v", f, err)
+			os.Exit(2)
+		}
+		reportTime := ts
+		if reportTime.IsZero() {
+			info, err := os.Stat(f)
+			if err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(2)
+			}
+			reportTime = info.ModTime()
+		}
+		docs = append(docs, export.Documents(report, project, reportTime)...)
+	}
+
+	if exportURL == "" {
+		w := bufio.NewWriter(os.Stdout)
+		if err := export.WriteBulk(w, exportIndex, docs); err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(2)
+		}
+		w.Flush()
+		return
+	}
+
+	search := &export.OpenSearch{
+		Client:   commentClient(),
+		URL:      exportURL,
+		Username: os.Getenv("OPENSEARCH_USERNAME"),
+		Password: os.Getenv("OPENSEARCH_PASSWORD"),
+		APIKey:   os.Getenv("OPENSEARCH_API_KEY"),
+	}
+	n, err := search.Bulk(exportIndex, docs)
+	if err != nil {
+		color.Red("Error exporting after %d documents: %v", n, err)
+		os.Exit(2)
+	}
+	color.Green("Indexed %d changes from %d reports into %s", n, len(files), exportIndex)
+}
+
+// ciProject returns the repository of the CI system we run in, or the name
+// of the current directory
+func ciProject() string {
+	for _, env := range []string{"GITHUB_REPOSITORY", "CI_PROJECT_PATH", "BITBUCKET_REPO_FULL_NAME", "BUILD_REPOSITORY_NAME"} {
+		if v := os.Getenv(env); v != "" {
+			return v
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(wd)
+}
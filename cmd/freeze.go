@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/stackgen-cli/compose-diff/internal/rules"
+)
+
+var nowFlag string
+
+// currentTime returns the time freeze windows are checked at: --now if
+// set, else the current time. Overriding it is logged to stderr so an
+// emergency deploy during a freeze leaves a trace in the CI log.
+func currentTime() time.Time {
+	if nowFlag == "" {
+		return time.Now()
+	}
+	now, err := rules.ParseTime(nowFlag)
+	if err != nil {
+		color.Red("Invalid --now: %v", err)
+		os.Exit(exitCodeError)
+	}
+	fmt.Fprintf(os.Stderr, "%s\n", color.YellowString("Checking freeze windows as of %s (--now)", now.Format(time.RFC3339)))
+	return now
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/parser"
+	"github.com/stackgen-cli/compose-diff/internal/redact"
+	"github.com/stackgen-cli/compose-diff/pkg/composediff"
+)
+
+var (
+	getPath string
+	getJSON bool
+)
+
+var getCmd = &cobra.Command{
+	Use:   "get <old-compose.yml> <new-compose.yml> --path <path>",
+	Short: "Print one field's value before and after",
+	Long: `Print the value at a path in the old and the new file, one per line, and
+exit 1 if it changed or 0 if not, so deploy scripts can decide on a single
+field without parsing a report. Strings print as they are, other values as
+JSON, and a line is empty where the path is unset. Values are normalized
+the way diff compares them, e.g. environment lists become maps.
+
+Paths are those diff reports: keys may contain dots, as in
+services.api.labels.traefik.enable, and list items are addressed by index
+(services.api.command.0) or by the key diff reports for them.
+
+Examples:
+  compose-diff get prod.yml docker-compose.yml --path services.api.image
+  compose-diff get --json prod.yml docker-compose.yml --path services.api.environment
+
+  # Run migrations only when the image changes
+  if ! compose-diff get prod.yml new.yml --path services.api.image > /dev/null; then
+    ./migrate.sh
+  fi`,
+	Args: cobra.ExactArgs(2),
+	Run:  runGet,
+}
+
+func init() {
+	getCmd.Flags().StringVar(&getPath, "path", "", "Path of the field, e.g. services.api.image")
+	getCmd.Flags().BoolVar(&getJSON, "json", false, "Print {path, before, after, changed} as JSON")
+	getCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "Print secret-looking values instead of masking them")
+	getCmd.MarkFlagRequired("path")
+
+	rootCmd.AddCommand(getCmd)
+}
+
+func runGet(cmd *cobra.Command, args []string) {
+	oldFile, newFile := args[0], args[1]
+	oldIR, err := composediff.LoadFile(oldFile, composediff.Options{})
+	if err != nil {
+		color.Red("Error parsing %s: %v", oldFile, err)
+		os.Exit(2)
+	}
+	newIR, err := composediff.LoadFile(newFile, composediff.Options{})
+	if err != nil {
+		color.Red("Error parsing %s: %v", newFile, err)
+		os.Exit(2)
+	}
+
+	before, hasBefore := parser.Lookup(oldIR, getPath)
+	after, hasAfter := parser.Lookup(newIR, getPath)
+	if !hasBefore && !hasAfter {
+		// Items keyed by diff, such as ports.80:80/tcp, are only in changes
+		report := composediff.Compare(oldIR, newIR, composediff.Options{IgnoreOrdering: true})
+		for _, c := range report.Changes {
+			if c.Path == getPath {
+				before, hasBefore = plainValue(c.Before)
+				after, hasAfter = plainValue(c.After)
+				break
+			}
+		}
+	}
+	if !hasBefore && !hasAfter {
+		color.Red("Error: nothing at %s in %s or %s", getPath, oldFile, newFile)
+		os.Exit(2)
+	}
+
+	oldJSON, _ := json.Marshal(before)
+	newJSON, _ := json.Marshal(after)
+	changed := hasBefore != hasAfter || !bytes.Equal(oldJSON, newJSON)
+	if !showSecrets {
+		masked := redact.Change(models.Change{Path: getPath, Before: before, After: after})
+		before, after = masked.Before, masked.After
+	}
+
+	if getJSON {
+		out, _ := json.MarshalIndent(struct {
+			Path    string `json:"path"`
+			Before  any    `json:"before"`
+			After   any    `json:"after"`
+			Changed bool   `json:"changed"`
+		}{getPath, before, after, changed}, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		fmt.Println(getValueLine(before, hasBefore))
+		fmt.Println(getValueLine(after, hasAfter))
+	}
+	if changed {
+		os.Exit(1)
+	}
+}
+
+// plainValue converts a change's before or after value to what the JSON IR
+// holds; nil means unset
+func plainValue(v any) (any, bool) {
+	if v == nil {
+		return nil, false
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v), true
+	}
+	var out any
+	json.Unmarshal(data, &out)
+	return out, true
+}
+
+// getValueLine prints a string as it is and anything else as JSON
+func getValueLine(v any, ok bool) string {
+	if !ok {
+		return ""
+	}
+	if s, isString := v.(string); isString {
+		return s
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
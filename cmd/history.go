@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/compose-diff/internal/baseline"
+	"github.com/stackgen-cli/compose-diff/internal/history"
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/parser"
+	"github.com/stackgen-cli/compose-diff/internal/redact"
+	"github.com/stackgen-cli/compose-diff/internal/risk"
+	"github.com/stackgen-cli/compose-diff/pkg/composediff"
+)
+
+var (
+	heatmapFormat  string
+	heatmapTop     int
+	heatmapByField bool
+
+	historyBaseline string
+	historyFrom     string
+	historyTo       string
+	historyFormat   string
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Analyze past JSON reports",
+}
+
+var historyHeatmapCmd = &cobra.Command{
+	Use:   "heatmap <report.json|dir>...",
+	Short: "Show which paths change most often across runs",
+	Long: `Count how many past JSON reports (from --format json) each path changed in,
+most frequent first. Paths that change on most runs are candidates for
+automation or parameterization (an image tag set by CI, a variable in .env).
+
+Examples:
+  compose-diff history heatmap reports/
+  compose-diff history heatmap --by-field --top 10 reports/
+  compose-diff history heatmap --format json reports/ > heatmap.json`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runHistoryHeatmap,
+}
+
+var historyDiffCmd = &cobra.Command{
+	Use:   "diff --baseline <name> --from <time> [--to <time>]",
+	Short: "Diff a baseline as it was at two points in time",
+	Long: `Compare the snapshots of a saved baseline at two points in time, e.g. to see
+what changed in the production config during an incident window. Each time
+picks the latest snapshot saved at or before it, like name@time in
+"diff --baseline"; a YYYY-MM-DD date means the end of that day. Without
+--to the current snapshot is compared.
+
+Examples:
+  compose-diff history diff --baseline prod --from 2024-12-01 --to 2025-01-15
+  compose-diff history diff --baseline prod --from 2025-01-14T22:00:00Z --format markdown`,
+	Args: cobra.NoArgs,
+	Run:  runHistoryDiff,
+}
+
+func init() {
+	historyDiffCmd.Flags().StringVar(&historyBaseline, "baseline", "", "Name of the baseline")
+	historyDiffCmd.Flags().StringVar(&historyFrom, "from", "", "Start of the window: YYYY-MM-DD or RFC 3339 time")
+	historyDiffCmd.Flags().StringVar(&historyTo, "to", "", "End of the window: YYYY-MM-DD or RFC 3339 time (default: the current snapshot)")
+	historyDiffCmd.Flags().StringVarP(&historyFormat, "format", "f", "text", "Output format, as for diff: text, json, markdown, html, ...")
+	historyDiffCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "Print secret-looking values instead of masking them")
+	historyDiffCmd.MarkFlagRequired("baseline")
+	historyDiffCmd.MarkFlagRequired("from")
+
+	historyCmd.AddCommand(historyDiffCmd)
+	historyHeatmapCmd.Flags().StringVarP(&heatmapFormat, "format", "f", "text", "Output format: text, json")
+	historyHeatmapCmd.Flags().IntVar(&heatmapTop, "top", 20, "Show only the N most frequent paths (0 for all)")
+	historyHeatmapCmd.Flags().BoolVar(&heatmapByField, "by-field", false, "Fold service paths into services.*.<field>")
+
+	historyCmd.AddCommand(historyHeatmapCmd)
+	rootCmd.AddCommand(historyCmd)
+}
+
+func runHistoryHeatmap(cmd *cobra.Command, args []string) {
+	reports, err := history.LoadReports(args)
+	if err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(2)
+	}
+	if len(reports) == 0 {
+		color.Red("No JSON reports found in %v", args)
+		os.Exit(2)
+	}
+
+	freqs := history.Heatmap(reports, heatmapByField)
+	if heatmapTop > 0 && len(freqs) > heatmapTop {
+		freqs = freqs[:heatmapTop]
+	}
+
+	switch heatmapFormat {
+	case "json":
+		out := struct {
+			Reports int                     `json:"reports"`
+			Paths   []history.PathFrequency `json:"paths"`
+		}{len(reports), freqs}
+		data, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			color.Red("Error generating JSON: %v", err)
+			os.Exit(2)
+		}
+		fmt.Println(string(data))
+	case "text":
+		if len(freqs) == 0 {
+			color.Green("No changes in %d reports.", len(reports))
+			return
+		}
+		fmt.Print(history.FormatHeatmap(freqs, len(reports)))
+	default:
+		color.Red("Unknown format %q: use text or json", heatmapFormat)
+		os.Exit(2)
+	}
+}
+
+func runHistoryDiff(cmd *cobra.Command, args []string) {
+	from := loadBaseline(historyBaseline + "@" + historyFrom)
+	to := loadBaseline(historyBaseline)
+	if historyTo != "" {
+		to = loadBaseline(historyBaseline + "@" + historyTo)
+	}
+	if to.CreatedAt.Before(from.CreatedAt) {
+		color.Red("Error: --to picks an older snapshot (%s) than --from (%s)", snapshotTime(to), snapshotTime(from))
+		os.Exit(2)
+	}
+
+	fromIR, toIR := snapshotIR(from), snapshotIR(to)
+	report := composediff.Compare(fromIR, toIR, composediff.Options{IgnoreOrdering: true})
+	report.Summary.RiskScore = risk.Score(report, risk.DefaultWeights())
+	if !showSecrets {
+		redact.Report(report)
+	}
+
+	oldFile := fmt.Sprintf("%s@%s", historyBaseline, snapshotTime(from))
+	newFile := fmt.Sprintf("%s@%s", historyBaseline, snapshotTime(to))
+	output, err := renderReport(historyFormat, report, oldFile, newFile)
+	if err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(2)
+	}
+	fmt.Println(output)
+}
+
+// snapshotIR parses a baseline snapshot, or exits
+func snapshotIR(b *baseline.Baseline) *models.ComposeIR {
+	ir, err := parser.ParseFromMap(b.Data)
+	if err != nil {
+		color.Red("Error parsing snapshot of %s: %v", snapshotTime(b), err)
+		os.Exit(2)
+	}
+	return ir
+}
+
+// snapshotTime is when a snapshot was saved, in local time
+func snapshotTime(b *baseline.Baseline) string {
+	return b.CreatedAt.Local().Format("2006-01-02 15:04")
+}
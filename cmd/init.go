@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/compose-diff/internal/parser"
+	"github.com/stackgen-cli/compose-diff/internal/rules"
+	"github.com/stackgen-cli/compose-diff/internal/scaffold"
+)
+
+var (
+	initGitHub bool
+	initGitLab bool
+	initForce  bool
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Scaffold compose-diff configuration for a project",
+}
+
+var initCICmd = &cobra.Command{
+	Use:   "ci [compose-file|dir]",
+	Short: "Generate a CI workflow and rules file",
+	Long: `Generate a CI workflow that diffs the compose file against the target
+branch, posts the markdown report as a PR/MR comment and fails on breaking
+changes (--strict), plus a starter rules file if none exists.
+
+Examples:
+  compose-diff init ci --github
+  compose-diff init ci --gitlab docker-compose.prod.yml`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runInitCI,
+}
+
+func init() {
+	initCICmd.Flags().BoolVar(&initGitHub, "github", false, "Generate a GitHub Actions workflow")
+	initCICmd.Flags().BoolVar(&initGitLab, "gitlab", false, "Generate a GitLab CI job")
+	initCICmd.Flags().BoolVar(&initForce, "force", false, "Overwrite existing files")
+	initCICmd.MarkFlagsMutuallyExclusive("github", "gitlab")
+	initCICmd.MarkFlagsOneRequired("github", "gitlab")
+
+	initCmd.AddCommand(initCICmd)
+	rootCmd.AddCommand(initCmd)
+}
+
+func runInitCI(cmd *cobra.Command, args []string) {
+	composePath := "."
+	if len(args) > 0 {
+		composePath = args[0]
+	}
+
+	composeFile, err := parser.ResolveComposePath(composePath)
+	if err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(2)
+	}
+	ir, err := parser.ParseComposeFile(composeFile)
+	if err != nil {
+		color.Red("Error parsing %s: %v", composeFile, err)
+		os.Exit(2)
+	}
+
+	provider := scaffold.ProviderGitHub
+	if initGitLab {
+		provider = scaffold.ProviderGitLab
+	}
+
+	files, err := scaffold.CIFiles(provider, filepath.ToSlash(composeFile), rules.DefaultRulesFile)
+	if err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(2)
+	}
+
+	// Reuse an existing rules file instead of failing on it
+	if _, err := os.Stat(rules.DefaultRulesFile); err != nil || initForce {
+		services := make([]string, 0, len(ir.Services))
+		for name := range ir.Services {
+			services = append(services, name)
+		}
+		files = append(files, scaffold.File{
+			Path:    rules.DefaultRulesFile,
+			Content: rules.StarterConfig(services),
+		})
+	}
+
+	for _, f := range files {
+		if err := writeNewFile(f.Path, []byte(f.Content), initForce); err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(2)
+		}
+		color.Green("Wrote %s", f.Path)
+	}
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/compose-diff/internal/lint"
+	"github.com/stackgen-cli/compose-diff/internal/parser"
+	"github.com/stackgen-cli/compose-diff/internal/reporter"
+)
+
+var lintMinServices int
+
+var lintCmd = &cobra.Command{
+	Use:   "lint <compose-file>",
+	Short: "Find broken references and environment variables duplicated across services",
+	Long: `List depends_on entries naming services that are not in the file,
+depends_on cycles, and volumes and networks used without a top-level
+declaration, which docker compose refuses to start; any of them makes lint
+exit 1. diff reports the ones a change introduces as dangling and cycle
+changes.
+
+Also list environment variables set to the same value in several services.
+Copies like these tend to drift: a change updates some of them and misses
+the rest. diff reports such partial updates as partial-env-update findings.
+
+Examples:
+  compose-diff lint docker-compose.yml
+  compose-diff lint --min-services 2 docker-compose.yml`,
+	Args: cobra.ExactArgs(1),
+	Run:  runLint,
+}
+
+func init() {
+	lintCmd.Flags().IntVar(&lintMinServices, "min-services", 3, "Report variables shared by at least this many services")
+
+	rootCmd.AddCommand(lintCmd)
+}
+
+func runLint(cmd *cobra.Command, args []string) {
+	ir, err := parser.ParseComposeFile(args[0])
+	if err != nil {
+		color.Red("Error parsing %s: %v", args[0], err)
+		os.Exit(2)
+	}
+
+	broken := append(lint.DependencyChanges(nil, ir), lint.ReferenceChanges(nil, ir)...)
+	for _, c := range broken {
+		color.Red("%s", reporter.ChangeLine(c))
+	}
+
+	shared := lint.DuplicateEnv(ir, lintMinServices)
+	if len(shared) == 0 {
+		color.Green("No variables shared by %d or more services.", lintMinServices)
+	} else {
+		fmt.Print(lint.FormatDuplicateEnv(shared))
+	}
+	if len(broken) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime/debug"
+
+	"github.com/fatih/color"
+	"github.com/stackgen-cli/compose-diff/internal/diff"
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/spill"
+	"github.com/stackgen-cli/compose-diff/internal/units"
+	"github.com/stackgen-cli/compose-diff/pkg/composediff"
+)
+
+var maxMemory string
+
+// memoryPerInputByte is roughly how much heap parsing takes per byte of YAML:
+// the node tree, the IR and the position index
+const memoryPerInputByte = 30
+
+// applyMemoryLimit sets the Go runtime's soft memory limit from --max-memory
+// and warns on stderr when the inputs are likely to need more than that
+func applyMemoryLimit(files []string) {
+	limit, err := units.ParseBytes(maxMemory)
+	if err != nil || limit <= 0 {
+		color.Red("Invalid --max-memory %q: use a size such as 512m or 2g", maxMemory)
+		os.Exit(exitCodeError)
+	}
+	debug.SetMemoryLimit(limit)
+
+	// Files are parsed one after the other, but both IRs live until the diff is done
+	var need int64
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			need += info.Size() * memoryPerInputByte
+		}
+	}
+	if need > limit {
+		fmt.Fprintf(os.Stderr, "Warning: these inputs typically need about %s, above --max-memory %s; expect heavy garbage collection or an out-of-memory kill. Diff smaller slices (e.g. with --profile) or raise the limit.\n",
+			units.FormatBytes(roundMiB(need)), maxMemory)
+	}
+}
+
+func roundMiB(n int64) int64 {
+	return (n + 1<<20 - 1) &^ (1<<20 - 1)
+}
+
+// compareSpilled is composediff.Compare for --max-memory: changes are
+// streamed and their large payloads written to disk, so the report does not
+// pin whole services in memory. Call cleanup once the report is rendered.
+func compareSpilled(oldIR, newIR *models.ComposeIR, opts composediff.Options) (*models.DiffReport, func()) {
+	sp, err := spill.New(spill.DefaultThreshold)
+	if err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(exitCodeError)
+	}
+
+	report := models.NewDiffReport()
+	err = composediff.CompareStream(context.Background(), oldIR, newIR, opts, func(c models.Change) error {
+		c, err := sp.Change(c)
+		if err != nil {
+			return err
+		}
+		report.AddChange(c)
+		return nil
+	})
+	if err != nil {
+		sp.Close()
+		color.Red("Error: %v", err)
+		os.Exit(exitCodeError)
+	}
+	diff.SummarizeEntities(report)
+
+	return report, func() { sp.Close() }
+}
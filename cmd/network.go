@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/stackgen-cli/compose-diff/internal/httpclient"
+)
+
+// Settings shared by every outbound request
+var (
+	retries     int
+	caCert      string
+	insecureTLS bool
+	credHelper  string
+)
+
+// newHTTPClient returns the client used for every outbound request
+func newHTTPClient() (*http.Client, error) {
+	if insecureTLS {
+		fmt.Fprintln(os.Stderr, "Warning: --insecure disables TLS certificate verification")
+	}
+	return httpclient.New(httpclient.Options{
+		Retries:  retries,
+		CACert:   caCert,
+		Insecure: insecureTLS,
+	})
+}
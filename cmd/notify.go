@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/compose-diff/internal/forge"
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/reporter"
+)
+
+var (
+	notifyJira      string
+	notifyJiraURL   string
+	notifyLabel     string
+	notifyKeepLabel bool
+)
+
+var notifyCmd = &cobra.Command{
+	Use:   "notify [report.json]",
+	Short: "Post a report summary to a Jira issue",
+	Long: `Post the summary of a JSON report (from a file, or stdin) as a comment on a
+Jira issue, and label the issue when the report has breaking changes. The
+comment is updated in place on later runs, and the label is removed again
+once the breaking changes are gone.
+
+The site URL is read from --jira-url or JIRA_URL. On Jira Cloud set
+JIRA_EMAIL and JIRA_API_TOKEN; on Jira Data Center set JIRA_API_TOKEN to a
+personal access token.
+
+Examples:
+  compose-diff diff --format json old.yml new.yml > report.json
+  compose-diff notify --jira OPS-123 report.json
+
+  # Keep the label once set, for audit
+  compose-diff notify --jira OPS-123 --keep-label report.json`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runNotify,
+}
+
+func init() {
+	notifyCmd.Flags().StringVar(&notifyJira, "jira", "", "Issue key to comment on, e.g. OPS-123")
+	notifyCmd.Flags().StringVar(&notifyJiraURL, "jira-url", "", "Jira site URL (default: JIRA_URL)")
+	notifyCmd.Flags().StringVar(&notifyLabel, "label", "compose-diff-breaking", "Label set on the issue while the report has breaking changes")
+	notifyCmd.Flags().BoolVar(&notifyKeepLabel, "keep-label", false, "Do not remove the label when there are no breaking changes")
+	notifyCmd.Flags().StringVar(&commentKey, "key", "", "Keeps a separate comment per key on the same issue")
+
+	rootCmd.AddCommand(notifyCmd)
+}
+
+func runNotify(cmd *cobra.Command, args []string) {
+	if err := requireOnline("notify (posts to the Jira API)"); err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(2)
+	}
+
+	siteURL := valueOr(notifyJiraURL, os.Getenv("JIRA_URL"))
+	if notifyJira == "" || siteURL == "" {
+		color.Red("Usage: compose-diff notify --jira <issue> --jira-url <site> [report.json]")
+		os.Exit(2)
+	}
+	token := os.Getenv("JIRA_API_TOKEN")
+	if token == "" {
+		color.Red("Error: set JIRA_API_TOKEN (with JIRA_EMAIL on Jira Cloud)")
+		os.Exit(2)
+	}
+
+	data, err := readReport(args)
+	if err != nil {
+		color.Red("Error reading report: %v", err)
+		os.Exit(2)
+	}
+	var jr reporter.JSONReport
+	if err := json.Unmarshal(data, &jr); err != nil {
+		color.Red("Error: not a JSON report (from diff --format json): %v", err)
+		os.Exit(2)
+	}
+	report := models.NewDiffReport()
+	for _, c := range jr.Changes {
+		report.AddChange(c)
+	}
+	report.Freeze = jr.Freeze
+	report.Summary.RiskScore = jr.Summary.RiskScore
+
+	jira := &forge.Jira{Client: commentClient(), BaseURL: siteURL, Email: os.Getenv("JIRA_EMAIL"), Token: token}
+	id, err := jira.UpsertIssueComment(notifyJira, commentKey, reporter.ToJira(report, jr.OldFile, jr.NewFile))
+	if err != nil {
+		color.Red("Error posting comment: %v", err)
+		os.Exit(2)
+	}
+	color.Green("Posted comment %s on %s", id, notifyJira)
+
+	breaking := report.Summary.BreakingCount > 0
+	if notifyLabel == "" || (!breaking && notifyKeepLabel) {
+		return
+	}
+	if err := jira.SetLabel(notifyJira, notifyLabel, breaking); err != nil {
+		color.Red("Error updating labels: %v", err)
+		os.Exit(2)
+	}
+	if breaking {
+		color.Yellow("Labeled %s %s", notifyJira, notifyLabel)
+	}
+}
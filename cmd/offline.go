@@ -0,0 +1,15 @@
+package cmd
+
+import "fmt"
+
+// offline hard-disables subprocesses, network access and writes other than
+// the command's own output (--offline), for locked-down build sandboxes
+var offline bool
+
+// requireOnline returns an error naming the feature if --offline is set
+func requireOnline(feature string) error {
+	if offline {
+		return fmt.Errorf("%s is not available with --offline", feature)
+	}
+	return nil
+}
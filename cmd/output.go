@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+var (
+	outputFiles  []string
+	templateFile string
+)
+
+// reportOutput is a file the report is written to with --output
+type reportOutput struct {
+	format string
+	path   string
+}
+
+// outputFormats maps file extensions to report formats
+var outputFormats = map[string]string{
+	".json":     "json",
+	".md":       "markdown",
+	".markdown": "markdown",
+	".html":     "html",
+	".htm":      "html",
+	".txt":      "text",
+	".csv":      "csv",
+	".tsv":      "tsv",
+	".patch":    "patch",
+	".diff":     "patch",
+}
+
+var reportFormats = []string{"text", "json", "markdown", "html", "github", "gitlab-codequality", "csv", "tsv", "patch", "review-json", "template"}
+
+// parseOutputs resolves the format of each --output before any work is
+// done, so a typo does not cost a whole diff
+func parseOutputs() []reportOutput {
+	var outputs []reportOutput
+	for _, arg := range outputFiles {
+		out := reportOutput{path: arg}
+		if format, path, ok := strings.Cut(arg, "="); ok && slices.Contains(reportFormats, format) {
+			out.format, out.path = format, path
+		} else if format, ok := outputFormats[strings.ToLower(filepath.Ext(arg))]; ok {
+			out.format = format
+		} else {
+			color.Red("Cannot tell the format of --output %s: use a .json, .md, .html, .txt, .csv or .tsv file, or format=path", arg)
+			os.Exit(exitCodeError)
+		}
+		outputs = append(outputs, out)
+	}
+	return outputs
+}
+
+// writeOutputs writes the report to each --output file, without terminal
+// colors, and notes each file on stderr so stdout stays the report
+func writeOutputs(outputs []reportOutput, report *models.DiffReport, oldFile, newFile string) {
+	if len(outputs) == 0 {
+		return
+	}
+
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	for _, out := range outputs {
+		content, err := renderReport(out.format, report, oldFile, newFile)
+		if err == nil {
+			err = os.WriteFile(out.path, []byte(strings.TrimRight(content, "\n")+"\n"), 0644)
+		}
+		if err != nil {
+			color.NoColor = noColor
+			color.Red("Error writing %s: %v", out.path, err)
+			os.Exit(exitCodeError)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %s report to %s\n", out.format, out.path)
+	}
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/compose-diff/internal/plan"
+	"github.com/stackgen-cli/compose-diff/internal/redact"
+	"github.com/stackgen-cli/compose-diff/internal/reporter"
+	"github.com/stackgen-cli/compose-diff/pkg/composediff"
+)
+
+// planKeyEnv names the variable holding the plan signing key
+const planKeyEnv = "COMPOSE_DIFF_PLAN_KEY"
+
+var (
+	planOut       string
+	allowUnsigned bool
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan <old-compose.yml> <new-compose.yml>",
+	Short: "Write a signed plan of a diff for review before deploy",
+	Long: `Diff two compose files like "diff", print the report, and write a plan file
+holding the report and the SHA-256 of the new file. The plan is signed with
+the key in COMPOSE_DIFF_PLAN_KEY. After the plan is reviewed, "approve" in
+the deploy job checks that the file being deployed is the reviewed one, so
+nothing sneaks in between review and deploy.
+
+Examples:
+  compose-diff plan --out compose.plan.json prod.yml docker-compose.yml
+  compose-diff approve compose.plan.json docker-compose.yml && docker compose up -d`,
+	Args: cobra.ExactArgs(2),
+	Run:  runPlan,
+}
+
+var approveCmd = &cobra.Command{
+	Use:   "approve <plan.json> <compose.yml>",
+	Short: "Check that a compose file is the one a plan approved",
+	Long: `Verify a plan file's signature with the key in COMPOSE_DIFF_PLAN_KEY and
+check that the compose file about to be deployed has exactly the contents
+the plan was made from. Exits 0 if so, and 1 with the reason otherwise.`,
+	Args: cobra.ExactArgs(2),
+	Run:  runApprove,
+}
+
+func init() {
+	planCmd.Flags().StringVarP(&planOut, "out", "o", "compose-diff.plan.json", "Plan file to write")
+	planCmd.Flags().StringVar(&rulesFile, "rules", "", "Path to rules file (default: .compose-diff.yaml)")
+	planCmd.Flags().StringVar(&policyBundle, "policy-bundle", "", "OCI reference of a rules/hints bundle")
+	planCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "Print and store secret-looking values instead of masking them")
+	planCmd.Flags().StringVar(&nowFlag, "now", "", "Check freeze windows at this time (RFC 3339 or YYYY-MM-DD) instead of now")
+	approveCmd.Flags().BoolVar(&allowUnsigned, "allow-unsigned", false, "Accept plans written without a key")
+
+	rootCmd.AddCommand(planCmd, approveCmd)
+}
+
+func runPlan(cmd *cobra.Command, args []string) {
+	if err := requireOnline("plan (writes the plan file)"); err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(2)
+	}
+
+	r, err := loadRules()
+	if err != nil {
+		color.Red("Error loading rules: %v", err)
+		os.Exit(2)
+	}
+
+	oldFile, newFile := args[0], args[1]
+	content, err := os.ReadFile(newFile)
+	if err != nil {
+		color.Red("Error reading %s: %v", newFile, err)
+		os.Exit(2)
+	}
+	oldIR, err := composediff.LoadFile(oldFile, composediff.Options{})
+	if err != nil {
+		color.Red("Error parsing %s: %v", oldFile, err)
+		os.Exit(2)
+	}
+	newIR, err := composediff.LoadFile(newFile, composediff.Options{})
+	if err != nil {
+		color.Red("Error parsing %s: %v", newFile, err)
+		os.Exit(2)
+	}
+
+	report := composediff.Compare(oldIR, newIR, composediff.Options{})
+	if r != nil {
+		report = applyRules(report, r, r.Policies(oldIR, newIR))
+	}
+	// The plan file is shared for review, so it holds masked values too
+	if !showSecrets {
+		redact.Report(report)
+	}
+
+	p, err := plan.New(reporter.ToJSON(report, oldFile, newFile), oldFile, newFile, content, currentTime())
+	if err != nil {
+		color.Red("Error creating plan: %v", err)
+		os.Exit(2)
+	}
+	if key := os.Getenv(planKeyEnv); key != "" {
+		if err := p.Sign([]byte(key)); err != nil {
+			color.Red("Error signing plan: %v", err)
+			os.Exit(2)
+		}
+	} else {
+		color.Yellow("Warning: %s is not set; the plan is unsigned and approve needs --allow-unsigned", planKeyEnv)
+	}
+	if err := p.Write(planOut); err != nil {
+		color.Red("Error writing plan: %v", err)
+		os.Exit(2)
+	}
+
+	fmt.Println(reporter.ToText(report, oldFile, newFile))
+	color.Green("Wrote plan to %s (%s sha256 %s)", planOut, newFile, p.NewSHA256[:12])
+}
+
+func runApprove(cmd *cobra.Command, args []string) {
+	planFile, composeFile := args[0], args[1]
+	p, err := plan.Load(planFile)
+	if err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(2)
+	}
+	content, err := os.ReadFile(composeFile)
+	if err != nil {
+		color.Red("Error reading %s: %v", composeFile, err)
+		os.Exit(2)
+	}
+
+	if err := p.Verify([]byte(os.Getenv(planKeyEnv)), content, allowUnsigned); err != nil {
+		if errors.Is(err, plan.ErrUnsigned) {
+			err = fmt.Errorf("%w: set %s when planning, or pass --allow-unsigned", err, planKeyEnv)
+		}
+		color.Red("Not approved: %s: %v", composeFile, err)
+		os.Exit(1)
+	}
+
+	var report reporter.JSONReport
+	json.Unmarshal(p.Report, &report)
+	color.Green("Approved: %s matches the plan of %s (%d changes, %d breaking)",
+		composeFile, p.CreatedAt.Local().Format("2006-01-02 15:04"), report.Summary.TotalChanges, report.Summary.BreakingCount)
+}
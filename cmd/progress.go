@@ -0,0 +1,15 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/stackgen-cli/compose-diff/internal/progress"
+)
+
+// noProgress disables spinners, e.g. for CI logs captured through a pseudo-terminal
+var noProgress bool
+
+// newProgress returns a reporter that draws on stderr when it is a terminal
+func newProgress() *progress.Reporter {
+	return progress.New(os.Stderr, !noProgress)
+}
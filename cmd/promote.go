@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/compose-diff/internal/promote"
+	"github.com/stackgen-cli/compose-diff/internal/redact"
+	"github.com/stackgen-cli/compose-diff/internal/reporter"
+	"github.com/stackgen-cli/compose-diff/pkg/composediff"
+)
+
+var promoteAllow []string
+
+var promoteCmd = &cobra.Command{
+	Use:   "promote <from-compose.yml> <to-compose.yml>",
+	Short: "Check that a promotion changes only allowed fields",
+	Long: `Check that promoting one environment's compose file to the next (staging to
+prod, blue to green) changes only the fields listed in --allow. Exits 1 and
+lists the offending changes if anything else differs, or if it makes
+breaking changes during a freeze window of the rules file.
+
+Patterns are globs relative to each service ("image", "environment.FEATURE_*")
+unless they start with services., volumes., networks. or x-. A pattern also
+covers everything below it, so "deploy" allows deploy.replicas. Ignore rules
+from the rules file apply first.
+
+Examples:
+  compose-diff promote staging.yml prod.yml --allow image,environment.FEATURE_*
+  compose-diff promote --allow services.api.image --allow labels blue.yml green.yml`,
+	Args: cobra.ExactArgs(2),
+	Run:  runPromote,
+}
+
+func init() {
+	promoteCmd.Flags().StringSliceVar(&promoteAllow, "allow", nil, "Change paths the promotion may touch (comma-separated or repeatable)")
+	promoteCmd.Flags().StringVar(&rulesFile, "rules", "", "Path to rules file (default: .compose-diff.yaml)")
+	promoteCmd.Flags().StringVar(&policyBundle, "policy-bundle", "", "OCI reference of a rules/hints bundle")
+	promoteCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "Print secret-looking values instead of masking them")
+	promoteCmd.Flags().StringVar(&nowFlag, "now", "", "Check freeze windows at this time (RFC 3339 or YYYY-MM-DD) instead of now")
+
+	rootCmd.AddCommand(promoteCmd)
+}
+
+func runPromote(cmd *cobra.Command, args []string) {
+	policy, err := promote.Compile(promoteAllow)
+	if err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(2)
+	}
+
+	r, err := loadRules()
+	if err != nil {
+		color.Red("Error loading rules: %v", err)
+		os.Exit(2)
+	}
+
+	fromFile, toFile := args[0], args[1]
+	fromIR, err := composediff.LoadFile(fromFile, composediff.Options{})
+	if err != nil {
+		color.Red("Error parsing %s: %v", fromFile, err)
+		os.Exit(2)
+	}
+	toIR, err := composediff.LoadFile(toFile, composediff.Options{})
+	if err != nil {
+		color.Red("Error parsing %s: %v", toFile, err)
+		os.Exit(2)
+	}
+
+	report := composediff.Compare(toIR, fromIR, composediff.Options{IgnoreOrdering: true})
+	if r != nil {
+		report = applyRules(report, r, r.Policies(toIR, fromIR))
+	}
+	if !showSecrets {
+		redact.Report(report)
+	}
+	allowed, blocked := policy.Check(report.Changes)
+
+	fmt.Printf("Promoting: %s → %s\n\n", fromFile, toFile)
+	for _, c := range allowed {
+		fmt.Printf("  %s %s\n", color.GreenString("✓"), reporter.ChangeLine(c))
+	}
+	for _, c := range blocked {
+		fmt.Printf("  %s %s (%s)\n", color.RedString("✗"), reporter.ChangeLine(c), severityLabel(c.Severity))
+	}
+	if len(allowed)+len(blocked) > 0 {
+		fmt.Println()
+	}
+
+	if len(blocked) > 0 {
+		color.Red("Promotion blocked: %d changes outside --allow", len(blocked))
+		os.Exit(1)
+	}
+	if report.Freeze != nil && report.Summary.BreakingCount > 0 {
+		color.Red("Promotion blocked: %s", reporter.FreezeNotice(report.Freeze))
+		os.Exit(1)
+	}
+	color.Green("Promotion OK: %d allowed changes", len(allowed))
+}
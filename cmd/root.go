@@ -6,6 +6,7 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/compose-diff/internal/httpclient"
 )
 
 var (
@@ -36,6 +37,12 @@ func Execute() {
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&colorMode, "color", "auto", "Color output: auto, always, never")
+	rootCmd.PersistentFlags().BoolVar(&noProgress, "no-progress", false, "Disable progress spinners on stderr (they only show on a terminal)")
+	rootCmd.PersistentFlags().IntVar(&retries, "retries", httpclient.DefaultRetries, "Retries for transient registry/API failures (429, 5xx, connection errors), with backoff")
+	rootCmd.PersistentFlags().StringVar(&caCert, "ca-cert", "", "PEM file of extra CAs to trust for registry/API calls (e.g. a TLS-intercepting proxy)")
+	rootCmd.PersistentFlags().BoolVar(&insecureTLS, "insecure", false, "Skip TLS certificate verification for registry/API calls")
+	rootCmd.PersistentFlags().StringVar(&credHelper, "cred-helper", "", "Docker credential helper for registries (e.g. ecr-login for docker-credential-ecr-login); default: docker config")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "Disable docker, network access and writes outside the requested output")
 
 	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
 		switch colorMode {
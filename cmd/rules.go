@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/compose-diff/internal/history"
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/parser"
+	"github.com/stackgen-cli/compose-diff/internal/rules"
+)
+
+var (
+	rulesInitOutput  string
+	rulesInitForce   bool
+	rulesApplyReport string
+)
+
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Manage compose-diff rules files",
+}
+
+var rulesInitCmd = &cobra.Command{
+	Use:   "init [compose-file|dir]",
+	Short: "Write a commented starter rules file",
+	Long: `Write a commented starter .compose-diff.yaml populated with the services
+found in the compose file and common override examples.
+
+Examples:
+  compose-diff rules init
+  compose-diff rules init docker-compose.prod.yml
+  compose-diff rules init --output ci/compose-diff.yaml`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runRulesInit,
+}
+
+var rulesApplyCmd = &cobra.Command{
+	Use:   "apply --report <report.json> --rules <rules.yaml>",
+	Short: "Dry-run a rules file against an existing JSON report",
+	Long: `Show how a proposed rules file would have reclassified the findings of a
+past JSON report (from --format json): which changes would be ignored,
+downgraded or upgraded. Nothing is written.
+
+Examples:
+  compose-diff rules apply --report report.json --rules new-rules.yaml`,
+	Args: cobra.NoArgs,
+	Run:  runRulesApply,
+}
+
+func init() {
+	rulesApplyCmd.Flags().StringVar(&rulesApplyReport, "report", "", "JSON report to re-evaluate")
+	rulesApplyCmd.Flags().StringVar(&rulesFile, "rules", "", "Proposed rules file (default: .compose-diff.yaml)")
+	rulesApplyCmd.MarkFlagRequired("report")
+	rulesCmd.AddCommand(rulesApplyCmd)
+
+	rulesInitCmd.Flags().StringVarP(&rulesInitOutput, "output", "o", rules.DefaultRulesFile, "Path of the rules file to write")
+	rulesInitCmd.Flags().BoolVar(&rulesInitForce, "force", false, "Overwrite an existing rules file")
+
+	rulesCmd.AddCommand(rulesInitCmd)
+	rootCmd.AddCommand(rulesCmd)
+}
+
+func runRulesInit(cmd *cobra.Command, args []string) {
+	composePath := "."
+	if len(args) > 0 {
+		composePath = args[0]
+	}
+
+	ir, err := parser.ParseComposeFile(composePath)
+	if err != nil {
+		color.Red("Error parsing %s: %v", composePath, err)
+		os.Exit(2)
+	}
+
+	services := make([]string, 0, len(ir.Services))
+	for name := range ir.Services {
+		services = append(services, name)
+	}
+
+	if err := writeNewFile(rulesInitOutput, []byte(rules.StarterConfig(services)), rulesInitForce); err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(2)
+	}
+
+	color.Green("Wrote %s with %d services", rulesInitOutput, len(services))
+}
+
+// writeNewFile writes content to path, refusing to overwrite unless force is set
+func writeNewFile(path string, content []byte, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", path)
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, content, 0644)
+}
+
+func runRulesApply(cmd *cobra.Command, args []string) {
+	reports, err := history.LoadReports([]string{rulesApplyReport})
+	if err != nil || len(reports) != 1 {
+		color.Red("Error loading report: %v", err)
+		os.Exit(2)
+	}
+	report := reports[0]
+
+	r, err := loadRules()
+	if err != nil {
+		color.Red("Error loading rules: %v", err)
+		os.Exit(2)
+	}
+
+	var ignored, downgraded, upgraded, unchanged int
+	var lines []string
+	// The report keeps each change's before and after but not the
+	// services, so policies reading old or new fail and are listed below
+	policies := r.Policies(nil, nil)
+	_, frozen := r.ActiveFreeze(currentTime())
+
+	for _, c := range report.Changes {
+		out := r.Apply(c, policies, frozen)
+		if len(out.Matches) == 0 {
+			unchanged++
+			continue
+		}
+
+		match := out.Matches[len(out.Matches)-1]
+		location := match.File
+		if match.Line > 0 {
+			location = fmt.Sprintf("%s:%d", match.File, match.Line)
+		}
+
+		severity := out.Change.Severity
+		switch {
+		case out.Ignored:
+			ignored++
+			lines = append(lines, fmt.Sprintf("  %s %s (%s) [%s]", color.CyanString("IGNORED   "), c.Path, c.Severity, location))
+		case models.SeverityLevel(severity) < models.SeverityLevel(c.Severity):
+			downgraded++
+			lines = append(lines, fmt.Sprintf("  %s %s: %s → %s [%s]", color.GreenString("DOWNGRADED"), c.Path, c.Severity, severity, location))
+		case models.SeverityLevel(severity) > models.SeverityLevel(c.Severity):
+			upgraded++
+			lines = append(lines, fmt.Sprintf("  %s %s: %s → %s [%s]", color.RedString("UPGRADED  "), c.Path, c.Severity, severity, location))
+		default:
+			unchanged++
+		}
+	}
+	if err := policies.Err(); err != nil {
+		color.Red("Error in rules: %v", err)
+		os.Exit(2)
+	}
+
+	rulesName := r.Path()
+	if rulesName == "" {
+		rulesName = "(no rules file)"
+	}
+
+	fmt.Printf("Applying %s to %s (%d findings)\n\n", rulesName, rulesApplyReport, len(report.Changes))
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	if len(lines) > 0 {
+		fmt.Println()
+	}
+	fmt.Printf("%d newly ignored, %d downgraded, %d upgraded, %d unchanged\n", ignored, downgraded, upgraded, unchanged)
+	for _, f := range policies.Findings() {
+		color.Yellow("Warning: %s (not evaluated; diff the compose files to apply it)", f.Message)
+	}
+}
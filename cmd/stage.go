@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/compose-diff/internal/staging"
+	"github.com/stackgen-cli/compose-diff/pkg/composediff"
+)
+
+var stageOut string
+
+var stageCmd = &cobra.Command{
+	Use:   "stage <old-compose.yml> <new-compose.yml>",
+	Short: "Suggest a safe order for applying a change in several steps",
+	Long: `Split a change into intermediate compose files to apply one after another
+with docker compose up -d, so no service loses something it needs halfway:
+
+  expand    add new services, volumes, networks and other declarations, and
+            apply the service changes that break nothing
+  update    apply the services with breaking changes, dependencies first,
+            one depends_on level per step
+  contract  remove what the new file no longer has (use --remove-orphans)
+
+The last step is the new file itself. Intermediate files are whole compose
+files with YAML aliases expanded, since override files cannot remove keys.
+Severities follow the rules file. Without --out only the steps are printed.
+
+Examples:
+  compose-diff stage prod.yml docker-compose.yml
+  compose-diff stage -o rollout/ prod.yml docker-compose.yml`,
+	Args: cobra.ExactArgs(2),
+	Run:  runStage,
+}
+
+func init() {
+	stageCmd.Flags().StringVarP(&stageOut, "out", "o", "", "Directory to write the step files to")
+	stageCmd.Flags().StringVar(&rulesFile, "rules", "", "Path to rules file (default: .compose-diff.yaml)")
+	stageCmd.Flags().StringVar(&policyBundle, "policy-bundle", "", "OCI reference of a rules/hints bundle")
+
+	rootCmd.AddCommand(stageCmd)
+}
+
+func runStage(cmd *cobra.Command, args []string) {
+	r, err := loadRules()
+	if err != nil {
+		color.Red("Error loading rules: %v", err)
+		os.Exit(2)
+	}
+
+	oldFile, newFile := args[0], args[1]
+	oldData, err := os.ReadFile(oldFile)
+	if err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(2)
+	}
+	newData, err := os.ReadFile(newFile)
+	if err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(2)
+	}
+	oldIR, err := composediff.LoadFile(oldFile, composediff.Options{})
+	if err != nil {
+		color.Red("Error parsing %s: %v", oldFile, err)
+		os.Exit(2)
+	}
+	newIR, err := composediff.LoadFile(newFile, composediff.Options{})
+	if err != nil {
+		color.Red("Error parsing %s: %v", newFile, err)
+		os.Exit(2)
+	}
+
+	report := composediff.Compare(oldIR, newIR, composediff.Options{IgnoreOrdering: true})
+	if r != nil {
+		report = applyRules(report, r, r.Policies(oldIR, newIR))
+	}
+	steps, err := staging.Plan(oldData, newData, report, newIR)
+	if err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(2)
+	}
+
+	if len(steps) == 1 {
+		color.Green("No staging needed: apply %s in one step.", newFile)
+	} else {
+		fmt.Printf("Apply %s → %s in %d steps:\n\n", oldFile, newFile, len(steps))
+		for i, step := range steps {
+			fmt.Printf("  %d. %s: %s\n", i+1, step.Name, strings.Join(step.Actions, ", "))
+		}
+	}
+	if stageOut == "" {
+		return
+	}
+
+	if err := os.MkdirAll(stageOut, 0755); err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(2)
+	}
+	fmt.Println()
+	for i, step := range steps {
+		path := filepath.Join(stageOut, fmt.Sprintf("%02d-%s.yml", i+1, step.Name))
+		if err := os.WriteFile(path, step.Data, 0644); err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(2)
+		}
+		fmt.Printf("Wrote %s\n", path)
+	}
+}
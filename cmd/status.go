@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/compose-diff/internal/forge"
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/reporter"
+)
+
+var (
+	statusSHA         string
+	statusPrefix      string
+	statusTargetURL   string
+	statusMaxBreaking int
+	statusMaxWarning  int
+	statusMaxInfo     int
+	statusCheckRun    bool
+	statusFile        string
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status [report.json]",
+	Short: "Post a commit status per severity",
+	Long: `Post one commit status per severity (compose-diff/breaking,
+compose-diff/warning, compose-diff/info) from a JSON report, from a file or
+stdin. A status fails when its count exceeds the budget for its severity, so
+branch protection can require "no breaking compose changes" without failing
+on warnings.
+
+On GitHub the token is read from GITHUB_TOKEN (or GH_TOKEN) and needs
+statuses: write. Bitbucket gets build statuses on the commit and Azure DevOps
+pull request statuses (genre compose-diff), with the credentials described
+in "compose-diff comment --help". The platform follows the CI system unless
+--platform is given, and in CI --repo, --sha, --pr and --target-url default
+to the current repository, pull request head commit and build.
+
+With --check-run, GitHub gets a single check run named after --context
+instead, failing if any severity is over its budget, with an annotation per
+change at its line in the new file so the changes show up on the Files
+Changed tab. That needs a GitHub App installation token with checks: write,
+such as GITHUB_TOKEN in Actions; personal access tokens cannot create check
+runs.
+
+Examples:
+  compose-diff diff --format json old.yml new.yml > report.json
+  compose-diff status report.json
+
+  # Fail on breaking changes or more than 10 warnings
+  compose-diff status --max-warning 10 report.json
+
+  # One check run with inline annotations
+  compose-diff status --check-run report.json`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runStatus,
+}
+
+func init() {
+	statusCmd.Flags().StringVar(&commentRepo, "repo", "", "Repository as owner/name, Bitbucket workspace/slug or Azure project/repository (default: from the CI environment)")
+	statusCmd.Flags().StringVar(&statusSHA, "sha", "", "Commit to post the statuses on (default: the pull request head, or GITHUB_SHA or BITBUCKET_COMMIT)")
+	statusCmd.Flags().IntVar(&commentPR, "pr", 0, "Azure DevOps pull request number (default: SYSTEM_PULLREQUEST_PULLREQUESTID)")
+	statusCmd.Flags().StringVar(&commentAPIURL, "api-url", "", "API URL, for GitHub Enterprise, or the Azure DevOps organization URL (default: from the CI environment)")
+	statusCmd.Flags().StringVar(&forgePlatform, "platform", "", "github, bitbucket or azure (default: detected from the CI environment)")
+	statusCmd.Flags().StringVar(&statusPrefix, "context", "compose-diff", "Status name prefix; statuses are <context>/<severity>")
+	statusCmd.Flags().StringVar(&statusTargetURL, "target-url", "", "Link for the statuses (default: the workflow run)")
+	statusCmd.Flags().IntVar(&statusMaxBreaking, "max-breaking", 0, "Breaking changes allowed before compose-diff/breaking fails (-1 for no limit)")
+	statusCmd.Flags().IntVar(&statusMaxWarning, "max-warning", -1, "Warnings allowed before compose-diff/warning fails (-1 for no limit)")
+	statusCmd.Flags().IntVar(&statusMaxInfo, "max-info", -1, "Info changes allowed before compose-diff/info fails (-1 for no limit)")
+	statusCmd.Flags().BoolVar(&statusCheckRun, "check-run", false, "Post one GitHub check run with inline annotations instead of statuses")
+	statusCmd.Flags().StringVar(&statusFile, "file", "", "Repository path of the new compose file, for --check-run annotations (default: the report's new_file)")
+
+	rootCmd.AddCommand(statusCmd)
+}
+
+func runStatus(cmd *cobra.Command, args []string) {
+	if err := requireOnline("status (posts to the code review platform's API)"); err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(2)
+	}
+
+	var post func(forge.CommitStatus) error
+	switch platform := detectPlatform(); {
+	case statusCheckRun && platform != "github":
+		color.Red("Error: --check-run needs --platform github, not %q", platform)
+		os.Exit(2)
+	case statusCheckRun:
+		// posted below, after the statuses are worked out
+	case platform == "github":
+		post = githubStatusPoster()
+	case platform == "bitbucket":
+		post = bitbucketStatusPoster()
+	case platform == "azure":
+		post = azureStatusPoster()
+	default:
+		color.Red("Error: status supports --platform github, bitbucket or azure, not %q", platform)
+		os.Exit(2)
+	}
+
+	data, err := readReport(args)
+	if err != nil {
+		color.Red("Error reading report: %v", err)
+		os.Exit(2)
+	}
+	var report reporter.JSONReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		color.Red("Error: not a JSON report (from diff --format json): %v", err)
+		os.Exit(2)
+	}
+
+	summary := models.DiffSummary{
+		BreakingCount: report.Summary.BreakingCount,
+		WarningCount:  report.Summary.WarningCount,
+		InfoCount:     report.Summary.InfoCount,
+	}
+	budget := forge.Budget{
+		models.SeverityBreaking: statusMaxBreaking,
+		models.SeverityWarning:  statusMaxWarning,
+		models.SeverityInfo:     statusMaxInfo,
+	}
+
+	statuses := forge.SeverityStatuses(summary, budget, statusPrefix)
+	if statusCheckRun {
+		postCheckRun(&report, statuses)
+		return
+	}
+	for _, s := range statuses {
+		s.TargetURL = valueOr(statusTargetURL, ciBuildURL())
+		if err := post(s); err != nil {
+			color.Red("Error posting status: %v", err)
+			os.Exit(2)
+		}
+		if s.State == "success" {
+			color.Green("%s: %s", s.Context, s.Description)
+		} else {
+			color.Red("%s: %s", s.Context, s.Description)
+		}
+	}
+}
+
+func githubStatusPoster() func(forge.CommitStatus) error {
+	gh, repo, sha := githubCommit("statuses: write")
+	return func(s forge.CommitStatus) error { return gh.SetCommitStatus(repo, sha, s) }
+}
+
+// postCheckRun posts the statuses as one GitHub check run annotating the
+// report's changes
+func postCheckRun(report *reporter.JSONReport, statuses []forge.CommitStatus) {
+	gh, repo, sha := githubCommit("checks: write")
+
+	run := forge.StatusCheckRun(statusPrefix, statuses)
+	run.HeadSHA = sha
+	run.DetailsURL = valueOr(statusTargetURL, ciBuildURL())
+	run.Annotations = forge.ChangeAnnotations(report.Changes, valueOr(statusFile, report.NewFile), reporter.ChangeMessage)
+	url, err := gh.CreateCheckRun(repo, run)
+	if err != nil {
+		color.Red("Error creating check run: %v", err)
+		os.Exit(2)
+	}
+
+	for _, s := range statuses {
+		if s.State == "success" {
+			color.Green("%s: %s", s.Context, s.Description)
+		} else {
+			color.Red("%s: %s", s.Context, s.Description)
+		}
+	}
+	fmt.Printf("Check run %s: %s, %d annotations\n%s\n", run.Name, run.Conclusion, len(run.Annotations), url)
+}
+
+// githubCommit returns a GitHub client, the repository and the commit to
+// post on, exiting if any is missing; scope is the permission the token needs
+func githubCommit(scope string) (*forge.GitHub, string, string) {
+	repo := valueOr(commentRepo, os.Getenv("GITHUB_REPOSITORY"))
+	sha := valueOr(statusSHA, valueOr(headSHAFromEvent(os.Getenv("GITHUB_EVENT_PATH")), os.Getenv("GITHUB_SHA")))
+	if repo == "" || sha == "" {
+		color.Red("Usage: compose-diff status --repo owner/name --sha <commit> [report.json]")
+		os.Exit(2)
+	}
+
+	token := valueOr(os.Getenv("GITHUB_TOKEN"), os.Getenv("GH_TOKEN"))
+	if token == "" {
+		color.Red("Error: set GITHUB_TOKEN (or GH_TOKEN) to a token with %s", scope)
+		os.Exit(2)
+	}
+
+	return &forge.GitHub{Client: commentClient(), BaseURL: valueOr(commentAPIURL, githubAPIURL()), Token: token}, repo, sha
+}
+
+func bitbucketStatusPoster() func(forge.CommitStatus) error {
+	repo := valueOr(commentRepo, os.Getenv("BITBUCKET_REPO_FULL_NAME"))
+	sha := valueOr(statusSHA, os.Getenv("BITBUCKET_COMMIT"))
+	if repo == "" || sha == "" {
+		color.Red("Usage: compose-diff status --platform bitbucket --repo workspace/slug --sha <commit> [report.json]")
+		os.Exit(2)
+	}
+
+	bb := bitbucketClient()
+	return func(s forge.CommitStatus) error { return bb.SetBuildStatus(repo, sha, s) }
+}
+
+func azureStatusPoster() func(forge.CommitStatus) error {
+	project, repo := azureRepo()
+	pr := commentPR
+	if pr == 0 {
+		pr, _ = strconv.Atoi(os.Getenv("SYSTEM_PULLREQUEST_PULLREQUESTID"))
+	}
+	if project == "" || repo == "" || pr == 0 {
+		color.Red("Usage: compose-diff status --platform azure --repo project/repository --pr <number> [report.json]")
+		os.Exit(2)
+	}
+
+	az := azureClient()
+	return func(s forge.CommitStatus) error { return az.SetPRStatus(project, repo, pr, s) }
+}
+
+// ciBuildURL links to the current GitHub Actions run, Bitbucket pipeline or
+// Azure Pipelines build, if any
+func ciBuildURL() string {
+	switch {
+	case os.Getenv("GITHUB_RUN_ID") != "":
+		return valueOr(os.Getenv("GITHUB_SERVER_URL"), "https://github.com") + "/" + os.Getenv("GITHUB_REPOSITORY") + "/actions/runs/" + os.Getenv("GITHUB_RUN_ID")
+	case os.Getenv("BITBUCKET_BUILD_NUMBER") != "":
+		return "https://bitbucket.org/" + os.Getenv("BITBUCKET_REPO_FULL_NAME") + "/pipelines/results/" + os.Getenv("BITBUCKET_BUILD_NUMBER")
+	case os.Getenv("BUILD_BUILDID") != "":
+		return strings.TrimRight(os.Getenv("SYSTEM_COLLECTIONURI"), "/") + "/" + url.PathEscape(os.Getenv("SYSTEM_TEAMPROJECT")) + "/_build/results?buildId=" + os.Getenv("BUILD_BUILDID")
+	}
+	return ""
+}
+
+// headSHAFromEvent returns the head commit of the pull request in a GitHub
+// Actions event payload. GITHUB_SHA is a merge commit for pull requests, and
+// statuses on it do not show on the pull request.
+func headSHAFromEvent(path string) string {
+	if path == "" {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var event struct {
+		PullRequest struct {
+			Head struct {
+				SHA string `json:"sha"`
+			} `json:"head"`
+		} `json:"pull_request"`
+	}
+	if json.Unmarshal(data, &event) != nil {
+		return ""
+	}
+	return event.PullRequest.Head.SHA
+}
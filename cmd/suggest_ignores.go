@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/compose-diff/internal/history"
+)
+
+var (
+	suggestMinRatio   float64
+	suggestMinReports int
+)
+
+var suggestIgnoresCmd = &cobra.Command{
+	Use:   "suggest-ignores <report.json|dir>...",
+	Short: "Propose ignore rules for fields that change on every run",
+	Long: `Analyze past JSON reports (from --format json) and propose ignore rules
+for paths that change on (almost) every run, such as build timestamps in
+labels or generated hashes. The output is a ready-to-commit rules snippet.
+
+Examples:
+  compose-diff suggest-ignores reports/
+  compose-diff suggest-ignores --min-ratio 0.8 run1.json run2.json run3.json`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runSuggestIgnores,
+}
+
+func init() {
+	suggestIgnoresCmd.Flags().Float64Var(&suggestMinRatio, "min-ratio", 1.0, "Fraction of reports a path must change in (0-1]")
+	suggestIgnoresCmd.Flags().IntVar(&suggestMinReports, "min-reports", 2, "Minimum number of reports required")
+
+	rootCmd.AddCommand(suggestIgnoresCmd)
+}
+
+func runSuggestIgnores(cmd *cobra.Command, args []string) {
+	reports, err := history.LoadReports(args)
+	if err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(2)
+	}
+
+	if len(reports) < suggestMinReports {
+		color.Red("Need at least %d reports, got %d", suggestMinReports, len(reports))
+		os.Exit(2)
+	}
+
+	suggestions := history.SuggestIgnores(reports, history.NoiseOptions{
+		MinReports: suggestMinReports,
+		MinRatio:   suggestMinRatio,
+	})
+
+	if len(suggestions) == 0 {
+		color.Green("No noisy fields found across %d reports.", len(reports))
+		return
+	}
+
+	fmt.Print(history.FormatRulesSnippet(suggestions))
+}
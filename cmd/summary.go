@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/progress"
+)
+
+// stderrSummary is --stderr-summary: auto, always or never
+var stderrSummary string
+
+// checkStderrSummaryFlag validates --stderr-summary
+func checkStderrSummaryFlag() {
+	switch stderrSummary {
+	case "auto", "always", "never":
+	default:
+		color.Red("Invalid --stderr-summary %q: use auto, always or never", stderrSummary)
+		os.Exit(exitCodeError)
+	}
+}
+
+// printStderrSummary writes the one-line verdict to stderr: always, never,
+// or in auto mode when stdout is not a terminal, where the report itself
+// may end up in a file nobody reads
+func printStderrSummary(report *models.DiffReport) {
+	if stderrSummary == "never" || (stderrSummary == "auto" && progress.IsTerminal(os.Stdout)) {
+		return
+	}
+	fmt.Fprintln(os.Stderr, summaryLine(report.Summary))
+}
+
+// summaryLine is the verdict as compose-diff: 3 breaking, 5 warning, 12 info
+func summaryLine(s models.DiffSummary) string {
+	return fmt.Sprintf("compose-diff: %d breaking, %d warning, %d info", s.BreakingCount, s.WarningCount, s.InfoCount)
+}
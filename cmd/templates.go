@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/compose-diff/internal/parser"
+	"github.com/stackgen-cli/compose-diff/internal/templates"
+)
+
+var (
+	templatesMinSimilarity float64
+	templatesMinServices   int
+	templatesSuggest       bool
+)
+
+var templatesCmd = &cobra.Command{
+	Use:   "templates <compose-file>",
+	Short: "Find near-identical services and suggest anchors for them",
+	Long: `Find groups of services that are copies of one another with a few fields
+changed (e.g. workers differing only by queue name), infer the template they
+share, and list what each service changes relative to it.
+
+With --suggest, print the group rewritten with a YAML anchor for the template
+and merge keys (<<:) in each service, ready to paste into the compose file.
+
+Examples:
+  compose-diff templates docker-compose.yml
+  compose-diff templates --suggest --min-services 2 docker-compose.yml`,
+	Args: cobra.ExactArgs(1),
+	Run:  runTemplates,
+}
+
+func init() {
+	defaults := templates.DefaultOptions()
+	templatesCmd.Flags().Float64Var(&templatesMinSimilarity, "min-similarity", defaults.MinSimilarity, "Fraction of fields services must share to be grouped (0-1]")
+	templatesCmd.Flags().IntVar(&templatesMinServices, "min-services", defaults.MinServices, "Smallest group to report")
+	templatesCmd.Flags().BoolVar(&templatesSuggest, "suggest", false, "Print each group rewritten with YAML anchors and merge keys")
+
+	rootCmd.AddCommand(templatesCmd)
+}
+
+func runTemplates(cmd *cobra.Command, args []string) {
+	path, err := parser.ResolveComposePath(args[0])
+	if err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(2)
+	}
+	data, err := parseRaw(path)
+	if err != nil {
+		color.Red("Error parsing %s: %v", path, err)
+		os.Exit(2)
+	}
+	services, _ := data["services"].(map[string]any)
+
+	groups := templates.Detect(services, templates.Options{
+		MinSimilarity: templatesMinSimilarity,
+		MinServices:   templatesMinServices,
+	})
+	if len(groups) == 0 {
+		color.Green("No groups of %d or more similar services found.", templatesMinServices)
+		return
+	}
+
+	if !templatesSuggest {
+		fmt.Print(templates.FormatReport(groups))
+		return
+	}
+	for i, g := range groups {
+		snippet, err := templates.Suggest(g)
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(2)
+		}
+		if i > 0 {
+			fmt.Println("---")
+		}
+		fmt.Print(snippet)
+	}
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/validate"
+)
+
+var (
+	schemaFile    string
+	validateFirst bool
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate <compose-file>...",
+	Short: "Check compose files against the Compose Specification schema",
+	Long: `Validate compose files against the Compose Specification JSON schema,
+reporting unknown keys and type errors with their file and line.
+
+Cross-field problems that docker compose only reports when it starts the
+stack, such as depends_on waiting for a service_healthy condition on a
+service without a healthcheck, are reported as warnings.
+
+Exits 1 if any file is invalid and 2 if a file cannot be read or parsed.
+Warnings alone do not fail.
+
+Examples:
+  compose-diff validate docker-compose.yml
+  compose-diff validate old.yml new.yml
+  compose-diff validate --schema compose-spec.json docker-compose.yml`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runValidate,
+}
+
+func init() {
+	validateCmd.Flags().StringVar(&schemaFile, "schema", "", "JSON schema to validate against (default: bundled compose-spec schema)")
+
+	rootCmd.AddCommand(validateCmd)
+}
+
+func runValidate(cmd *cobra.Command, args []string) {
+	invalid := false
+	for _, file := range args {
+		diags := validateFile(file)
+		printDiagnostics(os.Stdout, diags)
+		if hasErrors(diags) {
+			invalid = true
+		} else {
+			color.Green("%s: valid", file)
+		}
+	}
+	if invalid {
+		os.Exit(1)
+	}
+}
+
+// validateBeforeDiff checks the inputs of a diff and stops on any problem,
+// so a typo'd key is reported instead of silently not being compared
+func validateBeforeDiff(files []string) {
+	invalid := false
+	for _, file := range files {
+		diags := validateFile(file)
+		printDiagnostics(os.Stderr, diags)
+		if hasErrors(diags) {
+			invalid = true
+		}
+	}
+	if invalid {
+		color.Red("Schema validation failed")
+		os.Exit(exitCodeError)
+	}
+}
+
+// validateFile validates one file against --schema or the bundled schema,
+// exiting 2 if it cannot be read
+func validateFile(file string) []validate.Diagnostic {
+	schema := validate.Default()
+	if schemaFile != "" {
+		var err error
+		if schema, err = validate.LoadFile(schemaFile); err != nil {
+			color.Red("Error loading schema: %v", err)
+			os.Exit(exitCodeError)
+		}
+	}
+
+	diags, err := schema.ValidateFile(file)
+	if err != nil {
+		color.Red("Error validating %s: %v", file, err)
+		os.Exit(exitCodeError)
+	}
+	return diags
+}
+
+func printDiagnostics(w io.Writer, diags []validate.Diagnostic) {
+	red := color.New(color.FgRed).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+	for _, d := range diags {
+		if d.Warning {
+			fmt.Fprintln(w, yellow(d.String()))
+		} else {
+			fmt.Fprintln(w, red(d.String()))
+		}
+	}
+}
+
+func hasErrors(diags []validate.Diagnostic) bool {
+	for _, d := range diags {
+		if !d.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+// healthDependencyFindings returns the depends_on/healthcheck warnings of
+// newFile that oldFile does not already have, so a diff flags only the ones
+// the change introduces
+func healthDependencyFindings(oldFile, newFile string) []models.Finding {
+	warnings, err := validate.HealthDependencyWarnings(newFile)
+	if err != nil {
+		return nil // the file was parsed already; anything else is not ours to report
+	}
+	existing := make(map[string]bool)
+	if old, err := validate.HealthDependencyWarnings(oldFile); err == nil {
+		for _, d := range old {
+			existing[d.Path] = true
+		}
+	}
+
+	var findings []models.Finding
+	for _, d := range warnings {
+		if !existing[d.Path] {
+			findings = append(findings, models.Finding{
+				Check:    "healthcheck-missing",
+				Severity: models.SeverityWarning,
+				Message:  fmt.Sprintf("%s %s", strings.Split(d.Path, ".")[1], d.Message),
+				Paths:    []string{d.Path},
+			})
+		}
+	}
+	return findings
+}
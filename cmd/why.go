@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/compose-diff/internal/diff"
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/rules"
+)
+
+var whyAfter string
+
+var whyCmd = &cobra.Command{
+	Use:   "why <path> [kind]",
+	Short: "Explain the severity assigned to a change",
+	Long: `Explain which heuristic or rule produces the final severity for a change
+at the given path. The kind is added, removed, modified, reordered, renamed,
+dangling, cycle or orphaned, and defaults to "modified". --after gives the
+new value, which policies read as change.after and allowed_registries and
+forbid_tags check for an image.
+
+Examples:
+  compose-diff why services.api.environment.DATABASE_URL removed
+  compose-diff why --rules .compose-diff.yaml services.api.image
+  compose-diff why --after postgres:latest services.db.image`,
+	Args: cobra.RangeArgs(1, 2),
+	Run:  runWhy,
+}
+
+func init() {
+	whyCmd.Flags().StringVar(&rulesFile, "rules", "", "Path to rules file (default: .compose-diff.yaml)")
+	whyCmd.Flags().StringVar(&policyBundle, "policy-bundle", "", "OCI reference of a rules/hints bundle")
+	whyCmd.Flags().StringVar(&whyAfter, "after", "", "New value of the change, e.g. the image for an image path")
+
+	rootCmd.AddCommand(whyCmd)
+}
+
+func runWhy(cmd *cobra.Command, args []string) {
+	path := args[0]
+	kind := models.ChangeModified
+	if len(args) > 1 {
+		kind = models.ChangeKind(args[1])
+		if !models.ValidChangeKind(kind) {
+			color.Red("Unknown change kind %q: use added, removed, modified, reordered, renamed, dangling, cycle or orphaned", args[1])
+			os.Exit(2)
+		}
+	}
+
+	r, err := loadRules()
+	if err != nil {
+		color.Red("Error loading rules: %v", err)
+		os.Exit(2)
+	}
+
+	cyan := color.New(color.FgCyan).SprintFunc()
+
+	fmt.Printf("%s (%s)\n\n", cyan(path), kind)
+
+	heuristic := diff.ExplainSeverity(path, kind)
+	fmt.Printf("Heuristic: %s — %s\n", whySeverity(heuristic.Severity), heuristic.Heuristic)
+
+	final := heuristic.Severity
+	parts := splitPath(path)
+	service := ""
+	if len(parts) >= 2 {
+		service = parts[1]
+	}
+
+	scope := models.ScopeService
+	switch {
+	case strings.HasPrefix(path, "volumes."):
+		scope = models.ScopeVolume
+	case strings.HasPrefix(path, "networks."):
+		scope = models.ScopeNetwork
+	}
+	change := models.Change{Kind: kind, Scope: scope, Name: service, Path: path, Severity: final}
+	if cmd.Flags().Changed("after") {
+		change.After = whyAfter
+	}
+	// why has no compose files, so policies reading old or new fail and
+	// are reported below rather than silently skipped
+	policies := r.Policies(nil, nil)
+	freeze, frozen := r.ActiveFreeze(currentTime())
+	out := r.Apply(change, policies, frozen)
+	if err := policies.Err(); err != nil {
+		color.Red("Error in rules: %v", err)
+		os.Exit(2)
+	}
+
+	if len(out.Matches) == 0 {
+		if r.Path() == "" {
+			fmt.Println("Rule:      no rules file loaded")
+		} else {
+			fmt.Printf("Rule:      no rule in %s matches\n", r.Path())
+		}
+	}
+	for _, match := range out.Matches {
+		fmt.Printf("Rule:      %s\n", describeMatch(match))
+	}
+	if frozen && final == models.SeverityBreaking {
+		fmt.Printf("Freeze:    %s keeps it breaking\n", freeze.Name)
+	}
+	if change.After == nil && r.HasImageRules() && extractFieldFromPath(path) == "image" {
+		fmt.Println("Image:     allowed_registries and forbid_tags were not checked; pass the new image with --after")
+	}
+	findings := policies.Findings()
+	for _, f := range findings {
+		fmt.Printf("Policy:    %s\n", f.Message)
+	}
+
+	switch {
+	case out.Ignored:
+		fmt.Println("Final:     ignored (not reported)")
+	case len(findings) > 0:
+		fmt.Printf("Final:     %s, unless a policy that needs the compose files decides otherwise; run diff to evaluate it\n", whySeverity(out.Change.Severity))
+	default:
+		fmt.Printf("Final:     %s\n", whySeverity(out.Change.Severity))
+	}
+}
+
+// describeMatch names the rule that applied, with its file and line
+func describeMatch(match rules.Match) string {
+	location := match.File
+	if match.Line > 0 {
+		location = fmt.Sprintf("%s:%d", match.File, match.Line)
+	}
+
+	switch match.Action {
+	case "ignore":
+		s := fmt.Sprintf("%s ignore_patterns %q", location, match.Pattern)
+		if match.Reason != "" {
+			s += fmt.Sprintf(" (%s)", match.Reason)
+		}
+		return s
+	case "service-ignore":
+		return fmt.Sprintf("%s service_ignores %s", location, match.Pattern)
+	case "policy":
+		return fmt.Sprintf("%s severity_policy %s → %s", location, match.Pattern, match.Severity)
+	case "cel":
+		if match.Severity == "" {
+			return fmt.Sprintf("%s policies %s → ignore", location, match.Pattern)
+		}
+		return fmt.Sprintf("%s policies %s → %s", location, match.Pattern, match.Severity)
+	case "image":
+		return fmt.Sprintf("%s %s → %s", location, match.Reason, match.Severity)
+	}
+	return fmt.Sprintf("%s severity_overrides %q → %s", location, match.Pattern, match.Severity)
+}
+
+// whySeverity is severityLabel, or "unknown" when the engine's severity
+// depends on values why does not have
+func whySeverity(s models.Severity) string {
+	if s == "" {
+		return "unknown"
+	}
+	return severityLabel(s)
+}
+
+// severityLabel colors a severity name for terminal output
+func severityLabel(s models.Severity) string {
+	switch s {
+	case models.SeverityBreaking:
+		return color.RedString(string(s))
+	case models.SeverityWarning:
+		return color.YellowString(string(s))
+	}
+	return string(s)
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/compose-diff/internal/lint"
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/parser"
+	"github.com/stackgen-cli/compose-diff/internal/redact"
+	"github.com/stackgen-cli/compose-diff/internal/reporter"
+	"github.com/stackgen-cli/compose-diff/internal/risk"
+	"github.com/stackgen-cli/compose-diff/internal/rules"
+	"github.com/stackgen-cli/compose-diff/internal/workspace"
+	"github.com/stackgen-cli/compose-diff/pkg/composediff"
+)
+
+var (
+	workspaceFormat string
+	workspaceStrict bool
+)
+
+var workspaceCmd = &cobra.Command{
+	Use:   "workspace",
+	Short: "Diff several compose projects that share a host",
+}
+
+var workspaceDiffCmd = &cobra.Command{
+	Use:   "diff [workspace.yaml]",
+	Short: "Diff every project of a workspace and check what they share",
+	Long: `Diff each project listed in a workspace file (default: ` + workspace.DefaultFile + `),
+then check the external networks and volumes the projects share: a project
+that stops creating a network another project attaches to as external
+breaks that project even though its own diff looks fine.
+
+A workspace file lists each project's old and new compose file, relative to
+the workspace file. The name is the compose project name, which Docker uses
+to name volumes and networks without a name: of their own; it defaults to
+the directory of the new file.
+
+Each project is diffed like diff does, with the rules file (--rules,
+--policy-bundle or .compose-diff.yaml in the current directory) and the
+"# compose-diff:" comments of its new file applied to its report.
+
+  projects:
+    - name: edge
+      old: deploy/prod/edge.yml
+      new: edge/docker-compose.yml
+    - name: shop
+      old: deploy/prod/shop.yml
+      new: shop/docker-compose.yml
+
+Examples:
+  compose-diff workspace diff
+  compose-diff workspace diff --strict --format json stacks.yaml
+  compose-diff workspace diff --rules ops/.compose-diff.yaml`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runWorkspaceDiff,
+}
+
+func init() {
+	workspaceDiffCmd.Flags().StringVarP(&workspaceFormat, "format", "f", "text", "Output format: text, json")
+	workspaceDiffCmd.Flags().BoolVar(&workspaceStrict, "strict", false, "Exit 1 if any project or the workspace has breaking changes")
+	workspaceDiffCmd.Flags().StringVar(&rulesFile, "rules", "", "Path to rules file (default: .compose-diff.yaml)")
+	workspaceDiffCmd.Flags().StringVar(&policyBundle, "policy-bundle", "", "OCI reference of a rules/hints bundle")
+	workspaceDiffCmd.Flags().StringSliceVar(&orderedLists, "ordered-lists", nil, "List fields compared by position rather than as sets (default: command,entrypoint,env_file,dns_search)")
+	workspaceDiffCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "Print secret-looking values instead of masking them")
+
+	workspaceCmd.AddCommand(workspaceDiffCmd)
+	rootCmd.AddCommand(workspaceCmd)
+}
+
+// workspaceProject is one project's report in workspace diff --format json
+type workspaceProject struct {
+	Name   string               `json:"name"`
+	Report *reporter.JSONReport `json:"report"`
+}
+
+func runWorkspaceDiff(cmd *cobra.Command, args []string) {
+	path := workspace.DefaultFile
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if err := parser.CheckOrderedLists(orderedLists); err != nil {
+		color.Red("Invalid --ordered-lists: %v", err)
+		os.Exit(2)
+	}
+	ws, err := workspace.Load(path)
+	if err != nil {
+		color.Red("Error loading workspace: %v", err)
+		os.Exit(2)
+	}
+	r, err := loadRules()
+	if err != nil {
+		color.Red("Error loading rules: %v", err)
+		os.Exit(2)
+	}
+	weights := risk.DefaultWeights()
+	if r != nil {
+		weights = r.RiskWeights()
+	}
+
+	oldIRs := make(map[string]*models.ComposeIR)
+	newIRs := make(map[string]*models.ComposeIR)
+	reports := make(map[string]*models.DiffReport, len(ws.Projects))
+	for _, p := range ws.Projects {
+		oldIR, err := composediff.LoadFile(p.Old, composediff.Options{})
+		if err != nil {
+			color.Red("Error parsing %s: %v", p.Old, err)
+			os.Exit(2)
+		}
+		newIR, err := composediff.LoadFile(p.New, composediff.Options{})
+		if err != nil {
+			color.Red("Error parsing %s: %v", p.New, err)
+			os.Exit(2)
+		}
+		oldIRs[p.Name], newIRs[p.Name] = oldIR, newIR
+
+		findings := lint.Findings(oldIR, newIR)
+		var policies *rules.PolicyEvaluator
+		if r != nil {
+			findings = append(findings, r.Conventions().Check(oldIR, newIR)...)
+			findings = append(findings, r.CheckRequirements(oldIR, newIR)...)
+			if r.HasPolicies() {
+				policies = r.Policies(oldIR, newIR)
+			}
+		}
+
+		report := composediff.Compare(oldIR, newIR, compareOptions(r, composediff.Options{IgnoreOrdering: true}))
+		report = reviewReport(report, r, policies, newIR.Directives)
+		findings = append(findings, policies.Findings()...)
+		report.Findings = findings
+		report.Summary.RiskScore = risk.Score(report, weights)
+		if !showSecrets {
+			redact.Report(report)
+		}
+		reports[p.Name] = report
+	}
+
+	findings := workspace.Check(oldIRs, newIRs)
+	merged := workspace.Merge(reports, findings)
+
+	switch workspaceFormat {
+	case "json":
+		out := struct {
+			Projects []workspaceProject `json:"projects"`
+			Findings []models.Finding   `json:"findings"`
+			Summary  models.DiffSummary `json:"summary"`
+		}{Findings: findings, Summary: merged.Summary}
+		if out.Findings == nil {
+			out.Findings = []models.Finding{}
+		}
+		for _, p := range ws.Projects {
+			out.Projects = append(out.Projects, workspaceProject{p.Name, reporter.ToJSON(reports[p.Name], p.Old, p.New)})
+		}
+		data, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			color.Red("Error generating JSON: %v", err)
+			os.Exit(2)
+		}
+		fmt.Println(string(data))
+	case "text":
+		for _, p := range ws.Projects {
+			fmt.Println(color.CyanString("Project: %s", p.Name))
+			fmt.Println(reporter.ToText(reports[p.Name], p.Old, p.New))
+		}
+		fmt.Println(color.CyanString("Workspace: %d projects, %d changes (%d breaking)", len(ws.Projects), merged.Summary.TotalChanges, merged.Summary.BreakingCount))
+		if len(findings) == 0 {
+			color.Green("No changes break another project.")
+		}
+		for _, f := range findings {
+			fmt.Printf("  %s %s\n", severityLabel(f.Severity), f.Message)
+			for _, p := range f.Paths {
+				fmt.Printf("    %s\n", p)
+			}
+		}
+	default:
+		color.Red("Unknown format %q: use text or json", workspaceFormat)
+		os.Exit(2)
+	}
+
+	if workspaceStrict && workspace.Breaking(merged) {
+		os.Exit(1)
+	}
+}
@@ -2,6 +2,7 @@ package baseline
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"time"
@@ -31,11 +32,21 @@ func NewManager(baseDir string) *Manager {
 	return &Manager{baseDir: baseDir}
 }
 
-// Save saves a baseline snapshot
+// Save saves a baseline snapshot. The previous snapshot of the same name,
+// if any, is kept in the baseline's history.
 func (m *Manager) Save(name string, data map[string]any, source string, resolved bool) error {
+	return m.SaveWithMetadata(name, data, source, resolved, nil)
+}
+
+// SaveWithMetadata saves a baseline snapshot with metadata such as the git
+// commit it was taken at
+func (m *Manager) SaveWithMetadata(name string, data map[string]any, source string, resolved bool, metadata map[string]string) error {
 	if err := os.MkdirAll(m.baseDir, 0755); err != nil {
 		return err
 	}
+	if err := m.archive(name); err != nil {
+		return err
+	}
 
 	baseline := &Baseline{
 		Version:   "1.0",
@@ -44,14 +55,19 @@ func (m *Manager) Save(name string, data map[string]any, source string, resolved
 		Source:    source,
 		Resolved:  resolved,
 		Data:      data,
+		Metadata:  metadata,
 	}
 
+	return m.write(baseline)
+}
+
+func (m *Manager) write(baseline *Baseline) error {
 	content, err := json.MarshalIndent(baseline, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	filename := sanitizeFilename(name) + ".json"
+	filename := sanitizeFilename(baseline.Name) + ".json"
 	path := filepath.Join(m.baseDir, filename)
 
 	return os.WriteFile(path, content, 0644)
@@ -108,11 +124,42 @@ func (m *Manager) List() ([]*Baseline, error) {
 	return baselines, nil
 }
 
-// Delete removes a baseline
+// Delete removes a baseline and its history
 func (m *Manager) Delete(name string) error {
 	filename := sanitizeFilename(name) + ".json"
 	path := filepath.Join(m.baseDir, filename)
-	return os.Remove(path)
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	return os.RemoveAll(m.historyDir(name))
+}
+
+// Rename renames a baseline, keeping its contents and creation time. It
+// fails if a baseline named newName already exists.
+func (m *Manager) Rename(oldName, newName string) error {
+	baseline, err := m.Load(oldName)
+	if err != nil {
+		return err
+	}
+	if sanitizeFilename(oldName) == sanitizeFilename(newName) {
+		baseline.Name = newName
+		return m.write(baseline)
+	}
+	if m.Exists(newName) {
+		return fmt.Errorf("baseline %q already exists", newName)
+	}
+
+	baseline.Name = newName
+	if err := m.write(baseline); err != nil {
+		return err
+	}
+	if _, err := os.Stat(m.historyDir(oldName)); err == nil {
+		os.RemoveAll(m.historyDir(newName))
+		if err := os.Rename(m.historyDir(oldName), m.historyDir(newName)); err != nil {
+			return err
+		}
+	}
+	return m.Delete(oldName)
 }
 
 // Exists checks if a baseline exists
@@ -0,0 +1,33 @@
+package baseline
+
+import (
+	"testing"
+)
+
+func TestRename(t *testing.T) {
+	mgr := NewManager(t.TempDir())
+	data := map[string]any{"services": map[string]any{"api": map[string]any{"image": "api:1"}}}
+	if err := mgr.Save("prod", data, "docker-compose.yml", false); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := mgr.Save("staging", data, "docker-compose.yml", false); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if err := mgr.Rename("prod", "staging"); err == nil {
+		t.Error("Expected renaming onto an existing baseline to fail")
+	}
+	if err := mgr.Rename("prod", "production"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if mgr.Exists("prod") {
+		t.Error("Expected the old baseline to be gone")
+	}
+	b, err := mgr.Load("production")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if b.Name != "production" || b.Source != "docker-compose.yml" || b.Data["services"] == nil {
+		t.Errorf("Unexpected renamed baseline %+v", b)
+	}
+}
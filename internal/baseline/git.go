@@ -0,0 +1,106 @@
+package baseline
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Metadata keys recorded by --save-baseline auto
+const (
+	MetaGitSHA       = "git_sha"
+	MetaGitBranch    = "git_branch"
+	MetaGitSubject   = "git_subject"
+	MetaGitAuthor    = "git_author"
+	MetaGitCommitted = "git_committed_at"
+	MetaGitDirty     = "git_dirty"
+)
+
+// GitRefPrefix marks a baseline reference that names a branch, as in git:main
+const GitRefPrefix = "git:"
+
+// ciBranchVars hold the branch name in CI systems, which often check out a
+// detached HEAD
+var ciBranchVars = []string{"GITHUB_HEAD_REF", "GITHUB_REF_NAME", "CI_COMMIT_REF_NAME", "BITBUCKET_BRANCH", "BUILD_SOURCEBRANCHNAME"}
+
+// GitMetadata describes the commit checked out in dir
+func GitMetadata(dir string) (map[string]string, error) {
+	out, err := git(dir, "log", "-1", "--format=%H%x00%s%x00%an <%ae>%x00%cI")
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.SplitN(out, "\x00", 4)
+	if len(fields) != 4 {
+		return nil, fmt.Errorf("unexpected git log output %q", out)
+	}
+	meta := map[string]string{
+		MetaGitSHA:       fields[0],
+		MetaGitSubject:   fields[1],
+		MetaGitAuthor:    fields[2],
+		MetaGitCommitted: fields[3],
+	}
+
+	if branch, err := git(dir, "rev-parse", "--abbrev-ref", "HEAD"); err == nil && branch != "HEAD" {
+		meta[MetaGitBranch] = branch
+	} else {
+		for _, env := range ciBranchVars {
+			if v := os.Getenv(env); v != "" {
+				meta[MetaGitBranch] = v
+				break
+			}
+		}
+	}
+
+	if status, err := git(dir, "status", "--porcelain", "--untracked-files=no"); err == nil && status != "" {
+		meta[MetaGitDirty] = "true"
+	}
+	return meta, nil
+}
+
+// AutoName names a baseline after its commit: branch-shortsha, or just the
+// short SHA when the branch is unknown
+func AutoName(meta map[string]string) string {
+	sha := meta[MetaGitSHA]
+	if len(sha) > 12 {
+		sha = sha[:12]
+	}
+	if branch := meta[MetaGitBranch]; branch != "" {
+		return branch + "-" + sha
+	}
+	return sha
+}
+
+// LatestForBranch returns the most recently saved baseline recorded for a
+// git branch
+func (m *Manager) LatestForBranch(branch string) (*Baseline, error) {
+	baselines, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	var latest *Baseline
+	for _, b := range baselines {
+		if b.Metadata[MetaGitBranch] != branch {
+			continue
+		}
+		if latest == nil || b.CreatedAt.After(latest.CreatedAt) {
+			latest = b
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no baseline recorded for branch %s (save one with --save-baseline auto)", branch)
+	}
+	return latest, nil
+}
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		if exit, ok := err.(*exec.ExitError); ok && len(exit.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exit.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
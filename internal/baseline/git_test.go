@@ -0,0 +1,68 @@
+package baseline
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGitMetadata(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Dev", "GIT_AUTHOR_EMAIL=dev@example.com", "GIT_COMMITTER_NAME=Dev", "GIT_COMMITTER_EMAIL=dev@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "-q", "-b", "feature/x")
+	os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte("services: {}\n"), 0644)
+	run("add", ".")
+	run("commit", "-q", "-m", "Add compose file")
+
+	meta, err := GitMetadata(dir)
+	if err != nil {
+		t.Fatalf("GitMetadata failed: %v", err)
+	}
+	if len(meta[MetaGitSHA]) != 40 || meta[MetaGitBranch] != "feature/x" || meta[MetaGitSubject] != "Add compose file" || meta[MetaGitAuthor] != "Dev <dev@example.com>" {
+		t.Errorf("Unexpected metadata %v", meta)
+	}
+	if meta[MetaGitDirty] != "" {
+		t.Errorf("Expected a clean tree, got %v", meta)
+	}
+	if name := AutoName(meta); name != "feature/x-"+meta[MetaGitSHA][:12] {
+		t.Errorf("Unexpected auto name %q", name)
+	}
+
+	os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte("services: {api: {}}\n"), 0644)
+	if meta, _ := GitMetadata(dir); meta[MetaGitDirty] != "true" {
+		t.Errorf("Expected a dirty tree, got %v", meta)
+	}
+}
+
+func TestResolveGitBranch(t *testing.T) {
+	mgr := NewManager(t.TempDir())
+	save := func(name, branch string) {
+		meta := map[string]string{MetaGitBranch: branch, MetaGitSHA: name}
+		if err := mgr.SaveWithMetadata(name, map[string]any{}, "docker-compose.yml", false, meta); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	save("main-aaa", "main")
+	save("main-bbb", "main")
+	save("dev-ccc", "dev")
+
+	b, err := mgr.Resolve("git:main")
+	if err != nil || b.Name != "main-bbb" {
+		t.Fatalf("Expected the latest main baseline, got %v, %v", b, err)
+	}
+	if _, err := mgr.Resolve("git:release"); err == nil {
+		t.Error("Expected an error for a branch without baselines")
+	}
+}
@@ -0,0 +1,126 @@
+package baseline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// snapshotFormat names archived snapshots so they sort by creation time
+const snapshotFormat = "20060102T150405.000000000Z"
+
+// historyDir holds the earlier snapshots of a baseline
+func (m *Manager) historyDir(name string) string {
+	return filepath.Join(m.baseDir, "history", sanitizeFilename(name))
+}
+
+// archive moves the current snapshot of name, if any, into its history
+func (m *Manager) archive(name string) error {
+	current, err := m.Load(name)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	dir := m.historyDir(name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, current.CreatedAt.UTC().Format(snapshotFormat)+".json")
+	return os.Rename(filepath.Join(m.baseDir, sanitizeFilename(name)+".json"), path)
+}
+
+// History returns every snapshot of a baseline, oldest first; the last one
+// is the current baseline
+func (m *Manager) History(name string) ([]*Baseline, error) {
+	current, err := m.Load(name)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(m.historyDir(name))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	var snapshots []*Baseline
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(m.historyDir(name), entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var b Baseline
+		if err := json.Unmarshal(data, &b); err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		snapshots = append(snapshots, &b)
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool { return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt) })
+
+	return append(snapshots, current), nil
+}
+
+// Resolve loads a baseline by reference: name for the current snapshot,
+// name~N for the Nth snapshot before it, or name@time for the latest
+// snapshot saved at or before time (a YYYY-MM-DD date covers the whole day).
+// git:branch is the latest baseline saved with --save-baseline auto on that
+// branch.
+func (m *Manager) Resolve(ref string) (*Baseline, error) {
+	if branch, ok := strings.CutPrefix(ref, GitRefPrefix); ok {
+		return m.LatestForBranch(branch)
+	}
+
+	if i := strings.LastIndex(ref, "~"); i >= 0 {
+		n, err := strconv.Atoi(ref[i+1:])
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("expected a number of snapshots after ~")
+		}
+		history, err := m.History(ref[:i])
+		if err != nil {
+			return nil, err
+		}
+		if n >= len(history) {
+			return nil, fmt.Errorf("baseline %s has only %d snapshots", ref[:i], len(history))
+		}
+		return history[len(history)-1-n], nil
+	}
+
+	if i := strings.LastIndex(ref, "@"); i >= 0 {
+		at, err := parseRefTime(ref[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		history, err := m.History(ref[:i])
+		if err != nil {
+			return nil, err
+		}
+		for j := len(history) - 1; j >= 0; j-- {
+			if !history[j].CreatedAt.After(at) {
+				return history[j], nil
+			}
+		}
+		return nil, fmt.Errorf("baseline %s has no snapshot that old (the first is from %s)", ref[:i], history[0].CreatedAt.Local().Format("2006-01-02 15:04"))
+	}
+
+	return m.Load(ref)
+}
+
+// parseRefTime parses an RFC 3339 time, or a local date meaning its end
+func parseRefTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected a date (YYYY-MM-DD) or RFC 3339 time after @")
+	}
+	return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
@@ -0,0 +1,52 @@
+package baseline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolve(t *testing.T) {
+	mgr := NewManager(t.TempDir())
+	for _, image := range []string{"api:1", "api:2", "api:3"} {
+		data := map[string]any{"services": map[string]any{"api": map[string]any{"image": image}}}
+		if err := mgr.Save("prod", data, "docker-compose.yml", false); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	history, err := mgr.History("prod")
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 snapshots, got %d", len(history))
+	}
+
+	image := func(b *Baseline) any {
+		return b.Data["services"].(map[string]any)["api"].(map[string]any)["image"]
+	}
+	cases := map[string]any{
+		"prod":   "api:3",
+		"prod~0": "api:3",
+		"prod~2": "api:1",
+		"prod@" + history[1].CreatedAt.Format(time.RFC3339Nano): "api:2",
+		"prod@" + time.Now().Format("2006-01-02"):               "api:3",
+	}
+	for ref, want := range cases {
+		b, err := mgr.Resolve(ref)
+		if err != nil {
+			t.Errorf("Resolve(%q) failed: %v", ref, err)
+			continue
+		}
+		if got := image(b); got != want {
+			t.Errorf("Resolve(%q): expected %v, got %v", ref, want, got)
+		}
+	}
+
+	for _, ref := range []string{"prod~3", "prod~x", "prod@2000-01-01", "staging"} {
+		if _, err := mgr.Resolve(ref); err == nil {
+			t.Errorf("Expected Resolve(%q) to fail", ref)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Media types accepted for bundle manifests
+const (
+	mediaTypeOCIManifest    = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeOCIIndex       = "application/vnd.oci.image.index.v1+json"
+)
+
+// titleAnnotation names the file a layer holds, as set by `oras push`
+const titleAnnotation = "org.opencontainers.image.title"
+
+// File names looked up inside a bundle
+var (
+	rulesFiles = []string{"rules.yaml", "rules.yml", ".compose-diff.yaml", ".compose-diff.yml"}
+	hintsFiles = []string{"hints.yaml", "hints.yml"}
+)
+
+// maxBlobSize bounds how much a single bundle layer may download
+const maxBlobSize = 32 << 20
+
+// Bundle is a policy bundle extracted to a local directory
+type Bundle struct {
+	Reference Reference
+	Digest    string // manifest digest the content was verified against
+	Dir       string
+}
+
+// RulesPath returns the rules file in the bundle, or "" if it has none
+func (b *Bundle) RulesPath() string {
+	return b.find(rulesFiles)
+}
+
+// HintsPath returns the remediation hints file in the bundle, or "" if it has none
+func (b *Bundle) HintsPath() string {
+	return b.find(hintsFiles)
+}
+
+func (b *Bundle) find(names []string) string {
+	for _, name := range names {
+		path := filepath.Join(b.Dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// Client pulls policy bundles from an OCI registry
+type Client struct {
+	HTTP        *http.Client
+	CacheDir    string
+	Offline     bool             // only serve pinned bundles already in the cache
+	Credentials CredentialSource // nil pulls anonymously
+}
+
+// NewClient creates a client that caches bundles under cacheDir
+func NewClient(cacheDir string) *Client {
+	return &Client{
+		HTTP:     &http.Client{Timeout: 60 * time.Second},
+		CacheDir: cacheDir,
+	}
+}
+
+type manifest struct {
+	MediaType string       `json:"mediaType"`
+	Layers    []descriptor `json:"layers"`
+}
+
+type descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// Pull fetches a bundle, verifying the manifest against a pinned digest and
+// every layer against its descriptor. Pinned bundles are served from the
+// cache once downloaded, since their content cannot change.
+func (c *Client) Pull(ref Reference) (*Bundle, error) {
+	if ref.Pinned() {
+		if b, ok := c.cached(ref, ref.Digest); ok {
+			return b, nil
+		}
+	}
+	if c.Offline {
+		if !ref.Pinned() {
+			return nil, fmt.Errorf("%s is not pinned to a digest; offline mode can only use cached, pinned bundles", ref)
+		}
+		return nil, fmt.Errorf("%s is not in the bundle cache; pull it once while online", ref)
+	}
+
+	reg := &registry{client: c.HTTP, ref: ref, credentials: c.Credentials}
+
+	body, mediaType, err := reg.get("manifests/"+ref.manifestRef(),
+		strings.Join([]string{mediaTypeOCIManifest, mediaTypeDockerManifest, mediaTypeOCIIndex}, ", "))
+	if err != nil {
+		return nil, fmt.Errorf("fetching manifest for %s: %w", ref, err)
+	}
+
+	digest := sha256Digest(body)
+	if ref.Pinned() && digest != ref.Digest {
+		return nil, fmt.Errorf("manifest digest mismatch for %s: got %s", ref, digest)
+	}
+	if b, ok := c.cached(ref, digest); ok {
+		return b, nil
+	}
+
+	var m manifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest for %s: %w", ref, err)
+	}
+	if m.MediaType == "" {
+		m.MediaType = mediaType
+	}
+	if m.MediaType == mediaTypeOCIIndex {
+		return nil, fmt.Errorf("%s is an image index; push the bundle as a single artifact", ref)
+	}
+	if len(m.Layers) == 0 {
+		return nil, fmt.Errorf("%s has no layers", ref)
+	}
+
+	if err := os.MkdirAll(c.CacheDir, 0755); err != nil {
+		return nil, fmt.Errorf("creating bundle cache: %w", err)
+	}
+	tmp, err := os.MkdirTemp(c.CacheDir, ".pull-")
+	if err != nil {
+		return nil, fmt.Errorf("creating bundle cache: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	for _, layer := range m.Layers {
+		blob, _, err := reg.get("blobs/"+layer.Digest, "*/*")
+		if err != nil {
+			return nil, fmt.Errorf("fetching layer %s: %w", layer.Digest, err)
+		}
+		if got := sha256Digest(blob); got != layer.Digest {
+			return nil, fmt.Errorf("layer digest mismatch: expected %s, got %s", layer.Digest, got)
+		}
+		if err := extractLayer(tmp, layer, blob); err != nil {
+			return nil, fmt.Errorf("extracting layer %s: %w", layer.Digest, err)
+		}
+	}
+
+	dir := c.cacheDir(digest)
+	if err := os.Rename(tmp, dir); err != nil {
+		// Another process may have filled the cache first
+		if _, statErr := os.Stat(dir); statErr != nil {
+			return nil, fmt.Errorf("storing bundle: %w", err)
+		}
+	}
+
+	return &Bundle{Reference: ref, Digest: digest, Dir: dir}, nil
+}
+
+// cached returns the bundle for a manifest digest if it is already extracted
+func (c *Client) cached(ref Reference, digest string) (*Bundle, bool) {
+	dir := c.cacheDir(digest)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, false
+	}
+	return &Bundle{Reference: ref, Digest: digest, Dir: dir}, true
+}
+
+func (c *Client) cacheDir(digest string) string {
+	return filepath.Join(c.CacheDir, strings.ReplaceAll(digest, ":", "-"))
+}
+
+// extractLayer writes a layer into dir: tarballs are unpacked, other blobs
+// are written under their title annotation
+func extractLayer(dir string, layer descriptor, blob []byte) error {
+	switch {
+	case strings.HasSuffix(layer.MediaType, "tar+gzip") || strings.HasSuffix(layer.MediaType, ".tar.gzip"):
+		gz, err := gzip.NewReader(bytes.NewReader(blob))
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		return extractTar(dir, gz)
+	case strings.HasSuffix(layer.MediaType, ".tar"):
+		return extractTar(dir, bytes.NewReader(blob))
+	}
+
+	title := layer.Annotations[titleAnnotation]
+	if title == "" {
+		return fmt.Errorf("layer of type %s has no %s annotation", layer.MediaType, titleAnnotation)
+	}
+	return writeBundleFile(dir, title, bytes.NewReader(blob))
+}
+
+// extractTar unpacks regular files from a tar stream, rejecting paths outside dir
+func extractTar(dir string, r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := writeBundleFile(dir, hdr.Name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func writeBundleFile(dir, name string, r io.Reader) error {
+	name = filepath.Clean(filepath.FromSlash(name))
+	if !filepath.IsLocal(name) {
+		return fmt.Errorf("refusing to write %q outside the bundle", name)
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, io.LimitReader(r, maxBlobSize)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func sha256Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,158 @@
+package bundle
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// fakeRegistry serves one artifact whose layers are individual files, as
+// pushed by `oras push`, behind anonymous bearer auth. It answers every
+// manifest reference with the same manifest, like a tampered registry would.
+func fakeRegistry(t *testing.T, files map[string]string) (*httptest.Server, string) {
+	t.Helper()
+
+	blobs := make(map[string][]byte)
+	m := manifest{MediaType: mediaTypeOCIManifest}
+	for name, content := range files {
+		digest := sha256Digest([]byte(content))
+		blobs[digest] = []byte(content)
+		m.Layers = append(m.Layers, descriptor{
+			MediaType:   "application/vnd.compose-diff.policy.v1+yaml",
+			Digest:      digest,
+			Size:        int64(len(content)),
+			Annotations: map[string]string{titleAnnotation: name},
+		})
+	}
+	manifestBody, _ := json.Marshal(m)
+	manifestDigest := sha256Digest(manifestBody)
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			json.NewEncoder(w).Encode(map[string]string{"token": "secret"})
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+srv.URL+`/token",service="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/v2/org/policies/manifests/"):
+			w.Header().Set("Content-Type", mediaTypeOCIManifest)
+			w.Write(manifestBody)
+		case strings.HasPrefix(r.URL.Path, "/v2/org/policies/blobs/"):
+			blob, ok := blobs[strings.TrimPrefix(r.URL.Path, "/v2/org/policies/blobs/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(blob)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, manifestDigest
+}
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Reference
+	}{
+		{"ghcr.io/org/compose-policies:v3", Reference{Registry: "ghcr.io", Repository: "org/compose-policies", Tag: "v3"}},
+		{"localhost:5000/policies", Reference{Registry: "localhost:5000", Repository: "policies", Tag: "latest"}},
+		{"policies", Reference{Registry: defaultRegistry, Repository: "library/policies", Tag: "latest"}},
+		{"ghcr.io/org/p@sha256:" + strings.Repeat("a", 64), Reference{Registry: "ghcr.io", Repository: "org/p", Digest: "sha256:" + strings.Repeat("a", 64)}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseReference(tt.input)
+		if err != nil {
+			t.Errorf("%s: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+
+	if _, err := ParseReference("ghcr.io/org/p@sha256:abc"); err == nil {
+		t.Error("Expected error for a truncated digest")
+	}
+}
+
+func TestPull(t *testing.T) {
+	srv, digest := fakeRegistry(t, map[string]string{
+		"rules.yaml": "version: \"1\"\n",
+		"hints.yaml": "hints: []\n",
+	})
+	host := strings.TrimPrefix(srv.URL, "http://")
+	client := NewClient(t.TempDir())
+
+	ref, _ := ParseReference(host + "/org/policies:v3")
+	b, err := client.Pull(ref)
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if b.Digest != digest {
+		t.Errorf("Expected digest %s, got %s", digest, b.Digest)
+	}
+	data, err := os.ReadFile(b.RulesPath())
+	if err != nil || string(data) != "version: \"1\"\n" {
+		t.Errorf("Unexpected rules file: %q, %v", data, err)
+	}
+	if b.HintsPath() == "" {
+		t.Error("Expected hints file in bundle")
+	}
+
+	// Pinned to the right digest: served from the cache even if the registry is gone
+	srv.Close()
+	pinned, _ := ParseReference(host + "/org/policies@" + digest)
+	if _, err := client.Pull(pinned); err != nil {
+		t.Errorf("Expected pinned pull from cache, got %v", err)
+	}
+}
+
+func TestPullOffline(t *testing.T) {
+	srv, digest := fakeRegistry(t, map[string]string{"rules.yaml": "version: \"1\"\n"})
+	host := strings.TrimPrefix(srv.URL, "http://")
+	client := NewClient(t.TempDir())
+	client.Offline = true
+
+	pinned, _ := ParseReference(host + "/org/policies@" + digest)
+	if _, err := client.Pull(pinned); err == nil {
+		t.Error("Expected offline pull of an uncached bundle to fail")
+	}
+
+	client.Offline = false
+	if _, err := client.Pull(pinned); err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+
+	client.Offline = true
+	if _, err := client.Pull(pinned); err != nil {
+		t.Errorf("Expected cached pinned bundle to work offline, got %v", err)
+	}
+	tagged, _ := ParseReference(host + "/org/policies:v3")
+	if _, err := client.Pull(tagged); err == nil {
+		t.Error("Expected offline pull by tag to fail")
+	}
+}
+
+func TestPullDigestMismatch(t *testing.T) {
+	srv, _ := fakeRegistry(t, map[string]string{"rules.yaml": "version: \"1\"\n"})
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	ref, _ := ParseReference(host + "/org/policies:v3@sha256:" + strings.Repeat("0", 64))
+	if _, err := NewClient(t.TempDir()).Pull(ref); err == nil || !strings.Contains(err.Error(), "mismatch") {
+		t.Errorf("Expected digest mismatch error, got %v", err)
+	}
+}
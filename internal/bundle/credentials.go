@@ -0,0 +1,134 @@
+package bundle
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// dockerHubAuthKey is the key docker login uses for Docker Hub in config.json
+const dockerHubAuthKey = "https://index.docker.io/v1/"
+
+// Credentials authenticate pulls from a registry
+type Credentials struct {
+	Username string
+	Secret   string
+}
+
+// CredentialSource returns credentials for a registry host, or nil to pull anonymously
+type CredentialSource func(registry string) (*Credentials, error)
+
+// dockerConfig is the part of ~/.docker/config.json that holds credentials
+type dockerConfig struct {
+	Auths       map[string]struct{ Auth string } `json:"auths"`
+	CredsStore  string                           `json:"credsStore"`
+	CredHelpers map[string]string                `json:"credHelpers"`
+}
+
+// DockerCredentials resolves credentials the way docker does: a per-registry
+// credHelpers entry, then the credsStore, then inline auths from docker login.
+// A non-empty helper (e.g. "ecr-login" for docker-credential-ecr-login)
+// overrides the docker config for every registry.
+func DockerCredentials(helper string) CredentialSource {
+	return func(registry string) (*Credentials, error) {
+		key := registry
+		if registry == defaultRegistry {
+			key = dockerHubAuthKey
+		}
+		if helper != "" {
+			return runCredentialHelper(helper, key)
+		}
+
+		cfg, err := loadDockerConfig()
+		if err != nil || cfg == nil {
+			return nil, err
+		}
+		if name := cfg.CredHelpers[key]; name != "" {
+			return runCredentialHelper(name, key)
+		}
+		if cfg.CredsStore != "" {
+			creds, err := runCredentialHelper(cfg.CredsStore, key)
+			if creds != nil || err != nil {
+				return creds, err
+			}
+		}
+		if entry, ok := cfg.Auths[key]; ok && entry.Auth != "" {
+			return decodeAuth(entry.Auth)
+		}
+		return nil, nil
+	}
+}
+
+// loadDockerConfig reads $DOCKER_CONFIG/config.json, returning nil if there is none
+func loadDockerConfig() (*dockerConfig, error) {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, nil
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg dockerConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing docker config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// decodeAuth decodes a base64 "user:password" auths entry
+func decodeAuth(auth string) (*Credentials, error) {
+	data, err := base64.StdEncoding.DecodeString(auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth entry in docker config: %w", err)
+	}
+	user, secret, found := strings.Cut(string(data), ":")
+	if !found {
+		return nil, fmt.Errorf("invalid auth entry in docker config")
+	}
+	return &Credentials{Username: user, Secret: secret}, nil
+}
+
+// runCredentialHelper asks docker-credential-<name> for a registry's
+// credentials. A helper that has none for the registry is not an error.
+func runCredentialHelper(name, registry string) (*Credentials, error) {
+	cmd := exec.Command("docker-credential-"+name, "get")
+	cmd.Stdin = strings.NewReader(registry)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String() + string(out))
+		if strings.Contains(msg, "credentials not found") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("docker-credential-%s: %v %s", name, err, msg)
+	}
+
+	var resp struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return nil, fmt.Errorf("docker-credential-%s: invalid response: %w", name, err)
+	}
+	if resp.Secret == "" {
+		return nil, nil
+	}
+	return &Credentials{Username: resp.Username, Secret: resp.Secret}, nil
+}
@@ -0,0 +1,56 @@
+package bundle
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDockerCredentialsAuthenticate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "ci" || pass != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "private"})
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	// docker login stores base64 user:password under the registry host
+	dir := t.TempDir()
+	config := `{"auths": {"` + host + `": {"auth": "` + base64.StdEncoding.EncodeToString([]byte("ci:s3cret")) + `"}}}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0600); err != nil {
+		t.Fatalf("Failed to write docker config: %v", err)
+	}
+	t.Setenv("DOCKER_CONFIG", dir)
+
+	ref, _ := ParseReference(host + "/org/policies:v3")
+	reg := &registry{client: srv.Client(), ref: ref, credentials: DockerCredentials("")}
+	if err := reg.authenticate(`Bearer realm="` + srv.URL + `/token",service="test"`); err != nil {
+		t.Fatalf("authenticate failed: %v", err)
+	}
+	if reg.token != "private" {
+		t.Errorf("Expected token from credentialed request, got %q", reg.token)
+	}
+
+	// Registries with basic auth get the credentials directly
+	reg = &registry{client: srv.Client(), ref: ref, credentials: DockerCredentials("")}
+	if err := reg.authenticate(`Basic realm="registry"`); err != nil {
+		t.Fatalf("authenticate failed: %v", err)
+	}
+	if reg.basic == nil || reg.basic.Username != "ci" {
+		t.Errorf("Expected basic credentials, got %+v", reg.basic)
+	}
+
+	// Without credentials a basic challenge cannot be answered
+	reg = &registry{client: srv.Client(), ref: ref}
+	if err := reg.authenticate(`Basic realm="registry"`); err == nil {
+		t.Error("Expected an error without credentials")
+	}
+}
@@ -0,0 +1,83 @@
+package bundle
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/imageref"
+)
+
+// defaultRegistry is the API host of Docker Hub, used for references without
+// a registry host like Docker
+const defaultRegistry = "registry-1.docker.io"
+
+var digestPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// Reference is a parsed OCI artifact reference such as
+// ghcr.io/org/compose-policies:v3 or ghcr.io/org/compose-policies@sha256:...
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string // pins the manifest when set
+}
+
+// ParseReference parses an OCI reference, defaulting the tag to "latest"
+func ParseReference(s string) (Reference, error) {
+	if strings.TrimSpace(s) == "" {
+		return Reference{}, fmt.Errorf("empty bundle reference")
+	}
+	ref := Reference(imageref.Parse(s))
+	if ref.Registry == imageref.DockerHub {
+		ref.Registry = defaultRegistry
+	}
+
+	if strings.Contains(s, "@") && !digestPattern.MatchString(ref.Digest) {
+		return ref, fmt.Errorf("invalid digest %q in %s: expected sha256:<64 hex chars>", ref.Digest, s)
+	}
+	if ref.Repository == "" {
+		return ref, fmt.Errorf("invalid bundle reference %q: missing repository", s)
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+	return ref, nil
+}
+
+// String formats the reference as registry/repository[:tag][@digest]
+func (r Reference) String() string {
+	s := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// Pinned reports whether the reference names an immutable manifest digest
+func (r Reference) Pinned() bool {
+	return r.Digest != ""
+}
+
+// manifestRef returns the digest if pinned, otherwise the tag
+func (r Reference) manifestRef() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
+
+// baseURL returns the registry API root; local registries are spoken to over plain HTTP
+func (r Reference) baseURL() string {
+	host := r.Registry
+	if h, _, found := strings.Cut(host, ":"); found {
+		host = h
+	}
+	if host == "localhost" || host == "127.0.0.1" {
+		return "http://" + r.Registry + "/v2/"
+	}
+	return "https://" + r.Registry + "/v2/"
+}
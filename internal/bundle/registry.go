@@ -0,0 +1,165 @@
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// registry speaks the OCI distribution API for one repository, answering
+// auth challenges with a bearer token (anonymous unless credentials are
+// available) or basic auth
+type registry struct {
+	client      *http.Client
+	ref         Reference
+	credentials CredentialSource
+	token       string
+	basic       *Credentials
+}
+
+// get fetches a manifest or blob path below /v2/<repository>/
+func (r *registry) get(path, accept string) ([]byte, string, error) {
+	resp, err := r.do(path, accept)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && r.token == "" && r.basic == nil {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		if err := r.authenticate(challenge); err != nil {
+			return nil, "", err
+		}
+		if resp, err = r.do(path, accept); err != nil {
+			return nil, "", err
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("registry returned %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBlobSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(body) > maxBlobSize {
+		return nil, "", fmt.Errorf("response exceeds %d bytes", maxBlobSize)
+	}
+	return body, resp.Header.Get("Content-Type"), nil
+}
+
+func (r *registry) do(path, accept string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, r.ref.baseURL()+r.ref.Repository+"/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	} else if r.basic != nil {
+		req.SetBasicAuth(r.basic.Username, r.basic.Secret)
+	}
+	return r.client.Do(req)
+}
+
+// authenticate answers a Basic challenge with the registry's credentials, or
+// requests a pull token from the realm named in a Bearer challenge
+func (r *registry) authenticate(challenge string) error {
+	var creds *Credentials
+	if r.credentials != nil {
+		var err error
+		if creds, err = r.credentials(r.ref.Registry); err != nil {
+			return fmt.Errorf("looking up credentials for %s: %w", r.ref.Registry, err)
+		}
+	}
+
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if strings.EqualFold(scheme, "Basic") {
+		if creds == nil {
+			return fmt.Errorf("%s requires credentials; run docker login or pass --cred-helper", r.ref.Registry)
+		}
+		r.basic = creds
+		return nil
+	}
+	if !strings.EqualFold(scheme, "Bearer") {
+		return fmt.Errorf("registry requires unsupported authentication %q", scheme)
+	}
+
+	values := parseChallenge(params)
+	realm := values["realm"]
+	if realm == "" {
+		return fmt.Errorf("registry auth challenge has no realm")
+	}
+
+	q := url.Values{}
+	if service := values["service"]; service != "" {
+		q.Set("service", service)
+	}
+	scope := values["scope"]
+	if scope == "" {
+		scope = "repository:" + r.ref.Repository + ":pull"
+	}
+	q.Set("scope", scope)
+
+	req, err := http.NewRequest(http.MethodGet, realm+"?"+q.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if creds != nil {
+		req.SetBasicAuth(creds.Username, creds.Secret)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("requesting registry token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("requesting registry token: %s", resp.Status)
+	}
+
+	var tok struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return fmt.Errorf("decoding registry token: %w", err)
+	}
+	r.token = tok.Token
+	if r.token == "" {
+		r.token = tok.AccessToken
+	}
+	if r.token == "" {
+		return fmt.Errorf("registry returned an empty token")
+	}
+	return nil
+}
+
+// parseChallenge parses the key="value" pairs of a WWW-Authenticate header
+func parseChallenge(s string) map[string]string {
+	values := make(map[string]string)
+	for s != "" {
+		s = strings.TrimLeft(s, " ,")
+		key, rest, found := strings.Cut(s, "=")
+		if !found {
+			break
+		}
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, s = rest[1:], ""
+			} else {
+				value, s = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			value, s, _ = strings.Cut(rest, ",")
+		}
+		values[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+	return values
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// EnvDir overrides the cache location, e.g. for runners with a provisioned cache
+const EnvDir = "COMPOSE_DIFF_CACHE"
+
+// Dir returns the root of the compose-diff cache
+func Dir() string {
+	if dir := os.Getenv(EnvDir); dir != "" {
+		return dir
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "compose-diff")
+}
+
+// BundlesDir returns where policy bundles are cached
+func BundlesDir() string {
+	return filepath.Join(Dir(), "bundles")
+}
+
+// resolvedDir returns where `docker compose config` output is cached
+func resolvedDir() string {
+	return filepath.Join(Dir(), "resolved")
+}
+
+// varPattern matches $VAR and ${VAR...} references in a compose file
+var varPattern = regexp.MustCompile(`\$\{?([A-Za-z_][A-Za-z0-9_]*)`)
+
+// ResolvedKey derives a cache key for the resolved form of a compose file
+// from everything docker compose config reads: the file, the project .env
+// file, and the current values of the variables the file references
+func ResolvedKey(composeFile string) (string, error) {
+	data, err := os.ReadFile(composeFile)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write(data)
+
+	if env, err := os.ReadFile(filepath.Join(filepath.Dir(composeFile), ".env")); err == nil {
+		h.Write([]byte("\x00.env\x00"))
+		h.Write(env)
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, m := range varPattern.FindAllSubmatch(data, -1) {
+		name := string(m[1])
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value, set := os.LookupEnv(name)
+		fmt.Fprintf(h, "\x00%s=%t:%s", name, set, value)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// LoadResolved returns cached resolved output for a key
+func LoadResolved(key string) ([]byte, bool) {
+	data, err := os.ReadFile(filepath.Join(resolvedDir(), key+".yaml"))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// SaveResolved stores resolved output for a key
+func SaveResolved(key string, data []byte) error {
+	if err := os.MkdirAll(resolvedDir(), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(resolvedDir(), key+".yaml"), data, 0644)
+}
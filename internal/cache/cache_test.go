@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolvedKeyTracksReferencedVariables(t *testing.T) {
+	dir := t.TempDir()
+	composePath := filepath.Join(dir, "docker-compose.yml")
+	content := "services:\n  api:\n    image: app:${TAG:-latest}\n"
+	if err := os.WriteFile(composePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	t.Setenv("TAG", "1.0")
+	t.Setenv("UNRELATED", "a")
+	k1, err := ResolvedKey(composePath)
+	if err != nil {
+		t.Fatalf("ResolvedKey failed: %v", err)
+	}
+
+	t.Setenv("UNRELATED", "b")
+	if k2, _ := ResolvedKey(composePath); k2 != k1 {
+		t.Error("Unreferenced variable should not change the key")
+	}
+
+	t.Setenv("TAG", "2.0")
+	if k3, _ := ResolvedKey(composePath); k3 == k1 {
+		t.Error("Referenced variable should change the key")
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	t.Setenv(EnvDir, t.TempDir())
+	if err := SaveResolved("abc", []byte("services: {}\n")); err != nil {
+		t.Fatalf("SaveResolved failed: %v", err)
+	}
+
+	var first, second bytes.Buffer
+	if n, err := Export(&first); err != nil || n != 1 {
+		t.Fatalf("Export: %d files, %v", n, err)
+	}
+	if _, err := Export(&second); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Error("Expected identical archives for the same cache")
+	}
+
+	t.Setenv(EnvDir, t.TempDir())
+	if _, ok := LoadResolved("abc"); ok {
+		t.Fatal("Expected empty cache")
+	}
+	if n, err := Import(&first); err != nil || n != 1 {
+		t.Fatalf("Import: %d files, %v", n, err)
+	}
+	if data, ok := LoadResolved("abc"); !ok || string(data) != "services: {}\n" {
+		t.Errorf("Unexpected imported content: %q", data)
+	}
+}
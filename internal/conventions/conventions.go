@@ -0,0 +1,250 @@
+// Package conventions checks the container names, labels and published
+// ports a change introduces against an organization's naming and port
+// allocation conventions, configured in the rules file.
+package conventions
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// Finding.Check values of convention violations
+const (
+	CheckContainerName = "container-name-prefix"
+	CheckLabel         = "label-namespace"
+	CheckPort          = "reserved-port"
+)
+
+// Config is the conventions section of the rules file
+type Config struct {
+	ContainerNamePrefixes []string `yaml:"container_name_prefixes"` // container_name must start with one
+	LabelNamespaces       []string `yaml:"label_namespaces"`        // label keys must be in one, e.g. com.acme or traefik.*
+	Teams                 []Team   `yaml:"teams"`
+	Severity              string   `yaml:"severity"` // of violations; default warning
+}
+
+// Team owns a set of services and the host port ranges reserved for them
+type Team struct {
+	Name     string   `yaml:"name"`
+	Services []string `yaml:"services"` // service name globs, e.g. pay-*
+	Ports    []string `yaml:"ports"`    // host ports or ranges, e.g. 8100-8199
+}
+
+type portRange struct{ lo, hi int }
+
+type compiledTeam struct {
+	Team
+	ranges []portRange
+}
+
+// Checker checks changes against a Config. A nil Checker checks nothing.
+type Checker struct {
+	config   Config
+	teams    []compiledTeam
+	severity models.Severity
+}
+
+// Compile validates a Config. It returns nil if nothing is configured.
+func Compile(c Config) (*Checker, error) {
+	if len(c.ContainerNamePrefixes) == 0 && len(c.LabelNamespaces) == 0 && len(c.Teams) == 0 {
+		return nil, nil
+	}
+
+	ch := &Checker{config: c, severity: models.SeverityWarning}
+	if c.Severity != "" {
+		ch.severity = models.Severity(c.Severity)
+		if ch.severity != models.SeverityInfo && ch.severity != models.SeverityWarning && ch.severity != models.SeverityBreaking {
+			return nil, fmt.Errorf("conventions: invalid severity %q (use info, warning or breaking)", c.Severity)
+		}
+	}
+	for _, t := range c.Teams {
+		ct := compiledTeam{Team: t}
+		for _, p := range t.Ports {
+			r, err := parseRange(p)
+			if err != nil {
+				return nil, fmt.Errorf("conventions: team %s: %w", t.Name, err)
+			}
+			ct.ranges = append(ct.ranges, r)
+		}
+		for _, pattern := range t.Services {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("conventions: team %s: bad service pattern %q", t.Name, pattern)
+			}
+		}
+		ch.teams = append(ch.teams, ct)
+	}
+	return ch, nil
+}
+
+// parseRange parses a port or lo-hi range
+func parseRange(s string) (portRange, error) {
+	a, b, isRange := strings.Cut(s, "-")
+	lo, err := strconv.Atoi(strings.TrimSpace(a))
+	if err != nil {
+		return portRange{}, fmt.Errorf("invalid port range %q", s)
+	}
+	hi := lo
+	if isRange {
+		if hi, err = strconv.Atoi(strings.TrimSpace(b)); err != nil || hi < lo {
+			return portRange{}, fmt.Errorf("invalid port range %q", s)
+		}
+	}
+	return portRange{lo, hi}, nil
+}
+
+func (r portRange) String() string {
+	if r.lo == r.hi {
+		return strconv.Itoa(r.lo)
+	}
+	return fmt.Sprintf("%d-%d", r.lo, r.hi)
+}
+
+// Check reports violations among the container names, labels and host
+// ports that are added or modified between old and new. Values that were
+// already there are left alone, so adopting conventions does not flag
+// every existing service.
+func (c *Checker) Check(old, new *models.ComposeIR) []models.Finding {
+	if c == nil || new == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(new.Services))
+	for name := range new.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var findings []models.Finding
+	for _, name := range names {
+		svc := new.Services[name]
+		var before *models.ServiceIR
+		if old != nil {
+			if s, ok := old.Services[name]; ok {
+				before = &s
+			}
+		}
+		findings = append(findings, c.checkContainerName(name, svc, before)...)
+		findings = append(findings, c.checkLabels(name, svc, before)...)
+		findings = append(findings, c.checkPorts(name, svc, before)...)
+	}
+	return findings
+}
+
+func (c *Checker) finding(check, message, path string) models.Finding {
+	return models.Finding{Check: check, Severity: c.severity, Message: message, Paths: []string{path}}
+}
+
+func (c *Checker) checkContainerName(name string, svc models.ServiceIR, before *models.ServiceIR) []models.Finding {
+	prefixes := c.config.ContainerNamePrefixes
+	if len(prefixes) == 0 || svc.ContainerName == nil || (before != nil && before.ContainerName != nil && *before.ContainerName == *svc.ContainerName) {
+		return nil
+	}
+	for _, p := range prefixes {
+		if strings.HasPrefix(*svc.ContainerName, p) {
+			return nil
+		}
+	}
+	return []models.Finding{c.finding(CheckContainerName,
+		fmt.Sprintf("%s: container_name %q does not start with %s", name, *svc.ContainerName, strings.Join(prefixes, " or ")),
+		fmt.Sprintf("services.%s.container_name", name))}
+}
+
+func (c *Checker) checkLabels(name string, svc models.ServiceIR, before *models.ServiceIR) []models.Finding {
+	if len(c.config.LabelNamespaces) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(svc.Labels))
+	for key, value := range svc.Labels {
+		if before != nil {
+			if old, ok := before.Labels[key]; ok && old == value {
+				continue
+			}
+		}
+		if !inNamespace(key, c.config.LabelNamespaces) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var findings []models.Finding
+	for _, key := range keys {
+		findings = append(findings, c.finding(CheckLabel,
+			fmt.Sprintf("%s: label %s is outside the allowed namespaces (%s)", name, key, strings.Join(c.config.LabelNamespaces, ", ")),
+			fmt.Sprintf("services.%s.labels.%s", name, key)))
+	}
+	return findings
+}
+
+// inNamespace matches label keys the way warn_label_namespaces does:
+// "traefik", "traefik." and "traefik.*" all cover traefik.enable
+func inNamespace(key string, namespaces []string) bool {
+	for _, ns := range namespaces {
+		ns = strings.TrimSuffix(strings.TrimSuffix(ns, "*"), ".")
+		if key == ns || strings.HasPrefix(key, ns+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Checker) checkPorts(name string, svc models.ServiceIR, before *models.ServiceIR) []models.Finding {
+	if len(c.teams) == 0 {
+		return nil
+	}
+	existing := make(map[string]bool)
+	if before != nil {
+		for _, p := range before.Ports {
+			existing[p.HostPort] = true
+		}
+	}
+	team := c.teamOf(name)
+
+	var findings []models.Finding
+	for _, p := range svc.Ports {
+		if p.HostPort == "" || existing[p.HostPort] {
+			continue
+		}
+		ports, err := parseRange(p.HostPort)
+		if err != nil {
+			continue
+		}
+		path := fmt.Sprintf("services.%s.ports", name)
+		if owner := c.ownerOf(ports); owner != nil && owner != team {
+			findings = append(findings, c.finding(CheckPort,
+				fmt.Sprintf("%s: host port %s is reserved for team %s", name, ports, owner.Name), path))
+		} else if team != nil && len(team.ranges) > 0 && owner == nil {
+			findings = append(findings, c.finding(CheckPort,
+				fmt.Sprintf("%s: host port %s is outside team %s's ranges (%s)", name, ports, team.Name, strings.Join(team.Ports, ", ")), path))
+		}
+	}
+	return findings
+}
+
+// teamOf returns the first team whose service patterns match name
+func (c *Checker) teamOf(name string) *compiledTeam {
+	for i := range c.teams {
+		for _, pattern := range c.teams[i].Services {
+			if ok, _ := path.Match(pattern, name); ok {
+				return &c.teams[i]
+			}
+		}
+	}
+	return nil
+}
+
+// ownerOf returns the team whose ranges overlap ports
+func (c *Checker) ownerOf(ports portRange) *compiledTeam {
+	for i := range c.teams {
+		for _, r := range c.teams[i].ranges {
+			if ports.lo <= r.hi && r.lo <= ports.hi {
+				return &c.teams[i]
+			}
+		}
+	}
+	return nil
+}
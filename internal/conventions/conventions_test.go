@@ -0,0 +1,72 @@
+package conventions
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestCheck(t *testing.T) {
+	checker, err := Compile(Config{
+		ContainerNamePrefixes: []string{"acme-"},
+		LabelNamespaces:       []string{"com.acme", "traefik.*"},
+		Teams: []Team{
+			{Name: "payments", Services: []string{"pay-*"}, Ports: []string{"8100-8199"}},
+			{Name: "search", Services: []string{"search"}, Ports: []string{"8200-8299"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	name := func(s string) *string { return &s }
+	old := &models.ComposeIR{Services: map[string]models.ServiceIR{
+		"pay-api": {
+			ContainerName: name("payapi"),
+			Labels:        map[string]string{"legacy": "1"},
+			Ports:         []models.PortIR{{HostPort: "9000", ContainerPort: "80"}},
+		},
+	}}
+	new := &models.ComposeIR{Services: map[string]models.ServiceIR{
+		"pay-api": {
+			ContainerName: name("payapi"),
+			Labels:        map[string]string{"legacy": "1", "com.acme.team": "payments", "owner": "pay"},
+			Ports: []models.PortIR{
+				{HostPort: "9000", ContainerPort: "80"},
+				{HostPort: "8150", ContainerPort: "81"},
+				{HostPort: "8250", ContainerPort: "82"},
+			},
+		},
+		"search": {
+			ContainerName: name("search"),
+			Ports:         []models.PortIR{{HostPort: "8300", ContainerPort: "80"}},
+		},
+	}}
+
+	var got []string
+	for _, f := range checker.Check(old, new) {
+		got = append(got, f.Check+": "+f.Message)
+	}
+	want := []string{
+		`label-namespace: pay-api: label owner is outside the allowed namespaces (com.acme, traefik.*)`,
+		`reserved-port: pay-api: host port 8250 is reserved for team search`,
+		`container-name-prefix: search: container_name "search" does not start with acme-`,
+		`reserved-port: search: host port 8300 is outside team search's ranges (8200-8299)`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected findings:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	if c, err := Compile(Config{}); c != nil || err != nil {
+		t.Errorf("Expected no checker for an empty config, got %v, %v", c, err)
+	}
+	if _, err := Compile(Config{Teams: []Team{{Name: "a", Ports: []string{"90-80"}}}}); err == nil {
+		t.Error("Expected a backwards port range to fail")
+	}
+	if _, err := Compile(Config{LabelNamespaces: []string{"x"}, Severity: "fatal"}); err == nil {
+		t.Error("Expected an invalid severity to fail")
+	}
+}
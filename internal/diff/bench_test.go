@@ -0,0 +1,114 @@
+package diff
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// compareBudget is the time allowed to diff two megaStack(2000) IRs
+const compareBudget = 200 * time.Millisecond
+
+// megaStack builds a generated stack of n services shaped like real ones:
+// environment, ports, mounts, labels and dependencies. With changed set,
+// every tenth service gets a new image, env value, port and mount.
+func megaStack(n int, changed bool) *models.ComposeIR {
+	ir := models.NewComposeIR()
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("svc-%04d", i)
+		edit := changed && i%10 == 0
+
+		env := make(map[string]*string, 20)
+		for j := 0; j < 20; j++ {
+			env[fmt.Sprintf("VAR_%02d", j)] = strPtr(fmt.Sprintf("value-%d-%d", i, j))
+		}
+		labels := make(map[string]string, 5)
+		for j := 0; j < 5; j++ {
+			labels[fmt.Sprintf("com.example.label-%d", j)] = name
+		}
+		ports := []models.PortIR{
+			{HostPort: fmt.Sprint(10000 + i), ContainerPort: "80", Protocol: "tcp"},
+			{ContainerPort: "9090", Protocol: "tcp"},
+		}
+		mounts := []models.MountIR{
+			{Type: "volume", Source: name + "-data", Target: "/data"},
+			{Type: "bind", Source: "./config", Target: "/etc/app", ReadOnly: true},
+		}
+
+		image := "registry.example.com/" + name + ":1.0"
+		if edit {
+			image = "registry.example.com/" + name + ":1.1"
+			env["VAR_00"] = strPtr("changed")
+			ports = append(ports, models.PortIR{ContainerPort: "443", Protocol: "tcp"})
+			mounts[1].ReadOnly = false
+		}
+
+		var deps []string
+		if i > 0 {
+			deps = []string{fmt.Sprintf("svc-%04d", i-1)}
+		}
+
+		ir.Services[name] = models.ServiceIR{
+			Image:     &image,
+			Env:       env,
+			Labels:    labels,
+			Ports:     ports,
+			Volumes:   mounts,
+			DependsOn: deps,
+			Networks:  []string{"backend"},
+			Command:   []string{"serve", "--port", "80"},
+		}
+		ir.Volumes[name+"-data"] = models.VolumeIR{Name: name + "-data"}
+	}
+	ir.Networks["backend"] = models.NetworkIR{Name: "backend"}
+	return ir
+}
+
+func BenchmarkCompare(b *testing.B) {
+	for _, n := range []int{100, 2000} {
+		old, new := megaStack(n, false), megaStack(n, true)
+		b.Run(fmt.Sprintf("services=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				Compare(old, new)
+			}
+		})
+	}
+}
+
+func BenchmarkCompareIdentical(b *testing.B) {
+	old, new := megaStack(2000, false), megaStack(2000, false)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Compare(old, new)
+	}
+}
+
+// TestCompareMegaStackBudget fails if diffing two 2,000-service stacks takes
+// longer than compareBudget. Timings depend on the machine and on -race, so
+// the test only runs with COMPOSEDIFF_PERF_BUDGET=1; the best of a few runs
+// is used to ride out scheduler noise.
+func TestCompareMegaStackBudget(t *testing.T) {
+	if os.Getenv("COMPOSEDIFF_PERF_BUDGET") == "" {
+		t.Skip("set COMPOSEDIFF_PERF_BUDGET=1 to check the time budget")
+	}
+	old, new := megaStack(2000, false), megaStack(2000, true)
+
+	best := time.Duration(1<<63 - 1)
+	var report *models.DiffReport
+	for i := 0; i < 3; i++ {
+		start := time.Now()
+		report = Compare(old, new)
+		best = min(best, time.Since(start))
+	}
+
+	if report.Summary.ServicesChanged != 200 {
+		t.Errorf("Expected 200 changed services, got %d", report.Summary.ServicesChanged)
+	}
+	if best > compareBudget {
+		t.Errorf("Compare took %v for 2000 services, budget is %v", best, compareBudget)
+	}
+}
@@ -1,44 +1,39 @@
 package diff
 
 import (
+	"context"
 	"fmt"
+	"maps"
 	"reflect"
+	"slices"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/units"
 )
 
 // Compare compares two ComposeIR and produces a DiffReport
 func Compare(old, new *models.ComposeIR) *models.DiffReport {
 	report := models.NewDiffReport()
-
-	// Compare services
-	compareServices(old.Services, new.Services, report)
-
-	// Compare volumes
-	compareVolumes(old.Volumes, new.Volumes, report)
-
-	// Compare networks
-	compareNetworks(old.Networks, new.Networks, report)
-
+	_ = CompareStream(context.Background(), old, new, func(c models.Change) error {
+		report.AddChange(c)
+		return nil
+	})
+	SummarizeEntities(report)
 	return report
 }
 
 // compareServices compares service maps
-func compareServices(old, new map[string]models.ServiceIR, report *models.DiffReport) {
+func compareServices(old, new map[string]models.ServiceIR, e *emitter) {
 	// Find added and removed services
-	oldNames := mapKeys(old)
-	newNames := mapKeys(new)
-
-	added, removed, common := diffSets(oldNames, newNames)
-
-	// Track counts
-	report.Summary.ServicesAdded = len(added)
-	report.Summary.ServicesRemoved = len(removed)
+	added, removed, common := diffKeys(old, new)
 
 	// Report added services
 	for _, name := range added {
-		report.AddChange(models.Change{
+		e.add(models.Change{
 			Kind:     models.ChangeAdded,
 			Scope:    models.ScopeService,
 			Name:     name,
@@ -51,7 +46,7 @@ func compareServices(old, new map[string]models.ServiceIR, report *models.DiffRe
 
 	// Report removed services (breaking!)
 	for _, name := range removed {
-		report.AddChange(models.Change{
+		e.add(models.Change{
 			Kind:     models.ChangeRemoved,
 			Scope:    models.ScopeService,
 			Name:     name,
@@ -63,19 +58,16 @@ func compareServices(old, new map[string]models.ServiceIR, report *models.DiffRe
 	}
 
 	// Compare common services
-	changedServices := 0
 	for _, name := range common {
+		if e.stopped() {
+			return
+		}
 		oldSvc := old[name]
 		newSvc := new[name]
-		changes := compareService(name, &oldSvc, &newSvc)
-		if len(changes) > 0 {
-			changedServices++
-			for _, c := range changes {
-				report.AddChange(c)
-			}
+		for _, c := range compareService(name, &oldSvc, &newSvc) {
+			e.add(c)
 		}
 	}
-	report.Summary.ServicesChanged = changedServices
 }
 
 // compareService compares two services and returns changes
@@ -85,26 +77,30 @@ func compareService(name string, old, new *models.ServiceIR) []models.Change {
 	basePath := fmt.Sprintf("services.%s", name)
 
 	// Image
-	if !ptrEqual(old.Image, new.Image) {
-		changes = append(changes, models.Change{
-			Kind:     models.ChangeModified,
-			Scope:    models.ScopeService,
-			Name:     name,
-			Path:     basePath + ".image",
-			Before:   ptrValue(old.Image),
-			After:    ptrValue(new.Image),
-			Severity: imageSeverity(old.Image, new.Image),
-		})
-	}
+	changes = append(changes, compareImage(name, basePath, old.Image, new.Image)...)
 
 	// Environment variables
 	envChanges := compareEnv(name, basePath, old.Env, new.Env)
+	noteEnvSources(envChanges, old.EnvSources, new.EnvSources)
 	changes = append(changes, envChanges...)
 
+	// Later env files override earlier ones, so reordering them can change values
+	envFileChanges := compareStringSlice(name, basePath+".env_file", old.EnvFiles, new.EnvFiles, models.SeverityWarning)
+	for i := range envFileChanges {
+		if envFileChanges[i].Kind == models.ChangeReordered {
+			envFileChanges[i].Severity = models.SeverityWarning
+		}
+	}
+	changes = append(changes, envFileChanges...)
+
 	// Ports
 	portChanges := comparePorts(name, basePath, old.Ports, new.Ports)
 	changes = append(changes, portChanges...)
 
+	// Exposed ports are reachable from other services even without a host binding
+	exposeChanges := compareStringSlice(name, basePath+".expose", old.Expose, new.Expose, models.SeverityWarning)
+	changes = append(changes, exposeChanges...)
+
 	// Volumes
 	volChanges := compareServiceVolumes(name, basePath, old.Volumes, new.Volumes)
 	changes = append(changes, volChanges...)
@@ -117,22 +113,17 @@ func compareService(name string, old, new *models.ServiceIR) []models.Change {
 	depChanges := compareStringSlice(name, basePath+".depends_on", old.DependsOn, new.DependsOn, models.SeverityWarning)
 	changes = append(changes, depChanges...)
 
+	// Extensions
+	changes = append(changes, compareExtensions(name, basePath, old.Extensions, new.Extensions)...)
+
+	// Profiles
+	changes = append(changes, compareProfiles(name, basePath+".profiles", old.Profiles, new.Profiles)...)
+
+	// Labels
+	changes = append(changes, compareStringMap(name, basePath+".labels", old.Labels, new.Labels, models.SeverityInfo, models.SeverityInfo)...)
+
 	// Healthcheck
-	if !healthcheckEqual(old.Healthcheck, new.Healthcheck) {
-		sev := models.SeverityInfo
-		if old.Healthcheck != nil && new.Healthcheck == nil {
-			sev = models.SeverityBreaking // Healthcheck removed
-		}
-		changes = append(changes, models.Change{
-			Kind:     changeKindForPtrs(old.Healthcheck, new.Healthcheck),
-			Scope:    models.ScopeService,
-			Name:     name,
-			Path:     basePath + ".healthcheck",
-			Before:   old.Healthcheck,
-			After:    new.Healthcheck,
-			Severity: sev,
-		})
-	}
+	changes = append(changes, compareHealthcheck(name, basePath+".healthcheck", old.Healthcheck, new.Healthcheck)...)
 
 	// Command
 	if !sliceEqual(old.Command, new.Command) {
@@ -160,30 +151,607 @@ func compareService(name string, old, new *models.ServiceIR) []models.Change {
 		})
 	}
 
-	// Restart
-	if !ptrEqual(old.Restart, new.Restart) {
-		changes = append(changes, models.Change{
-			Kind:     models.ChangeModified,
-			Scope:    models.ScopeService,
-			Name:     name,
-			Path:     basePath + ".restart",
-			Before:   ptrValue(old.Restart),
-			After:    ptrValue(new.Restart),
-			Severity: models.SeverityInfo,
-		})
+	// Restart
+	if !ptrEqual(old.Restart, new.Restart) {
+		changes = append(changes, models.Change{
+			Kind:     models.ChangeModified,
+			Scope:    models.ScopeService,
+			Name:     name,
+			Path:     basePath + ".restart",
+			Before:   ptrValue(old.Restart),
+			After:    ptrValue(new.Restart),
+			Severity: models.SeverityInfo,
+		})
+	}
+
+	// Stop grace period
+	if !ptrEqual(old.StopGracePeriod, new.StopGracePeriod) {
+		changes = append(changes, ptrChange(name, basePath+".stop_grace_period", old.StopGracePeriod, new.StopGracePeriod,
+			gracePeriodSeverity(old.StopGracePeriod, new.StopGracePeriod)))
+	}
+
+	// Stop signal
+	if !ptrEqual(old.StopSignal, new.StopSignal) {
+		changes = append(changes, ptrChange(name, basePath+".stop_signal", old.StopSignal, new.StopSignal, models.SeverityInfo))
+	}
+
+	// Deploy
+	deployChanges := compareDeploy(name, basePath+".deploy", old.Deploy, new.Deploy)
+	changes = append(changes, deployChanges...)
+
+	// Legacy resource settings
+	changes = append(changes, compareQuantity(name, basePath+".mem_limit", float64(old.MemLimit), float64(new.MemLimit), true, formatMemory)...)
+	changes = append(changes, compareQuantity(name, basePath+".mem_reservation", float64(old.MemReservation), float64(new.MemReservation), false, formatMemory)...)
+	changes = append(changes, compareQuantity(name, basePath+".cpus", old.CPUs, new.CPUs, true, formatNumber)...)
+	if shareChanges := compareQuantity(name, basePath+".cpu_shares", float64(old.CPUShares), float64(new.CPUShares), false, formatNumber); len(shareChanges) > 0 {
+		// Unset shares means Docker's default weight, not zero
+		if effectiveShares(new.CPUShares) < effectiveShares(old.CPUShares) {
+			shareChanges[0].Severity = models.SeverityWarning
+		} else {
+			shareChanges[0].Severity = models.SeverityInfo
+		}
+		changes = append(changes, shareChanges...)
+	}
+
+	// Security posture
+	secChanges := compareSecurity(name, basePath, old.Security, new.Security)
+	changes = append(changes, secChanges...)
+
+	// User
+	if !ptrEqual(old.User, new.User) {
+		changes = append(changes, ptrChange(name, basePath+".user", old.User, new.User, userSeverity(old.User, new.User)))
+	}
+
+	// Working directory
+	if !ptrEqual(old.WorkingDir, new.WorkingDir) {
+		changes = append(changes, ptrChange(name, basePath+".working_dir", old.WorkingDir, new.WorkingDir, models.SeverityInfo))
+	}
+
+	// Init
+	if !boolPtrEqual(old.Init, new.Init) {
+		changes = append(changes, ptrChange(name, basePath+".init", boolString(old.Init), boolString(new.Init), models.SeverityInfo))
+	}
+
+	// Hostname and domain name
+	if !ptrEqual(old.Hostname, new.Hostname) {
+		changes = append(changes, ptrChange(name, basePath+".hostname", old.Hostname, new.Hostname, models.SeverityInfo))
+	}
+	if !ptrEqual(old.Domainname, new.Domainname) {
+		changes = append(changes, ptrChange(name, basePath+".domainname", old.Domainname, new.Domainname, models.SeverityInfo))
+	}
+
+	// Container name: scripts and other containers may refer to it
+	if !ptrEqual(old.ContainerName, new.ContainerName) {
+		changes = append(changes, ptrChange(name, basePath+".container_name", old.ContainerName, new.ContainerName, models.SeverityWarning))
+	}
+
+	// Devices
+	devChanges := compareDevices(name, basePath+".devices", old.Devices, new.Devices)
+	changes = append(changes, devChanges...)
+
+	// Device cgroup rules
+	ruleChanges := compareStringSlice(name, basePath+".device_cgroup_rules", old.DeviceCgroupRules, new.DeviceCgroupRules, models.SeverityWarning)
+	changes = append(changes, ruleChanges...)
+
+	// Name resolution: losing a static host entry or resolver can break lookups
+	hostChanges := compareStringMap(name, basePath+".extra_hosts", old.ExtraHosts, new.ExtraHosts, models.SeverityWarning, models.SeverityWarning)
+	changes = append(changes, hostChanges...)
+	changes = append(changes, compareStringSlice(name, basePath+".dns", old.DNS, new.DNS, models.SeverityWarning)...)
+	changes = append(changes, compareStringSlice(name, basePath+".dns_search", old.DNSSearch, new.DNSSearch, models.SeverityWarning)...)
+	changes = append(changes, compareStringSlice(name, basePath+".dns_opt", old.DNSOpt, new.DNSOpt, models.SeverityInfo)...)
+
+	// In-memory filesystems
+	changes = append(changes, compareStringSlice(name, basePath+".tmpfs", old.Tmpfs, new.Tmpfs, models.SeverityWarning)...)
+	changes = append(changes, compareQuantity(name, basePath+".shm_size", float64(old.ShmSize), float64(new.ShmSize), false, formatMemory)...)
+
+	// Namespaces: sharing the host's or another container's namespace changes isolation
+	if !ptrEqual(old.IPC, new.IPC) {
+		changes = append(changes, ptrChange(name, basePath+".ipc", old.IPC, new.IPC, models.SeverityBreaking))
+	}
+	if !ptrEqual(old.PID, new.PID) {
+		changes = append(changes, ptrChange(name, basePath+".pid", old.PID, new.PID, models.SeverityBreaking))
+	}
+	if !ptrEqual(old.NetworkMode, new.NetworkMode) {
+		changes = append(changes, ptrChange(name, basePath+".network_mode", old.NetworkMode, new.NetworkMode,
+			networkModeSeverity(new.NetworkMode)))
+	}
+
+	// Build
+	changes = append(changes, compareBuild(name, basePath+".build", old.Build, new.Build)...)
+
+	return compareUnparsed(name, basePath, old.Unparsed, new.Unparsed, changes)
+}
+
+// compareUnparsed compares the values kept as text because they could not be
+// converted, such as mem_limit: ${API_MEM:-512m}. Text replaces its side of
+// the typed change at the same path, if there is one, and since it cannot be
+// rated any difference is a warning.
+func compareUnparsed(svcName, basePath string, old, new map[string]string, changes []models.Change) []models.Change {
+	if len(old) == 0 && len(new) == 0 {
+		return changes
+	}
+	added, removed, common := diffKeys(old, new)
+	keys := append(append(added, removed...), common...)
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		oldText, inOld := old[key]
+		newText, inNew := new[key]
+		path := basePath + "." + key
+
+		var before, after any
+		if i := slices.IndexFunc(changes, func(c models.Change) bool { return c.Path == path }); i >= 0 {
+			before, after = changes[i].Before, changes[i].After
+			changes = slices.Delete(changes, i, i+1)
+		}
+		if inOld {
+			before = oldText
+		}
+		if inNew {
+			after = newText
+		}
+		if inOld && inNew && oldText == newText {
+			continue
+		}
+
+		kind := models.ChangeModified
+		switch {
+		case before == nil:
+			kind = models.ChangeAdded
+		case after == nil:
+			kind = models.ChangeRemoved
+		}
+		changes = append(changes, models.Change{
+			Kind:     kind,
+			Scope:    models.ScopeService,
+			Name:     svcName,
+			Path:     path,
+			Before:   before,
+			After:    after,
+			Severity: models.SeverityWarning,
+		})
+	}
+	return changes
+}
+
+// compareProfiles compares a service's profiles. Going from no profiles to
+// some (or back) changes whether a plain `docker compose up` starts the
+// service, so those changes are warnings.
+func compareProfiles(svcName, path string, old, new []string) []models.Change {
+	changes := compareStringSlice(svcName, path, old, new, models.SeverityInfo)
+	if (len(old) == 0) != (len(new) == 0) {
+		for i := range changes {
+			changes[i].Severity = models.SeverityWarning
+		}
+	}
+	return changes
+}
+
+// compareHealthcheck compares healthchecks field by field. Adding or removing
+// the whole healthcheck is reported once; disabling it is breaking because
+// service_healthy dependents will never start.
+func compareHealthcheck(svcName, path string, old, new *models.HealthcheckIR) []models.Change {
+	if old == nil && new == nil {
+		return nil
+	}
+	if old == nil || new == nil {
+		kind, sev := models.ChangeAdded, models.SeverityInfo
+		if new == nil {
+			kind, sev = models.ChangeRemoved, models.SeverityBreaking
+		}
+		return []models.Change{{
+			Kind:     kind,
+			Scope:    models.ScopeService,
+			Name:     svcName,
+			Path:     path,
+			Before:   old,
+			After:    new,
+			Severity: sev,
+		}}
+	}
+
+	var changes []models.Change
+
+	oldDisabled, newDisabled := healthcheckDisabled(old), healthcheckDisabled(new)
+	if oldDisabled != newDisabled {
+		sev := models.SeverityInfo
+		if newDisabled {
+			sev = models.SeverityBreaking
+		}
+		changes = append(changes, ptrChange(svcName, path+".disable", boolString(&oldDisabled), boolString(&newDisabled), sev))
+	}
+
+	oldTest, newTest := healthcheckTest(old.Test), healthcheckTest(new.Test)
+	if !sliceEqual(oldTest, newTest) && !oldDisabled && !newDisabled {
+		changes = append(changes, models.Change{
+			Kind:     models.ChangeModified,
+			Scope:    models.ScopeService,
+			Name:     svcName,
+			Path:     path + ".test",
+			Before:   old.Test,
+			After:    new.Test,
+			Severity: models.SeverityWarning,
+		})
+	}
+
+	changes = appendDurationChange(changes, svcName, path+".interval", old.Interval, new.Interval)
+	changes = appendDurationChange(changes, svcName, path+".timeout", old.Timeout, new.Timeout)
+	changes = appendDurationChange(changes, svcName, path+".start_period", old.StartPeriod, new.StartPeriod)
+	changes = appendFieldChange(changes, svcName, path+".retries", intString(old.Retries), intString(new.Retries), models.SeverityInfo)
+
+	return changes
+}
+
+// healthcheckDisabled reports whether a healthcheck is turned off, either
+// with disable: true or a NONE test
+func healthcheckDisabled(hc *models.HealthcheckIR) bool {
+	return hc.Disable || len(hc.Test) > 0 && hc.Test[0] == "NONE"
+}
+
+// healthcheckTest expands the string form of a test to its CMD-SHELL equivalent
+func healthcheckTest(test []string) []string {
+	if len(test) == 1 && test[0] != "NONE" {
+		return []string{"CMD-SHELL", test[0]}
+	}
+	return test
+}
+
+// appendDurationChange reports a duration change unless both values parse to
+// the same length of time (30s and 0.5m are equal)
+func appendDurationChange(changes []models.Change, svcName, path, old, new string) []models.Change {
+	if old == new {
+		return changes
+	}
+	if o, err := time.ParseDuration(old); err == nil {
+		if n, err := time.ParseDuration(new); err == nil && o == n {
+			return changes
+		}
+	}
+	return appendFieldChange(changes, svcName, path, old, new, models.SeverityInfo)
+}
+
+// compareBuild compares build sections field by field. A different target
+// stage or removed build arg can change what ends up in the image.
+func compareBuild(svcName, path string, old, new *models.BuildIR) []models.Change {
+	if old == nil && new == nil {
+		return nil
+	}
+	if old == nil {
+		old = &models.BuildIR{}
+	}
+	if new == nil {
+		new = &models.BuildIR{}
+	}
+
+	var changes []models.Change
+	changes = appendFieldChange(changes, svcName, path+".context", old.Context, new.Context, models.SeverityInfo)
+	changes = appendFieldChange(changes, svcName, path+".dockerfile", old.Dockerfile, new.Dockerfile, models.SeverityInfo)
+	changes = appendFieldChange(changes, svcName, path+".target", old.Target, new.Target, models.SeverityWarning)
+	changes = append(changes, compareStringMap(svcName, path+".args", old.Args, new.Args, models.SeverityWarning, models.SeverityInfo)...)
+	changes = append(changes, compareStringSlice(svcName, path+".platforms", old.Platforms, new.Platforms, models.SeverityWarning)...)
+	changes = append(changes, compareStringSlice(svcName, path+".secrets", old.Secrets, new.Secrets, models.SeverityWarning)...)
+	changes = append(changes, compareStringSlice(svcName, path+".ssh", old.SSH, new.SSH, models.SeverityInfo)...)
+	changes = append(changes, compareStringSlice(svcName, path+".cache_from", old.CacheFrom, new.CacheFrom, models.SeverityInfo)...)
+	changes = append(changes, compareStringMap(svcName, path+".labels", old.Labels, new.Labels, models.SeverityInfo, models.SeverityInfo)...)
+	return changes
+}
+
+// compareSecurity compares privileged mode, capabilities and security options
+func compareSecurity(svcName, basePath string, old, new *models.SecurityIR) []models.Change {
+	if old == nil && new == nil {
+		return nil
+	}
+	if old == nil {
+		old = &models.SecurityIR{}
+	}
+	if new == nil {
+		new = &models.SecurityIR{}
+	}
+
+	var changes []models.Change
+
+	if old.Privileged != new.Privileged {
+		sev := models.SeverityWarning // Dropping privileges may break the service
+		if new.Privileged {
+			sev = models.SeverityBreaking // Full host access granted
+		}
+		changes = append(changes, models.Change{
+			Kind:     models.ChangeModified,
+			Scope:    models.ScopeService,
+			Name:     svcName,
+			Path:     basePath + ".privileged",
+			Before:   old.Privileged,
+			After:    new.Privileged,
+			Severity: sev,
+		})
+	}
+
+	changes = append(changes, compareSecurityList(svcName, basePath+".cap_add", old.CapAdd, new.CapAdd, capAddSeverity)...)
+	changes = append(changes, compareSecurityList(svcName, basePath+".cap_drop", old.CapDrop, new.CapDrop, capDropSeverity)...)
+	changes = append(changes, compareSecurityList(svcName, basePath+".security_opt", old.SecurityOpt, new.SecurityOpt, securityOptSeverity)...)
+
+	return changes
+}
+
+// compareSecurityList compares a set of security settings with per-item severity
+func compareSecurityList(svcName, path string, old, new []string, severity func(string, models.ChangeKind) models.Severity) []models.Change {
+	var changes []models.Change
+
+	added, removed, _ := diffSets(old, new)
+
+	for _, item := range added {
+		changes = append(changes, models.Change{
+			Kind:     models.ChangeAdded,
+			Scope:    models.ScopeService,
+			Name:     svcName,
+			Path:     fmt.Sprintf("%s.%s", path, item),
+			Before:   nil,
+			After:    item,
+			Severity: severity(item, models.ChangeAdded),
+		})
+	}
+
+	for _, item := range removed {
+		changes = append(changes, models.Change{
+			Kind:     models.ChangeRemoved,
+			Scope:    models.ScopeService,
+			Name:     svcName,
+			Path:     fmt.Sprintf("%s.%s", path, item),
+			Before:   item,
+			After:    nil,
+			Severity: severity(item, models.ChangeRemoved),
+		})
+	}
+
+	return changes
+}
+
+// compareDeploy compares the deploy sections of a service
+func compareDeploy(svcName, path string, old, new *models.DeployIR) []models.Change {
+	if old == nil && new == nil {
+		return nil
+	}
+	if old == nil {
+		old = &models.DeployIR{}
+	}
+	if new == nil {
+		new = &models.DeployIR{}
+	}
+
+	var changes []models.Change
+
+	// Restart policy
+	oldRP := old.RestartPolicy
+	newRP := new.RestartPolicy
+	if oldRP == nil {
+		oldRP = &models.RestartPolicyIR{}
+	}
+	if newRP == nil {
+		newRP = &models.RestartPolicyIR{}
+	}
+	rpPath := path + ".restart_policy"
+	changes = appendFieldChange(changes, svcName, rpPath+".condition", oldRP.Condition, newRP.Condition, models.SeverityInfo)
+	changes = appendFieldChange(changes, svcName, rpPath+".delay", oldRP.Delay, newRP.Delay, models.SeverityInfo)
+	changes = appendFieldChange(changes, svcName, rpPath+".max_attempts", intString(oldRP.MaxAttempts), intString(newRP.MaxAttempts), models.SeverityInfo)
+	changes = appendFieldChange(changes, svcName, rpPath+".window", oldRP.Window, newRP.Window, models.SeverityInfo)
+
+	// Resources
+	oldRes := old.Resources
+	newRes := new.Resources
+	if oldRes == nil {
+		oldRes = &models.ResourcesIR{}
+	}
+	if newRes == nil {
+		newRes = &models.ResourcesIR{}
+	}
+	changes = append(changes, compareResourceSpec(svcName, path+".resources.limits", oldRes.Limits, newRes.Limits, true)...)
+	changes = append(changes, compareResourceSpec(svcName, path+".resources.reservations", oldRes.Reservations, newRes.Reservations, false)...)
+
+	return changes
+}
+
+// compareResourceSpec compares deploy resource limits or reservations
+func compareResourceSpec(svcName, path string, old, new *models.ResourceSpecIR, limit bool) []models.Change {
+	if old == nil {
+		old = &models.ResourceSpecIR{}
+	}
+	if new == nil {
+		new = &models.ResourceSpecIR{}
+	}
+
+	var changes []models.Change
+	changes = append(changes, compareQuantity(svcName, path+".cpus", old.CPUs, new.CPUs, limit, formatNumber)...)
+	changes = append(changes, compareQuantity(svcName, path+".memory", float64(old.Memory), float64(new.Memory), limit, formatMemory)...)
+	changes = append(changes, compareQuantity(svcName, path+".pids", float64(old.Pids), float64(new.Pids), limit, formatNumber)...)
+	changes = append(changes, compareDeviceRequests(svcName, path+".devices", old.Devices, new.Devices)...)
+	return changes
+}
+
+// compareDevices compares host device mappings, keyed by container path.
+// Removing a passthrough is breaking since the service loses the hardware.
+func compareDevices(svcName, path string, old, new []models.DeviceIR) []models.Change {
+	var changes []models.Change
+
+	oldMap := make(map[string]models.DeviceIR, len(old))
+	for _, d := range old {
+		oldMap[d.Target] = d
+	}
+	newMap := make(map[string]models.DeviceIR, len(new))
+	for _, d := range new {
+		newMap[d.Target] = d
+	}
+
+	added, removed, common := diffKeys(oldMap, newMap)
+
+	for _, key := range added {
+		changes = append(changes, models.Change{
+			Kind:     models.ChangeAdded,
+			Scope:    models.ScopeService,
+			Name:     svcName,
+			Path:     fmt.Sprintf("%s.%s", path, key),
+			Before:   nil,
+			After:    newMap[key],
+			Severity: models.SeverityInfo,
+		})
+	}
+
+	for _, key := range removed {
+		changes = append(changes, models.Change{
+			Kind:     models.ChangeRemoved,
+			Scope:    models.ScopeService,
+			Name:     svcName,
+			Path:     fmt.Sprintf("%s.%s", path, key),
+			Before:   oldMap[key],
+			After:    nil,
+			Severity: models.SeverityBreaking,
+		})
+	}
+
+	for _, key := range common {
+		if oldMap[key] != newMap[key] {
+			changes = append(changes, models.Change{
+				Kind:     models.ChangeModified,
+				Scope:    models.ScopeService,
+				Name:     svcName,
+				Path:     fmt.Sprintf("%s.%s", path, key),
+				Before:   oldMap[key],
+				After:    newMap[key],
+				Severity: models.SeverityWarning,
+			})
+		}
+	}
+
+	return changes
+}
+
+// compareDeviceRequests compares device reservations (e.g. GPUs), keyed by
+// driver and capabilities. Removing a reservation is breaking because the
+// workload no longer gets the device.
+func compareDeviceRequests(svcName, path string, old, new []models.DeviceRequestIR) []models.Change {
+	var changes []models.Change
+
+	oldMap := deviceRequestMap(old)
+	newMap := deviceRequestMap(new)
+
+	added, removed, common := diffKeys(oldMap, newMap)
+
+	for _, key := range added {
+		changes = append(changes, models.Change{
+			Kind:     models.ChangeAdded,
+			Scope:    models.ScopeService,
+			Name:     svcName,
+			Path:     fmt.Sprintf("%s.%s", path, key),
+			Before:   nil,
+			After:    newMap[key],
+			Severity: models.SeverityInfo,
+		})
+	}
+
+	for _, key := range removed {
+		changes = append(changes, models.Change{
+			Kind:     models.ChangeRemoved,
+			Scope:    models.ScopeService,
+			Name:     svcName,
+			Path:     fmt.Sprintf("%s.%s", path, key),
+			Before:   oldMap[key],
+			After:    nil,
+			Severity: models.SeverityBreaking,
+		})
+	}
+
+	for _, key := range common {
+		if !deviceRequestEqual(oldMap[key], newMap[key]) {
+			changes = append(changes, models.Change{
+				Kind:     models.ChangeModified,
+				Scope:    models.ScopeService,
+				Name:     svcName,
+				Path:     fmt.Sprintf("%s.%s", path, key),
+				Before:   oldMap[key],
+				After:    newMap[key],
+				Severity: models.SeverityWarning,
+			})
+		}
+	}
+
+	return changes
+}
+
+// deviceRequestMap keys device requests as "driver/cap1,cap2" (or just the
+// capabilities when no driver is set)
+func deviceRequestMap(requests []models.DeviceRequestIR) map[string]models.DeviceRequestIR {
+	m := make(map[string]models.DeviceRequestIR, len(requests))
+	for _, r := range requests {
+		caps := make([]string, len(r.Capabilities))
+		copy(caps, r.Capabilities)
+		sort.Strings(caps)
+		key := strings.Join(caps, ",")
+		if r.Driver != "" {
+			key = r.Driver + "/" + key
+		}
+		m[key] = r
+	}
+	return m
+}
+
+// compareQuantity compares a numeric resource setting where 0 means unset.
+// An unset limit is unlimited, so adding one is a reduction; reductions are
+// warnings because the service gets less memory or CPU than before.
+func compareQuantity(svcName, path string, old, new float64, limit bool, format func(float64) string) []models.Change {
+	if old == new {
+		return nil
+	}
+
+	reduced := new < old
+	if limit {
+		switch {
+		case old == 0:
+			reduced = true // unlimited -> limited
+		case new == 0:
+			reduced = false // limited -> unlimited
+		}
+	}
+
+	sev := models.SeverityInfo
+	if reduced {
+		sev = models.SeverityWarning
+	}
+
+	var before, after *string
+	if old != 0 {
+		v := format(old)
+		before = &v
+	}
+	if new != 0 {
+		v := format(new)
+		after = &v
+	}
+	return []models.Change{ptrChange(svcName, path, before, after, sev)}
+}
+
+// effectiveShares returns the CPU weight Docker applies, defaulting to 1024
+func effectiveShares(shares int64) int64 {
+	if shares == 0 {
+		return 1024
 	}
+	return shares
+}
 
-	return changes
+func formatMemory(n float64) string {
+	return units.FormatBytes(int64(n))
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
 }
 
 // compareEnv compares environment variable maps
 func compareEnv(svcName, basePath string, old, new map[string]*string) []models.Change {
+	if maps.EqualFunc(old, new, ptrEqual) {
+		return nil
+	}
 	var changes []models.Change
 
-	oldKeys := envKeys(old)
-	newKeys := envKeys(new)
-
-	added, removed, common := diffSets(oldKeys, newKeys)
+	added, removed, common := diffKeys(old, new)
 
 	// Added env vars
 	for _, key := range added {
@@ -231,17 +799,51 @@ func compareEnv(svcName, basePath string, old, new map[string]*string) []models.
 	return changes
 }
 
+// noteEnvSources explains changed variables when env_file is expanded: a
+// variable whose value now comes from another env_file or the environment
+// section has changed its effective value, not just one definition, and a
+// variable set in several places only takes the last. sources are nil when
+// env_file is not expanded or the service has none, in which case every
+// variable is set by the environment section.
+func noteEnvSources(changes []models.Change, oldSources, newSources map[string][]string) {
+	if oldSources == nil && newSources == nil {
+		return
+	}
+	for i := range changes {
+		c := &changes[i]
+		key := c.Path[strings.LastIndex(c.Path, ".environment.")+len(".environment."):]
+		before, after := envSources(oldSources, key), envSources(newSources, key)
+
+		if c.Kind == models.ChangeModified && before[len(before)-1] != after[len(after)-1] {
+			c.Notes = append(c.Notes, fmt.Sprintf("effective value changed: set by %s, now by %s",
+				before[len(before)-1], after[len(after)-1]))
+		}
+		if c.Kind != models.ChangeRemoved && len(after) > 1 {
+			c.Notes = append(c.Notes, fmt.Sprintf("set in %d places (%s); %s wins",
+				len(after), strings.Join(after, ", "), after[len(after)-1]))
+		}
+	}
+}
+
+// envSources returns where a variable is set, in precedence order
+func envSources(sources map[string][]string, key string) []string {
+	if s := sources[key]; len(s) > 0 {
+		return s
+	}
+	return []string{"environment"}
+}
+
 // comparePorts compares port mappings
 func comparePorts(svcName, basePath string, old, new []models.PortIR) []models.Change {
+	if slices.Equal(old, new) {
+		return nil
+	}
 	var changes []models.Change
 
 	oldMap := portMap(old)
 	newMap := portMap(new)
 
-	oldKeys := mapKeys(oldMap)
-	newKeys := mapKeys(newMap)
-
-	added, removed, common := diffSets(oldKeys, newKeys)
+	added, removed, common := diffKeys(oldMap, newMap)
 
 	// Added ports
 	for _, key := range added {
@@ -273,7 +875,7 @@ func comparePorts(svcName, basePath string, old, new []models.PortIR) []models.C
 	for _, key := range common {
 		oldPort := oldMap[key]
 		newPort := newMap[key]
-		if !reflect.DeepEqual(oldPort, newPort) {
+		if oldPort != newPort {
 			changes = append(changes, models.Change{
 				Kind:     models.ChangeModified,
 				Scope:    models.ScopeService,
@@ -291,15 +893,15 @@ func comparePorts(svcName, basePath string, old, new []models.PortIR) []models.C
 
 // compareServiceVolumes compares volume mounts
 func compareServiceVolumes(svcName, basePath string, old, new []models.MountIR) []models.Change {
+	if slices.Equal(old, new) {
+		return nil
+	}
 	var changes []models.Change
 
 	oldMap := mountMap(old)
 	newMap := mountMap(new)
 
-	oldKeys := mapKeys(oldMap)
-	newKeys := mapKeys(newMap)
-
-	added, removed, common := diffSets(oldKeys, newKeys)
+	added, removed, common := diffKeys(oldMap, newMap)
 
 	// Added volumes
 	for _, key := range added {
@@ -331,7 +933,7 @@ func compareServiceVolumes(svcName, basePath string, old, new []models.MountIR)
 	for _, key := range common {
 		oldMount := oldMap[key]
 		newMount := newMap[key]
-		if !reflect.DeepEqual(oldMount, newMount) {
+		if oldMount != newMount {
 			changes = append(changes, models.Change{
 				Kind:     models.ChangeModified,
 				Scope:    models.ScopeService,
@@ -347,11 +949,78 @@ func compareServiceVolumes(svcName, basePath string, old, new []models.MountIR)
 	return changes
 }
 
-// compareStringSlice compares string slices and reports changes
+// compareStringMap compares per-key string maps; additions are info
+func compareStringMap(svcName, path string, old, new map[string]string, removedSeverity, modifiedSeverity models.Severity) []models.Change {
+	if maps.Equal(old, new) {
+		return nil
+	}
+	var changes []models.Change
+
+	added, removed, common := diffKeys(old, new)
+
+	for _, key := range added {
+		changes = append(changes, models.Change{
+			Kind:     models.ChangeAdded,
+			Scope:    models.ScopeService,
+			Name:     svcName,
+			Path:     fmt.Sprintf("%s.%s", path, key),
+			Before:   nil,
+			After:    new[key],
+			Severity: models.SeverityInfo,
+		})
+	}
+
+	for _, key := range removed {
+		changes = append(changes, models.Change{
+			Kind:     models.ChangeRemoved,
+			Scope:    models.ScopeService,
+			Name:     svcName,
+			Path:     fmt.Sprintf("%s.%s", path, key),
+			Before:   old[key],
+			After:    nil,
+			Severity: removedSeverity,
+		})
+	}
+
+	for _, key := range common {
+		if old[key] != new[key] {
+			changes = append(changes, models.Change{
+				Kind:     models.ChangeModified,
+				Scope:    models.ScopeService,
+				Name:     svcName,
+				Path:     fmt.Sprintf("%s.%s", path, key),
+				Before:   old[key],
+				After:    new[key],
+				Severity: modifiedSeverity,
+			})
+		}
+	}
+
+	return changes
+}
+
+// compareStringSlice compares string slices and reports changes. Items kept
+// in another relative order are one reordered change, alongside any added
+// or removed items; lists whose order does not matter are sorted by
+// normalization first.
 func compareStringSlice(svcName, path string, old, new []string, severity models.Severity) []models.Change {
+	if sliceEqual(old, new) {
+		return nil
+	}
 	var changes []models.Change
 
-	added, removed, _ := diffSets(old, new)
+	added, removed, common := diffSets(old, new)
+	if !sliceEqual(keepOnly(old, common), keepOnly(new, common)) {
+		changes = append(changes, models.Change{
+			Kind:     models.ChangeReordered,
+			Scope:    models.ScopeService,
+			Name:     svcName,
+			Path:     path,
+			Before:   old,
+			After:    new,
+			Severity: models.SeverityInfo,
+		})
+	}
 
 	for _, item := range added {
 		changes = append(changes, models.Change{
@@ -381,17 +1050,11 @@ func compareStringSlice(svcName, path string, old, new []string, severity models
 }
 
 // compareVolumes compares top-level volume definitions
-func compareVolumes(old, new map[string]models.VolumeIR, report *models.DiffReport) {
-	oldNames := mapKeys(old)
-	newNames := mapKeys(new)
-
-	added, removed, _ := diffSets(oldNames, newNames)
-
-	report.Summary.VolumesAdded = len(added)
-	report.Summary.VolumesRemoved = len(removed)
+func compareVolumes(old, new map[string]models.VolumeIR, e *emitter) {
+	added, removed, common := diffKeys(old, new)
 
 	for _, name := range added {
-		report.AddChange(models.Change{
+		e.add(models.Change{
 			Kind:     models.ChangeAdded,
 			Scope:    models.ScopeVolume,
 			Name:     name,
@@ -403,7 +1066,7 @@ func compareVolumes(old, new map[string]models.VolumeIR, report *models.DiffRepo
 	}
 
 	for _, name := range removed {
-		report.AddChange(models.Change{
+		e.add(models.Change{
 			Kind:     models.ChangeRemoved,
 			Scope:    models.ScopeVolume,
 			Name:     name,
@@ -413,20 +1076,27 @@ func compareVolumes(old, new map[string]models.VolumeIR, report *models.DiffRepo
 			Severity: models.SeverityBreaking,
 		})
 	}
+
+	// Definitions: a different driver or name points at different data
+	for _, name := range common {
+		o, n := old[name], new[name]
+		changes := compareDefinition(name, "volumes."+name,
+			definition{o.Driver, o.DriverOpts, o.External, o.Name, o.Labels},
+			definition{n.Driver, n.DriverOpts, n.External, n.Name, n.Labels},
+			models.SeverityBreaking)
+		for _, c := range changes {
+			c.Scope = models.ScopeVolume
+			e.add(c)
+		}
+	}
 }
 
 // compareNetworks compares top-level network definitions
-func compareNetworks(old, new map[string]models.NetworkIR, report *models.DiffReport) {
-	oldNames := mapKeys(old)
-	newNames := mapKeys(new)
-
-	added, removed, _ := diffSets(oldNames, newNames)
-
-	report.Summary.NetworksAdded = len(added)
-	report.Summary.NetworksRemoved = len(removed)
+func compareNetworks(old, new map[string]models.NetworkIR, e *emitter) {
+	added, removed, common := diffKeys(old, new)
 
 	for _, name := range added {
-		report.AddChange(models.Change{
+		e.add(models.Change{
 			Kind:     models.ChangeAdded,
 			Scope:    models.ScopeNetwork,
 			Name:     name,
@@ -438,7 +1108,7 @@ func compareNetworks(old, new map[string]models.NetworkIR, report *models.DiffRe
 	}
 
 	for _, name := range removed {
-		report.AddChange(models.Change{
+		e.add(models.Change{
 			Kind:     models.ChangeRemoved,
 			Scope:    models.ScopeNetwork,
 			Name:     name,
@@ -448,15 +1118,202 @@ func compareNetworks(old, new map[string]models.NetworkIR, report *models.DiffRe
 			Severity: models.SeverityWarning,
 		})
 	}
+
+	for _, name := range common {
+		o, n := old[name], new[name]
+		changes := compareDefinition(name, "networks."+name,
+			definition{o.Driver, o.DriverOpts, o.External, o.Name, o.Labels},
+			definition{n.Driver, n.DriverOpts, n.External, n.Name, n.Labels},
+			models.SeverityWarning)
+		changes = append(changes, compareIPAM(name, "networks."+name+".ipam", o.IPAM, n.IPAM)...)
+		for _, c := range changes {
+			c.Scope = models.ScopeNetwork
+			e.add(c)
+		}
+	}
+}
+
+// compareTopLevelExtensions compares top-level x-* fields, named by their key
+func compareTopLevelExtensions(old, new map[string]any, e *emitter) {
+	for _, c := range compareExtensions("", "", old, new) {
+		c.Scope = models.ScopeExtension
+		c.Name = strings.SplitN(c.Path, ".", 2)[0]
+		e.add(c)
+	}
+}
+
+// compareExtensions compares x-* fields below path, descending into nested
+// mappings so each changed leaf is reported on its own. Extensions carry
+// team metadata rather than runtime config, so changes are info.
+func compareExtensions(svcName, path string, old, new map[string]any) []models.Change {
+	var changes []models.Change
+
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	added, removed, common := diffKeys(old, new)
+	for _, key := range added {
+		changes = append(changes, models.Change{
+			Kind:     models.ChangeAdded,
+			Scope:    models.ScopeService,
+			Name:     svcName,
+			Path:     join(key),
+			Before:   nil,
+			After:    new[key],
+			Severity: models.SeverityInfo,
+		})
+	}
+	for _, key := range removed {
+		changes = append(changes, models.Change{
+			Kind:     models.ChangeRemoved,
+			Scope:    models.ScopeService,
+			Name:     svcName,
+			Path:     join(key),
+			Before:   old[key],
+			After:    nil,
+			Severity: models.SeverityInfo,
+		})
+	}
+	for _, key := range common {
+		oldMap, oldIsMap := old[key].(map[string]any)
+		newMap, newIsMap := new[key].(map[string]any)
+		if oldIsMap && newIsMap {
+			changes = append(changes, compareExtensions(svcName, join(key), oldMap, newMap)...)
+			continue
+		}
+		if !reflect.DeepEqual(old[key], new[key]) {
+			changes = append(changes, models.Change{
+				Kind:     models.ChangeModified,
+				Scope:    models.ScopeService,
+				Name:     svcName,
+				Path:     join(key),
+				Before:   old[key],
+				After:    new[key],
+				Severity: models.SeverityInfo,
+			})
+		}
+	}
+
+	return changes
+}
+
+// compareIPAM compares network address pools keyed by subnet. Re-addressing
+// a network breaks static container addresses and firewall rules, so removed
+// or altered pools are breaking.
+func compareIPAM(name, path string, old, new *models.IPAMIR) []models.Change {
+	if old == nil {
+		old = &models.IPAMIR{}
+	}
+	if new == nil {
+		new = &models.IPAMIR{}
+	}
+
+	var changes []models.Change
+	changes = appendFieldChange(changes, name, path+".driver", old.Driver, new.Driver, models.SeverityBreaking)
+
+	oldPools := make(map[string]models.IPAMPoolIR, len(old.Config))
+	for _, p := range old.Config {
+		oldPools[p.Subnet] = p
+	}
+	newPools := make(map[string]models.IPAMPoolIR, len(new.Config))
+	for _, p := range new.Config {
+		newPools[p.Subnet] = p
+	}
+
+	added, removed, common := diffKeys(oldPools, newPools)
+
+	for _, subnet := range added {
+		changes = append(changes, models.Change{
+			Kind:     models.ChangeAdded,
+			Scope:    models.ScopeService,
+			Name:     name,
+			Path:     fmt.Sprintf("%s.config.%s", path, subnet),
+			Before:   nil,
+			After:    newPools[subnet],
+			Severity: models.SeverityInfo,
+		})
+	}
+
+	for _, subnet := range removed {
+		changes = append(changes, models.Change{
+			Kind:     models.ChangeRemoved,
+			Scope:    models.ScopeService,
+			Name:     name,
+			Path:     fmt.Sprintf("%s.config.%s", path, subnet),
+			Before:   oldPools[subnet],
+			After:    nil,
+			Severity: models.SeverityBreaking,
+		})
+	}
+
+	for _, subnet := range common {
+		if !ipamPoolEqual(oldPools[subnet], newPools[subnet]) {
+			changes = append(changes, models.Change{
+				Kind:     models.ChangeModified,
+				Scope:    models.ScopeService,
+				Name:     name,
+				Path:     fmt.Sprintf("%s.config.%s", path, subnet),
+				Before:   oldPools[subnet],
+				After:    newPools[subnet],
+				Severity: models.SeverityBreaking,
+			})
+		}
+	}
+
+	return changes
+}
+
+// definition holds the fields top-level volumes and networks have in common
+type definition struct {
+	Driver     string
+	DriverOpts map[string]string
+	External   bool
+	Name       string
+	Labels     map[string]string
+}
+
+// compareDefinition compares two volume or network definitions. Changing the
+// driver or the backing name gets identitySeverity; the returned changes use
+// the service scope and must be re-scoped by the caller.
+func compareDefinition(name, path string, old, new definition, identitySeverity models.Severity) []models.Change {
+	var changes []models.Change
+
+	changes = appendFieldChange(changes, name, path+".driver", old.Driver, new.Driver, identitySeverity)
+	changes = appendFieldChange(changes, name, path+".name", old.Name, new.Name, identitySeverity)
+	if old.External != new.External {
+		changes = append(changes, models.Change{
+			Kind:     models.ChangeModified,
+			Scope:    models.ScopeService,
+			Name:     name,
+			Path:     path + ".external",
+			Before:   old.External,
+			After:    new.External,
+			Severity: models.SeverityWarning, // Compose stops (or starts) managing its lifecycle
+		})
+	}
+	changes = append(changes, compareStringMap(name, path+".driver_opts", old.DriverOpts, new.DriverOpts, models.SeverityWarning, models.SeverityWarning)...)
+	changes = append(changes, compareStringMap(name, path+".labels", old.Labels, new.Labels, models.SeverityInfo, models.SeverityInfo)...)
+
+	return changes
 }
 
 // FilterByService filters a report to only include changes for a specific service
 func FilterByService(report *models.DiffReport, service string) *models.DiffReport {
 	filtered := models.NewDiffReport()
 	filtered.Summary = report.Summary
+	filtered.Freeze = report.Freeze
 
 	for _, c := range report.Changes {
-		if c.Scope == models.ScopeService && c.Name == service {
+		if c.Scope != models.ScopeService {
+			continue
+		}
+		// A renamed service also matches its old name
+		renamedFrom := c.Kind == models.ChangeRenamed && c.Path == "services."+c.Name && c.Before == service
+		if c.Name == service || renamedFrom {
 			filtered.Changes = append(filtered.Changes, c)
 		}
 	}
@@ -470,6 +1327,7 @@ func FilterBySeverity(report *models.DiffReport, minSeverity string) *models.Dif
 
 	filtered := models.NewDiffReport()
 	filtered.Summary = report.Summary
+	filtered.Freeze = report.Freeze
 
 	for _, c := range report.Changes {
 		if models.SeverityLevel(c.Severity) >= minLevel {
@@ -480,46 +1338,55 @@ func FilterBySeverity(report *models.DiffReport, minSeverity string) *models.Dif
 	return filtered
 }
 
-// Helper functions
+// FilterByKind filters a report to only include changes of the given kinds
+func FilterByKind(report *models.DiffReport, kinds []models.ChangeKind) *models.DiffReport {
+	filtered := models.NewDiffReport()
+	filtered.Summary = report.Summary
+	filtered.Freeze = report.Freeze
 
-func mapKeys[K comparable, V any](m map[K]V) []K {
-	keys := make([]K, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
+	for _, c := range report.Changes {
+		for _, k := range kinds {
+			if c.Kind == k {
+				filtered.Changes = append(filtered.Changes, c)
+				break
+			}
+		}
 	}
-	return keys
-}
 
-func envKeys(m map[string]*string) []string {
-	keys := make([]string, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-	return keys
+	return filtered
 }
 
-func diffSets(old, new []string) (added, removed, common []string) {
-	oldSet := make(map[string]bool)
-	newSet := make(map[string]bool)
+// FilterFindings returns the findings at or above a severity level
+func FilterFindings(findings []models.Finding, minSeverity string) []models.Finding {
+	minLevel := models.SeverityLevel(models.ParseSeverity(minSeverity))
 
-	for _, s := range old {
-		oldSet[s] = true
-	}
-	for _, s := range new {
-		newSet[s] = true
+	var filtered []models.Finding
+	for _, f := range findings {
+		if models.SeverityLevel(f.Severity) >= minLevel {
+			filtered = append(filtered, f)
+		}
 	}
+	return filtered
+}
+
+// Helper functions
 
-	for s := range newSet {
-		if !oldSet[s] {
-			added = append(added, s)
+// diffKeys splits the keys of two maps into sorted added, removed and
+// common keys without building intermediate sets
+func diffKeys[V any](old, new map[string]V) (added, removed, common []string) {
+	common = make([]string, 0, min(len(old), len(new)))
+	for k := range old {
+		if _, ok := new[k]; ok {
+			common = append(common, k)
+		} else {
+			removed = append(removed, k)
 		}
 	}
-	for s := range oldSet {
-		if !newSet[s] {
-			removed = append(removed, s)
-		} else {
-			common = append(common, s)
+	if len(common) < len(new) {
+		for k := range new {
+			if _, ok := old[k]; !ok {
+				added = append(added, k)
+			}
 		}
 	}
 
@@ -529,6 +1396,53 @@ func diffSets(old, new []string) (added, removed, common []string) {
 	return
 }
 
+// diffSets splits two string lists, treated as sets, into sorted added,
+// removed and common items by merging sorted copies
+func diffSets(old, new []string) (added, removed, common []string) {
+	a := sortedUnique(old)
+	b := sortedUnique(new)
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			common = append(common, a[i])
+			i++
+			j++
+		case a[i] < b[j]:
+			removed = append(removed, a[i])
+			i++
+		default:
+			added = append(added, b[j])
+			j++
+		}
+	}
+	removed = append(removed, a[i:]...)
+	added = append(added, b[j:]...)
+	return
+}
+
+// keepOnly returns the items of list that are in sorted, in list order
+func keepOnly(list, sorted []string) []string {
+	var kept []string
+	for _, item := range list {
+		if _, found := slices.BinarySearch(sorted, item); found {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// sortedUnique returns a sorted copy of list without duplicates
+func sortedUnique(list []string) []string {
+	if len(list) == 0 {
+		return nil
+	}
+	sorted := slices.Clone(list)
+	sort.Strings(sorted)
+	return slices.Compact(sorted)
+}
+
 func ptrEqual(a, b *string) bool {
 	if a == nil && b == nil {
 		return true
@@ -558,16 +1472,6 @@ func sliceEqual(a, b []string) bool {
 	return true
 }
 
-func healthcheckEqual(a, b *models.HealthcheckIR) bool {
-	if a == nil && b == nil {
-		return true
-	}
-	if a == nil || b == nil {
-		return false
-	}
-	return reflect.DeepEqual(a, b)
-}
-
 func changeKindForPtrs(old, new interface{}) models.ChangeKind {
 	if old == nil {
 		return models.ChangeAdded
@@ -578,17 +1482,88 @@ func changeKindForPtrs(old, new interface{}) models.ChangeKind {
 	return models.ChangeModified
 }
 
+// ptrChange builds a change for an optional scalar field
+func ptrChange(svcName, path string, old, new *string, severity models.Severity) models.Change {
+	kind := models.ChangeModified
+	if old == nil {
+		kind = models.ChangeAdded
+	} else if new == nil {
+		kind = models.ChangeRemoved
+	}
+	return models.Change{
+		Kind:     kind,
+		Scope:    models.ScopeService,
+		Name:     svcName,
+		Path:     path,
+		Before:   ptrValue(old),
+		After:    ptrValue(new),
+		Severity: severity,
+	}
+}
+
+// appendFieldChange appends a change if a string field differs, treating "" as unset
+func appendFieldChange(changes []models.Change, svcName, path, old, new string, severity models.Severity) []models.Change {
+	if old == new {
+		return changes
+	}
+	return append(changes, ptrChange(svcName, path, strPtr(old), strPtr(new), severity))
+}
+
+// strPtr returns nil for an empty string
+func strPtr(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// boolPtrEqual compares two optional booleans
+func boolPtrEqual(a, b *bool) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// boolString formats an optional boolean, returning nil if unset
+func boolString(b *bool) *string {
+	if b == nil {
+		return nil
+	}
+	return strPtr(strconv.FormatBool(*b))
+}
+
+// intString formats an optional integer field, treating 0 as unset
+func intString(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// deviceRequestEqual compares device requests field by field
+func deviceRequestEqual(a, b models.DeviceRequestIR) bool {
+	return a.Driver == b.Driver && a.Count == b.Count &&
+		sliceEqual(a.Capabilities, b.Capabilities) && sliceEqual(a.DeviceIDs, b.DeviceIDs)
+}
+
+// ipamPoolEqual compares address pools field by field
+func ipamPoolEqual(a, b models.IPAMPoolIR) bool {
+	return a.Subnet == b.Subnet && a.Gateway == b.Gateway && a.IPRange == b.IPRange &&
+		maps.Equal(a.AuxAddresses, b.AuxAddresses)
+}
+
 func portMap(ports []models.PortIR) map[string]models.PortIR {
-	m := make(map[string]models.PortIR)
+	m := make(map[string]models.PortIR, len(ports))
 	for _, p := range ports {
-		key := fmt.Sprintf("%s:%s/%s", p.HostPort, p.ContainerPort, p.Protocol)
+		key := p.HostPort + ":" + p.ContainerPort + "/" + p.Protocol
 		m[key] = p
 	}
 	return m
 }
 
 func mountMap(mounts []models.MountIR) map[string]models.MountIR {
-	m := make(map[string]models.MountIR)
+	m := make(map[string]models.MountIR, len(mounts))
 	for _, mount := range mounts {
 		m[mount.Target] = mount
 	}
@@ -1,6 +1,9 @@
 package diff
 
 import (
+	"fmt"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/stackgen-cli/compose-diff/internal/models"
@@ -66,7 +69,7 @@ func TestCompareServices(t *testing.T) {
 	hasServiceRemoved := false
 
 	for _, c := range report.Changes {
-		if c.Path == "services.api.image" {
+		if c.Path == "services.api.image.tag" {
 			hasImageChange = true
 		}
 		if c.Path == "services.api.environment.DATABASE_URL" && c.Kind == models.ChangeRemoved {
@@ -225,6 +228,745 @@ func TestFilterBySeverity(t *testing.T) {
 	}
 }
 
+func TestCompareStopSettings(t *testing.T) {
+	old := &models.ComposeIR{
+		Services: map[string]models.ServiceIR{
+			"api": {
+				StopGracePeriod: ptrStr("1m"),
+				Deploy: &models.DeployIR{
+					RestartPolicy: &models.RestartPolicyIR{Condition: "on-failure", MaxAttempts: 3},
+				},
+			},
+		},
+	}
+	new := &models.ComposeIR{
+		Services: map[string]models.ServiceIR{
+			"api": {
+				StopGracePeriod: ptrStr("30s"),
+				StopSignal:      ptrStr("SIGINT"),
+				Deploy: &models.DeployIR{
+					RestartPolicy: &models.RestartPolicyIR{Condition: "any", MaxAttempts: 3},
+				},
+			},
+		},
+	}
+
+	report := Compare(old, new)
+
+	found := make(map[string]models.Change)
+	for _, c := range report.Changes {
+		found[c.Path] = c
+	}
+
+	grace, ok := found["services.api.stop_grace_period"]
+	if !ok {
+		t.Fatal("Expected stop_grace_period change")
+	}
+	if grace.Severity != models.SeverityWarning {
+		t.Errorf("Shorter grace period should be warning, got %s", grace.Severity)
+	}
+
+	signal, ok := found["services.api.stop_signal"]
+	if !ok || signal.Kind != models.ChangeAdded {
+		t.Errorf("Expected stop_signal added, got %+v", signal)
+	}
+
+	if _, ok := found["services.api.deploy.restart_policy.condition"]; !ok {
+		t.Error("Expected restart_policy.condition change")
+	}
+	if _, ok := found["services.api.deploy.restart_policy.max_attempts"]; ok {
+		t.Error("Unchanged max_attempts should not be reported")
+	}
+}
+
+func TestCompareResources(t *testing.T) {
+	old := &models.ComposeIR{
+		Services: map[string]models.ServiceIR{
+			"api": {
+				MemLimit: 1 << 30,
+				CPUs:     2,
+				Deploy: &models.DeployIR{
+					Resources: &models.ResourcesIR{
+						Reservations: &models.ResourceSpecIR{Memory: 256 << 20},
+					},
+				},
+			},
+		},
+	}
+	new := &models.ComposeIR{
+		Services: map[string]models.ServiceIR{
+			"api": {
+				MemLimit:  2 << 30,
+				CPUs:      1,
+				CPUShares: 512,
+				Deploy: &models.DeployIR{
+					Resources: &models.ResourcesIR{
+						Limits:       &models.ResourceSpecIR{Memory: 512 << 20},
+						Reservations: &models.ResourceSpecIR{Memory: 256 << 20},
+					},
+				},
+			},
+		},
+	}
+
+	report := Compare(old, new)
+
+	expected := map[string]models.Severity{
+		"services.api.mem_limit":                      models.SeverityInfo,    // raised
+		"services.api.cpus":                           models.SeverityWarning, // lowered
+		"services.api.cpu_shares":                     models.SeverityWarning, // below default 1024
+		"services.api.deploy.resources.limits.memory": models.SeverityWarning, // unlimited -> limited
+	}
+
+	found := make(map[string]models.Change)
+	for _, c := range report.Changes {
+		found[c.Path] = c
+	}
+
+	for path, sev := range expected {
+		c, ok := found[path]
+		if !ok {
+			t.Errorf("Expected change at %s", path)
+			continue
+		}
+		if c.Severity != sev {
+			t.Errorf("%s: expected %s, got %s", path, sev, c.Severity)
+		}
+	}
+
+	if _, ok := found["services.api.deploy.resources.reservations.memory"]; ok {
+		t.Error("Unchanged reservation should not be reported")
+	}
+	if len(report.Changes) != len(expected) {
+		t.Errorf("Expected %d changes, got %d", len(expected), len(report.Changes))
+	}
+}
+
+func TestCompareSecurity(t *testing.T) {
+	old := &models.ComposeIR{
+		Services: map[string]models.ServiceIR{
+			"api": {
+				Security: &models.SecurityIR{
+					CapDrop:     []string{"ALL"},
+					SecurityOpt: []string{"seccomp:profile.json"},
+				},
+			},
+		},
+	}
+	new := &models.ComposeIR{
+		Services: map[string]models.ServiceIR{
+			"api": {
+				Security: &models.SecurityIR{
+					Privileged:  true,
+					CapAdd:      []string{"SYS_ADMIN", "NET_BIND_SERVICE"},
+					SecurityOpt: []string{"apparmor:unconfined"},
+				},
+			},
+		},
+	}
+
+	report := Compare(old, new)
+
+	expected := map[string]models.Severity{
+		"services.api.privileged":                        models.SeverityBreaking,
+		"services.api.cap_add.SYS_ADMIN":                 models.SeverityBreaking,
+		"services.api.cap_add.NET_BIND_SERVICE":          models.SeverityWarning,
+		"services.api.cap_drop.ALL":                      models.SeverityBreaking,
+		"services.api.security_opt.seccomp:profile.json": models.SeverityWarning,
+		"services.api.security_opt.apparmor:unconfined":  models.SeverityBreaking,
+	}
+
+	found := make(map[string]models.Severity)
+	for _, c := range report.Changes {
+		found[c.Path] = c.Severity
+	}
+
+	for path, sev := range expected {
+		got, ok := found[path]
+		if !ok {
+			t.Errorf("Expected change at %s", path)
+			continue
+		}
+		if got != sev {
+			t.Errorf("%s: expected %s, got %s", path, sev, got)
+		}
+	}
+}
+
+func TestCompareUserSeverity(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new *string
+		expected models.Severity
+	}{
+		{"root to non-root", ptrStr("root"), ptrStr("1000:1000"), models.SeverityWarning},
+		{"unset to non-root", nil, ptrStr("app"), models.SeverityWarning},
+		{"non-root to uid 0", ptrStr("app"), ptrStr("0:0"), models.SeverityWarning},
+		{"non-root to non-root", ptrStr("app"), ptrStr("1000"), models.SeverityInfo},
+		{"unset to root", nil, ptrStr("root"), models.SeverityInfo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := &models.ComposeIR{Services: map[string]models.ServiceIR{"api": {User: tt.old}}}
+			new := &models.ComposeIR{Services: map[string]models.ServiceIR{"api": {User: tt.new}}}
+
+			report := Compare(old, new)
+			if len(report.Changes) != 1 {
+				t.Fatalf("Expected 1 change, got %d", len(report.Changes))
+			}
+			if got := report.Changes[0].Severity; got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCompareDevices(t *testing.T) {
+	gpu := &models.DeployIR{
+		Resources: &models.ResourcesIR{
+			Reservations: &models.ResourceSpecIR{
+				Devices: []models.DeviceRequestIR{{Driver: "nvidia", Capabilities: []string{"gpu"}, Count: "1"}},
+			},
+		},
+	}
+	old := &models.ComposeIR{
+		Services: map[string]models.ServiceIR{
+			"ml": {
+				Devices: []models.DeviceIR{
+					{Source: "/dev/ttyUSB0", Target: "/dev/ttyUSB0"},
+					{Source: "/dev/snd", Target: "/dev/snd", Permissions: "rwm"},
+				},
+				Deploy: gpu,
+			},
+		},
+	}
+	new := &models.ComposeIR{
+		Services: map[string]models.ServiceIR{
+			"ml": {
+				Devices: []models.DeviceIR{
+					{Source: "/dev/snd", Target: "/dev/snd", Permissions: "r"},
+				},
+			},
+		},
+	}
+
+	report := Compare(old, new)
+
+	expected := map[string]models.Severity{
+		"services.ml.devices./dev/ttyUSB0":                             models.SeverityBreaking,
+		"services.ml.devices./dev/snd":                                 models.SeverityWarning,
+		"services.ml.deploy.resources.reservations.devices.nvidia/gpu": models.SeverityBreaking,
+	}
+
+	found := make(map[string]models.Severity)
+	for _, c := range report.Changes {
+		found[c.Path] = c.Severity
+	}
+
+	for path, sev := range expected {
+		got, ok := found[path]
+		if !ok {
+			t.Errorf("Expected change at %s, got %v", path, found)
+			continue
+		}
+		if got != sev {
+			t.Errorf("%s: expected %s, got %s", path, sev, got)
+		}
+	}
+}
+
+func TestCompareExtraHosts(t *testing.T) {
+	old := &models.ComposeIR{
+		Services: map[string]models.ServiceIR{
+			"api": {
+				ExtraHosts: map[string]string{"db.internal": "10.0.0.5", "cache": "10.0.0.6"},
+				DNS:        []string{"10.0.0.2"},
+			},
+		},
+	}
+	new := &models.ComposeIR{
+		Services: map[string]models.ServiceIR{
+			"api": {
+				ExtraHosts: map[string]string{"cache": "10.0.0.6", "queue": "10.0.0.9"},
+				DNS:        []string{"10.0.0.2"},
+			},
+		},
+	}
+
+	report := Compare(old, new)
+
+	if len(report.Changes) != 2 {
+		t.Fatalf("Expected 2 changes, got %d: %+v", len(report.Changes), report.Changes)
+	}
+	for _, c := range report.Changes {
+		switch c.Path {
+		case "services.api.extra_hosts.db.internal":
+			if c.Kind != models.ChangeRemoved || c.Severity != models.SeverityWarning {
+				t.Errorf("Expected removed/warning for db.internal, got %s/%s", c.Kind, c.Severity)
+			}
+		case "services.api.extra_hosts.queue":
+			if c.Severity != models.SeverityInfo {
+				t.Errorf("Expected info for added host, got %s", c.Severity)
+			}
+		default:
+			t.Errorf("Unexpected change at %s", c.Path)
+		}
+	}
+}
+
+func TestCompareNamespaces(t *testing.T) {
+	old := &models.ComposeIR{
+		Services: map[string]models.ServiceIR{
+			"api":    {NetworkMode: ptrStr("bridge")},
+			"worker": {IPC: ptrStr("private"), ShmSize: 256 << 20},
+			"proxy":  {NetworkMode: ptrStr("bridge")},
+		},
+	}
+	new := &models.ComposeIR{
+		Services: map[string]models.ServiceIR{
+			"api":    {NetworkMode: ptrStr("host")},
+			"worker": {IPC: ptrStr("host"), PID: ptrStr("host"), ShmSize: 64 << 20},
+			"proxy":  {NetworkMode: ptrStr("service:vpn")},
+		},
+	}
+
+	report := Compare(old, new)
+
+	expected := map[string]models.Severity{
+		"services.api.network_mode":   models.SeverityBreaking,
+		"services.proxy.network_mode": models.SeverityWarning,
+		"services.worker.ipc":         models.SeverityBreaking,
+		"services.worker.pid":         models.SeverityBreaking,
+		"services.worker.shm_size":    models.SeverityWarning,
+	}
+
+	found := make(map[string]models.Severity)
+	for _, c := range report.Changes {
+		found[c.Path] = c.Severity
+	}
+	if len(found) != len(expected) {
+		t.Errorf("Expected %d changes, got %v", len(expected), found)
+	}
+	for path, sev := range expected {
+		if got := found[path]; got != sev {
+			t.Errorf("%s: expected %s, got %q", path, sev, got)
+		}
+	}
+}
+
+func TestCompareExposeSeparateFromPorts(t *testing.T) {
+	old := &models.ComposeIR{
+		Services: map[string]models.ServiceIR{
+			"api": {
+				Ports:  []models.PortIR{{HostPort: "8080", ContainerPort: "8080", Protocol: "tcp"}},
+				Expose: []string{"8080/tcp", "9090/tcp"},
+			},
+		},
+	}
+	new := &models.ComposeIR{
+		Services: map[string]models.ServiceIR{
+			"api": {
+				Ports:  []models.PortIR{{HostPort: "8080", ContainerPort: "8080", Protocol: "tcp"}},
+				Expose: []string{"8080/tcp"},
+			},
+		},
+	}
+
+	report := Compare(old, new)
+
+	if len(report.Changes) != 1 {
+		t.Fatalf("Expected 1 change, got %d", len(report.Changes))
+	}
+	c := report.Changes[0]
+	if c.Path != "services.api.expose.9090/tcp" || c.Kind != models.ChangeRemoved || c.Severity != models.SeverityWarning {
+		t.Errorf("Unexpected change: %+v", c)
+	}
+}
+
+func TestCompareMountOptions(t *testing.T) {
+	mount := models.MountIR{Type: "tmpfs", Target: "/scratch", TmpfsSize: 64 << 20}
+	resized := mount
+	resized.TmpfsSize = 32 << 20
+
+	old := &models.ComposeIR{Services: map[string]models.ServiceIR{"api": {Volumes: []models.MountIR{mount}}}}
+	new := &models.ComposeIR{Services: map[string]models.ServiceIR{"api": {Volumes: []models.MountIR{resized}}}}
+
+	report := Compare(old, new)
+
+	if len(report.Changes) != 1 || report.Changes[0].Kind != models.ChangeModified {
+		t.Fatalf("Expected one modified mount, got %+v", report.Changes)
+	}
+}
+
+func TestCompareDefinitions(t *testing.T) {
+	old := &models.ComposeIR{
+		Volumes:  map[string]models.VolumeIR{"data": {Driver: "local"}},
+		Networks: map[string]models.NetworkIR{"back": {Driver: "bridge", Labels: map[string]string{"team": "a"}}},
+	}
+	new := &models.ComposeIR{
+		Volumes:  map[string]models.VolumeIR{"data": {Driver: "rexray"}},
+		Networks: map[string]models.NetworkIR{"back": {Driver: "bridge", External: true}},
+	}
+
+	report := Compare(old, new)
+
+	expected := map[string]models.Change{
+		"volumes.data.driver":       {Kind: models.ChangeModified, Scope: models.ScopeVolume, Severity: models.SeverityBreaking},
+		"networks.back.external":    {Kind: models.ChangeModified, Scope: models.ScopeNetwork, Severity: models.SeverityWarning},
+		"networks.back.labels.team": {Kind: models.ChangeRemoved, Scope: models.ScopeNetwork, Severity: models.SeverityInfo},
+	}
+
+	if len(report.Changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %+v", len(expected), report.Changes)
+	}
+	for _, c := range report.Changes {
+		want, ok := expected[c.Path]
+		if !ok {
+			t.Errorf("Unexpected change at %s", c.Path)
+			continue
+		}
+		if c.Kind != want.Kind || c.Scope != want.Scope || c.Severity != want.Severity {
+			t.Errorf("%s: got %s/%s/%s", c.Path, c.Kind, c.Scope, c.Severity)
+		}
+	}
+}
+
+func TestCompareIPAM(t *testing.T) {
+	old := &models.ComposeIR{
+		Networks: map[string]models.NetworkIR{"back": {IPAM: &models.IPAMIR{Config: []models.IPAMPoolIR{
+			{Subnet: "172.28.0.0/16", Gateway: "172.28.0.1"},
+			{Subnet: "10.5.0.0/24"},
+		}}}},
+	}
+	new := &models.ComposeIR{
+		Networks: map[string]models.NetworkIR{"back": {IPAM: &models.IPAMIR{Config: []models.IPAMPoolIR{
+			{Subnet: "172.28.0.0/16", Gateway: "172.28.5.254"},
+			{Subnet: "10.6.0.0/24"},
+		}}}},
+	}
+
+	report := Compare(old, new)
+
+	expected := map[string]models.Severity{
+		"networks.back.ipam.config.172.28.0.0/16": models.SeverityBreaking,
+		"networks.back.ipam.config.10.5.0.0/24":   models.SeverityBreaking,
+		"networks.back.ipam.config.10.6.0.0/24":   models.SeverityInfo,
+	}
+	if len(report.Changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %+v", len(expected), report.Changes)
+	}
+	for _, c := range report.Changes {
+		if sev, ok := expected[c.Path]; !ok || sev != c.Severity || c.Scope != models.ScopeNetwork {
+			t.Errorf("Unexpected change: %s %s %s", c.Path, c.Scope, c.Severity)
+		}
+	}
+}
+
+func TestCompareBuild(t *testing.T) {
+	old := &models.ComposeIR{
+		Services: map[string]models.ServiceIR{
+			"api": {Build: &models.BuildIR{
+				Context:   ".",
+				Target:    "dev",
+				Args:      map[string]string{"NODE_ENV": "development", "DEBUG": "1"},
+				Platforms: []string{"linux/amd64", "linux/arm64"},
+			}},
+		},
+	}
+	new := &models.ComposeIR{
+		Services: map[string]models.ServiceIR{
+			"api": {Build: &models.BuildIR{
+				Context:   ".",
+				Target:    "prod",
+				Args:      map[string]string{"NODE_ENV": "production", "VERSION": "2"},
+				Platforms: []string{"linux/amd64"},
+				CacheFrom: []string{"type=registry,ref=api:cache"},
+			}},
+		},
+	}
+
+	report := Compare(old, new)
+
+	expected := map[string]models.Severity{
+		"services.api.build.target":                                 models.SeverityWarning,
+		"services.api.build.args.NODE_ENV":                          models.SeverityInfo,
+		"services.api.build.args.DEBUG":                             models.SeverityWarning,
+		"services.api.build.args.VERSION":                           models.SeverityInfo,
+		"services.api.build.platforms.linux/arm64":                  models.SeverityWarning,
+		"services.api.build.cache_from.type=registry,ref=api:cache": models.SeverityInfo,
+	}
+	if len(report.Changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %+v", len(expected), report.Changes)
+	}
+	for _, c := range report.Changes {
+		if sev, ok := expected[c.Path]; !ok || sev != c.Severity {
+			t.Errorf("Unexpected change: %s %s", c.Path, c.Severity)
+		}
+	}
+}
+
+func TestCompareHealthcheckFields(t *testing.T) {
+	old := &models.ComposeIR{
+		Services: map[string]models.ServiceIR{
+			"db": {Healthcheck: &models.HealthcheckIR{
+				Test:     []string{"pg_isready"},
+				Interval: "30s",
+				Timeout:  "5s",
+				Retries:  3,
+			}},
+			"cache": {Healthcheck: &models.HealthcheckIR{Test: []string{"CMD", "redis-cli", "ping"}}},
+		},
+	}
+	new := &models.ComposeIR{
+		Services: map[string]models.ServiceIR{
+			"db": {Healthcheck: &models.HealthcheckIR{
+				Test:     []string{"CMD-SHELL", "pg_isready"},
+				Interval: "0.5m",
+				Timeout:  "10s",
+				Retries:  5,
+			}},
+			"cache": {Healthcheck: &models.HealthcheckIR{Test: []string{"CMD", "redis-cli", "ping"}, Disable: true}},
+		},
+	}
+
+	report := Compare(old, new)
+
+	// The string test form equals CMD-SHELL and 30s equals 0.5m
+	expected := map[string]models.Severity{
+		"services.db.healthcheck.timeout":    models.SeverityInfo,
+		"services.db.healthcheck.retries":    models.SeverityInfo,
+		"services.cache.healthcheck.disable": models.SeverityBreaking,
+	}
+	if len(report.Changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %+v", len(expected), report.Changes)
+	}
+	for _, c := range report.Changes {
+		if sev, ok := expected[c.Path]; !ok || sev != c.Severity {
+			t.Errorf("Unexpected change: %s %s", c.Path, c.Severity)
+		}
+	}
+}
+
+func TestCompareLabelsPerKey(t *testing.T) {
+	old := &models.ComposeIR{Services: map[string]models.ServiceIR{"web": {Labels: map[string]string{
+		"traefik.http.routers.web.rule": "Host(`a.example.com`)",
+		"team":                          "core",
+	}}}}
+	new := &models.ComposeIR{Services: map[string]models.ServiceIR{"web": {Labels: map[string]string{
+		"traefik.http.routers.web.rule": "Host(`b.example.com`)",
+		"owner":                         "platform",
+	}}}}
+
+	report := Compare(old, new)
+
+	namespaces := []string{"traefik.*"}
+	expected := map[string]models.Severity{
+		"services.web.labels.traefik.http.routers.web.rule": models.SeverityWarning,
+		"services.web.labels.team":                          models.SeverityInfo,
+		"services.web.labels.owner":                         models.SeverityInfo,
+	}
+	if len(report.Changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %+v", len(expected), report.Changes)
+	}
+	for _, c := range report.Changes {
+		if sev, ok := expected[c.Path]; !ok || LabelNamespaceSeverity(c, namespaces) != sev {
+			t.Errorf("Unexpected change: %s %s", c.Path, c.Severity)
+		}
+	}
+}
+
+func TestCompareProfiles(t *testing.T) {
+	old := &models.ComposeIR{Services: map[string]models.ServiceIR{
+		"debug":  {},
+		"worker": {Profiles: []string{"batch"}},
+	}}
+	new := &models.ComposeIR{Services: map[string]models.ServiceIR{
+		"debug":  {Profiles: []string{"dev"}},
+		"worker": {Profiles: []string{"batch", "nightly"}},
+	}}
+
+	report := Compare(old, new)
+
+	// debug no longer starts by default; worker just gains a profile
+	expected := map[string]models.Severity{
+		"services.debug.profiles.dev":      models.SeverityWarning,
+		"services.worker.profiles.nightly": models.SeverityInfo,
+	}
+	if len(report.Changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %+v", len(expected), report.Changes)
+	}
+	for _, c := range report.Changes {
+		if sev, ok := expected[c.Path]; !ok || sev != c.Severity {
+			t.Errorf("Unexpected change: %s %s", c.Path, c.Severity)
+		}
+	}
+}
+
+func TestCompareExtensions(t *testing.T) {
+	old := &models.ComposeIR{
+		Services: map[string]models.ServiceIR{"api": {Extensions: map[string]any{
+			"x-deploy": map[string]any{"owner": "payments", "tier": 1},
+		}}},
+		Extensions: map[string]any{"x-release": "2024.05"},
+	}
+	new := &models.ComposeIR{
+		Services: map[string]models.ServiceIR{"api": {Extensions: map[string]any{
+			"x-deploy": map[string]any{"owner": "checkout", "tier": 1},
+			"x-oncall": "team-b",
+		}}},
+		Extensions: map[string]any{"x-release": "2024.06"},
+	}
+
+	report := Compare(old, new)
+
+	expected := map[string]models.Scope{
+		"services.api.x-deploy.owner": models.ScopeService,
+		"services.api.x-oncall":       models.ScopeService,
+		"x-release":                   models.ScopeExtension,
+	}
+	if len(report.Changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %+v", len(expected), report.Changes)
+	}
+	for _, c := range report.Changes {
+		if scope, ok := expected[c.Path]; !ok || scope != c.Scope || c.Severity != models.SeverityInfo {
+			t.Errorf("Unexpected change: %s %s %s", c.Path, c.Scope, c.Severity)
+		}
+	}
+}
+
 func ptrStr(s string) *string {
 	return &s
 }
+
+func TestCompareContainerName(t *testing.T) {
+	old := &models.ComposeIR{Services: map[string]models.ServiceIR{"api": {ContainerName: ptrStr("api")}}}
+	new := &models.ComposeIR{Services: map[string]models.ServiceIR{"api": {ContainerName: ptrStr("acme-api")}}}
+
+	report := Compare(old, new)
+	if len(report.Changes) != 1 {
+		t.Fatalf("Expected 1 change, got %d", len(report.Changes))
+	}
+	c := report.Changes[0]
+	if c.Path != "services.api.container_name" || c.Severity != models.SeverityWarning {
+		t.Errorf("Expected a container_name warning, got %s (%s)", c.Path, c.Severity)
+	}
+}
+
+func TestFilterByKind(t *testing.T) {
+	report := &models.DiffReport{
+		Changes: []models.Change{
+			{Kind: models.ChangeModified, Scope: models.ScopeService, Name: "api", Path: "services.api.image"},
+			{Kind: models.ChangeReordered, Scope: models.ScopeService, Name: "api", Path: "services.api.command"},
+			{Kind: models.ChangeRenamed, Scope: models.ScopeService, Name: "web", Path: "services.web", Before: "frontend", After: "web"},
+		},
+	}
+
+	filtered := FilterByKind(report, []models.ChangeKind{models.ChangeModified, models.ChangeRenamed})
+	if len(filtered.Changes) != 2 || filtered.Changes[1].Kind != models.ChangeRenamed {
+		t.Errorf("Expected the modified and renamed changes, got %+v", filtered.Changes)
+	}
+
+	if filtered := FilterByService(report, "frontend"); len(filtered.Changes) != 1 || filtered.Changes[0].Name != "web" {
+		t.Errorf("Expected a renamed service to match its old name, got %+v", filtered.Changes)
+	}
+}
+
+func TestCompareReorderedLists(t *testing.T) {
+	old := &models.ComposeIR{Services: map[string]models.ServiceIR{"api": {
+		EnvFiles:  []string{"base.env", "prod.env"},
+		DependsOn: []string{"db", "redis"},
+	}}}
+	new := &models.ComposeIR{Services: map[string]models.ServiceIR{"api": {
+		EnvFiles:  []string{"prod.env", "base.env"},
+		DependsOn: []string{"db", "redis"},
+	}}}
+
+	report := Compare(old, new)
+	if len(report.Changes) != 1 {
+		t.Fatalf("Expected 1 change, got %+v", report.Changes)
+	}
+	c := report.Changes[0]
+	if c.Kind != models.ChangeReordered || c.Path != "services.api.env_file" || c.Severity != models.SeverityWarning {
+		t.Errorf("Expected a reordered env_file warning, got %s %s (%s)", c.Kind, c.Path, c.Severity)
+	}
+}
+
+func TestCompareReorderedListsWithChanges(t *testing.T) {
+	old := &models.ComposeIR{Services: map[string]models.ServiceIR{"api": {
+		EnvFiles: []string{"a.env", "b.env"},
+		DNS:      []string{"1.1.1.1", "8.8.8.8"},
+	}}}
+	new := &models.ComposeIR{Services: map[string]models.ServiceIR{"api": {
+		EnvFiles: []string{"b.env", "a.env", "c.env"},
+		DNS:      []string{"1.1.1.1", "9.9.9.9", "8.8.8.8"},
+	}}}
+
+	var got []string
+	for _, c := range Compare(old, new).Changes {
+		got = append(got, fmt.Sprintf("%s %s %s", c.Kind, c.Path, c.Severity))
+	}
+	slices.Sort(got)
+	want := []string{
+		"added services.api.dns.9.9.9.9 info",
+		"added services.api.env_file.c.env info",
+		"reordered services.api.env_file warning",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Compare() = %q, want %q", got, want)
+	}
+}
+
+func TestCompareEnvSources(t *testing.T) {
+	info, debug, port := "info", "debug", "80"
+	old := &models.ComposeIR{Services: map[string]models.ServiceIR{"api": {
+		Env:        map[string]*string{"LOG_LEVEL": &info, "PORT": &port},
+		EnvSources: map[string][]string{"LOG_LEVEL": {"env_file .env"}, "PORT": {"env_file .env"}},
+	}}}
+	new := &models.ComposeIR{Services: map[string]models.ServiceIR{"api": {
+		Env:        map[string]*string{"LOG_LEVEL": &debug, "PORT": &port},
+		EnvSources: map[string][]string{"LOG_LEVEL": {"env_file .env", "environment"}, "PORT": {"env_file .env"}},
+	}}}
+
+	report := Compare(old, new)
+	if len(report.Changes) != 1 {
+		t.Fatalf("Expected 1 change, got %+v", report.Changes)
+	}
+	want := []string{
+		"effective value changed: set by env_file .env, now by environment",
+		"set in 2 places (env_file .env, environment); environment wins",
+	}
+	if notes := report.Changes[0].Notes; !slices.Equal(notes, want) {
+		t.Errorf("Unexpected notes: %q", notes)
+	}
+
+	// Without expanded env files there is nothing to explain
+	old.Services["api"] = models.ServiceIR{Env: map[string]*string{"LOG_LEVEL": &info}}
+	new.Services["api"] = models.ServiceIR{Env: map[string]*string{"LOG_LEVEL": &debug}}
+	if notes := Compare(old, new).Changes[0].Notes; len(notes) != 0 {
+		t.Errorf("Expected no notes, got %q", notes)
+	}
+}
+
+func TestCompareUnparsed(t *testing.T) {
+	old := &models.ComposeIR{Services: map[string]models.ServiceIR{"api": {
+		MemLimit: 512 * 1024 * 1024,
+		Unparsed: map[string]string{"cpus": "${CPUS}", "cpu_shares": "${SHARES}"},
+	}}}
+	new := &models.ComposeIR{Services: map[string]models.ServiceIR{"api": {
+		Unparsed: map[string]string{"mem_limit": "${API_MEM:-512m}", "cpus": "${CPUS}", "cpu_shares": "${WEIGHT}"},
+	}}}
+
+	report := Compare(old, new)
+	var got []string
+	for _, c := range report.Changes {
+		got = append(got, fmt.Sprintf("%s %s %v -> %v", c.Kind, c.Path, c.Before, c.After))
+	}
+	want := []string{
+		"modified services.api.cpu_shares ${SHARES} -> ${WEIGHT}",
+		"modified services.api.mem_limit 512MiB -> ${API_MEM:-512m}",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Unexpected changes:\n%s", strings.Join(got, "\n"))
+	}
+}
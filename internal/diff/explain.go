@@ -0,0 +1,241 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/parser"
+)
+
+// SeverityExplanation describes the severity the engine gives a change
+type SeverityExplanation struct {
+	Severity  models.Severity // empty if unknown
+	Heuristic string
+}
+
+// keyedFields map arbitrary keys, which may contain dots, to values: the
+// rest of a path below one is a single key
+var keyedFields = map[string]bool{
+	"environment": true,
+	"labels":      true,
+	"extra_hosts": true,
+	"args":        true,
+	"driver_opts": true,
+}
+
+// listFields are lists in a service: the rest of a path below one is a
+// single item
+var listFields = map[string]bool{
+	"env_file":            true,
+	"ports":               true,
+	"expose":              true,
+	"volumes":             true,
+	"networks":            true,
+	"depends_on":          true,
+	"command":             true,
+	"entrypoint":          true,
+	"profiles":            true,
+	"cap_add":             true,
+	"cap_drop":            true,
+	"security_opt":        true,
+	"devices":             true,
+	"device_cgroup_rules": true,
+	"dns":                 true,
+	"dns_search":          true,
+	"dns_opt":             true,
+	"tmpfs":               true,
+	"platforms":           true,
+	"secrets":             true,
+	"ssh":                 true,
+	"cache_from":          true,
+}
+
+// fieldExamples are values tried for service fields whose severity depends
+// on the values, before examplePairs. key is the rest of the path below the
+// field; for list fields the values are whole items.
+var fieldExamples = map[string]func(key string) [][2]any{
+	"image": func(string) [][2]any {
+		return [][2]any{
+			{"acme/app:1", "acme/app:1.1"}, {"acme/app:1", "acme/app:2"}, {"acme/app:1", "mirror.example.com/acme/app:1"},
+			{"acme/app:1@sha256:a", "acme/app:1@sha256:b"}, {"acme/app:1@sha256:a", "acme/app:1"},
+			{"acme/app:1", "acme/web:1"}, {"acme/app:1", "acme/web:2"},
+		}
+	},
+	"volumes": func(target string) [][2]any {
+		return [][2]any{{"a:" + target, "b:" + target}, {"a:" + target, "a:" + target + ":ro"}}
+	},
+	"network_mode":      func(string) [][2]any { return [][2]any{{"bridge", "host"}, {"bridge", "none"}} },
+	"user":              func(string) [][2]any { return [][2]any{{"app", "root"}, {"app", "web"}} },
+	"stop_grace_period": func(string) [][2]any { return [][2]any{{"10s", "5s"}} },
+}
+
+// exampleValues are tried in turn as the value added or removed at a path
+var exampleValues = []any{map[string]any{}, 1, true, "a"}
+
+// examplePairs are compared in both directions for a modified path; the
+// engine may rate them differently, e.g. lowering a limit against raising it
+var examplePairs = [][2]any{{1, 2}, {0, 1}, {false, true}, {"a", "b"}}
+
+// ExplainSeverity returns the severity the engine assigns to a change of
+// the given kind at path, before any rules are applied. Rather than
+// restating the engine's heuristics, it writes example files that differ
+// only at path, compares them and reads the severity off the change the
+// engine reports there; for a modified path it tries several values and
+// returns the highest severity, noting which values got which.
+func ExplainSeverity(path string, kind models.ChangeKind) SeverityExplanation {
+	parts := strings.Split(path, ".")
+	field, key := "", ""
+	if len(parts) > 2 && parts[0] == "services" {
+		field, key = parts[2], strings.Join(parts[3:], ".")
+	}
+	item := listFields[field] && key != ""
+
+	switch kind {
+	case models.ChangeAdded, models.ChangeRemoved:
+		values := exampleValues
+		if item {
+			values = []any{key}
+		}
+		for _, v := range values {
+			before, after := any(nil), v
+			if kind == models.ChangeRemoved {
+				before, after = v, nil
+			}
+			if severity, ok := exampleSeverity(path, kind, before, after); ok {
+				return SeverityExplanation{severity, "the engine's rating of an example " + string(kind) + " at this path"}
+			}
+		}
+	case models.ChangeModified:
+		var pairs [][2]any
+		if examples, ok := fieldExamples[field]; ok {
+			pairs = examples(key)
+		}
+		if !item {
+			pairs = append(pairs, examplePairs...)
+		}
+		bySeverity := make(map[models.Severity][]string)
+		for _, pair := range pairs {
+			for _, p := range [][2]any{pair, {pair[1], pair[0]}} {
+				if severity, ok := exampleSeverity(path, kind, p[0], p[1]); ok {
+					bySeverity[severity] = append(bySeverity[severity], fmt.Sprintf("%v → %v", p[0], p[1]))
+				}
+			}
+		}
+		return explainExamples(bySeverity)
+	}
+	return unknownSeverity
+}
+
+// unknownSeverity is the explanation when no example file produces the change
+var unknownSeverity = SeverityExplanation{"", "unknown: no example file produces this change, so its severity depends on the actual values; run diff on the files"}
+
+// explainExamples returns the highest severity the examples got, and which
+// examples got which severity when they differ
+func explainExamples(bySeverity map[models.Severity][]string) SeverityExplanation {
+	levels := []models.Severity{models.SeverityBreaking, models.SeverityWarning, models.SeverityInfo}
+	var found []models.Severity
+	for _, s := range levels {
+		if len(bySeverity[s]) > 0 {
+			found = append(found, s)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return unknownSeverity
+	case 1:
+		return SeverityExplanation{found[0], "the engine's rating of example values at this path, whichever way they change"}
+	}
+
+	var parts []string
+	for _, s := range found {
+		examples := bySeverity[s]
+		if len(examples) > 2 {
+			examples = examples[:2]
+		}
+		parts = append(parts, fmt.Sprintf("%s for %s", s, strings.Join(examples, ", ")))
+	}
+	return SeverityExplanation{found[0], "the engine rates example values by how they change: " + strings.Join(parts, "; ")}
+}
+
+// exampleSeverity compares two files that differ only in the value at path,
+// before and after (nil for none), and returns the severity of the change
+// of the given kind the engine reports at path. ok is false if the values
+// are not valid there or the engine reports no such change.
+func exampleSeverity(path string, kind models.ChangeKind, before, after any) (models.Severity, bool) {
+	parts := strings.Split(path, ".")
+	oldIR, ok := exampleFile(parts, before)
+	if !ok {
+		return "", false
+	}
+	newIR, ok := exampleFile(parts, after)
+	if !ok {
+		return "", false
+	}
+	for _, c := range Compare(oldIR, newIR).Changes {
+		if c.Path == path && c.Kind == kind {
+			return c.Severity, true
+		}
+	}
+	return "", false
+}
+
+// exampleFile parses a compose file holding value at path, or only what
+// encloses path if value is nil. ok is false if the file does not parse or
+// the value is kept as text rather than the type the field expects.
+func exampleFile(parts []string, value any) (*models.ComposeIR, bool) {
+	doc := make(map[string]any)
+	if len(parts) > 2 {
+		doc[parts[0]] = map[string]any{parts[1]: map[string]any{}}
+	}
+	if value != nil {
+		put(doc, documentPath(parts), value)
+	}
+
+	ir, err := parser.ParseFromMap(doc)
+	if err != nil {
+		return nil, false
+	}
+	for _, svc := range ir.Services {
+		if len(svc.Unparsed) > 0 {
+			return nil, false
+		}
+	}
+	return ir, true
+}
+
+// documentPath is where in a compose file the value of a change at path is
+// set: image.registry, image.tag and image.digest are parts of image
+func documentPath(parts []string) []string {
+	if len(parts) > 3 && parts[0] == "services" && parts[2] == "image" {
+		return parts[:3]
+	}
+	return parts
+}
+
+// put sets value at path in doc, creating the maps on the way. Below a
+// keyed field the rest of the path is one key; below a list field of a
+// service value is the one item of the list.
+func put(doc map[string]any, parts []string, value any) {
+	m := doc
+	for i, part := range parts {
+		rest := strings.Join(parts[i+1:], ".")
+		switch {
+		case i == len(parts)-1:
+			m[part] = value
+			return
+		case parts[0] == "services" && i >= 2 && listFields[part]:
+			m[part] = []any{value}
+			return
+		case i >= 2 && keyedFields[part]:
+			m[part] = map[string]any{rest: value}
+			return
+		}
+		child, ok := m[part].(map[string]any)
+		if !ok {
+			child = make(map[string]any)
+			m[part] = child
+		}
+		m = child
+	}
+}
@@ -0,0 +1,64 @@
+package diff
+
+import (
+	"github.com/stackgen-cli/compose-diff/internal/imageref"
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// compareImage compares two image references. A different repository is a
+// different image and one change at image; otherwise the registry, tag and
+// digest that differ are changes of their own at image.registry, image.tag
+// and image.digest. When both references are pinned by digest the digest
+// decides and tags are not compared. Before and After stay the whole
+// references, so each change reads on its own and image policies can check
+// the new image.
+func compareImage(svcName, basePath string, old, new *string) []models.Change {
+	if ptrEqual(old, new) {
+		return nil
+	}
+	change := func(field string, severity models.Severity) models.Change {
+		path := basePath + ".image"
+		if field != "" {
+			path += "." + field
+		}
+		return models.Change{
+			Kind:     models.ChangeModified,
+			Scope:    models.ScopeService,
+			Name:     svcName,
+			Path:     path,
+			Before:   ptrValue(old),
+			After:    ptrValue(new),
+			Severity: severity,
+		}
+	}
+	if old == nil || new == nil {
+		return []models.Change{change("", models.SeverityInfo)}
+	}
+
+	before, after := imageref.Parse(*old), imageref.Parse(*new)
+	if before.Repository != after.Repository {
+		return []models.Change{change("", imageSeverity(old, new))}
+	}
+
+	var changes []models.Change
+	if before.Registry != after.Registry {
+		// A mirror may serve other content under the same tag
+		changes = append(changes, change("registry", models.SeverityWarning))
+	}
+	switch {
+	case before.Digest != "" && after.Digest != "":
+		if before.Digest != after.Digest {
+			changes = append(changes, change("digest", models.SeverityInfo))
+		}
+		return changes
+	case before.Digest != "" && after.Digest == "":
+		// The tag may now move to another image without the file changing
+		changes = append(changes, change("digest", models.SeverityWarning))
+	case before.Digest == "" && after.Digest != "":
+		changes = append(changes, change("digest", models.SeverityInfo))
+	}
+	if before.Tag != "" && after.Tag != "" && before.Tag != after.Tag {
+		changes = append(changes, change("tag", tagSeverity(before.Tag, after.Tag)))
+	}
+	return changes
+}
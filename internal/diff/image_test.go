@@ -0,0 +1,45 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestCompareImage(t *testing.T) {
+	tests := []struct {
+		old, new string
+		want     []string // path suffix and severity of each change
+	}{
+		{"nginx:1.25", "docker.io/library/nginx:1.25", nil},
+		{"nginx", "nginx:latest", nil},
+		{"nginx:1.25", "nginx:1.26", []string{"image.tag info"}},
+		{"nginx:1.25", "nginx:2.0", []string{"image.tag warning"}},
+		{"nginx:1.25", "httpd:2.4", []string{"image warning"}},
+		{"nginx:1.25", "mirror.acme.io/library/nginx:1.25", []string{"image.registry warning"}},
+		{"nginx:1.25", "mirror.acme.io/library/nginx:1.26", []string{"image.registry warning", "image.tag info"}},
+		{"index.docker.io/nginx:1.25", "nginx:1.26", []string{"image.tag info"}},
+		{"nginx:1.25@sha256:aaa", "nginx:1.25.3@sha256:aaa", nil},
+		{"nginx:1.25@sha256:aaa", "nginx:1.26@sha256:bbb", []string{"image.digest info"}},
+		{"nginx:1.25@sha256:aaa", "nginx:1.25", []string{"image.digest warning"}},
+		{"nginx:1.25", "nginx:1.26@sha256:bbb", []string{"image.digest info", "image.tag info"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, c := range compareImage("web", "services.web", &tt.old, &tt.new) {
+			got = append(got, strings.TrimPrefix(c.Path, "services.web.")+" "+string(c.Severity))
+			if c.Before != tt.old || c.After != tt.new {
+				t.Errorf("%s -> %s: expected whole references, got %v -> %v", tt.old, tt.new, c.Before, c.After)
+			}
+		}
+		if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+			t.Errorf("%s -> %s: got %v, want %v", tt.old, tt.new, got, tt.want)
+		}
+	}
+
+	image := "nginx"
+	if changes := compareImage("web", "services.web", nil, &image); len(changes) != 1 || changes[0].Path != "services.web.image" || changes[0].Kind != models.ChangeModified {
+		t.Errorf("Expected one image change when the image is set, got %+v", changes)
+	}
+}
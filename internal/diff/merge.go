@@ -0,0 +1,88 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// MergeReports combines reports from several inputs into one, collapsing
+// identical changes (e.g. from a shared base file) into a single entry whose
+// Sources lists every input that produced it
+func MergeReports(reports map[string]*models.DiffReport) *models.DiffReport {
+	merged := models.NewDiffReport()
+
+	sources := make([]string, 0, len(reports))
+	for source := range reports {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	index := make(map[string]int)
+	for _, source := range sources {
+		for _, c := range reports[source].Changes {
+			key := changeKey(c)
+			if i, ok := index[key]; ok {
+				merged.Changes[i].Sources = appendUnique(merged.Changes[i].Sources, source)
+				continue
+			}
+
+			c.Sources = append(append([]string(nil), c.Sources...), source)
+			index[key] = len(merged.Changes)
+			merged.AddChange(c)
+		}
+	}
+
+	SummarizeEntities(merged)
+	return merged
+}
+
+// changeKey identifies a change by everything except where it came from
+func changeKey(c models.Change) string {
+	before, _ := json.Marshal(c.Before)
+	after, _ := json.Marshal(c.After)
+	return strings.Join([]string{string(c.Kind), string(c.Scope), c.Path, string(c.Severity), string(before), string(after)}, "\x00")
+}
+
+// SummarizeEntities recomputes the service/volume/network counts from the changes
+func SummarizeEntities(report *models.DiffReport) {
+	s := &report.Summary
+	s.ServicesAdded, s.ServicesRemoved, s.ServicesChanged, s.ServicesRenamed = 0, 0, 0, 0
+	s.VolumesAdded, s.VolumesRemoved, s.NetworksAdded, s.NetworksRemoved = 0, 0, 0, 0
+
+	changed := make(map[string]bool)
+	for _, c := range report.Changes {
+		whole := c.Path == fmt.Sprintf("%ss.%s", c.Scope, c.Name)
+		switch {
+		case c.Scope == models.ScopeService && !whole:
+			changed[c.Name] = true
+		case c.Scope == models.ScopeService && c.Kind == models.ChangeAdded:
+			s.ServicesAdded++
+		case c.Scope == models.ScopeService && c.Kind == models.ChangeRemoved:
+			s.ServicesRemoved++
+		case c.Scope == models.ScopeService && c.Kind == models.ChangeRenamed:
+			s.ServicesRenamed++
+		case c.Scope == models.ScopeVolume && whole && c.Kind == models.ChangeAdded:
+			s.VolumesAdded++
+		case c.Scope == models.ScopeVolume && whole && c.Kind == models.ChangeRemoved:
+			s.VolumesRemoved++
+		case c.Scope == models.ScopeNetwork && whole && c.Kind == models.ChangeAdded:
+			s.NetworksAdded++
+		case c.Scope == models.ScopeNetwork && whole && c.Kind == models.ChangeRemoved:
+			s.NetworksRemoved++
+		}
+	}
+	s.ServicesChanged = len(changed)
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
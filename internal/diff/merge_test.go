@@ -0,0 +1,56 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestMergeReports(t *testing.T) {
+	shared := models.Change{
+		Kind:     models.ChangeModified,
+		Scope:    models.ScopeService,
+		Name:     "api",
+		Path:     "services.api.image",
+		Before:   "app:1",
+		After:    "app:2",
+		Severity: models.SeverityInfo,
+	}
+
+	a := models.NewDiffReport()
+	a.AddChange(shared)
+	a.AddChange(models.Change{
+		Kind:     models.ChangeRemoved,
+		Scope:    models.ScopeService,
+		Name:     "worker",
+		Path:     "services.worker",
+		Severity: models.SeverityBreaking,
+	})
+
+	b := models.NewDiffReport()
+	b.AddChange(shared)
+
+	merged := MergeReports(map[string]*models.DiffReport{
+		"prod/docker-compose.yml":    a,
+		"staging/docker-compose.yml": b,
+	})
+
+	if len(merged.Changes) != 2 {
+		t.Fatalf("Expected 2 merged changes, got %d", len(merged.Changes))
+	}
+	if merged.Summary.TotalChanges != 2 || merged.Summary.BreakingCount != 1 {
+		t.Errorf("Unexpected summary: %+v", merged.Summary)
+	}
+	if merged.Summary.ServicesRemoved != 1 || merged.Summary.ServicesChanged != 1 {
+		t.Errorf("Unexpected entity counts: %+v", merged.Summary)
+	}
+
+	for _, c := range merged.Changes {
+		if c.Path == "services.api.image" && len(c.Sources) != 2 {
+			t.Errorf("Shared change should list both sources, got %v", c.Sources)
+		}
+		if c.Path == "services.worker" && len(c.Sources) != 1 {
+			t.Errorf("Unique change should list one source, got %v", c.Sources)
+		}
+	}
+}
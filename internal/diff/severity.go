@@ -4,6 +4,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/stackgen-cli/compose-diff/internal/models"
 )
@@ -14,9 +15,11 @@ func imageSeverity(old, new *string) models.Severity {
 		return models.SeverityInfo
 	}
 
-	oldTag := extractTag(*old)
-	newTag := extractTag(*new)
+	return tagSeverity(extractTag(*old), extractTag(*new))
+}
 
+// tagSeverity flags a tag change that crosses a major version as a warning
+func tagSeverity(oldTag, newTag string) models.Severity {
 	// Same tag or both latest
 	if oldTag == newTag {
 		return models.SeverityInfo
@@ -33,6 +36,110 @@ func imageSeverity(old, new *string) models.Severity {
 	return models.SeverityInfo
 }
 
+// defaultStopGracePeriod is what Docker uses when stop_grace_period is unset
+const defaultStopGracePeriod = 10 * time.Second
+
+// gracePeriodSeverity flags a shorter stop grace period as a warning, since
+// containers get less time to drain before being killed
+func gracePeriodSeverity(old, new *string) models.Severity {
+	oldDur := defaultStopGracePeriod
+	newDur := defaultStopGracePeriod
+	if old != nil {
+		d, err := time.ParseDuration(*old)
+		if err != nil {
+			return models.SeverityInfo
+		}
+		oldDur = d
+	}
+	if new != nil {
+		d, err := time.ParseDuration(*new)
+		if err != nil {
+			return models.SeverityInfo
+		}
+		newDur = d
+	}
+
+	if newDur < oldDur {
+		return models.SeverityWarning
+	}
+	return models.SeverityInfo
+}
+
+// userSeverity flags a switch between root and a non-root user as a warning:
+// gaining root widens the blast radius, dropping it can break file permissions.
+// An unset user runs as the image default, which is assumed to be root.
+func userSeverity(old, new *string) models.Severity {
+	if isRootUser(old) != isRootUser(new) {
+		return models.SeverityWarning
+	}
+	return models.SeverityInfo
+}
+
+// isRootUser reports whether a "user[:group]" value runs as root
+func isRootUser(user *string) bool {
+	if user == nil {
+		return true
+	}
+	name, _, _ := strings.Cut(strings.TrimSpace(*user), ":")
+	return name == "" || name == "root" || name == "0"
+}
+
+// networkModeSeverity flags switching to the host network as breaking, since
+// the service loses network isolation and its ports bind directly on the host
+func networkModeSeverity(new *string) models.Severity {
+	if new != nil && *new == "host" {
+		return models.SeverityBreaking
+	}
+	return models.SeverityWarning
+}
+
+// dangerousCapabilities grant (near) root-equivalent access to the host
+var dangerousCapabilities = map[string]bool{
+	"ALL":             true,
+	"SYS_ADMIN":       true,
+	"SYS_MODULE":      true,
+	"SYS_PTRACE":      true,
+	"SYS_RAWIO":       true,
+	"NET_ADMIN":       true,
+	"DAC_READ_SEARCH": true,
+}
+
+// capAddSeverity rates adding or removing a granted capability
+func capAddSeverity(capability string, kind models.ChangeKind) models.Severity {
+	if kind == models.ChangeAdded {
+		if dangerousCapabilities[capability] {
+			return models.SeverityBreaking
+		}
+		return models.SeverityWarning
+	}
+	return models.SeverityWarning // The service may rely on it
+}
+
+// capDropSeverity rates dropping a capability or no longer dropping it
+func capDropSeverity(capability string, kind models.ChangeKind) models.Severity {
+	if kind == models.ChangeRemoved {
+		if capability == "ALL" || dangerousCapabilities[capability] {
+			return models.SeverityBreaking // Capability regained
+		}
+		return models.SeverityWarning
+	}
+	return models.SeverityInfo
+}
+
+// securityOptSeverity rates security_opt changes; options that disable a
+// confinement mechanism are breaking, removing any option loosens the posture
+func securityOptSeverity(opt string, kind models.ChangeKind) models.Severity {
+	if kind == models.ChangeRemoved {
+		return models.SeverityWarning
+	}
+
+	o := strings.ToLower(strings.ReplaceAll(opt, "=", ":"))
+	if strings.Contains(o, "unconfined") || o == "no-new-privileges:false" || o == "label:disable" {
+		return models.SeverityBreaking
+	}
+	return models.SeverityInfo
+}
+
 // extractTag extracts the tag from an image reference
 // e.g., "postgres:16-alpine" -> "16-alpine"
 // e.g., "myregistry/app:v1.2.3" -> "v1.2.3"
@@ -108,7 +215,7 @@ func IsBreakingChange(c models.Change) bool {
 	}
 
 	// Image major version changes
-	if strings.HasSuffix(c.Path, ".image") && c.Kind == models.ChangeModified {
+	if (strings.HasSuffix(c.Path, ".image") || strings.HasSuffix(c.Path, ".image.tag")) && c.Kind == models.ChangeModified {
 		if before, ok := c.Before.(string); ok {
 			if after, ok := c.After.(string); ok {
 				oldMajor := extractMajorVersion(extractTag(before))
@@ -126,3 +233,21 @@ func IsBreakingChange(c models.Change) bool {
 
 	return false
 }
+
+// LabelNamespaceSeverity raises informational service label changes to
+// warning when the key falls under one of the namespaces, such as "traefik.*"
+// for labels that drive routing
+func LabelNamespaceSeverity(c models.Change, namespaces []string) models.Severity {
+	parts := strings.SplitN(c.Path, ".", 4)
+	if c.Severity != models.SeverityInfo || len(parts) < 4 || parts[0] != "services" || parts[2] != "labels" {
+		return c.Severity
+	}
+	key := parts[3]
+	for _, ns := range namespaces {
+		ns = strings.TrimSuffix(strings.TrimSuffix(ns, "*"), ".")
+		if key == ns || strings.HasPrefix(key, ns+".") {
+			return models.SeverityWarning
+		}
+	}
+	return c.Severity
+}
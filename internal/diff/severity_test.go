@@ -1,7 +1,10 @@
 package diff
 
 import (
+	"strings"
 	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
 )
 
 func TestExtractTag(t *testing.T) {
@@ -74,3 +77,59 @@ func TestImageSeverity(t *testing.T) {
 		})
 	}
 }
+
+func TestExplainSeverity(t *testing.T) {
+	tests := []struct {
+		path     string
+		kind     models.ChangeKind
+		expected models.Severity
+	}{
+		{"services.api.environment.DATABASE_URL", models.ChangeRemoved, models.SeverityBreaking},
+		{"services.api.environment.DATABASE_URL", models.ChangeModified, models.SeverityWarning},
+		{"services.api.environment.DATABASE_URL", models.ChangeAdded, models.SeverityInfo},
+		{"services.api", models.ChangeRemoved, models.SeverityBreaking},
+		{"networks.backend", models.ChangeRemoved, models.SeverityWarning},
+		{"services.api.healthcheck", models.ChangeRemoved, models.SeverityBreaking},
+		{"services.api.healthcheck.disable", models.ChangeModified, models.SeverityBreaking},
+		{"services.api.restart", models.ChangeModified, models.SeverityInfo},
+		{"services.api.cap_add.SYS_ADMIN", models.ChangeAdded, models.SeverityBreaking},
+		{"services.api.cap_add.CHOWN", models.ChangeAdded, models.SeverityWarning},
+		{"services.api.privileged", models.ChangeModified, models.SeverityBreaking},
+		{"services.api.deploy.resources.limits.memory", models.ChangeModified, models.SeverityWarning},
+		{"services.api.labels.traefik.enable", models.ChangeRemoved, models.SeverityInfo},
+		{"volumes.data.driver", models.ChangeModified, models.SeverityBreaking},
+		{"services.api.image", models.ChangeModified, models.SeverityWarning},
+		{"services.api.image.tag", models.ChangeModified, models.SeverityWarning},
+		{"services.api.image.registry", models.ChangeModified, models.SeverityWarning},
+		{"services.api.image.digest", models.ChangeModified, models.SeverityWarning},
+		{"services.api.volumes./data", models.ChangeModified, models.SeverityWarning},
+		{"services.api.volumes./data", models.ChangeRemoved, models.SeverityBreaking},
+		{"services.api.network_mode", models.ChangeModified, models.SeverityBreaking},
+		{"services.api.user", models.ChangeModified, models.SeverityWarning},
+		{"services.api.ports.8080:80/tcp", models.ChangeModified, ""}, // no example: unknown
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path+" "+string(tt.kind), func(t *testing.T) {
+			result := ExplainSeverity(tt.path, tt.kind)
+			if result.Severity != tt.expected {
+				t.Errorf("ExplainSeverity(%q, %q) = %q, want %q", tt.path, tt.kind, result.Severity, tt.expected)
+			}
+			if result.Heuristic == "" {
+				t.Errorf("ExplainSeverity(%q, %q) has no explanation", tt.path, tt.kind)
+			}
+		})
+	}
+}
+
+func TestExplainSeverityExamples(t *testing.T) {
+	got := ExplainSeverity("services.api.image.tag", models.ChangeModified)
+	if !strings.Contains(got.Heuristic, "warning for acme/app:1 → acme/app:2") || !strings.Contains(got.Heuristic, "info for acme/app:1 → acme/app:1.1") {
+		t.Errorf("Expected a major tag bump to rate higher than a minor one, got %+v", got)
+	}
+
+	got = ExplainSeverity("services.api.mem_limit", models.ChangeModified)
+	if got.Severity != models.SeverityWarning || !strings.Contains(got.Heuristic, "warning for 2 → 1") || !strings.Contains(got.Heuristic, "info for 1 → 2") {
+		t.Errorf("Expected a lower limit to rate higher than a raised one, got %+v", got)
+	}
+}
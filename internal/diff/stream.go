@@ -0,0 +1,72 @@
+package diff
+
+import (
+	"context"
+
+	"github.com/stackgen-cli/compose-diff/internal/lint"
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// CompareStream compares two ComposeIR, calling fn with each change as soon
+// as it is found: added and removed services first, then each common service,
+// then volumes, networks and x-* extensions, and last the broken references
+// the new file introduces: dangling or circular depends_on, undeclared
+// volumes and networks, and orphaned declarations. It stops at the first
+// error returned by fn or when ctx is done, and returns that error.
+func CompareStream(ctx context.Context, old, new *models.ComposeIR, fn func(models.Change) error) error {
+	e := &emitter{ctx: ctx, fn: fn, old: old, new: new}
+
+	compareServices(old.Services, new.Services, e)
+	if !e.stopped() {
+		compareVolumes(old.Volumes, new.Volumes, e)
+	}
+	if !e.stopped() {
+		compareNetworks(old.Networks, new.Networks, e)
+	}
+	if !e.stopped() {
+		compareTopLevelExtensions(old.Extensions, new.Extensions, e)
+	}
+	for _, c := range lint.DependencyChanges(old, new) {
+		e.add(c)
+	}
+	for _, c := range lint.ReferenceChanges(old, new) {
+		e.add(c)
+	}
+
+	return e.err
+}
+
+// emitter forwards changes to a callback until it fails or the context ends
+type emitter struct {
+	ctx      context.Context
+	fn       func(models.Change) error
+	err      error
+	old, new *models.ComposeIR
+}
+
+func (e *emitter) add(c models.Change) {
+	if e.stopped() {
+		return
+	}
+	AttachLines(&c, e.old, e.new)
+	e.err = e.fn(c)
+}
+
+// AttachLines sets the source lines of a change from the IRs it was computed
+// from, keeping any lines a comparator already set
+func AttachLines(c *models.Change, old, new *models.ComposeIR) {
+	if c.OldLine == 0 {
+		c.OldLine = old.Line(c.Path)
+	}
+	if c.NewLine == 0 {
+		c.NewLine = new.Line(c.Path)
+	}
+}
+
+// stopped reports whether no more changes should be computed
+func (e *emitter) stopped() bool {
+	if e.err == nil {
+		e.err = e.ctx.Err()
+	}
+	return e.err != nil
+}
@@ -0,0 +1,48 @@
+package diff
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestCompareStreamStopsEarly(t *testing.T) {
+	old := &models.ComposeIR{Services: map[string]models.ServiceIR{
+		"a": {Image: ptrStr("a:1")},
+		"b": {Image: ptrStr("b:1")},
+		"c": {Image: ptrStr("c:1")},
+	}}
+	new := &models.ComposeIR{Services: map[string]models.ServiceIR{
+		"a": {Image: ptrStr("a:2")},
+		"b": {Image: ptrStr("b:2")},
+		"c": {Image: ptrStr("c:2")},
+	}}
+
+	errPolicy := errors.New("policy failed")
+	var seen []string
+	err := CompareStream(context.Background(), old, new, func(c models.Change) error {
+		seen = append(seen, c.Name)
+		if c.Name == "b" {
+			return errPolicy
+		}
+		return nil
+	})
+	if !errors.Is(err, errPolicy) {
+		t.Fatalf("Expected the callback error, got %v", err)
+	}
+	if len(seen) != 2 || seen[0] != "a" || seen[1] != "b" {
+		t.Errorf("Expected changes for a then b only, got %v", seen)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = CompareStream(ctx, old, new, func(c models.Change) error {
+		t.Errorf("Unexpected change after cancellation: %s", c.Path)
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
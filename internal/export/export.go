@@ -0,0 +1,211 @@
+// Package export turns JSON reports into one document per change and ships
+// them to a search backend (OpenSearch or Elasticsearch bulk API), so
+// changes across many projects can be charted on one dashboard.
+package export
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stackgen-cli/compose-diff/internal/reporter"
+)
+
+// DefaultIndex is the index documents are written to unless told otherwise
+const DefaultIndex = "compose-diff-changes"
+
+// bulkChunk is the number of documents sent per bulk request
+const bulkChunk = 1000
+
+// Document is a single change, flattened for indexing
+type Document struct {
+	ID        string    `json:"-"` // stable across re-exports of the same report
+	Project   string    `json:"project"`
+	Timestamp time.Time `json:"@timestamp"`
+	ReportID  string    `json:"report_id"`
+	OldFile   string    `json:"old_file"`
+	NewFile   string    `json:"new_file"`
+	Kind      string    `json:"kind"`
+	Scope     string    `json:"scope"`
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Field     string    `json:"field,omitempty"` // first path element below the entity, e.g. environment
+	Severity  string    `json:"severity"`
+	Before    string    `json:"before,omitempty"`
+	After     string    `json:"after,omitempty"`
+	Frozen    bool      `json:"frozen,omitempty"` // a change freeze was in effect
+}
+
+// Documents flattens a report's changes. The report ID is a hash of the
+// report itself, so exporting the same report twice overwrites the same
+// documents instead of counting its changes again.
+func Documents(report *reporter.JSONReport, project string, ts time.Time) []Document {
+	data, _ := json.Marshal(report)
+	reportID := hash(project, string(data))[:16]
+
+	docs := make([]Document, 0, len(report.Changes))
+	for i, c := range report.Changes {
+		docs = append(docs, Document{
+			ID:        hash(reportID, fmt.Sprint(i), c.Path, string(c.Kind)),
+			Project:   project,
+			Timestamp: ts.UTC(),
+			ReportID:  reportID,
+			OldFile:   report.OldFile,
+			NewFile:   report.NewFile,
+			Kind:      string(c.Kind),
+			Scope:     string(c.Scope),
+			Name:      c.Name,
+			Path:      c.Path,
+			Field:     field(c.Path, c.Name),
+			Severity:  string(c.Severity),
+			Before:    valueString(c.Before),
+			After:     valueString(c.After),
+			Frozen:    report.Freeze != nil,
+		})
+	}
+	return docs
+}
+
+// WriteBulk writes documents as a bulk API request body (newline-delimited
+// action and source lines)
+func WriteBulk(w io.Writer, index string, docs []Document) error {
+	enc := json.NewEncoder(w)
+	for _, d := range docs {
+		action := map[string]map[string]string{"index": {"_index": index, "_id": d.ID}}
+		if err := enc.Encode(action); err != nil {
+			return err
+		}
+		if err := enc.Encode(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OpenSearch posts documents to an OpenSearch or Elasticsearch cluster
+type OpenSearch struct {
+	Client   *http.Client
+	URL      string // cluster URL, e.g. https://search.example.com:9200
+	Username string // basic auth, if set
+	Password string
+	APIKey   string // sent as "Authorization: ApiKey ...", if set
+}
+
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// Bulk indexes documents in chunks. It stops at the first chunk that fails,
+// returning the number of documents indexed before it.
+func (o *OpenSearch) Bulk(index string, docs []Document) (int, error) {
+	indexed := 0
+	for start := 0; start < len(docs); start += bulkChunk {
+		chunk := docs[start:min(start+bulkChunk, len(docs))]
+		if err := o.bulk(index, chunk); err != nil {
+			return indexed, err
+		}
+		indexed += len(chunk)
+	}
+	return indexed, nil
+}
+
+func (o *OpenSearch) bulk(index string, docs []Document) error {
+	var body bytes.Buffer
+	if err := WriteBulk(&body, index, docs); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(o.URL, "/")+"/_bulk", &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	switch {
+	case o.APIKey != "":
+		req.Header.Set("Authorization", "ApiKey "+o.APIKey)
+	case o.Username != "":
+		req.SetBasicAuth(o.Username, o.Password)
+	}
+
+	resp, err := o.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("bulk request returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var result bulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("reading bulk response: %w", err)
+	}
+	if !result.Errors {
+		return nil
+	}
+	failed, reason := 0, ""
+	for _, item := range result.Items {
+		for _, r := range item {
+			if r.Error != nil {
+				failed++
+				if reason == "" {
+					reason = r.Error.Type + ": " + r.Error.Reason
+				}
+			}
+		}
+	}
+	return fmt.Errorf("%d of %d documents were rejected (%s)", failed, len(docs), reason)
+}
+
+// field returns the first path element below the changed entity, e.g.
+// environment for services.api.environment.DEBUG
+func field(path, name string) string {
+	for _, section := range []string{"services.", "volumes.", "networks."} {
+		if rest, ok := strings.CutPrefix(path, section+name+"."); ok {
+			f, _, _ := strings.Cut(rest, ".")
+			return f
+		}
+	}
+	return ""
+}
+
+// valueString renders scalars as themselves and anything else as JSON
+func valueString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool, int, int64, float64:
+		return fmt.Sprint(v)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func hash(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
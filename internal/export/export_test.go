@@ -0,0 +1,94 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/reporter"
+)
+
+func testReport() *reporter.JSONReport {
+	return &reporter.JSONReport{
+		OldFile: "old.yml",
+		NewFile: "new.yml",
+		Changes: []models.Change{
+			{Kind: models.ChangeModified, Scope: models.ScopeService, Name: "api", Path: "services.api.environment.DEBUG", Before: "1", After: "0", Severity: models.SeverityInfo},
+			{Kind: models.ChangeRemoved, Scope: models.ScopeService, Name: "api", Path: "services.api.ports", Before: []any{"80:80"}, Severity: models.SeverityBreaking},
+		},
+	}
+}
+
+func TestDocuments(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	docs := Documents(testReport(), "acme/shop", ts)
+	if len(docs) != 2 {
+		t.Fatalf("Expected 2 documents, got %d", len(docs))
+	}
+
+	d := docs[1]
+	if d.Project != "acme/shop" || d.Field != "ports" || d.Severity != "breaking" || d.Before != `["80:80"]` || d.After != "" {
+		t.Errorf("Unexpected document %+v", d)
+	}
+	if docs[0].Field != "environment" || docs[0].Before != "1" {
+		t.Errorf("Unexpected document %+v", docs[0])
+	}
+
+	again := Documents(testReport(), "acme/shop", ts)
+	if again[0].ID != docs[0].ID || docs[0].ID == docs[1].ID {
+		t.Error("Expected IDs to be stable across exports and distinct within one")
+	}
+	if other := Documents(testReport(), "acme/billing", ts); other[0].ID == docs[0].ID {
+		t.Error("Expected IDs to differ between projects")
+	}
+}
+
+func TestBulk(t *testing.T) {
+	var lines []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_bulk" || r.Header.Get("Authorization") != "ApiKey secret" {
+			t.Errorf("Unexpected request %s %v", r.URL.Path, r.Header)
+		}
+		sc := bufio.NewScanner(r.Body)
+		for sc.Scan() {
+			lines = append(lines, sc.Text())
+		}
+		io.WriteString(w, `{"errors":false,"items":[]}`)
+	}))
+	defer srv.Close()
+
+	search := &OpenSearch{Client: srv.Client(), URL: srv.URL + "/", APIKey: "secret"}
+	n, err := search.Bulk(DefaultIndex, Documents(testReport(), "acme/shop", time.Now()))
+	if err != nil || n != 2 {
+		t.Fatalf("Bulk = %d, %v", n, err)
+	}
+	if len(lines) != 4 {
+		t.Fatalf("Expected action and source lines per document, got %q", lines)
+	}
+	var action map[string]map[string]string
+	if err := json.Unmarshal([]byte(lines[0]), &action); err != nil || action["index"]["_index"] != DefaultIndex || action["index"]["_id"] == "" {
+		t.Errorf("Unexpected action line %s", lines[0])
+	}
+	if !strings.Contains(lines[1], `"@timestamp"`) {
+		t.Errorf("Expected a timestamp in %s", lines[1])
+	}
+}
+
+func TestBulkRejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"errors":true,"items":[{"index":{"status":201}},{"index":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"bad field"}}}]}`)
+	}))
+	defer srv.Close()
+
+	search := &OpenSearch{Client: srv.Client(), URL: srv.URL}
+	_, err := search.Bulk(DefaultIndex, Documents(testReport(), "acme/shop", time.Now()))
+	if err == nil || !strings.Contains(err.Error(), "1 of 2") || !strings.Contains(err.Error(), "bad field") {
+		t.Errorf("Expected rejected documents to fail, got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package forge
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// AzureDevOps posts to the Azure DevOps Services REST API
+type AzureDevOps struct {
+	Client  *http.Client
+	BaseURL string // organization URL, e.g. https://dev.azure.com/acme, or $SYSTEM_COLLECTIONURI
+	Token   string // personal access token or $(System.AccessToken)
+}
+
+const azureAPIVersion = "7.1"
+
+type azureThread struct {
+	ID       int64          `json:"id"`
+	Comments []azureComment `json:"comments"`
+}
+
+type azureComment struct {
+	ID      int64  `json:"id"`
+	Content string `json:"content"`
+}
+
+// UpsertPRThread replaces the first comment of the pull request thread
+// carrying the marker for key, or starts the thread. New threads are
+// closed, so they don't hold up a comment resolution policy. It returns
+// the thread's ID.
+func (a *AzureDevOps) UpsertPRThread(project, repo string, pr int, key, body string) (int64, error) {
+	marker := Marker(key)
+	body = marker + "\n" + body
+	threads := a.pullRequestPath(project, repo, pr) + "/threads"
+
+	var list struct {
+		Value []azureThread `json:"value"`
+	}
+	if err := a.request(http.MethodGet, threads, nil, &list); err != nil {
+		return 0, err
+	}
+	for _, t := range list.Value {
+		if len(t.Comments) > 0 && strings.HasPrefix(t.Comments[0].Content, marker) {
+			var c azureComment
+			path := fmt.Sprintf("%s/%d/comments/%d", threads, t.ID, t.Comments[0].ID)
+			if err := a.request(http.MethodPatch, path, map[string]string{"content": body}, &c); err != nil {
+				return 0, err
+			}
+			return t.ID, nil
+		}
+	}
+
+	payload := map[string]any{
+		"comments": []map[string]any{{"parentCommentId": 0, "content": body, "commentType": "text"}},
+		"status":   "closed",
+	}
+	var t azureThread
+	if err := a.request(http.MethodPost, threads, payload, &t); err != nil {
+		return 0, err
+	}
+	return t.ID, nil
+}
+
+// SetPRStatus posts a status on the pull request. A context of
+// compose-diff/breaking becomes genre compose-diff and name breaking, which
+// branch policies can require.
+func (a *AzureDevOps) SetPRStatus(project, repo string, pr int, status CommitStatus) error {
+	state := "succeeded"
+	switch status.State {
+	case "failure":
+		state = "failed"
+	case "error", "pending":
+		state = status.State
+	}
+	genre, name, ok := strings.Cut(status.Context, "/")
+	if !ok {
+		genre, name = "", status.Context
+	}
+	payload := map[string]any{
+		"state":       state,
+		"description": status.Description,
+		"context":     map[string]string{"genre": genre, "name": name},
+	}
+	if status.TargetURL != "" {
+		payload["targetUrl"] = status.TargetURL
+	}
+	var out struct{}
+	return a.request(http.MethodPost, a.pullRequestPath(project, repo, pr)+"/statuses", payload, &out)
+}
+
+func (a *AzureDevOps) pullRequestPath(project, repo string, pr int) string {
+	return fmt.Sprintf("/%s/_apis/git/repositories/%s/pullRequests/%d", url.PathEscape(project), url.PathEscape(repo), pr)
+}
+
+func (a *AzureDevOps) request(method, path string, payload, out any) error {
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	header.Set("Authorization", "Basic "+basicAuth("", a.Token))
+	path += "?api-version=" + azureAPIVersion
+	return send(a.Client, "Azure DevOps", method, strings.TrimRight(a.BaseURL, "/")+path, header, payload, out)
+}
+
+func basicAuth(user, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte(user + ":" + password))
+}
@@ -0,0 +1,85 @@
+package forge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAzureUpsertPRThread(t *testing.T) {
+	threads := []azureThread{{ID: 1, Comments: []azureComment{{ID: 1, Content: "Looks good"}}}}
+	var creates, updates int
+	const base = "/acme/Shop%20Project/_apis/git/repositories/shop/pullRequests/7/threads"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "" || pass != "secret" || r.URL.Query().Get("api-version") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var payload struct {
+			Content  string
+			Comments []azureComment
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		switch {
+		case r.Method == http.MethodGet && r.URL.EscapedPath() == base:
+			json.NewEncoder(w).Encode(map[string]any{"value": threads})
+		case r.Method == http.MethodPost && r.URL.EscapedPath() == base:
+			creates++
+			thread := azureThread{ID: 2, Comments: payload.Comments}
+			threads = append(threads, thread)
+			json.NewEncoder(w).Encode(thread)
+		case r.Method == http.MethodPatch && r.URL.EscapedPath() == base+"/2/comments/0":
+			updates++
+			threads[1].Comments[0].Content = payload.Content
+			json.NewEncoder(w).Encode(threads[1].Comments[0])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	az := &AzureDevOps{Client: server.Client(), BaseURL: server.URL + "/acme/", Token: "secret"}
+	for _, body := range []string{"first report", "second report"} {
+		id, err := az.UpsertPRThread("Shop Project", "shop", 7, "", body)
+		if err != nil {
+			t.Fatalf("UpsertPRThread failed: %v", err)
+		}
+		if id != 2 {
+			t.Errorf("Expected thread 2, got %d", id)
+		}
+	}
+
+	if creates != 1 || updates != 1 {
+		t.Errorf("Expected 1 create and 1 update, got %d and %d", creates, updates)
+	}
+	if got := threads[1].Comments[0].Content; got != Marker("")+"\nsecond report" {
+		t.Errorf("Unexpected thread comment %q", got)
+	}
+}
+
+func TestAzureSetPRStatus(t *testing.T) {
+	var got struct {
+		State   string
+		Context map[string]string
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/acme/shop/_apis/git/repositories/shop/pullRequests/7/statuses" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"id": 1}`))
+	}))
+	defer server.Close()
+
+	az := &AzureDevOps{Client: server.Client(), BaseURL: server.URL + "/acme", Token: "secret"}
+	status := CommitStatus{State: "failure", Context: "compose-diff/breaking", Description: "1 breaking changes"}
+	if err := az.SetPRStatus("shop", "shop", 7, status); err != nil {
+		t.Fatalf("SetPRStatus failed: %v", err)
+	}
+	if got.State != "failed" || got.Context["genre"] != "compose-diff" || got.Context["name"] != "breaking" {
+		t.Errorf("Unexpected status %+v", got)
+	}
+}
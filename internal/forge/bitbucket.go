@@ -0,0 +1,123 @@
+package forge
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultBitbucketAPI is the Bitbucket Cloud REST endpoint
+const DefaultBitbucketAPI = "https://api.bitbucket.org/2.0"
+
+// Bitbucket posts to the Bitbucket Cloud REST API (2.0). With Username set
+// Token is an app password; otherwise it is an access token.
+type Bitbucket struct {
+	Client   *http.Client
+	BaseURL  string // e.g. https://api.bitbucket.org/2.0
+	Username string
+	Token    string
+}
+
+type bitbucketComment struct {
+	ID      int64 `json:"id"`
+	Content struct {
+		Raw string `json:"raw"`
+	} `json:"content"`
+	Links struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+}
+
+// bitbucketMarker is Marker as a markdown link definition, since Bitbucket
+// shows HTML comments as text
+func bitbucketMarker(key string) string {
+	if key == "" {
+		return "[//]: # (compose-diff)"
+	}
+	return "[//]: # (compose-diff:" + key + ")"
+}
+
+// UpsertPRComment replaces the body of the pull request comment carrying
+// the marker for key, or creates it. repo is workspace/slug. It returns the
+// comment's URL.
+func (b *Bitbucket) UpsertPRComment(repo string, pr int, key, body string) (string, error) {
+	marker := bitbucketMarker(key)
+	payload := map[string]any{"content": map[string]string{"raw": marker + "\n\n" + body}}
+	comments := fmt.Sprintf("/repositories/%s/pullrequests/%d/comments", repo, pr)
+
+	existing, err := b.findComment(comments, marker)
+	if err != nil {
+		return "", err
+	}
+
+	var c bitbucketComment
+	if existing != nil {
+		err = b.request(http.MethodPut, fmt.Sprintf("%s/%d", comments, existing.ID), payload, &c)
+	} else {
+		err = b.request(http.MethodPost, comments, payload, &c)
+	}
+	if err != nil {
+		return "", err
+	}
+	return c.Links.HTML.Href, nil
+}
+
+// findComment pages through a pull request's comments for the marker
+func (b *Bitbucket) findComment(comments, marker string) (*bitbucketComment, error) {
+	for page := 1; ; page++ {
+		var batch struct {
+			Values []bitbucketComment `json:"values"`
+			Next   string             `json:"next"`
+		}
+		if err := b.request(http.MethodGet, fmt.Sprintf("%s?pagelen=100&page=%d", comments, page), nil, &batch); err != nil {
+			return nil, err
+		}
+		for i := range batch.Values {
+			if strings.HasPrefix(batch.Values[i].Content.Raw, marker) {
+				return &batch.Values[i], nil
+			}
+		}
+		if batch.Next == "" {
+			return nil, nil
+		}
+	}
+}
+
+// SetBuildStatus reports a status on a commit as a build status, keyed by
+// its context so later runs replace it
+func (b *Bitbucket) SetBuildStatus(repo, sha string, status CommitStatus) error {
+	state := "SUCCESSFUL"
+	switch status.State {
+	case "failure", "error":
+		state = "FAILED"
+	case "pending":
+		state = "INPROGRESS"
+	}
+	// Bitbucket requires a link
+	link := status.TargetURL
+	if link == "" {
+		link = "https://bitbucket.org/" + repo + "/commits/" + sha
+	}
+	payload := map[string]string{
+		"key":         status.Context,
+		"name":        status.Context,
+		"state":       state,
+		"description": status.Description,
+		"url":         link,
+	}
+	var out struct{}
+	return b.request(http.MethodPost, fmt.Sprintf("/repositories/%s/commit/%s/statuses/build", repo, sha), payload, &out)
+}
+
+func (b *Bitbucket) request(method, path string, payload, out any) error {
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	if b.Username != "" {
+		header.Set("Authorization", "Basic "+basicAuth(b.Username, b.Token))
+	} else {
+		header.Set("Authorization", "Bearer "+b.Token)
+	}
+	return send(b.Client, "Bitbucket", method, strings.TrimRight(b.BaseURL, "/")+path, header, payload, out)
+}
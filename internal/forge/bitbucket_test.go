@@ -0,0 +1,96 @@
+package forge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeBitbucket keeps the comments of one pull request in memory
+type fakeBitbucket struct {
+	comments []bitbucketComment
+	creates  int
+	updates  int
+	statuses []map[string]string
+}
+
+func (f *fakeBitbucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if user, pass, ok := r.BasicAuth(); !ok || user != "ci-bot" || pass != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	const comments = "/repositories/acme/shop/pullrequests/7/comments"
+	var payload bitbucketComment
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == comments:
+		json.NewEncoder(w).Encode(map[string]any{"values": f.comments})
+	case r.Method == http.MethodPost && r.URL.Path == comments:
+		json.NewDecoder(r.Body).Decode(&payload)
+		f.creates++
+		payload.ID = int64(100 + len(f.comments))
+		f.comments = append(f.comments, payload)
+		json.NewEncoder(w).Encode(payload)
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, comments+"/"):
+		json.NewDecoder(r.Body).Decode(&payload)
+		f.updates++
+		for i := range f.comments {
+			if r.URL.Path == fmt.Sprintf("%s/%d", comments, f.comments[i].ID) {
+				f.comments[i].Content = payload.Content
+				json.NewEncoder(w).Encode(f.comments[i])
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == http.MethodPost && r.URL.Path == "/repositories/acme/shop/commit/abc123/statuses/build":
+		var status map[string]string
+		json.NewDecoder(r.Body).Decode(&status)
+		f.statuses = append(f.statuses, status)
+		w.Write([]byte(`{}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestBitbucketUpsertPRComment(t *testing.T) {
+	fake := &fakeBitbucket{comments: []bitbucketComment{{ID: 1}}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	bb := &Bitbucket{Client: server.Client(), BaseURL: server.URL, Username: "ci-bot", Token: "secret"}
+	for _, body := range []string{"first report", "second report"} {
+		if _, err := bb.UpsertPRComment("acme/shop", 7, "", body); err != nil {
+			t.Fatalf("UpsertPRComment failed: %v", err)
+		}
+	}
+
+	if fake.creates != 1 || fake.updates != 1 {
+		t.Errorf("Expected 1 create and 1 update, got %d and %d", fake.creates, fake.updates)
+	}
+	if got := fake.comments[1].Content.Raw; got != bitbucketMarker("")+"\n\nsecond report" {
+		t.Errorf("Unexpected comment body %q", got)
+	}
+}
+
+func TestBitbucketSetBuildStatus(t *testing.T) {
+	fake := &fakeBitbucket{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	bb := &Bitbucket{Client: server.Client(), BaseURL: server.URL, Username: "ci-bot", Token: "secret"}
+	status := CommitStatus{State: "failure", Context: "compose-diff/breaking", Description: "1 breaking changes"}
+	if err := bb.SetBuildStatus("acme/shop", "abc123", status); err != nil {
+		t.Fatalf("SetBuildStatus failed: %v", err)
+	}
+
+	if len(fake.statuses) != 1 {
+		t.Fatalf("Expected 1 status, got %d", len(fake.statuses))
+	}
+	got := fake.statuses[0]
+	if got["key"] != "compose-diff/breaking" || got["state"] != "FAILED" || got["url"] == "" {
+		t.Errorf("Unexpected build status %v", got)
+	}
+}
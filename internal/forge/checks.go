@@ -0,0 +1,136 @@
+package forge
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// maxAnnotations is how many annotations GitHub takes per check run request
+const maxAnnotations = 50
+
+// CheckRun is a completed GitHub check run. Unlike a commit status it
+// carries annotations, which GitHub shows inline on the Files Changed tab.
+type CheckRun struct {
+	Name        string
+	HeadSHA     string
+	Conclusion  string // success or failure
+	Title       string
+	Summary     string // markdown
+	DetailsURL  string
+	Annotations []CheckAnnotation
+}
+
+// CheckAnnotation marks a line of a file in a check run
+type CheckAnnotation struct {
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Level     string `json:"annotation_level"` // notice, warning or failure
+	Title     string `json:"title,omitempty"`
+	Message   string `json:"message"`
+}
+
+// ChangeAnnotations returns one annotation per change on file, a path
+// relative to the repository root, at the change's line in the new file.
+// Removed values are on their enclosing key, and changes without a line on
+// the first line. describe gives the message.
+func ChangeAnnotations(changes []models.Change, file string, describe func(models.Change) string) []CheckAnnotation {
+	file = strings.TrimPrefix(path.Clean(strings.ReplaceAll(file, "\\", "/")), "./")
+	annotations := make([]CheckAnnotation, 0, len(changes))
+	for _, c := range changes {
+		line := max(c.NewLine, 1)
+		annotations = append(annotations, CheckAnnotation{
+			Path:      file,
+			StartLine: line,
+			EndLine:   line,
+			Level:     checkLevel(c.Severity),
+			Title:     fmt.Sprintf("compose-diff: %s %s", c.Severity, c.Kind),
+			Message:   describe(c),
+		})
+	}
+	return annotations
+}
+
+// checkLevel maps a severity to an annotation level
+func checkLevel(s models.Severity) string {
+	switch s {
+	case models.SeverityBreaking:
+		return "failure"
+	case models.SeverityWarning:
+		return "warning"
+	}
+	return "notice"
+}
+
+// StatusCheckRun folds the per-severity statuses into one check run that
+// fails if any of them does
+func StatusCheckRun(name string, statuses []CommitStatus) CheckRun {
+	run := CheckRun{Name: name, Conclusion: "success"}
+	var lines, failed []string
+	for _, s := range statuses {
+		mark := "✅"
+		if s.State != "success" {
+			mark = "❌"
+			run.Conclusion = "failure"
+			failed = append(failed, s.Description)
+		}
+		lines = append(lines, fmt.Sprintf("- %s **%s**: %s", mark, strings.TrimPrefix(s.Context, name+"/"), s.Description))
+	}
+	run.Title = "Within budget"
+	if len(failed) > 0 {
+		run.Title = strings.Join(failed, "; ")
+	}
+	run.Summary = strings.Join(lines, "\n")
+	return run
+}
+
+type checkOutput struct {
+	Title       string            `json:"title"`
+	Summary     string            `json:"summary"`
+	Annotations []CheckAnnotation `json:"annotations,omitempty"`
+}
+
+// CreateCheckRun creates a completed check run on a commit and returns its
+// URL. GitHub takes 50 annotations per request, so the rest are added by
+// updating the run. The token must be a GitHub App installation token, such
+// as GITHUB_TOKEN in Actions, with checks: write.
+func (g *GitHub) CreateCheckRun(repo string, run CheckRun) (string, error) {
+	batch := func(i int) checkOutput {
+		out := checkOutput{Title: run.Title, Summary: run.Summary}
+		if i < len(run.Annotations) {
+			out.Annotations = run.Annotations[i:min(i+maxAnnotations, len(run.Annotations))]
+		}
+		return out
+	}
+
+	payload := map[string]any{
+		"name":       run.Name,
+		"head_sha":   run.HeadSHA,
+		"status":     "completed",
+		"conclusion": run.Conclusion,
+		"output":     batch(0),
+	}
+	if run.DetailsURL != "" {
+		payload["details_url"] = run.DetailsURL
+	}
+	var created struct {
+		ID      int64  `json:"id"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := g.request(http.MethodPost, fmt.Sprintf("/repos/%s/check-runs", repo), payload, &created); err != nil {
+		return "", err
+	}
+
+	for i := maxAnnotations; i < len(run.Annotations); i += maxAnnotations {
+		var out struct{}
+		update := map[string]any{"output": batch(i)}
+		if err := g.request(http.MethodPatch, fmt.Sprintf("/repos/%s/check-runs/%d", repo, created.ID), update, &out); err != nil {
+			return "", err
+		}
+	}
+	return created.HTMLURL, nil
+}
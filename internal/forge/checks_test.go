@@ -0,0 +1,91 @@
+package forge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestChangeAnnotations(t *testing.T) {
+	changes := []models.Change{
+		{Kind: models.ChangeRemoved, Path: "services.api.ports.80:80/tcp", Severity: models.SeverityBreaking, NewLine: 7},
+		{Kind: models.ChangeAdded, Path: "volumes.data", Severity: models.SeverityInfo},
+	}
+	got := ChangeAnnotations(changes, "./deploy/compose.yml", func(c models.Change) string { return c.Path })
+	want := []CheckAnnotation{
+		{Path: "deploy/compose.yml", StartLine: 7, EndLine: 7, Level: "failure", Title: "compose-diff: breaking removed", Message: "services.api.ports.80:80/tcp"},
+		{Path: "deploy/compose.yml", StartLine: 1, EndLine: 1, Level: "notice", Title: "compose-diff: info added", Message: "volumes.data"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d annotations, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Annotation %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestStatusCheckRun(t *testing.T) {
+	run := StatusCheckRun("compose-diff", []CommitStatus{
+		{State: "failure", Context: "compose-diff/breaking", Description: "1 breaking changes, over the budget of 0"},
+		{State: "success", Context: "compose-diff/warning", Description: "3 warnings"},
+	})
+	if run.Conclusion != "failure" || run.Title != "1 breaking changes, over the budget of 0" {
+		t.Errorf("Expected a failed run titled by the breaking status, got %+v", run)
+	}
+	if want := "- ❌ **breaking**: 1 breaking changes, over the budget of 0\n- ✅ **warning**: 3 warnings"; run.Summary != want {
+		t.Errorf("Summary = %q, want %q", run.Summary, want)
+	}
+}
+
+func TestCreateCheckRun(t *testing.T) {
+	var created map[string]any
+	var batches []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Output checkOutput `json:"output"`
+		}
+		switch {
+		case r.Header.Get("Authorization") != "Bearer secret":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/acme/shop/check-runs":
+			json.NewDecoder(r.Body).Decode(&created)
+			out, _ := json.Marshal(created["output"])
+			json.Unmarshal(out, &payload.Output)
+			batches = append(batches, len(payload.Output.Annotations))
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 9, "html_url": "https://github.example/acme/shop/runs/9"}`))
+		case r.Method == http.MethodPatch && r.URL.Path == "/repos/acme/shop/check-runs/9":
+			json.NewDecoder(r.Body).Decode(&payload)
+			batches = append(batches, len(payload.Output.Annotations))
+			w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	run := CheckRun{Name: "compose-diff", HeadSHA: "abc123", Conclusion: "failure", Title: "t", Summary: "s"}
+	for i := 0; i < 120; i++ {
+		run.Annotations = append(run.Annotations, CheckAnnotation{Path: "compose.yml", StartLine: i + 1, EndLine: i + 1, Level: "notice", Message: fmt.Sprint(i)})
+	}
+	gh := &GitHub{Client: server.Client(), BaseURL: server.URL, Token: "secret"}
+	url, err := gh.CreateCheckRun("acme/shop", run)
+	if err != nil {
+		t.Fatalf("CreateCheckRun failed: %v", err)
+	}
+	if url != "https://github.example/acme/shop/runs/9" {
+		t.Errorf("Unexpected URL %q", url)
+	}
+	if created["head_sha"] != "abc123" || created["status"] != "completed" || created["conclusion"] != "failure" {
+		t.Errorf("Unexpected check run %v", created)
+	}
+	if fmt.Sprint(batches) != "[50 50 20]" {
+		t.Errorf("Expected annotations in batches of 50, got %v", batches)
+	}
+}
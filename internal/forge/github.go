@@ -0,0 +1,128 @@
+// Package forge posts compose-diff reports to code review platforms
+// (GitHub, GitLab, Bitbucket Cloud and Azure DevOps) and Jira issues. Each report is a
+// single "sticky" comment that is edited in place on later runs instead of
+// adding a new comment per push. On GitHub, Bitbucket and Azure DevOps the
+// severity counts can also be posted as commit or pull request statuses.
+package forge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultGitHubAPI is the public GitHub REST endpoint
+const DefaultGitHubAPI = "https://api.github.com"
+
+// Marker returns the hidden tag that identifies compose-diff's comment. A
+// key distinguishes several reports on one pull request (e.g. one per
+// compose file).
+func Marker(key string) string {
+	if key == "" {
+		return "<!-- compose-diff -->"
+	}
+	return "<!-- compose-diff:" + key + " -->"
+}
+
+// GitHub posts to the GitHub (or GitHub Enterprise) REST API
+type GitHub struct {
+	Client  *http.Client
+	BaseURL string // e.g. https://api.github.com or https://ghe.example.com/api/v3
+	Token   string
+}
+
+type githubComment struct {
+	ID      int64  `json:"id"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+}
+
+// UpsertPRComment replaces the body of the pull request comment carrying
+// the marker for key, or creates it. It returns the comment's URL.
+func (g *GitHub) UpsertPRComment(repo string, pr int, key, body string) (string, error) {
+	marker := Marker(key)
+	body = marker + "\n" + body
+
+	existing, err := g.findComment(repo, pr, marker)
+	if err != nil {
+		return "", err
+	}
+
+	var c githubComment
+	if existing != nil {
+		err = g.request(http.MethodPatch, fmt.Sprintf("/repos/%s/issues/comments/%d", repo, existing.ID), map[string]string{"body": body}, &c)
+	} else {
+		err = g.request(http.MethodPost, fmt.Sprintf("/repos/%s/issues/%d/comments", repo, pr), map[string]string{"body": body}, &c)
+	}
+	if err != nil {
+		return "", err
+	}
+	return c.HTMLURL, nil
+}
+
+// findComment pages through a pull request's comments for the marker
+func (g *GitHub) findComment(repo string, pr int, marker string) (*githubComment, error) {
+	for page := 1; ; page++ {
+		var comments []githubComment
+		path := fmt.Sprintf("/repos/%s/issues/%d/comments?per_page=100&page=%d", repo, pr, page)
+		if err := g.request(http.MethodGet, path, nil, &comments); err != nil {
+			return nil, err
+		}
+		for i := range comments {
+			if strings.HasPrefix(comments[i].Body, marker) {
+				return &comments[i], nil
+			}
+		}
+		if len(comments) < 100 {
+			return nil, nil
+		}
+	}
+}
+
+func (g *GitHub) request(method, path string, payload, out any) error {
+	header := http.Header{}
+	header.Set("Accept", "application/vnd.github+json")
+	header.Set("X-GitHub-Api-Version", "2022-11-28")
+	header.Set("Authorization", "Bearer "+g.Token)
+	return send(g.Client, "GitHub", method, strings.TrimRight(g.BaseURL, "/")+path, header, payload, out)
+}
+
+// send makes a JSON API call and decodes the response into out, unless out
+// is nil. api names the platform in errors.
+func send(client *http.Client, api, method, url string, header http.Header, payload, out any) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header = header
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s API %s %s returned %s: %s", api, method, req.URL.RequestURI(), resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
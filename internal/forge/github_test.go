@@ -0,0 +1,98 @@
+package forge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeGitHub keeps the comments of one pull request in memory
+type fakeGitHub struct {
+	comments []githubComment
+	creates  int
+	updates  int
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var payload struct{ Body string }
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/shop/issues/7/comments":
+		page := f.comments
+		if r.URL.Query().Get("page") != "1" {
+			page = nil
+		}
+		json.NewEncoder(w).Encode(page)
+	case r.Method == http.MethodPost && r.URL.Path == "/repos/acme/shop/issues/7/comments":
+		json.NewDecoder(r.Body).Decode(&payload)
+		f.creates++
+		c := githubComment{ID: int64(100 + len(f.comments)), Body: payload.Body}
+		c.HTMLURL = fmt.Sprintf("https://github.example/acme/shop/pull/7#issuecomment-%d", c.ID)
+		f.comments = append(f.comments, c)
+		json.NewEncoder(w).Encode(c)
+	case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, "/repos/acme/shop/issues/comments/"):
+		json.NewDecoder(r.Body).Decode(&payload)
+		f.updates++
+		for i := range f.comments {
+			if fmt.Sprint(f.comments[i].ID) == strings.TrimPrefix(r.URL.Path, "/repos/acme/shop/issues/comments/") {
+				f.comments[i].Body = payload.Body
+				json.NewEncoder(w).Encode(f.comments[i])
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestUpsertPRComment(t *testing.T) {
+	fake := &fakeGitHub{comments: []githubComment{{ID: 1, Body: "LGTM"}}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	gh := &GitHub{Client: server.Client(), BaseURL: server.URL, Token: "secret"}
+
+	if _, err := gh.UpsertPRComment("acme/shop", 7, "", "first report"); err != nil {
+		t.Fatalf("UpsertPRComment failed: %v", err)
+	}
+	url, err := gh.UpsertPRComment("acme/shop", 7, "", "second report")
+	if err != nil {
+		t.Fatalf("UpsertPRComment failed: %v", err)
+	}
+
+	if fake.creates != 1 || fake.updates != 1 {
+		t.Errorf("Expected 1 create and 1 update, got %d and %d", fake.creates, fake.updates)
+	}
+	if len(fake.comments) != 2 || fake.comments[1].Body != Marker("")+"\nsecond report" {
+		t.Errorf("Unexpected comments: %+v", fake.comments)
+	}
+	if !strings.HasSuffix(url, "#issuecomment-101") {
+		t.Errorf("Unexpected comment URL %s", url)
+	}
+
+	// A different key gets its own comment
+	if _, err := gh.UpsertPRComment("acme/shop", 7, "worker", "worker report"); err != nil {
+		t.Fatalf("UpsertPRComment failed: %v", err)
+	}
+	if fake.creates != 2 {
+		t.Errorf("Expected a separate comment for another key, got %d creates", fake.creates)
+	}
+}
+
+func TestUpsertPRCommentAPIError(t *testing.T) {
+	server := httptest.NewServer(&fakeGitHub{})
+	defer server.Close()
+
+	gh := &GitHub{Client: server.Client(), BaseURL: server.URL, Token: "wrong"}
+	if _, err := gh.UpsertPRComment("acme/shop", 7, "", "report"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Expected an authorization error, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package forge
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultGitLabAPI is the gitlab.com REST endpoint
+const DefaultGitLabAPI = "https://gitlab.com/api/v4"
+
+// GitLab posts to the GitLab REST API (v4)
+type GitLab struct {
+	Client  *http.Client
+	BaseURL string // e.g. https://gitlab.com/api/v4, or $CI_API_V4_URL
+	Token   string // personal, project or group access token with api scope
+}
+
+type gitlabNote struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+// UpsertMRNote replaces the body of the merge request note carrying the
+// marker for key, or creates it. project is a numeric ID or a path such as
+// group/app. It returns the note's ID.
+func (g *GitLab) UpsertMRNote(project string, mr int, key, body string) (int64, error) {
+	marker := Marker(key)
+	body = marker + "\n" + body
+	notes := fmt.Sprintf("/projects/%s/merge_requests/%d/notes", url.PathEscape(project), mr)
+
+	existing, err := g.findNote(notes, marker)
+	if err != nil {
+		return 0, err
+	}
+
+	var n gitlabNote
+	if existing != nil {
+		err = g.request(http.MethodPut, fmt.Sprintf("%s/%d", notes, existing.ID), map[string]string{"body": body}, &n)
+	} else {
+		err = g.request(http.MethodPost, notes, map[string]string{"body": body}, &n)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return n.ID, nil
+}
+
+// findNote pages through a merge request's notes for the marker
+func (g *GitLab) findNote(notes, marker string) (*gitlabNote, error) {
+	for page := 1; ; page++ {
+		var batch []gitlabNote
+		if err := g.request(http.MethodGet, fmt.Sprintf("%s?per_page=100&page=%d", notes, page), nil, &batch); err != nil {
+			return nil, err
+		}
+		for i := range batch {
+			if strings.HasPrefix(batch[i].Body, marker) {
+				return &batch[i], nil
+			}
+		}
+		if len(batch) < 100 {
+			return nil, nil
+		}
+	}
+}
+
+func (g *GitLab) request(method, path string, payload, out any) error {
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	header.Set("PRIVATE-TOKEN", g.Token)
+	return send(g.Client, "GitLab", method, strings.TrimRight(g.BaseURL, "/")+path, header, payload, out)
+}
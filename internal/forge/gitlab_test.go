@@ -0,0 +1,83 @@
+package forge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeGitLab keeps the notes of one merge request in memory
+type fakeGitLab struct {
+	notes   []gitlabNote
+	creates int
+	updates int
+}
+
+func (f *fakeGitLab) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	const notes = "/api/v4/projects/acme%2Fshop/merge_requests/7/notes"
+	var payload struct{ Body string }
+	path := r.URL.EscapedPath()
+	switch {
+	case r.Method == http.MethodGet && path == notes:
+		page := f.notes
+		if r.URL.Query().Get("page") != "1" {
+			page = nil
+		}
+		json.NewEncoder(w).Encode(page)
+	case r.Method == http.MethodPost && path == notes:
+		json.NewDecoder(r.Body).Decode(&payload)
+		f.creates++
+		n := gitlabNote{ID: int64(100 + len(f.notes)), Body: payload.Body}
+		f.notes = append(f.notes, n)
+		json.NewEncoder(w).Encode(n)
+	case r.Method == http.MethodPut && strings.HasPrefix(path, notes+"/"):
+		json.NewDecoder(r.Body).Decode(&payload)
+		f.updates++
+		for i := range f.notes {
+			if path == fmt.Sprintf("%s/%d", notes, f.notes[i].ID) {
+				f.notes[i].Body = payload.Body
+				json.NewEncoder(w).Encode(f.notes[i])
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestUpsertMRNote(t *testing.T) {
+	fake := &fakeGitLab{notes: []gitlabNote{{ID: 1, Body: "Looks good"}}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	gl := &GitLab{Client: server.Client(), BaseURL: server.URL + "/api/v4", Token: "secret"}
+
+	if _, err := gl.UpsertMRNote("acme/shop", 7, "", "first report"); err != nil {
+		t.Fatalf("UpsertMRNote failed: %v", err)
+	}
+	id, err := gl.UpsertMRNote("acme/shop", 7, "", "second report")
+	if err != nil {
+		t.Fatalf("UpsertMRNote failed: %v", err)
+	}
+
+	if fake.creates != 1 || fake.updates != 1 || id != 101 {
+		t.Errorf("Expected 1 create and 1 update of note 101, got %d, %d and note %d", fake.creates, fake.updates, id)
+	}
+	if fake.notes[1].Body != Marker("")+"\nsecond report" {
+		t.Errorf("Unexpected notes: %+v", fake.notes)
+	}
+
+	gl.Token = "wrong"
+	if _, err := gl.UpsertMRNote("acme/shop", 7, "", "report"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Expected an authorization error, got %v", err)
+	}
+}
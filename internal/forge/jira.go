@@ -0,0 +1,97 @@
+package forge
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Jira posts to the Jira REST API (v2, which takes wiki markup). With
+// Email set Token is a Jira Cloud API token; otherwise it is a Data Center
+// personal access token.
+type Jira struct {
+	Client  *http.Client
+	BaseURL string // site URL, e.g. https://acme.atlassian.net
+	Email   string
+	Token   string
+}
+
+type jiraComment struct {
+	ID   string `json:"id"`
+	Body string `json:"body"`
+}
+
+// jiraMarker is Marker as an anchor macro, which Jira does not display
+func jiraMarker(key string) string {
+	if key == "" {
+		return "{anchor:compose-diff}"
+	}
+	return "{anchor:compose-diff-" + key + "}"
+}
+
+// UpsertIssueComment replaces the body of the issue comment carrying the
+// marker for key, or creates it. It returns the comment's ID.
+func (j *Jira) UpsertIssueComment(issue, key, body string) (string, error) {
+	marker := jiraMarker(key)
+	body = marker + "\n" + body
+	comments := fmt.Sprintf("/rest/api/2/issue/%s/comment", url.PathEscape(issue))
+
+	existing, err := j.findComment(comments, marker)
+	if err != nil {
+		return "", err
+	}
+
+	var c jiraComment
+	if existing != nil {
+		err = j.request(http.MethodPut, comments+"/"+existing.ID, map[string]string{"body": body}, &c)
+	} else {
+		err = j.request(http.MethodPost, comments, map[string]string{"body": body}, &c)
+	}
+	if err != nil {
+		return "", err
+	}
+	return c.ID, nil
+}
+
+// findComment pages through an issue's comments for the marker
+func (j *Jira) findComment(comments, marker string) (*jiraComment, error) {
+	for start := 0; ; start += 100 {
+		var page struct {
+			Comments []jiraComment `json:"comments"`
+			Total    int           `json:"total"`
+		}
+		if err := j.request(http.MethodGet, fmt.Sprintf("%s?startAt=%d&maxResults=100", comments, start), nil, &page); err != nil {
+			return nil, err
+		}
+		for i := range page.Comments {
+			if strings.HasPrefix(page.Comments[i].Body, marker) {
+				return &page.Comments[i], nil
+			}
+		}
+		if len(page.Comments) == 0 || start+len(page.Comments) >= page.Total {
+			return nil, nil
+		}
+	}
+}
+
+// SetLabel adds label to the issue, or with present false removes it
+func (j *Jira) SetLabel(issue, label string, present bool) error {
+	op := "add"
+	if !present {
+		op = "remove"
+	}
+	payload := map[string]any{"update": map[string]any{"labels": []map[string]string{{op: label}}}}
+	return j.request(http.MethodPut, "/rest/api/2/issue/"+url.PathEscape(issue), payload, nil)
+}
+
+func (j *Jira) request(method, path string, payload, out any) error {
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	if j.Email != "" {
+		header.Set("Authorization", "Basic "+basicAuth(j.Email, j.Token))
+	} else {
+		header.Set("Authorization", "Bearer "+j.Token)
+	}
+	return send(j.Client, "Jira", method, strings.TrimRight(j.BaseURL, "/")+path, header, payload, out)
+}
@@ -0,0 +1,60 @@
+package forge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJiraUpsertIssueComment(t *testing.T) {
+	comments := []jiraComment{{ID: "1", Body: "Deploying Friday"}}
+	var creates, updates int
+	var labels []any
+	const base = "/rest/api/2/issue/OPS-7"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "ci@acme.io" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var payload map[string]any
+		json.NewDecoder(r.Body).Decode(&payload)
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == base+"/comment":
+			json.NewEncoder(w).Encode(map[string]any{"comments": comments, "total": len(comments)})
+		case r.Method == http.MethodPost && r.URL.Path == base+"/comment":
+			creates++
+			c := jiraComment{ID: "2", Body: payload["body"].(string)}
+			comments = append(comments, c)
+			json.NewEncoder(w).Encode(c)
+		case r.Method == http.MethodPut && r.URL.Path == base+"/comment/2":
+			updates++
+			comments[1].Body = payload["body"].(string)
+			json.NewEncoder(w).Encode(comments[1])
+		case r.Method == http.MethodPut && r.URL.Path == base:
+			labels = append(labels, payload["update"])
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	j := &Jira{Client: server.Client(), BaseURL: server.URL, Email: "ci@acme.io", Token: "secret"}
+	for _, body := range []string{"first report", "second report"} {
+		if _, err := j.UpsertIssueComment("OPS-7", "", body); err != nil {
+			t.Fatalf("UpsertIssueComment failed: %v", err)
+		}
+	}
+	if creates != 1 || updates != 1 || comments[1].Body != jiraMarker("")+"\nsecond report" {
+		t.Errorf("Expected 1 create and 1 update, got %d, %d and %+v", creates, updates, comments)
+	}
+
+	if err := j.SetLabel("OPS-7", "compose-diff-breaking", true); err != nil {
+		t.Fatalf("SetLabel failed: %v", err)
+	}
+	if len(labels) != 1 {
+		t.Errorf("Expected a label update, got %v", labels)
+	}
+}
@@ -0,0 +1,60 @@
+package forge
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// CommitStatus is one GitHub commit status, shown as a check on the pull
+// request and usable as a required check in branch protection. Bitbucket
+// and Azure DevOps statuses are mapped from it.
+type CommitStatus struct {
+	State       string `json:"state"` // success, failure, error or pending
+	Context     string `json:"context"`
+	Description string `json:"description,omitempty"`
+	TargetURL   string `json:"target_url,omitempty"`
+}
+
+// Budget is the number of changes of one severity a pull request may make
+// before its status fails; negative means unlimited
+type Budget map[models.Severity]int
+
+// SeverityStatuses returns one status per severity, breaking first, named
+// <prefix>/<severity>. A status fails when its count exceeds the budget.
+func SeverityStatuses(summary models.DiffSummary, budget Budget, prefix string) []CommitStatus {
+	counts := []struct {
+		severity models.Severity
+		count    int
+		noun     string
+	}{
+		{models.SeverityBreaking, summary.BreakingCount, "breaking changes"},
+		{models.SeverityWarning, summary.WarningCount, "warnings"},
+		{models.SeverityInfo, summary.InfoCount, "info changes"},
+	}
+
+	statuses := make([]CommitStatus, 0, len(counts))
+	for _, c := range counts {
+		s := CommitStatus{State: "success", Context: prefix + "/" + string(c.severity)}
+		limit, ok := budget[c.severity]
+		switch {
+		case !ok || limit < 0:
+			s.Description = fmt.Sprintf("%d %s", c.count, c.noun)
+		case c.count > limit:
+			s.State = "failure"
+			s.Description = fmt.Sprintf("%d %s, over the budget of %d", c.count, c.noun, limit)
+		default:
+			s.Description = fmt.Sprintf("%d %s, within the budget of %d", c.count, c.noun, limit)
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses
+}
+
+// SetCommitStatus creates a status on a commit. A later status with the
+// same context replaces it on the pull request.
+func (g *GitHub) SetCommitStatus(repo, sha string, status CommitStatus) error {
+	var out struct{}
+	return g.request(http.MethodPost, fmt.Sprintf("/repos/%s/statuses/%s", repo, sha), status, &out)
+}
@@ -0,0 +1,53 @@
+package forge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestSeverityStatuses(t *testing.T) {
+	summary := models.DiffSummary{BreakingCount: 1, WarningCount: 3, InfoCount: 12}
+	budget := Budget{models.SeverityBreaking: 0, models.SeverityWarning: 5, models.SeverityInfo: -1}
+
+	statuses := SeverityStatuses(summary, budget, "compose-diff")
+	want := []CommitStatus{
+		{State: "failure", Context: "compose-diff/breaking", Description: "1 breaking changes, over the budget of 0"},
+		{State: "success", Context: "compose-diff/warning", Description: "3 warnings, within the budget of 5"},
+		{State: "success", Context: "compose-diff/info", Description: "12 info changes"},
+	}
+	if len(statuses) != len(want) {
+		t.Fatalf("Expected %d statuses, got %+v", len(want), statuses)
+	}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Errorf("Status %d: expected %+v, got %+v", i, want[i], statuses[i])
+		}
+	}
+}
+
+func TestSetCommitStatus(t *testing.T) {
+	var got CommitStatus
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/repos/acme/shop/statuses/abc123" || r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": 1}`))
+	}))
+	defer server.Close()
+
+	gh := &GitHub{Client: server.Client(), BaseURL: server.URL, Token: "secret"}
+	status := CommitStatus{State: "failure", Context: "compose-diff/breaking", Description: "1 breaking changes"}
+	if err := gh.SetCommitStatus("acme/shop", "abc123", status); err != nil {
+		t.Fatalf("SetCommitStatus failed: %v", err)
+	}
+	if got != status {
+		t.Errorf("Expected %+v to be posted, got %+v", status, got)
+	}
+}
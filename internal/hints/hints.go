@@ -0,0 +1,186 @@
+package hints
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Hint is a remediation entry in the knowledge base
+type Hint struct {
+	ID        string            `yaml:"id"`
+	Field     string            `yaml:"field"`     // service field (e.g. "ports"), or scope name for top-level entries
+	Kind      models.ChangeKind `yaml:"kind"`      // change kind this hint applies to
+	Remedy    string            `yaml:"remedy"`    // what to do about the change
+	Checklist string            `yaml:"checklist"` // review task; {service} and {item} are substituted
+}
+
+// knowledgeBase holds the built-in remediation hints, most specific first
+var knowledgeBase = []Hint{
+	{
+		ID:        "service-removed",
+		Field:     "service",
+		Kind:      models.ChangeRemoved,
+		Remedy:    "Make sure no other service, script or load balancer still targets this service.",
+		Checklist: "Confirm nothing still calls or depends on service `{service}`",
+	},
+	{
+		ID:        "env-removed",
+		Field:     "environment",
+		Kind:      models.ChangeRemoved,
+		Remedy:    "Check the application no longer reads this variable, or provide a default.",
+		Checklist: "Confirm `{service}` no longer reads `{item}` (or has a safe default)",
+	},
+	{
+		ID:        "port-removed",
+		Field:     "ports",
+		Kind:      models.ChangeRemoved,
+		Remedy:    "Clients connecting through this published port will fail to connect.",
+		Checklist: "Confirm consumers of port {item} on `{service}` are migrated",
+	},
+	{
+		ID:        "port-modified",
+		Field:     "ports",
+		Kind:      models.ChangeModified,
+		Remedy:    "Update clients, firewall rules and health probes for the new mapping.",
+		Checklist: "Confirm clients and firewall rules follow the port change {item} on `{service}`",
+	},
+	{
+		ID:        "expose-removed",
+		Field:     "expose",
+		Kind:      models.ChangeRemoved,
+		Remedy:    "Services on the same network that connect to this port, or proxies that discover it, may lose access.",
+		Checklist: "Confirm no service connects to `{service}` on exposed port {item}",
+	},
+	{
+		ID:        "mount-removed",
+		Field:     "volumes",
+		Kind:      models.ChangeRemoved,
+		Remedy:    "Data written to this path will no longer persist across restarts.",
+		Checklist: "Confirm data at `{item}` in `{service}` is migrated or no longer needed",
+	},
+	{
+		ID:        "volume-removed",
+		Field:     "volume",
+		Kind:      models.ChangeRemoved,
+		Remedy:    "Back up the named volume before removing it; `docker compose down -v` deletes the data.",
+		Checklist: "Back up named volume `{service}` before it is removed",
+	},
+	{
+		ID:        "device-removed",
+		Field:     "devices",
+		Kind:      models.ChangeRemoved,
+		Remedy:    "The container can no longer open this device; hardware-dependent code paths will fail.",
+		Checklist: "Confirm `{service}` no longer needs device `{item}`",
+	},
+	{
+		ID:        "depends-removed",
+		Field:     "depends_on",
+		Kind:      models.ChangeRemoved,
+		Remedy:    "Startup ordering is no longer enforced; the service must tolerate the dependency being unavailable.",
+		Checklist: "Confirm `{service}` tolerates `{item}` starting later or being absent",
+	},
+	{
+		ID:        "healthcheck-removed",
+		Field:     "healthcheck",
+		Kind:      models.ChangeRemoved,
+		Remedy:    "Dependents using condition: service_healthy will no longer wait for readiness.",
+		Checklist: "Confirm services waiting on `{service}` being healthy still start correctly",
+	},
+	{
+		ID:        "image-changed",
+		Field:     "image",
+		Kind:      models.ChangeModified,
+		Remedy:    "Review the upstream changelog and data format compatibility between versions.",
+		Checklist: "Review release notes for the `{service}` image change and test data compatibility",
+	},
+}
+
+// LoadFile reads a YAML list of hints, as shipped in policy bundles
+func LoadFile(path string) ([]Hint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Hints []Hint `yaml:"hints"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse hints: %w", err)
+	}
+
+	for i, h := range file.Hints {
+		if h.Field == "" || h.Kind == "" || h.Checklist == "" {
+			return nil, fmt.Errorf("hint %d (%s): field, kind and checklist are required", i+1, h.ID)
+		}
+	}
+	return file.Hints, nil
+}
+
+// Register adds hints ahead of the built-in ones, so they take precedence
+func Register(hs ...Hint) {
+	knowledgeBase = append(append([]Hint{}, hs...), knowledgeBase...)
+}
+
+// Lookup returns the remediation hint for a change, if any
+func Lookup(c models.Change) (Hint, bool) {
+	field := fieldOf(c)
+	for _, h := range knowledgeBase {
+		if h.Field == field && h.Kind == c.Kind {
+			return h, true
+		}
+	}
+	return Hint{}, false
+}
+
+// ChecklistItem renders the checklist task for a change
+func ChecklistItem(c models.Change) string {
+	h, ok := Lookup(c)
+	if !ok {
+		return "Review " + string(c.Kind) + " `" + c.Path + "`"
+	}
+
+	r := strings.NewReplacer(
+		"{service}", c.Name,
+		"{item}", itemOf(c),
+	)
+	return r.Replace(h.Checklist)
+}
+
+// fieldOf returns the service field of a change, or the scope for whole entities
+func fieldOf(c models.Change) string {
+	parts := strings.SplitN(c.Path, ".", 4)
+	if c.Scope != models.ScopeService || len(parts) < 3 {
+		return string(c.Scope)
+	}
+	return parts[2]
+}
+
+// itemOf returns the part of the path below the field (env key, port, mount target)
+func itemOf(c models.Change) string {
+	parts := strings.SplitN(c.Path, ".", 4)
+	if len(parts) < 4 {
+		return c.Name
+	}
+	if parts[2] == "ports" {
+		return publishedPort(parts[3])
+	}
+	return parts[3]
+}
+
+// publishedPort turns a "host:container/proto" key into the port clients use
+func publishedPort(key string) string {
+	key = strings.SplitN(key, "/", 2)[0]
+	host, container, found := strings.Cut(key, ":")
+	if !found {
+		return key
+	}
+	if host == "" {
+		return container
+	}
+	return host
+}
@@ -0,0 +1,52 @@
+package hints
+
+import (
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestChecklistItem(t *testing.T) {
+	tests := []struct {
+		change   models.Change
+		expected string
+	}{
+		{
+			models.Change{Kind: models.ChangeRemoved, Scope: models.ScopeService, Name: "db", Path: "services.db.ports.5432:5432/tcp"},
+			"Confirm consumers of port 5432 on `db` are migrated",
+		},
+		{
+			models.Change{Kind: models.ChangeRemoved, Scope: models.ScopeService, Name: "api", Path: "services.api.environment.DATABASE_URL"},
+			"Confirm `api` no longer reads `DATABASE_URL` (or has a safe default)",
+		},
+		{
+			models.Change{Kind: models.ChangeRemoved, Scope: models.ScopeVolume, Name: "pgdata", Path: "volumes.pgdata"},
+			"Back up named volume `pgdata` before it is removed",
+		},
+		{
+			models.Change{Kind: models.ChangeModified, Scope: models.ScopeService, Name: "api", Path: "services.api.entrypoint"},
+			"Review modified `services.api.entrypoint`",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.change.Path, func(t *testing.T) {
+			if got := ChecklistItem(tt.change); got != tt.expected {
+				t.Errorf("ChecklistItem() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPublishedPort(t *testing.T) {
+	tests := map[string]string{
+		"8080:80/tcp": "8080",
+		":80/tcp":     "80",
+		"80":          "80",
+	}
+	for key, expected := range tests {
+		if got := publishedPort(key); got != expected {
+			t.Errorf("publishedPort(%q) = %q, want %q", key, got, expected)
+		}
+	}
+}
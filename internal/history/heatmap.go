@@ -0,0 +1,89 @@
+package history
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/reporter"
+)
+
+// PathFrequency is how often a path changed across a set of reports
+type PathFrequency struct {
+	Path     string         `json:"path"`
+	Count    int            `json:"count"`              // reports the path changed in
+	Ratio    float64        `json:"ratio"`              // Count / total reports
+	Kinds    map[string]int `json:"kinds"`              // added/removed/modified counts
+	Services []string       `json:"services,omitempty"` // with ByField, the services it changed in
+}
+
+// Heatmap counts, for each path, the reports it changed in, most frequent
+// first. With byField, service paths are folded into services.*.<field> so
+// a field that changes in many services stands out.
+func Heatmap(reports []*reporter.JSONReport, byField bool) []PathFrequency {
+	byPath := make(map[string]*PathFrequency)
+	services := make(map[string]map[string]bool)
+
+	for _, r := range reports {
+		seen := make(map[string]bool)
+		for _, c := range r.Changes {
+			path := c.Path
+			if field := serviceField(path); byField && field != "" {
+				path = "services.*." + field
+				if services[path] == nil {
+					services[path] = make(map[string]bool)
+				}
+				services[path][c.Name] = true
+			}
+
+			pf, ok := byPath[path]
+			if !ok {
+				pf = &PathFrequency{Path: path, Kinds: make(map[string]int)}
+				byPath[path] = pf
+			}
+			pf.Kinds[string(c.Kind)]++
+			if !seen[path] {
+				seen[path] = true
+				pf.Count++
+			}
+		}
+	}
+
+	result := make([]PathFrequency, 0, len(byPath))
+	for path, pf := range byPath {
+		pf.Ratio = float64(pf.Count) / float64(len(reports))
+		for name := range services[path] {
+			pf.Services = append(pf.Services, name)
+		}
+		sort.Strings(pf.Services)
+		result = append(result, *pf)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
+
+// FormatHeatmap renders frequencies as a table with a bar per path, scaled
+// to the total number of reports
+func FormatHeatmap(freqs []PathFrequency, total int) string {
+	var sb strings.Builder
+
+	width := len("PATH")
+	for _, f := range freqs {
+		width = max(width, len(f.Path))
+	}
+
+	const barWidth = 20
+	sb.WriteString(fmt.Sprintf("%-*s  %5s  %4s  %s\n", width, "PATH", "RUNS", "", "HEAT"))
+	for _, f := range freqs {
+		bar := max(int(f.Ratio*barWidth+0.5), 1)
+		sb.WriteString(fmt.Sprintf("%-*s  %5d  %3.0f%%  %s\n", width, f.Path, f.Count, f.Ratio*100, strings.Repeat("█", bar)))
+	}
+	sb.WriteString(fmt.Sprintf("\n%d paths across %d reports\n", len(freqs), total))
+
+	return sb.String()
+}
@@ -0,0 +1,46 @@
+package history
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/reporter"
+)
+
+func TestHeatmap(t *testing.T) {
+	api := func(path string) models.Change {
+		c := modified("services.api."+path, "x")
+		c.Name = "api"
+		return c
+	}
+	worker := func(path string) models.Change {
+		c := modified("services.worker."+path, "x")
+		c.Name = "worker"
+		return c
+	}
+	reports := []*reporter.JSONReport{
+		reportWith(api("image"), api("environment.A"), api("environment.A")),
+		reportWith(api("image"), worker("image")),
+		reportWith(api("image")),
+		reportWith(),
+	}
+
+	freqs := Heatmap(reports, false)
+	if len(freqs) != 3 || freqs[0].Path != "services.api.image" || freqs[0].Count != 3 || freqs[0].Ratio != 0.75 {
+		t.Fatalf("Unexpected heatmap %+v", freqs)
+	}
+	if freqs[1].Path != "services.api.environment.A" || freqs[1].Count != 1 || freqs[1].Kinds["modified"] != 2 {
+		t.Errorf("Expected a path to count once per report, got %+v", freqs[1])
+	}
+
+	freqs = Heatmap(reports, true)
+	if freqs[0].Path != "services.*.image" || freqs[0].Count != 3 || strings.Join(freqs[0].Services, ",") != "api,worker" {
+		t.Errorf("Unexpected folded heatmap %+v", freqs[0])
+	}
+
+	out := FormatHeatmap(freqs, len(reports))
+	if !strings.Contains(out, "services.*.image") || !strings.Contains(out, "75%") {
+		t.Errorf("Unexpected table:\n%s", out)
+	}
+}
@@ -0,0 +1,224 @@
+package history
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/reporter"
+)
+
+// NoiseOptions controls how noisy fields are detected
+type NoiseOptions struct {
+	MinReports int     // minimum number of reports needed to suggest anything
+	MinRatio   float64 // fraction of reports a path must change in
+}
+
+// DefaultNoiseOptions returns options that only flag fields changing on every run
+func DefaultNoiseOptions() NoiseOptions {
+	return NoiseOptions{
+		MinReports: 2,
+		MinRatio:   1.0,
+	}
+}
+
+// IgnoreSuggestion is a proposed ignore rule for a noisy path
+type IgnoreSuggestion struct {
+	Pattern string
+	Reason  string
+	Count   int // reports the path changed in
+	Total   int // reports analyzed
+}
+
+// pathStats accumulates how often a path changed and with which values
+type pathStats struct {
+	count  int
+	values []string
+}
+
+var (
+	timestampPattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}([T ]\d{2}:\d{2}(:\d{2})?)?|\d{10}|\d{13})`)
+	hexHashPattern   = regexp.MustCompile(`^(sha256:)?[0-9a-fA-F]{7,}$`)
+)
+
+// SuggestIgnores finds paths that change in (nearly) every report and proposes ignore rules
+func SuggestIgnores(reports []*reporter.JSONReport, opts NoiseOptions) []IgnoreSuggestion {
+	total := len(reports)
+	if total == 0 || total < opts.MinReports {
+		return nil
+	}
+
+	stats := make(map[string]*pathStats)
+	for _, r := range reports {
+		seen := make(map[string]bool)
+		for _, c := range r.Changes {
+			if c.Kind != models.ChangeModified || seen[c.Path] {
+				continue
+			}
+			seen[c.Path] = true
+
+			ps, ok := stats[c.Path]
+			if !ok {
+				ps = &pathStats{}
+				stats[c.Path] = ps
+			}
+			ps.count++
+			ps.values = append(ps.values, fmt.Sprintf("%v", c.After))
+		}
+	}
+
+	// Pick noisy paths
+	noisy := make(map[string]string)
+	for path, ps := range stats {
+		ratio := float64(ps.count) / float64(total)
+		kind := classifyValues(ps.values)
+		if ratio >= opts.MinRatio || (kind != "" && ratio >= opts.MinRatio/2) {
+			noisy[path] = kind
+		}
+	}
+
+	// Generalize fields that are noisy in more than one service
+	byField := make(map[string][]string)
+	for path := range noisy {
+		if field := serviceField(path); field != "" {
+			byField[field] = append(byField[field], path)
+		}
+	}
+
+	var suggestions []IgnoreSuggestion
+	generalized := make(map[string]bool)
+	for field, paths := range byField {
+		if len(paths) < 2 {
+			continue
+		}
+		sort.Strings(paths)
+		count := 0
+		for _, p := range paths {
+			generalized[p] = true
+			if stats[p].count > count {
+				count = stats[p].count
+			}
+		}
+		suggestions = append(suggestions, IgnoreSuggestion{
+			Pattern: "services.*." + field,
+			Reason:  noiseReason(count, total, noisy[paths[0]]) + fmt.Sprintf(" in %d services", len(paths)),
+			Count:   count,
+			Total:   total,
+		})
+	}
+
+	for path, kind := range noisy {
+		if generalized[path] {
+			continue
+		}
+		suggestions = append(suggestions, IgnoreSuggestion{
+			Pattern: path,
+			Reason:  noiseReason(stats[path].count, total, kind),
+			Count:   stats[path].count,
+			Total:   total,
+		})
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Count != suggestions[j].Count {
+			return suggestions[i].Count > suggestions[j].Count
+		}
+		return suggestions[i].Pattern < suggestions[j].Pattern
+	})
+
+	return suggestions
+}
+
+// FormatRulesSnippet renders suggestions as a rules file snippet
+func FormatRulesSnippet(suggestions []IgnoreSuggestion) string {
+	var sb strings.Builder
+
+	sb.WriteString("# Suggested by compose-diff suggest-ignores\n")
+	sb.WriteString("# Review before committing: these paths changed on (almost) every run.\n")
+	sb.WriteString("ignore_patterns:\n")
+	for _, s := range suggestions {
+		sb.WriteString(fmt.Sprintf("  - pattern: %q\n", s.Pattern))
+		sb.WriteString(fmt.Sprintf("    reason: %q\n", s.Reason))
+	}
+
+	return sb.String()
+}
+
+// classifyValues reports whether every value looks generated (timestamp, hash, random)
+func classifyValues(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	distinct := make(map[string]bool)
+	for _, v := range values {
+		distinct[v] = true
+	}
+	if len(distinct) != len(values) {
+		return ""
+	}
+
+	allTimestamps, allHashes, allRandom := true, true, true
+	for _, v := range values {
+		if !timestampPattern.MatchString(v) {
+			allTimestamps = false
+		}
+		if !hexHashPattern.MatchString(v) {
+			allHashes = false
+		}
+		if len(v) < 16 || shannonEntropy(v) < 3.5 {
+			allRandom = false
+		}
+	}
+
+	switch {
+	case allTimestamps:
+		return "timestamps"
+	case allHashes:
+		return "hashes"
+	case allRandom:
+		return "high-entropy values"
+	}
+	return ""
+}
+
+// shannonEntropy returns the entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+
+	freq := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		freq[r]++
+		n++
+	}
+
+	var entropy float64
+	for _, count := range freq {
+		p := float64(count) / float64(n)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// serviceField strips "services.<name>." from a path
+func serviceField(path string) string {
+	parts := strings.SplitN(path, ".", 3)
+	if len(parts) == 3 && parts[0] == "services" {
+		return parts[2]
+	}
+	return ""
+}
+
+func noiseReason(count, total int, kind string) string {
+	reason := fmt.Sprintf("changed in %d/%d reports", count, total)
+	if kind != "" {
+		reason += "; values look like " + kind
+	}
+	return reason
+}
@@ -0,0 +1,72 @@
+package history
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/reporter"
+)
+
+func reportWith(changes ...models.Change) *reporter.JSONReport {
+	return &reporter.JSONReport{Changes: changes}
+}
+
+func modified(path, after string) models.Change {
+	return models.Change{Kind: models.ChangeModified, Path: path, After: after}
+}
+
+func TestSuggestIgnores(t *testing.T) {
+	reports := []*reporter.JSONReport{
+		reportWith(
+			modified("services.api.labels.build.time", "2024-05-01T10:00:00Z"),
+			modified("services.worker.labels.build.time", "2024-05-01T10:00:01Z"),
+			modified("services.api.image", "app:1"),
+		),
+		reportWith(
+			modified("services.api.labels.build.time", "2024-05-02T10:00:00Z"),
+			modified("services.worker.labels.build.time", "2024-05-02T10:00:01Z"),
+			modified("services.api.labels.git.sha", "a1b2c3d4e5"),
+		),
+		reportWith(
+			modified("services.api.labels.build.time", "2024-05-03T10:00:00Z"),
+			modified("services.worker.labels.build.time", "2024-05-03T10:00:01Z"),
+			modified("services.api.labels.git.sha", "f6e7d8c9b0"),
+		),
+	}
+
+	suggestions := SuggestIgnores(reports, DefaultNoiseOptions())
+
+	patterns := make(map[string]IgnoreSuggestion)
+	for _, s := range suggestions {
+		patterns[s.Pattern] = s
+	}
+
+	ts, ok := patterns["services.*.labels.build.time"]
+	if !ok {
+		t.Fatalf("Expected generalized timestamp pattern, got %+v", suggestions)
+	}
+	if !strings.Contains(ts.Reason, "timestamps") {
+		t.Errorf("Expected timestamp reason, got %q", ts.Reason)
+	}
+
+	// Changed in 2/3 reports but values look like hashes
+	if _, ok := patterns["services.api.labels.git.sha"]; !ok {
+		t.Error("Expected hash-valued label to be suggested")
+	}
+
+	// Changed once with an ordinary value
+	if _, ok := patterns["services.api.image"]; ok {
+		t.Error("Image changed once and should not be suggested")
+	}
+}
+
+func TestSuggestIgnoresTooFewReports(t *testing.T) {
+	reports := []*reporter.JSONReport{
+		reportWith(modified("services.api.labels.build.time", "2024-05-01T10:00:00Z")),
+	}
+
+	if s := SuggestIgnores(reports, DefaultNoiseOptions()); len(s) != 0 {
+		t.Errorf("Expected no suggestions from a single report, got %+v", s)
+	}
+}
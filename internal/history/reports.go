@@ -0,0 +1,67 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/stackgen-cli/compose-diff/internal/reporter"
+)
+
+// LoadReports loads JSON reports from files or directories of *.json files
+func LoadReports(paths []string) ([]*reporter.JSONReport, error) {
+	files, err := ReportFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]*reporter.JSONReport, 0, len(files))
+	for _, f := range files {
+		report, err := LoadReport(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load report %s: %w", f, err)
+		}
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// ReportFiles expands directories among paths into their *.json files
+func ReportFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, fmt.Errorf("file not found: %s", p)
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(p, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// LoadReport reads a single JSON report produced by --format json
+func LoadReport(path string) (*reporter.JSONReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var report reporter.JSONReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
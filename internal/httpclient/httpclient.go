@@ -0,0 +1,72 @@
+// Package httpclient builds the HTTP client shared by every network feature
+// (registries, and later remote stores and forge APIs), so retry behavior is
+// configured in one place.
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// DefaultRetries is how many times a failed request is retried by default
+const DefaultRetries = 3
+
+// requestTimeout bounds one request including its retries
+const requestTimeout = 2 * time.Minute
+
+// Options configures the shared client
+type Options struct {
+	Retries  int    // retries after the first attempt; 0 disables retrying
+	CACert   string // PEM file of extra CAs to trust, e.g. a TLS-intercepting proxy's
+	Insecure bool   // skip TLS certificate verification
+}
+
+// New returns a client that honors HTTP(S)_PROXY and NO_PROXY, trusts the
+// configured CAs and retries transient failures with backoff
+func New(opts Options) (*http.Client, error) {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.Proxy = http.ProxyFromEnvironment
+
+	if opts.CACert != "" || opts.Insecure {
+		tlsConfig := &tls.Config{InsecureSkipVerify: opts.Insecure}
+		if opts.CACert != "" {
+			pool, err := certPool(opts.CACert)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.RootCAs = pool
+		}
+		base.TLSClientConfig = tlsConfig
+	}
+
+	return &http.Client{
+		Timeout: requestTimeout,
+		Transport: &retryTransport{
+			base:     base,
+			retries:  opts.Retries,
+			minDelay: 500 * time.Millisecond,
+			maxDelay: 30 * time.Second,
+		},
+	}, nil
+}
+
+// certPool returns the system roots plus the certificates in a PEM file
+func certPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA certificate: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificates found in %s", path)
+	}
+	return pool, nil
+}
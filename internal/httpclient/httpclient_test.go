@@ -0,0 +1,46 @@
+package httpclient
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCustomCA(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	// Without the CA the self-signed test certificate is rejected
+	client, err := New(Options{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, err := client.Get(srv.URL); err == nil {
+		t.Fatal("Expected an untrusted certificate error")
+	}
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caPath, pemData, 0644); err != nil {
+		t.Fatalf("Failed to write CA: %v", err)
+	}
+
+	client, err = New(Options{CACert: caPath})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Expected the custom CA to be trusted: %v", err)
+	}
+	resp.Body.Close()
+
+	if _, err := New(Options{CACert: filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+		t.Error("Expected an error for a missing CA file")
+	}
+}
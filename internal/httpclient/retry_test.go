@@ -0,0 +1,70 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testClient(retries int) *http.Client {
+	return &http.Client{Transport: &retryTransport{
+		base:     http.DefaultTransport,
+		retries:  retries,
+		minDelay: time.Millisecond,
+		maxDelay: 10 * time.Millisecond,
+	}}
+}
+
+func TestRetryOnRateLimit(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	resp, err := testClient(3).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls != 3 {
+		t.Errorf("Expected success on the third call, got %s after %d calls", resp.Status, calls)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	resp, err := testClient(2).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || calls != 3 {
+		t.Errorf("Expected the last 502 after 3 calls, got %s after %d calls", resp.Status, calls)
+	}
+
+	// A POST that reached a failing gateway may have been applied, so it is not retried
+	calls = 0
+	resp, err = testClient(2).Post(srv.URL, "text/plain", strings.NewReader("comment"))
+	if err != nil {
+		t.Fatalf("Post failed: %v", err)
+	}
+	resp.Body.Close()
+	if calls != 1 {
+		t.Errorf("Expected POST not to be retried on 502, got %d calls", calls)
+	}
+}
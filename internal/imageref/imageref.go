@@ -0,0 +1,56 @@
+// Package imageref splits container image references the way Docker
+// resolves them, so that every part of compose-diff agrees that nginx and
+// docker.io/library/nginx:latest are the same image.
+package imageref
+
+import "strings"
+
+// DockerHub is the registry of images that name none
+const DockerHub = "docker.io"
+
+// dockerHubAliases are the hosts Docker Hub is pulled from under other names
+var dockerHubAliases = map[string]bool{
+	"docker.io":            true,
+	"index.docker.io":      true,
+	"registry-1.docker.io": true,
+}
+
+// Reference is an image reference split into its parts
+type Reference struct {
+	Registry   string // lower case; docker.io for Docker Hub under any of its names
+	Repository string // with library/ for official Docker Hub images
+	Tag        string // latest if unset, empty if pinned by digest only
+	Digest     string
+}
+
+// Parse splits an image reference such as ghcr.io/acme/api:1.2 or
+// nginx@sha256:... into its registry, repository, tag and digest. It does
+// not validate them.
+func Parse(image string) Reference {
+	var ref Reference
+	name, digest, pinned := strings.Cut(strings.TrimSpace(image), "@")
+	ref.Digest = digest
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Tag = name[:i], name[i+1:]
+	} else if !pinned {
+		ref.Tag = "latest"
+	}
+
+	ref.Registry = DockerHub
+	if first, rest, ok := strings.Cut(name, "/"); ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		ref.Registry, name = strings.ToLower(first), rest
+	}
+	if dockerHubAliases[ref.Registry] {
+		ref.Registry = DockerHub
+		if name != "" && !strings.Contains(name, "/") {
+			name = "library/" + name
+		}
+	}
+	ref.Repository = name
+	return ref
+}
+
+// Name is the registry and repository, e.g. docker.io/library/nginx
+func (r Reference) Name() string {
+	return r.Registry + "/" + r.Repository
+}
@@ -0,0 +1,23 @@
+package imageref
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		image string
+		want  Reference
+	}{
+		{"nginx", Reference{"docker.io", "library/nginx", "latest", ""}},
+		{"docker.io/library/nginx:1.25", Reference{"docker.io", "library/nginx", "1.25", ""}},
+		{"index.docker.io/nginx:1.25", Reference{"docker.io", "library/nginx", "1.25", ""}},
+		{"bitnami/redis:7", Reference{"docker.io", "bitnami/redis", "7", ""}},
+		{"localhost:5000/api:dev", Reference{"localhost:5000", "api", "dev", ""}},
+		{"GHCR.io/acme/api@sha256:abc", Reference{"ghcr.io", "acme/api", "", "sha256:abc"}},
+		{"nginx:1.25@sha256:abc", Reference{"docker.io", "library/nginx", "1.25", "sha256:abc"}},
+	}
+	for _, tt := range tests {
+		if got := Parse(tt.image); got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.image, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Package impact works out the blast radius of breaking changes: the
+// services that depend, through depends_on, on a service whose change may
+// break them.
+package impact
+
+import (
+	"sort"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// Graph maps each service to the services that depend on it. It is the
+// union of both files' depends_on edges, so a dependency that the change
+// removes still counts: whatever relied on it is affected too.
+type Graph struct {
+	dependents map[string][]string
+}
+
+// NewGraph builds the dependency graph of two IRs; either may be nil
+func NewGraph(old, new *models.ComposeIR) *Graph {
+	seen := make(map[[2]string]bool)
+	g := &Graph{dependents: make(map[string][]string)}
+	for _, ir := range []*models.ComposeIR{old, new} {
+		if ir == nil {
+			continue
+		}
+		for name, svc := range ir.Services {
+			for _, dep := range svc.DependsOn {
+				edge := [2]string{dep, name}
+				if seen[edge] || dep == name {
+					continue
+				}
+				seen[edge] = true
+				g.dependents[dep] = append(g.dependents[dep], name)
+			}
+		}
+	}
+	for _, names := range g.dependents {
+		sort.Strings(names)
+	}
+	return g
+}
+
+// Dependents returns the services that depend on service directly or
+// transitively, nearest first and by name within the same distance
+func (g *Graph) Dependents(service string) []string {
+	visited := map[string]bool{service: true}
+	var result []string
+	for level := []string{service}; len(level) > 0; {
+		var next []string
+		for _, name := range level {
+			for _, dep := range g.dependents[name] {
+				if !visited[dep] {
+					visited[dep] = true
+					next = append(next, dep)
+				}
+			}
+		}
+		sort.Strings(next)
+		result = append(result, next...)
+		level = next
+	}
+	return result
+}
+
+// Analyze returns, for each service with breaking changes that others
+// depend on, the changes and the downstream services, by service name
+func (g *Graph) Analyze(changes []models.Change) []models.Impact {
+	byService := make(map[string]*models.Impact)
+	var order []string
+	for _, c := range changes {
+		if c.Scope != models.ScopeService || c.Severity != models.SeverityBreaking {
+			continue
+		}
+		imp, ok := byService[c.Name]
+		if !ok {
+			dependents := g.Dependents(c.Name)
+			// Services that depended on a renamed service by its old name
+			if old, isName := c.Before.(string); c.Kind == models.ChangeRenamed && isName && c.Path == "services."+c.Name {
+				dependents = mergeNames(dependents, g.Dependents(old), c.Name)
+			}
+			imp = &models.Impact{Service: c.Name, Dependents: dependents}
+			byService[c.Name] = imp
+			order = append(order, c.Name)
+		}
+		imp.Changes = append(imp.Changes, c.Path)
+	}
+
+	sort.Strings(order)
+	var result []models.Impact
+	for _, name := range order {
+		if imp := byService[name]; len(imp.Dependents) > 0 {
+			result = append(result, *imp)
+		}
+	}
+	return result
+}
+
+// mergeNames appends the names in b missing from a, except skip
+func mergeNames(a, b []string, skip string) []string {
+	have := map[string]bool{skip: true}
+	for _, name := range a {
+		have[name] = true
+	}
+	for _, name := range b {
+		if !have[name] {
+			have[name] = true
+			a = append(a, name)
+		}
+	}
+	return a
+}
@@ -0,0 +1,64 @@
+package impact
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func irWith(deps map[string][]string) *models.ComposeIR {
+	ir := &models.ComposeIR{Services: make(map[string]models.ServiceIR)}
+	for name, d := range deps {
+		ir.Services[name] = models.ServiceIR{DependsOn: d}
+	}
+	return ir
+}
+
+func TestDependents(t *testing.T) {
+	old := irWith(map[string][]string{
+		"db":     nil,
+		"api":    {"db"},
+		"worker": {"db", "api"},
+		"nginx":  {"api"},
+		"cron":   {"legacy"},
+	})
+	new := irWith(map[string][]string{
+		"db":     nil,
+		"api":    {"db"},
+		"worker": {"db"},
+		"nginx":  {"api"},
+		"admin":  {"nginx"},
+	})
+	g := NewGraph(old, new)
+
+	if got, want := g.Dependents("db"), []string{"api", "worker", "nginx", "admin"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Dependents(db) = %v, want %v", got, want)
+	}
+	if got := g.Dependents("admin"); len(got) != 0 {
+		t.Errorf("Expected no dependents of admin, got %v", got)
+	}
+
+	changes := []models.Change{
+		{Scope: models.ScopeService, Name: "api", Path: "services.api.ports.80:80/tcp", Severity: models.SeverityBreaking},
+		{Scope: models.ScopeService, Name: "api", Path: "services.api.environment.DB", Severity: models.SeverityBreaking},
+		{Scope: models.ScopeService, Name: "db", Path: "services.db.image", Severity: models.SeverityWarning},
+		{Scope: models.ScopeService, Name: "admin", Path: "services.admin", Severity: models.SeverityBreaking},
+		{Kind: models.ChangeRenamed, Scope: models.ScopeService, Name: "jobs", Path: "services.jobs", Before: "legacy", After: "jobs", Severity: models.SeverityBreaking},
+	}
+	impacts := g.Analyze(changes)
+	want := []models.Impact{
+		{Service: "api", Changes: []string{"services.api.ports.80:80/tcp", "services.api.environment.DB"}, Dependents: []string{"nginx", "worker", "admin"}},
+		{Service: "jobs", Changes: []string{"services.jobs"}, Dependents: []string{"cron"}},
+	}
+	if !reflect.DeepEqual(impacts, want) {
+		t.Errorf("Analyze = %+v, want %+v", impacts, want)
+	}
+}
+
+func TestDependentsCycle(t *testing.T) {
+	g := NewGraph(nil, irWith(map[string][]string{"a": {"b"}, "b": {"a"}}))
+	if got, want := g.Dependents("a"), []string{"b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Dependents(a) = %v, want %v", got, want)
+	}
+}
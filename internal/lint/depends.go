@@ -0,0 +1,189 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// Dependency is a depends_on entry of Service
+type Dependency struct {
+	Service    string
+	Dependency string
+}
+
+// Path is the dotted path of the depends_on entry
+func (d Dependency) Path() string {
+	return fmt.Sprintf("services.%s.depends_on.%s", d.Service, d.Dependency)
+}
+
+// DanglingDependencies finds depends_on entries naming services that are not
+// in the file, sorted by service
+func DanglingDependencies(ir *models.ComposeIR) []Dependency {
+	var dangling []Dependency
+	for _, name := range serviceNames(ir) {
+		for _, dep := range ir.Services[name].DependsOn {
+			if _, ok := ir.Services[dep]; !ok {
+				dangling = append(dangling, Dependency{name, dep})
+			}
+		}
+	}
+	return dangling
+}
+
+// DependencyCycles finds groups of services that depend on each other,
+// directly or transitively, which docker compose refuses to start. Each
+// cycle is sorted, and cycles are sorted by their first service.
+func DependencyCycles(ir *models.ComposeIR) [][]string {
+	// Tarjan's strongly connected components
+	var (
+		index   = make(map[string]int)
+		low     = make(map[string]int)
+		onStack = make(map[string]bool)
+		stack   []string
+		cycles  [][]string
+		visit   func(name string)
+	)
+	visit = func(name string) {
+		index[name] = len(index)
+		low[name] = index[name]
+		stack = append(stack, name)
+		onStack[name] = true
+
+		selfLoop := false
+		for _, dep := range ir.Services[name].DependsOn {
+			if _, ok := ir.Services[dep]; !ok {
+				continue
+			}
+			if dep == name {
+				selfLoop = true
+			}
+			if _, seen := index[dep]; !seen {
+				visit(dep)
+				low[name] = min(low[name], low[dep])
+			} else if onStack[dep] {
+				low[name] = min(low[name], index[dep])
+			}
+		}
+
+		if low[name] != index[name] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == name {
+				break
+			}
+		}
+		if len(component) > 1 || selfLoop {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+
+	for _, name := range serviceNames(ir) {
+		if _, seen := index[name]; !seen {
+			visit(name)
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	return cycles
+}
+
+// DependencyChanges reports the dangling depends_on entries and dependency
+// cycles that new introduces, as breaking changes: docker compose will not
+// start the stack. Problems already present in old are left out.
+func DependencyChanges(old, new *models.ComposeIR) []models.Change {
+	existing := make(map[string]bool)
+	if old != nil {
+		for _, d := range DanglingDependencies(old) {
+			existing[d.Path()] = true
+		}
+		for _, c := range DependencyCycles(old) {
+			existing[strings.Join(c, ",")] = true
+		}
+	}
+
+	var changes []models.Change
+	for _, d := range DanglingDependencies(new) {
+		if existing[d.Path()] {
+			continue
+		}
+		changes = append(changes, models.Change{
+			Kind:     models.ChangeDangling,
+			Scope:    models.ScopeService,
+			Name:     d.Service,
+			Path:     d.Path(),
+			After:    d.Dependency,
+			Severity: models.SeverityBreaking,
+		})
+	}
+	for _, c := range DependencyCycles(new) {
+		if existing[strings.Join(c, ",")] {
+			continue
+		}
+		changes = append(changes, models.Change{
+			Kind:     models.ChangeCycle,
+			Scope:    models.ScopeService,
+			Name:     c[0],
+			Path:     fmt.Sprintf("services.%s.depends_on", c[0]),
+			After:    describeCycle(new, c),
+			Severity: models.SeverityBreaking,
+		})
+	}
+	return changes
+}
+
+// describeCycle follows depends_on from the cycle's first service back to
+// it, e.g. a → b → c → a
+func describeCycle(ir *models.ComposeIR, cycle []string) string {
+	members := make(map[string]bool, len(cycle))
+	for _, name := range cycle {
+		members[name] = true
+	}
+
+	// Breadth-first search for the shortest way back to the start
+	start := cycle[0]
+	prev := make(map[string]string)
+	queue := []string{start}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, dep := range ir.Services[name].DependsOn {
+			if !members[dep] {
+				continue
+			}
+			if dep == start {
+				path := []string{start}
+				for n := name; n != start; n = prev[n] {
+					path = append(path, n)
+				}
+				// path is start, then the walk back; reverse all but the start
+				for i, j := 1, len(path)-1; i < j; i, j = i+1, j-1 {
+					path[i], path[j] = path[j], path[i]
+				}
+				return strings.Join(append(path, start), " → ")
+			}
+			if _, seen := prev[dep]; !seen && dep != start {
+				prev[dep] = name
+				queue = append(queue, dep)
+			}
+		}
+	}
+	return strings.Join(cycle, ", ")
+}
+
+func serviceNames(ir *models.ComposeIR) []string {
+	names := make([]string, 0, len(ir.Services))
+	for name := range ir.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
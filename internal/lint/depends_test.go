@@ -0,0 +1,67 @@
+package lint
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func dependsIR(deps map[string][]string) *models.ComposeIR {
+	ir := models.NewComposeIR()
+	for name, d := range deps {
+		ir.Services[name] = models.ServiceIR{DependsOn: d}
+	}
+	return ir
+}
+
+func TestDependencyCycles(t *testing.T) {
+	ir := dependsIR(map[string][]string{
+		"a":    {"b"},
+		"b":    {"c"},
+		"c":    {"a", "db"},
+		"db":   nil,
+		"self": {"self"},
+		"x":    {"y"},
+		"y":    {"x", "gone"},
+	})
+
+	want := [][]string{{"a", "b", "c"}, {"self"}, {"x", "y"}}
+	if got := DependencyCycles(ir); !reflect.DeepEqual(got, want) {
+		t.Errorf("DependencyCycles = %v, want %v", got, want)
+	}
+	if got := DanglingDependencies(ir); len(got) != 1 || got[0].Path() != "services.y.depends_on.gone" {
+		t.Errorf("Expected y's dependency on gone to dangle, got %+v", got)
+	}
+	if got := describeCycle(ir, want[0]); got != "a → b → c → a" {
+		t.Errorf("describeCycle = %q", got)
+	}
+}
+
+func TestDependencyChanges(t *testing.T) {
+	old := dependsIR(map[string][]string{
+		"api":    {"db"},
+		"db":     nil,
+		"worker": {"legacy"},
+	})
+	new := dependsIR(map[string][]string{
+		"api":    {"db", "cache"},
+		"db":     {"api"},
+		"worker": {"legacy"},
+	})
+
+	changes := DependencyChanges(old, new)
+	if len(changes) != 2 {
+		t.Fatalf("Expected a dangling dependency and a cycle, got %+v", changes)
+	}
+	if c := changes[0]; c.Kind != models.ChangeDangling || c.Path != "services.api.depends_on.cache" || c.After != "cache" || c.Severity != models.SeverityBreaking {
+		t.Errorf("Unexpected dangling change %+v", c)
+	}
+	if c := changes[1]; c.Kind != models.ChangeCycle || c.Path != "services.api.depends_on" || c.After != "api → db → api" {
+		t.Errorf("Unexpected cycle change %+v", c)
+	}
+
+	if got := DependencyChanges(new, new); len(got) != 0 {
+		t.Errorf("Expected problems already in old to be left out, got %+v", got)
+	}
+}
@@ -0,0 +1,129 @@
+// Package lint holds checks that look at a compose file, or a change to one,
+// as a whole rather than path by path
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// CheckPartialEnvUpdate is the Finding.Check of PartialEnvUpdates findings
+const CheckPartialEnvUpdate = "partial-env-update"
+
+// SharedEnv is an environment variable set to the same value in several
+// services
+type SharedEnv struct {
+	Name     string   `json:"name"`
+	Value    string   `json:"value"`
+	Services []string `json:"services"` // sorted
+}
+
+// DuplicateEnv finds variables defined identically in at least minServices
+// services, most widely shared first
+func DuplicateEnv(ir *models.ComposeIR, minServices int) []SharedEnv {
+	type key struct{ name, value string }
+	byValue := make(map[key][]string)
+	for svc, s := range ir.Services {
+		for name, value := range s.Env {
+			if value != nil {
+				k := key{name, *value}
+				byValue[k] = append(byValue[k], svc)
+			}
+		}
+	}
+
+	var shared []SharedEnv
+	for k, services := range byValue {
+		if len(services) < max(minServices, 2) {
+			continue
+		}
+		sort.Strings(services)
+		shared = append(shared, SharedEnv{Name: k.name, Value: k.value, Services: services})
+	}
+	sort.Slice(shared, func(i, j int) bool {
+		if len(shared[i].Services) != len(shared[j].Services) {
+			return len(shared[i].Services) > len(shared[j].Services)
+		}
+		if shared[i].Name != shared[j].Name {
+			return shared[i].Name < shared[j].Name
+		}
+		return shared[i].Value < shared[j].Value
+	})
+	return shared
+}
+
+// PartialUpdate is a shared variable that a change updated in some of the
+// services sharing it but not in others
+type PartialUpdate struct {
+	SharedEnv
+	Updated []string // services where the value changed
+	Stale   []string // services still on the old value
+}
+
+// PartialEnvUpdates finds variables shared by several services in old whose
+// value changed in some of those services but not others, a common source
+// of half-applied configuration changes. Services or variables removed in
+// new are not counted either way.
+func PartialEnvUpdates(old, new *models.ComposeIR) []PartialUpdate {
+	var updates []PartialUpdate
+	for _, shared := range DuplicateEnv(old, 2) {
+		u := PartialUpdate{SharedEnv: shared}
+		for _, svc := range shared.Services {
+			s, ok := new.Services[svc]
+			if !ok {
+				continue
+			}
+			value, ok := s.Env[shared.Name]
+			switch {
+			case !ok:
+			case value != nil && *value == shared.Value:
+				u.Stale = append(u.Stale, svc)
+			default:
+				u.Updated = append(u.Updated, svc)
+			}
+		}
+		if len(u.Updated) > 0 && len(u.Stale) > 0 {
+			updates = append(updates, u)
+		}
+	}
+	return updates
+}
+
+// Findings reports the partial updates between old and new as warnings
+// that point at the services left behind
+func Findings(old, new *models.ComposeIR) []models.Finding {
+	var findings []models.Finding
+	for _, u := range PartialEnvUpdates(old, new) {
+		paths := make([]string, len(u.Stale))
+		for i, svc := range u.Stale {
+			paths[i] = fmt.Sprintf("services.%s.environment.%s", svc, u.Name)
+		}
+		findings = append(findings, models.Finding{
+			Check:    CheckPartialEnvUpdate,
+			Severity: models.SeverityWarning,
+			Message: fmt.Sprintf("%s was the same in %d services but changed only in %s; still the old value in %s",
+				u.Name, len(u.Services), strings.Join(u.Updated, ", "), strings.Join(u.Stale, ", ")),
+			Paths: paths,
+		})
+	}
+	return findings
+}
+
+// FormatDuplicateEnv lists shared variables one per line, with long values
+// shortened
+func FormatDuplicateEnv(shared []SharedEnv) string {
+	var sb strings.Builder
+	for _, s := range shared {
+		value := s.Value
+		if len(value) > 40 {
+			value = value[:37] + "..."
+		}
+		sb.WriteString(fmt.Sprintf("%s=%s in %d services: %s\n", s.Name, value, len(s.Services), strings.Join(s.Services, ", ")))
+	}
+	sb.WriteString(fmt.Sprintf("\n%d variables are defined identically in several services. Moving them to an\n", len(shared)))
+	sb.WriteString("x- anchor or a shared env_file keeps the copies from drifting apart.\n")
+	return sb.String()
+}
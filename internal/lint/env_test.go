@@ -0,0 +1,66 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func envIR(services map[string]map[string]string) *models.ComposeIR {
+	ir := models.NewComposeIR()
+	for name, env := range services {
+		svc := models.ServiceIR{Env: make(map[string]*string)}
+		for k, v := range env {
+			value := v
+			svc.Env[k] = &value
+		}
+		ir.Services[name] = svc
+	}
+	return ir
+}
+
+func TestDuplicateEnv(t *testing.T) {
+	ir := envIR(map[string]map[string]string{
+		"api":    {"DB": "pg://v1", "LOG": "info", "PORT": "80"},
+		"worker": {"DB": "pg://v1", "LOG": "info", "PORT": "81"},
+		"cron":   {"DB": "pg://v1", "LOG": "debug"},
+	})
+
+	shared := DuplicateEnv(ir, 2)
+	if len(shared) != 2 || shared[0].Name != "DB" || strings.Join(shared[0].Services, ",") != "api,cron,worker" {
+		t.Fatalf("Unexpected shared variables %+v", shared)
+	}
+	if shared[1].Name != "LOG" || shared[1].Value != "info" {
+		t.Errorf("Expected LOG=info shared by two services, got %+v", shared[1])
+	}
+	if got := DuplicateEnv(ir, 3); len(got) != 1 {
+		t.Errorf("Expected only DB with --min-services 3, got %+v", got)
+	}
+}
+
+func TestPartialEnvUpdates(t *testing.T) {
+	old := envIR(map[string]map[string]string{
+		"api":    {"DB": "pg://v1", "LOG": "info"},
+		"worker": {"DB": "pg://v1", "LOG": "info"},
+		"cron":   {"DB": "pg://v1", "LOG": "info"},
+	})
+	new := envIR(map[string]map[string]string{
+		"api":    {"DB": "pg://v2", "LOG": "warn"},
+		"worker": {"DB": "pg://v1", "LOG": "warn"},
+		"cron":   {"DB": "pg://v1"},
+	})
+
+	updates := PartialEnvUpdates(old, new)
+	if len(updates) != 1 || updates[0].Name != "DB" {
+		t.Fatalf("Expected only DB to be partially updated, got %+v", updates)
+	}
+	if strings.Join(updates[0].Updated, ",") != "api" || strings.Join(updates[0].Stale, ",") != "cron,worker" {
+		t.Errorf("Unexpected split %+v", updates[0])
+	}
+
+	findings := Findings(old, new)
+	if len(findings) != 1 || findings[0].Severity != models.SeverityWarning || len(findings[0].Paths) != 2 {
+		t.Errorf("Unexpected findings %+v", findings)
+	}
+}
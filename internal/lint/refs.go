@@ -0,0 +1,143 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// Reference is a service's use of a named volume or network
+type Reference struct {
+	Service string
+	Scope   models.Scope // ScopeVolume or ScopeNetwork
+	Name    string
+	Path    string // services.<service>.volumes.<target> or services.<service>.networks.<name>
+}
+
+// References lists the named volumes and networks each service uses, by
+// service. Anonymous volumes, bind mounts and the implicit default network
+// are left out.
+func References(ir *models.ComposeIR) []Reference {
+	var refs []Reference
+	for _, name := range serviceNames(ir) {
+		svc := ir.Services[name]
+		for _, m := range svc.Volumes {
+			if m.Type == "volume" && m.Source != "" {
+				refs = append(refs, Reference{name, models.ScopeVolume, m.Source, fmt.Sprintf("services.%s.volumes.%s", name, m.Target)})
+			}
+		}
+		for _, n := range svc.Networks {
+			if n != "default" {
+				refs = append(refs, Reference{name, models.ScopeNetwork, n, fmt.Sprintf("services.%s.networks.%s", name, n)})
+			}
+		}
+	}
+	return refs
+}
+
+// UndeclaredReferences lists the references to volumes and networks that are
+// not declared top-level, which docker compose up rejects
+func UndeclaredReferences(ir *models.ComposeIR) []Reference {
+	var undeclared []Reference
+	for _, ref := range References(ir) {
+		if !declared(ir, ref.Scope, ref.Name) {
+			undeclared = append(undeclared, ref)
+		}
+	}
+	return undeclared
+}
+
+// users maps each top-level volume and network, as scope.name, to the
+// services using it
+func users(ir *models.ComposeIR) map[string][]string {
+	used := make(map[string][]string)
+	for _, ref := range References(ir) {
+		key := string(ref.Scope) + "." + ref.Name
+		if n := len(used[key]); n == 0 || used[key][n-1] != ref.Service {
+			used[key] = append(used[key], ref.Service)
+		}
+	}
+	// Services without networks or network_mode join the default network
+	for _, name := range serviceNames(ir) {
+		if svc := ir.Services[name]; len(svc.Networks) == 0 && svc.NetworkMode == nil {
+			used["network.default"] = append(used["network.default"], name)
+		}
+	}
+	return used
+}
+
+// ReferenceChanges reports the volume and network references new introduces
+// without a top-level declaration, as breaking dangling changes, and the
+// declarations that old's services used but none of new's do any more, as
+// orphaned warnings
+func ReferenceChanges(old, new *models.ComposeIR) []models.Change {
+	existing := make(map[string]bool)
+	if old != nil {
+		for _, ref := range UndeclaredReferences(old) {
+			existing[ref.Path] = true
+		}
+	}
+
+	var changes []models.Change
+	for _, ref := range UndeclaredReferences(new) {
+		if existing[ref.Path] {
+			continue
+		}
+		changes = append(changes, models.Change{
+			Kind:     models.ChangeDangling,
+			Scope:    models.ScopeService,
+			Name:     ref.Service,
+			Path:     ref.Path,
+			After:    ref.Name,
+			Severity: models.SeverityBreaking,
+		})
+	}
+	if old == nil {
+		return changes
+	}
+
+	oldUsers, newUsers := users(old), users(new)
+	var orphaned []models.Change
+	for _, scope := range []models.Scope{models.ScopeVolume, models.ScopeNetwork} {
+		for _, name := range declaredNames(new, scope) {
+			key := string(scope) + "." + name
+			if !declared(old, scope, name) || len(oldUsers[key]) == 0 || len(newUsers[key]) > 0 {
+				continue
+			}
+			orphaned = append(orphaned, models.Change{
+				Kind:     models.ChangeOrphaned,
+				Scope:    scope,
+				Name:     name,
+				Path:     fmt.Sprintf("%ss.%s", scope, name),
+				Before:   oldUsers[key],
+				Severity: models.SeverityWarning,
+			})
+		}
+	}
+	return append(changes, orphaned...)
+}
+
+func declared(ir *models.ComposeIR, scope models.Scope, name string) bool {
+	if scope == models.ScopeVolume {
+		_, ok := ir.Volumes[name]
+		return ok
+	}
+	_, ok := ir.Networks[name]
+	return ok || name == "default"
+}
+
+func declaredNames(ir *models.ComposeIR, scope models.Scope) []string {
+	var names []string
+	if scope == models.ScopeVolume {
+		for name := range ir.Volumes {
+			names = append(names, name)
+		}
+	} else {
+		for name := range ir.Networks {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,54 @@
+package lint
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestReferenceChanges(t *testing.T) {
+	old := models.NewComposeIR()
+	old.Services["api"] = models.ServiceIR{
+		Volumes:  []models.MountIR{{Type: "volume", Source: "uploads", Target: "/uploads"}, {Type: "volume", Target: "/tmp"}},
+		Networks: []string{"backend"},
+	}
+	old.Services["db"] = models.ServiceIR{Volumes: []models.MountIR{{Type: "volume", Source: "pgdata", Target: "/var/lib/postgresql/data"}}}
+	old.Volumes["uploads"] = models.VolumeIR{}
+	old.Volumes["pgdata"] = models.VolumeIR{}
+	old.Networks["backend"] = models.NetworkIR{}
+
+	new := models.NewComposeIR()
+	new.Services["api"] = models.ServiceIR{
+		Volumes:  []models.MountIR{{Type: "volume", Source: "uploads", Target: "/uploads"}, {Type: "bind", Source: "./conf", Target: "/conf"}},
+		Networks: []string{"backend", "frontend", "default"},
+	}
+	new.Services["db"] = models.ServiceIR{Volumes: []models.MountIR{{Type: "volume", Source: "pgdata", Target: "/var/lib/postgresql/data"}}}
+	new.Networks["backend"] = models.NetworkIR{}
+	new.Volumes["pgdata"] = models.VolumeIR{}
+	new.Volumes["cache"] = models.VolumeIR{}
+
+	changes := ReferenceChanges(old, new)
+	var got []string
+	for _, c := range changes {
+		got = append(got, string(c.Kind)+" "+string(c.Severity)+" "+c.Path)
+	}
+	want := []string{
+		"dangling breaking services.api.volumes./uploads",
+		"dangling breaking services.api.networks.frontend",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ReferenceChanges = %v, want %v", got, want)
+	}
+
+	// Dropping the last user of a declaration orphans it
+	delete(new.Services, "db")
+	changes = ReferenceChanges(old, new)
+	last := changes[len(changes)-1]
+	if last.Kind != models.ChangeOrphaned || last.Path != "volumes.pgdata" || !reflect.DeepEqual(last.Before, []string{"db"}) {
+		t.Errorf("Expected pgdata to be orphaned, got %+v", last)
+	}
+	if got := ReferenceChanges(nil, old); len(got) != 0 {
+		t.Errorf("Expected no problems in a consistent file, got %+v", got)
+	}
+}
@@ -1,5 +1,10 @@
 package models
 
+import (
+	"fmt"
+	"time"
+)
+
 // ChangeKind represents the type of change
 type ChangeKind string
 
@@ -7,15 +12,45 @@ const (
 	ChangeAdded    ChangeKind = "added"
 	ChangeRemoved  ChangeKind = "removed"
 	ChangeModified ChangeKind = "modified"
+	// ChangeReordered is a list whose items are the same but in a different
+	// order; Before and After are the two orders
+	ChangeReordered ChangeKind = "reordered"
+	// ChangeRenamed is an entity or key that moved to a new name with the
+	// same content; Path is the new path, Before and After the two names
+	ChangeRenamed ChangeKind = "renamed"
+	// ChangeDangling is a depends_on entry, volume or network reference,
+	// new in this change, naming a service that is not in the file or a
+	// volume or network that is not declared; After is the missing name
+	ChangeDangling ChangeKind = "dangling"
+	// ChangeCycle is a depends_on cycle the change introduces; Path is the
+	// depends_on of the cycle's first service and After the cycle, a → b → a
+	ChangeCycle ChangeKind = "cycle"
+	// ChangeOrphaned is a top-level volume or network that services used
+	// in the old file and none use in the new; Before lists the old users
+	ChangeOrphaned ChangeKind = "orphaned"
 )
 
+// ChangeKinds lists every change kind
+var ChangeKinds = []ChangeKind{ChangeAdded, ChangeRemoved, ChangeModified, ChangeReordered, ChangeRenamed, ChangeDangling, ChangeCycle, ChangeOrphaned}
+
+// ValidChangeKind reports whether k is one of ChangeKinds
+func ValidChangeKind(k ChangeKind) bool {
+	for _, kind := range ChangeKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
 // Scope represents what entity was changed
 type Scope string
 
 const (
-	ScopeService Scope = "service"
-	ScopeVolume  Scope = "volume"
-	ScopeNetwork Scope = "network"
+	ScopeService   Scope = "service"
+	ScopeVolume    Scope = "volume"
+	ScopeNetwork   Scope = "network"
+	ScopeExtension Scope = "extension" // top-level x-* field
 )
 
 // Severity represents the impact level of a change
@@ -36,6 +71,10 @@ type Change struct {
 	Before   interface{} `json:"before"`
 	After    interface{} `json:"after"`
 	Severity Severity    `json:"severity"`
+	Sources  []string    `json:"sources,omitempty"`  // inputs that produced this change, for merged reports
+	OldLine  int         `json:"old_line,omitempty"` // line in the old file, or of the nearest enclosing key
+	NewLine  int         `json:"new_line,omitempty"` // line in the new file, or of the nearest enclosing key
+	Notes    []string    `json:"notes,omitempty"`    // messages from a --policy
 }
 
 // DiffSummary provides aggregate counts of changes
@@ -43,6 +82,7 @@ type DiffSummary struct {
 	ServicesAdded    int `json:"services_added"`
 	ServicesRemoved  int `json:"services_removed"`
 	ServicesChanged  int `json:"services_changed"`
+	ServicesRenamed  int `json:"services_renamed"`
 	VolumesAdded     int `json:"volumes_added"`
 	VolumesRemoved   int `json:"volumes_removed"`
 	NetworksAdded    int `json:"networks_added"`
@@ -51,12 +91,104 @@ type DiffSummary struct {
 	BreakingCount    int `json:"breaking_count"`
 	WarningCount     int `json:"warning_count"`
 	InfoCount        int `json:"info_count"`
+	RiskScore        int `json:"risk_score"` // weighted by the rules file's risk section
 }
 
 // DiffReport contains the full comparison result
 type DiffReport struct {
-	Summary DiffSummary `json:"summary"`
-	Changes []Change    `json:"changes"`
+	Summary     DiffSummary  `json:"summary"`
+	Changes     []Change     `json:"changes"`
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"` // parts of the inputs that were skipped
+	Freeze      *Freeze      `json:"freeze,omitempty"`      // change freeze in effect, if any
+	Findings    []Finding    `json:"findings,omitempty"`    // observations about the change as a whole
+	Impacts     []Impact     `json:"impacts,omitempty"`     // services downstream of breaking changes
+
+	ServiceHashes map[string]ServiceHash `json:"service_hashes,omitempty"` // by service name
+	Runtime       []ServiceRuntime       `json:"runtime,omitempty"`        // what applying the change does to each service
+	Rollout       []RolloutStep          `json:"rollout,omitempty"`        // restart order of the services in Runtime
+}
+
+// RuntimeEffect is what applying a change does to a running service
+type RuntimeEffect string
+
+const (
+	RuntimeNone     RuntimeEffect = "none"     // metadata such as labels and profiles
+	RuntimeReload   RuntimeEffect = "reload"   // environment, resources or restart policy only
+	RuntimeRecreate RuntimeEffect = "recreate" // the container is replaced
+)
+
+// ServiceRuntime is the strongest runtime effect of a service's changes
+type ServiceRuntime struct {
+	Service string        `json:"service"`
+	Effect  RuntimeEffect `json:"effect"`
+}
+
+// RolloutStep is a group of services docker compose up -d restarts together,
+// after the steps before it
+type RolloutStep struct {
+	Services   []ServiceRuntime `json:"services"`
+	HealthWait string           `json:"health_wait,omitempty"` // longest estimated wait for a healthcheck to pass, e.g. 30s
+}
+
+// ServiceHash fingerprints the normalized configuration of a service in the
+// old and new file; the service needs recreating when they differ. Either is
+// empty when the service is not in that file.
+type ServiceHash struct {
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+}
+
+// Impact is the blast radius of the breaking changes to one service: the
+// services that depend on it, directly or transitively, through depends_on
+type Impact struct {
+	Service    string   `json:"service"`
+	Changes    []string `json:"changes"`    // paths of the breaking changes
+	Dependents []string `json:"dependents"` // sorted, nearest first
+}
+
+// Finding is an observation about a change as a whole rather than a single
+// path, such as a shared value updated in only some services
+type Finding struct {
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Paths    []string `json:"paths,omitempty"` // paths the finding is about
+}
+
+// Freeze is a change freeze window that was in effect when a report was
+// made. Breaking changes fail the diff during a freeze.
+type Freeze struct {
+	Name   string    `json:"name"`
+	Reason string    `json:"reason,omitempty"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+}
+
+// Diagnostic points at a problem in an input file, such as a schema
+// violation or a value that could not be read
+type Diagnostic struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+	Warning bool   `json:"warning,omitempty"` // suspicious but valid
+}
+
+// String formats the diagnostic as file:line:column: path: message, with
+// "warning: " before the message of a warning
+func (d Diagnostic) String() string {
+	pos := fmt.Sprintf("%s:%d", d.File, d.Line)
+	if d.Column > 0 {
+		pos += fmt.Sprintf(":%d", d.Column)
+	}
+	if d.Warning {
+		pos += ": warning"
+	}
+	if d.Path == "" {
+		return fmt.Sprintf("%s: %s", pos, d.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", pos, d.Path, d.Message)
 }
 
 // NewDiffReport creates an empty diff report
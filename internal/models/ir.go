@@ -1,10 +1,15 @@
 package models
 
+import "strings"
+
 // ComposeIR is the canonical intermediate representation of a Docker Compose file
 type ComposeIR struct {
-	Services map[string]ServiceIR `json:"services"`
-	Volumes  map[string]VolumeIR  `json:"volumes"`
-	Networks map[string]NetworkIR `json:"networks"`
+	Services   map[string]ServiceIR `json:"services"`
+	Volumes    map[string]VolumeIR  `json:"volumes"`
+	Networks   map[string]NetworkIR `json:"networks"`
+	Extensions map[string]any       `json:"extensions,omitempty"` // top-level x-* fields
+	Lines      map[string]int       `json:"-"`                    // source line of each YAML path, when parsed from a file
+	Directives Directives           `json:"-"`                    // # compose-diff: comments by YAML path, when parsed from a file
 }
 
 // ServiceIR represents a normalized service configuration
@@ -13,7 +18,10 @@ type ServiceIR struct {
 	Build       *BuildIR       `json:"build,omitempty"`
 	Env         map[string]*string `json:"environment,omitempty"` // nil value means present but empty
 	EnvFiles    []string       `json:"env_file,omitempty"`
+	EnvFilesOptional []string  `json:"env_file_optional,omitempty"` // env_file entries with required: false
+	EnvSources  map[string][]string `json:"-"` // with env_file expanded: where each variable is set, in precedence order; the last wins
 	Ports       []PortIR       `json:"ports,omitempty"`
+	Expose      []string       `json:"expose,omitempty"` // normalized: "port/protocol"
 	Volumes     []MountIR      `json:"volumes,omitempty"`
 	Networks    []string       `json:"networks,omitempty"`
 	DependsOn   []string       `json:"depends_on,omitempty"`
@@ -23,6 +31,84 @@ type ServiceIR struct {
 	Profiles    []string       `json:"profiles,omitempty"`
 	Restart     *string        `json:"restart,omitempty"`
 	Labels      map[string]string `json:"labels,omitempty"`
+	StopGracePeriod *string     `json:"stop_grace_period,omitempty"`
+	StopSignal      *string     `json:"stop_signal,omitempty"`
+	Deploy          *DeployIR   `json:"deploy,omitempty"`
+	MemLimit        int64       `json:"mem_limit,omitempty"`       // bytes
+	MemReservation  int64       `json:"mem_reservation,omitempty"` // bytes
+	CPUs            float64     `json:"cpus,omitempty"`
+	CPUShares       int64       `json:"cpu_shares,omitempty"`
+	Security        *SecurityIR `json:"security,omitempty"`
+	User            *string     `json:"user,omitempty"`
+	WorkingDir      *string     `json:"working_dir,omitempty"`
+	Init            *bool       `json:"init,omitempty"`
+	Hostname        *string     `json:"hostname,omitempty"`
+	Domainname      *string     `json:"domainname,omitempty"`
+	ContainerName   *string     `json:"container_name,omitempty"`
+	Devices         []DeviceIR  `json:"devices,omitempty"`
+	DeviceCgroupRules []string  `json:"device_cgroup_rules,omitempty"`
+	ExtraHosts      map[string]string `json:"extra_hosts,omitempty"` // hostname -> IPs, comma-separated and sorted
+	DNS             []string    `json:"dns,omitempty"`
+	DNSSearch       []string    `json:"dns_search,omitempty"`
+	DNSOpt          []string    `json:"dns_opt,omitempty"`
+	Tmpfs           []string    `json:"tmpfs,omitempty"`
+	ShmSize         int64       `json:"shm_size,omitempty"` // bytes
+	IPC             *string     `json:"ipc,omitempty"`
+	PID             *string     `json:"pid,omitempty"`
+	NetworkMode     *string     `json:"network_mode,omitempty"`
+	Extensions      map[string]any `json:"extensions,omitempty"` // x-* fields
+	Unparsed        map[string]string `json:"unparsed,omitempty"` // values that are not a valid size, number or boolean, such as ${VAR}, by path below the service; compared as text
+}
+
+// SecurityIR groups the settings that define a container's security posture
+type SecurityIR struct {
+	Privileged  bool     `json:"privileged,omitempty"`
+	CapAdd      []string `json:"cap_add,omitempty"`  // normalized: upper case, no CAP_ prefix
+	CapDrop     []string `json:"cap_drop,omitempty"` // normalized: upper case, no CAP_ prefix
+	SecurityOpt []string `json:"security_opt,omitempty"`
+}
+
+// DeployIR represents the deploy section of a service
+type DeployIR struct {
+	RestartPolicy *RestartPolicyIR `json:"restart_policy,omitempty"`
+	Resources     *ResourcesIR     `json:"resources,omitempty"`
+}
+
+// ResourcesIR represents deploy.resources
+type ResourcesIR struct {
+	Limits       *ResourceSpecIR `json:"limits,omitempty"`
+	Reservations *ResourceSpecIR `json:"reservations,omitempty"`
+}
+
+// ResourceSpecIR is a normalized set of resource limits or reservations
+type ResourceSpecIR struct {
+	CPUs   float64 `json:"cpus,omitempty"`
+	Memory int64   `json:"memory,omitempty"` // bytes
+	Pids   int64   `json:"pids,omitempty"`
+	Devices []DeviceRequestIR `json:"devices,omitempty"`
+}
+
+// DeviceIR represents a host device mapped into the container
+type DeviceIR struct {
+	Source      string `json:"source"`
+	Target      string `json:"target"`
+	Permissions string `json:"permissions,omitempty"` // cgroup permissions, e.g. rwm
+}
+
+// DeviceRequestIR represents a device reservation such as a GPU request
+type DeviceRequestIR struct {
+	Driver       string   `json:"driver,omitempty"`
+	Capabilities []string `json:"capabilities"`         // sorted
+	Count        string   `json:"count,omitempty"`      // a number or "all"
+	DeviceIDs    []string `json:"device_ids,omitempty"` // sorted
+}
+
+// RestartPolicyIR represents deploy.restart_policy
+type RestartPolicyIR struct {
+	Condition   string `json:"condition,omitempty"` // none, on-failure, any
+	Delay       string `json:"delay,omitempty"`
+	MaxAttempts int    `json:"max_attempts,omitempty"`
+	Window      string `json:"window,omitempty"`
 }
 
 // BuildIR represents a build configuration
@@ -31,6 +117,11 @@ type BuildIR struct {
 	Dockerfile string            `json:"dockerfile,omitempty"`
 	Args       map[string]string `json:"args,omitempty"`
 	Target     string            `json:"target,omitempty"`
+	CacheFrom  []string          `json:"cache_from,omitempty"`
+	Platforms  []string          `json:"platforms,omitempty"`
+	SSH        []string          `json:"ssh,omitempty"`     // "id" or "id=path"
+	Secrets    []string          `json:"secrets,omitempty"` // secret names
+	Labels     map[string]string `json:"labels,omitempty"`
 }
 
 // PortIR represents a normalized port mapping
@@ -47,6 +138,15 @@ type MountIR struct {
 	Source   string `json:"source,omitempty"`
 	Target   string `json:"target"`
 	ReadOnly bool   `json:"read_only,omitempty"`
+
+	Consistency   string `json:"consistency,omitempty"`     // cached, delegated, consistent
+	Propagation   string `json:"propagation,omitempty"`     // bind: shared, rslave, private, ...
+	SELinux       string `json:"selinux,omitempty"`         // bind: z or Z
+	NoCopy        bool   `json:"nocopy,omitempty"`          // volume: don't copy image data into a new volume
+	Subpath       string `json:"subpath,omitempty"`         // volume: mount a sub-directory
+	TmpfsSize     int64  `json:"tmpfs_size,omitempty"`      // bytes
+	TmpfsSizeText string `json:"tmpfs_size_text,omitempty"` // size as written when it is not one, such as ${TMPFS_SIZE}
+	TmpfsMode     string `json:"tmpfs_mode,omitempty"`      // octal file mode
 }
 
 // HealthcheckIR represents a healthcheck configuration
@@ -75,6 +175,21 @@ type NetworkIR struct {
 	External   bool              `json:"external,omitempty"`
 	Name       string            `json:"name,omitempty"`
 	Labels     map[string]string `json:"labels,omitempty"`
+	IPAM       *IPAMIR           `json:"ipam,omitempty"`
+}
+
+// IPAMIR represents a network's IP address management configuration
+type IPAMIR struct {
+	Driver string       `json:"driver,omitempty"`
+	Config []IPAMPoolIR `json:"config,omitempty"` // sorted by subnet
+}
+
+// IPAMPoolIR is one address pool of a network
+type IPAMPoolIR struct {
+	Subnet       string            `json:"subnet,omitempty"`
+	Gateway      string            `json:"gateway,omitempty"`
+	IPRange      string            `json:"ip_range,omitempty"`
+	AuxAddresses map[string]string `json:"aux_addresses,omitempty"`
 }
 
 // NewComposeIR creates an empty ComposeIR with initialized maps
@@ -85,3 +200,49 @@ func NewComposeIR() *ComposeIR {
 		Networks: make(map[string]NetworkIR),
 	}
 }
+
+// Directive is a "# compose-diff:" comment on a key, with which the author
+// of a change acknowledges it: ignore, or severity=info, with an optional
+// reason=... that runs to the end of the comment
+type Directive struct {
+	Ignore   bool
+	Severity Severity
+	Reason   string
+	Line     int
+}
+
+// Directives maps YAML paths to the directive written on them
+type Directives map[string]Directive
+
+// For returns the directive on a change path or its nearest enclosing key,
+// so a comment on environment covers every variable in it
+func (d Directives) For(path string) (Directive, bool) {
+	for path != "" {
+		if directive, ok := d[path]; ok {
+			return directive, true
+		}
+		i := strings.LastIndex(path, ".")
+		if i < 0 {
+			break
+		}
+		path = path[:i]
+	}
+	return Directive{}, false
+}
+
+// Line returns the source line of a change path, falling back to the nearest
+// enclosing key the file has (e.g. the environment block for a variable that
+// is only in the other file). It returns 0 when positions are unknown.
+func (ir *ComposeIR) Line(path string) int {
+	for path != "" {
+		if line, ok := ir.Lines[path]; ok {
+			return line
+		}
+		i := strings.LastIndex(path, ".")
+		if i < 0 {
+			break
+		}
+		path = path[:i]
+	}
+	return 0
+}
@@ -0,0 +1,15 @@
+package models
+
+// IRSchemaVersion is the version of the ComposeIR JSON representation.
+// Bump it whenever a change would make older documents decode differently,
+// and register a migration in the parser package.
+//
+//	1: initial representation
+//	2: port ranges expanded into one PortIR per port
+const IRSchemaVersion = 2
+
+// VersionedIR is the serialized form of a ComposeIR
+type VersionedIR struct {
+	SchemaVersion int `json:"schema_version"`
+	ComposeIR
+}
@@ -0,0 +1,117 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ParseNode parses a YAML document into a node tree with aliases and merge
+// keys expanded, keeping the line and column of every value
+func ParseNode(data []byte) (*yaml.Node, error) {
+	return decodeNode(bytes.NewReader(data))
+}
+
+// decodeNode is ParseNode for a reader. The decoder reads tokens as it goes,
+// so a large file is never held in memory next to its node tree.
+func decodeNode(r io.Reader) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	expandAliases(&doc)
+	return &doc, nil
+}
+
+// expandAliases resolves aliases and merge keys (<<:) throughout a document
+// in place, so fields decoded as yaml.Node see the same content whether a
+// file spells values out or shares them through anchors
+func expandAliases(n *yaml.Node) {
+	recursive := make(map[*yaml.Node]bool)
+	findRecursiveAnchors(n, nil, recursive)
+	expandNode(n, recursive, 0)
+}
+
+// maxAliasDepth guards against alias chains that refer back to themselves
+const maxAliasDepth = 100
+
+// findRecursiveAnchors marks anchored nodes that contain an alias to
+// themselves (a: &a {b: *a}). Aliases to them are left alone, so decoding
+// reports the loop instead of recursing forever.
+func findRecursiveAnchors(n *yaml.Node, open []*yaml.Node, recursive map[*yaml.Node]bool) {
+	if n.Kind == yaml.AliasNode {
+		for _, anchor := range open {
+			if anchor == n.Alias {
+				recursive[anchor] = true
+			}
+		}
+		return
+	}
+	if n.Anchor != "" {
+		open = append(open, n)
+	}
+	for _, child := range n.Content {
+		findRecursiveAnchors(child, open, recursive)
+	}
+}
+
+func expandNode(n *yaml.Node, recursive map[*yaml.Node]bool, depth int) {
+	if depth > maxAliasDepth {
+		return
+	}
+	for n.Kind == yaml.AliasNode && n.Alias != nil && !recursive[n.Alias] {
+		*n = *n.Alias
+	}
+
+	if n.Kind == yaml.MappingNode {
+		n.Content = mergeKeys(n.Content, recursive, depth)
+	}
+	for _, child := range n.Content {
+		expandNode(child, recursive, depth+1)
+	}
+}
+
+// mergeKeys replaces merge keys in a mapping's key/value pairs with the
+// merged mappings' pairs. Explicit keys win over merged ones, and earlier
+// mappings in a merge list win over later ones.
+func mergeKeys(content []*yaml.Node, recursive map[*yaml.Node]bool, depth int) []*yaml.Node {
+	var explicit, merged []*yaml.Node
+	for i := 0; i+1 < len(content); i += 2 {
+		key, value := content[i], content[i+1]
+		if key.Kind != yaml.ScalarNode || key.Value != "<<" || (key.Tag != "!!merge" && key.Tag != "") {
+			explicit = append(explicit, key, value)
+			continue
+		}
+
+		expandNode(value, recursive, depth+1)
+		sources := []*yaml.Node{value}
+		if value.Kind == yaml.SequenceNode {
+			sources = value.Content
+		}
+		for _, src := range sources {
+			expandNode(src, recursive, depth+1)
+			if src.Kind == yaml.MappingNode {
+				merged = append(merged, src.Content...)
+			}
+		}
+	}
+	if merged == nil {
+		return content
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(explicit); i += 2 {
+		seen[explicit[i].Value] = true
+	}
+	result := explicit
+	for i := 0; i+1 < len(merged); i += 2 {
+		if !seen[merged[i].Value] {
+			seen[merged[i].Value] = true
+			result = append(result, merged[i], merged[i+1])
+		}
+	}
+	return result
+}
@@ -0,0 +1,174 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// yamlBool is a boolean field that also takes the quoted and YAML 1.1
+// spellings docker compose accepts, such as "true" or yes
+type yamlBool bool
+
+func (b *yamlBool) UnmarshalYAML(node *yaml.Node) error {
+	v, ok := parseBool(node.Value)
+	if node.Kind != yaml.ScalarNode || !ok {
+		return fmt.Errorf("line %d: cannot use %q as a boolean", node.Line, node.Value)
+	}
+	*b = yamlBool(v)
+	return nil
+}
+
+// parseBool parses the spellings of a compose boolean
+func parseBool(s string) (bool, bool) {
+	switch strings.ToLower(s) {
+	case "true", "yes", "y", "on":
+		return true, true
+	case "false", "no", "n", "off":
+		return false, true
+	}
+	return false, false
+}
+
+// canonicalizeService rewrites values that have several equivalent spellings
+// to one, so 30s and 0.5m, or a shell-form command and a list with the same
+// argv, compare equal. Byte sizes are already bytes and ports strings after
+// parsing.
+func canonicalizeService(svc models.ServiceIR) models.ServiceIR {
+	if svc.StopGracePeriod != nil {
+		grace := canonicalDuration(*svc.StopGracePeriod)
+		svc.StopGracePeriod = &grace
+	}
+	if svc.Healthcheck != nil {
+		hc := *svc.Healthcheck
+		hc.Interval = canonicalDuration(hc.Interval)
+		hc.Timeout = canonicalDuration(hc.Timeout)
+		hc.StartPeriod = canonicalDuration(hc.StartPeriod)
+		svc.Healthcheck = &hc
+	}
+	if svc.Deploy != nil && svc.Deploy.RestartPolicy != nil {
+		deploy := *svc.Deploy
+		policy := *deploy.RestartPolicy
+		policy.Delay = canonicalDuration(policy.Delay)
+		policy.Window = canonicalDuration(policy.Window)
+		deploy.RestartPolicy = &policy
+		svc.Deploy = &deploy
+	}
+	svc.Command = commandArgv(svc.Command)
+	svc.Entrypoint = commandArgv(svc.Entrypoint)
+	svc.Extensions = canonicalExtensions(svc.Extensions)
+	return svc
+}
+
+// canonicalDuration writes a duration the way Go prints it, without zero
+// trailing units: 0.5m and 30s become 30s, 90s becomes 1m30s, 60m becomes 1h.
+// Values that are not durations are left alone.
+func canonicalDuration(s string) string {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return s
+	}
+	out := d.String()
+	if strings.HasSuffix(out, "m0s") {
+		out = strings.TrimSuffix(out, "0s")
+	}
+	if strings.HasSuffix(out, "h0m") {
+		out = strings.TrimSuffix(out, "0m")
+	}
+	return out
+}
+
+// commandArgv splits a shell-form command into the argv docker compose runs,
+// so "npm run start" equals [npm, run, start]. The parser keeps a string
+// command as a one-item list; one that does not split cleanly, such as an
+// unterminated quote, is left as it is.
+func commandArgv(command []string) []string {
+	if len(command) != 1 {
+		return command
+	}
+	words, ok := shellWords(command[0])
+	if !ok || len(words) == 0 {
+		return command
+	}
+	return words
+}
+
+// shellWords splits s into words like a POSIX shell, honoring single and
+// double quotes and backslash escapes, without expanding anything
+func shellWords(s string) ([]string, bool) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			if quote == '"' && !strings.ContainsRune(`"\$`+"`", r) {
+				word.WriteRune('\\')
+			}
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, false
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, true
+}
+
+// canonicalExtensions turns the strings "true" and "false" in x-* values into
+// booleans, since x-flag: "true" and x-flag: true say the same
+func canonicalExtensions(ext map[string]any) map[string]any {
+	if ext == nil {
+		return nil
+	}
+	out := make(map[string]any, len(ext))
+	for k, v := range ext {
+		out[k] = canonicalScalar(v)
+	}
+	return out
+}
+
+func canonicalScalar(v any) any {
+	switch v := v.(type) {
+	case string:
+		if v == "true" || v == "false" {
+			return v == "true"
+		}
+	case map[string]any:
+		return canonicalExtensions(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = canonicalScalar(item)
+		}
+		return out
+	}
+	return v
+}
@@ -0,0 +1,117 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestCanonicalDuration(t *testing.T) {
+	for in, want := range map[string]string{
+		"30s":    "30s",
+		"0.5m":   "30s",
+		"90s":    "1m30s",
+		"60m":    "1h",
+		"1h30m":  "1h30m",
+		"1500ms": "1.5s",
+		"":       "",
+		"soon":   "soon",
+	} {
+		if got := canonicalDuration(in); got != want {
+			t.Errorf("canonicalDuration(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestShellWords(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+		ok   bool
+	}{
+		{"npm run start", []string{"npm", "run", "start"}, true},
+		{`sh -c "echo \"hi\" && sleep 1"`, []string{"sh", "-c", `echo "hi" && sleep 1`}, true},
+		{`echo 'a  b' c\ d`, []string{"echo", "a  b", "c d"}, true},
+		{`echo ""`, []string{"echo", ""}, true},
+		{`echo "unterminated`, nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := shellWords(tt.in)
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("shellWords(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestNormalizeEquivalentValues(t *testing.T) {
+	old, err := parseContent(t, `
+services:
+  api:
+    image: api
+    init: "true"
+    command: npm run start
+    stop_grace_period: 0.5m
+    healthcheck:
+      test: ["CMD", "true"]
+      interval: 90s
+    x-managed: "true"
+`)
+	if err != nil {
+		t.Fatalf("ParseComposeFile failed: %v", err)
+	}
+	new, err := parseContent(t, `
+services:
+  api:
+    image: api
+    init: true
+    command: ["npm", "run", "start"]
+    stop_grace_period: 30s
+    healthcheck:
+      test: ["CMD", "true"]
+      interval: 1m30s
+    x-managed: true
+`)
+	if err != nil {
+		t.Fatalf("ParseComposeFile failed: %v", err)
+	}
+
+	a, b := Normalize(old).Services["api"], Normalize(new).Services["api"]
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("Expected equivalent services to normalize alike:\n%+v\n%+v", a, b)
+	}
+}
+
+func TestQuotedBooleanInvalid(t *testing.T) {
+	_, err := parseContent(t, "services:\n  api:\n    image: api\n    volumes:\n      - {type: bind, source: ., target: /app, read_only: maybe}\n")
+	if err == nil || !strings.Contains(err.Error(), "boolean") {
+		t.Errorf("Expected a boolean error, got %v", err)
+	}
+
+	// privileged and init may be interpolated, so a value that is not a boolean is
+	// kept as text
+	ir, err := parseContent(t, "services:\n  api:\n    image: api\n    privileged: ${PRIVILEGED:-false}\n    init: ${INIT}\n")
+	if err != nil {
+		t.Fatalf("ParseComposeFile failed: %v", err)
+	}
+	api := ir.Services["api"]
+	if got := api.Unparsed["privileged"]; got != "${PRIVILEGED:-false}" {
+		t.Errorf("Expected privileged kept as text, got %q", got)
+	}
+	if got := api.Unparsed["init"]; got != "${INIT}" || api.Init != nil {
+		t.Errorf("Expected init kept as text, got %q and %v", got, api.Init)
+	}
+}
+
+// parseContent parses compose content written to a temporary file
+func parseContent(t *testing.T, content string) (*models.ComposeIR, error) {
+	t.Helper()
+	composePath := filepath.Join(t.TempDir(), "docker-compose.yml")
+	if err := os.WriteFile(composePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	return ParseComposeFile(composePath)
+}
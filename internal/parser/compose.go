@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/units"
 	"gopkg.in/yaml.v3"
 )
 
@@ -16,6 +19,7 @@ type RawComposeFile struct {
 	Services map[string]RawService         `yaml:"services"`
 	Volumes  map[string]yaml.Node          `yaml:"volumes,omitempty"`
 	Networks map[string]yaml.Node          `yaml:"networks,omitempty"`
+	Extra    map[string]any                `yaml:",inline"` // x-* extensions and unmodeled keys
 }
 
 // RawService represents a raw service from YAML
@@ -25,6 +29,7 @@ type RawService struct {
 	Environment yaml.Node              `yaml:"environment,omitempty"`
 	EnvFile     yaml.Node              `yaml:"env_file,omitempty"`
 	Ports       yaml.Node              `yaml:"ports,omitempty"`
+	Expose      yaml.Node              `yaml:"expose,omitempty"`
 	Volumes     yaml.Node              `yaml:"volumes,omitempty"`
 	Networks    yaml.Node              `yaml:"networks,omitempty"`
 	DependsOn   yaml.Node              `yaml:"depends_on,omitempty"`
@@ -34,31 +39,86 @@ type RawService struct {
 	Profiles    []string               `yaml:"profiles,omitempty"`
 	Restart     string                 `yaml:"restart,omitempty"`
 	Labels      yaml.Node              `yaml:"labels,omitempty"`
+	StopGracePeriod string             `yaml:"stop_grace_period,omitempty"`
+	StopSignal      string             `yaml:"stop_signal,omitempty"`
+	Deploy          yaml.Node          `yaml:"deploy,omitempty"`
+	MemLimit        string             `yaml:"mem_limit,omitempty"`
+	MemReservation  string             `yaml:"mem_reservation,omitempty"`
+	CPUs            string             `yaml:"cpus,omitempty"`
+	CPUShares       string             `yaml:"cpu_shares,omitempty"`
+	Privileged      string             `yaml:"privileged,omitempty"`
+	CapAdd          []string           `yaml:"cap_add,omitempty"`
+	CapDrop         []string           `yaml:"cap_drop,omitempty"`
+	SecurityOpt     []string           `yaml:"security_opt,omitempty"`
+	User            string             `yaml:"user,omitempty"`
+	WorkingDir      string             `yaml:"working_dir,omitempty"`
+	Init            string             `yaml:"init,omitempty"`
+	Hostname        string             `yaml:"hostname,omitempty"`
+	Domainname      string             `yaml:"domainname,omitempty"`
+	ContainerName   string             `yaml:"container_name,omitempty"`
+	Devices         yaml.Node          `yaml:"devices,omitempty"`
+	DeviceCgroupRules []string         `yaml:"device_cgroup_rules,omitempty"`
+	ExtraHosts      yaml.Node          `yaml:"extra_hosts,omitempty"`
+	DNS             yaml.Node          `yaml:"dns,omitempty"`
+	DNSSearch       yaml.Node          `yaml:"dns_search,omitempty"`
+	DNSOpt          []string           `yaml:"dns_opt,omitempty"`
+	Tmpfs           yaml.Node          `yaml:"tmpfs,omitempty"`
+	ShmSize         string             `yaml:"shm_size,omitempty"`
+	IPC             string             `yaml:"ipc,omitempty"`
+	PID             string             `yaml:"pid,omitempty"`
+	NetworkMode     string             `yaml:"network_mode,omitempty"`
+	Extra           map[string]any     `yaml:",inline"` // x-* extensions and unmodeled keys
 }
 
 // ParseComposeFile parses a Docker Compose file into the intermediate representation
 func ParseComposeFile(filePath string) (*models.ComposeIR, error) {
+	doc, err := loadDocument(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return parseDocument(doc)
+}
+
+// loadDocument reads a compose file (or a directory containing one) into a
+// node tree with aliases expanded
+func loadDocument(filePath string) (*yaml.Node, error) {
 	// Handle auto-detection of compose file
-	actualPath, err := resolveComposePath(filePath)
+	actualPath, err := ResolveComposePath(filePath)
 	if err != nil {
 		return nil, err
 	}
 
-	data, err := os.ReadFile(actualPath)
+	f, err := os.Open(actualPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
+	defer f.Close()
+
+	return decodeNode(f)
+}
 
+// parseDocument converts a compose node tree to the IR
+func parseDocument(doc *yaml.Node) (*models.ComposeIR, error) {
 	var raw RawComposeFile
-	if err := yaml.Unmarshal(data, &raw); err != nil {
+	if err := doc.Decode(&raw); err != nil {
 		return nil, fmt.Errorf("failed to parse YAML: %w", err)
 	}
 
-	return convertToIR(&raw)
+	ir, err := convertToIR(&raw)
+	if err != nil {
+		return nil, err
+	}
+	ir.Lines = lineIndex(doc)
+	directives, diags := directiveIndex(doc)
+	if len(diags) > 0 {
+		return nil, fmt.Errorf("line %d: %s", diags[0].Line, diags[0].Message)
+	}
+	ir.Directives = directives
+	return ir, nil
 }
 
-// resolveComposePath finds the actual compose file, supporting auto-detection
-func resolveComposePath(path string) (string, error) {
+// ResolveComposePath finds the actual compose file, supporting auto-detection
+func ResolveComposePath(path string) (string, error) {
 	// If it's a directory, look for compose files
 	info, err := os.Stat(path)
 	if err == nil && info.IsDir() {
@@ -99,6 +159,8 @@ func convertToIR(raw *RawComposeFile) (*models.ComposeIR, error) {
 		ir.Services[name] = *svc
 	}
 
+	ir.Extensions = extensions(raw.Extra)
+
 	// Convert volumes
 	for name, node := range raw.Volumes {
 		nodeCopy := node // Create local copy
@@ -122,9 +184,24 @@ func convertToIR(raw *RawComposeFile) (*models.ComposeIR, error) {
 	return ir, nil
 }
 
+// extensions returns the x-* keys of a mapping, or nil if it has none
+func extensions(extra map[string]any) map[string]any {
+	var result map[string]any
+	for key, value := range extra {
+		if strings.HasPrefix(key, "x-") {
+			if result == nil {
+				result = make(map[string]any)
+			}
+			result[key] = value
+		}
+	}
+	return result
+}
+
 // convertService converts a raw service to ServiceIR
 func convertService(raw *RawService) (*models.ServiceIR, error) {
-	svc := &models.ServiceIR{}
+	svc := &models.ServiceIR{Extensions: extensions(raw.Extra)}
+	u := make(unparsed)
 
 	// Image
 	if raw.Image != "" {
@@ -152,11 +229,12 @@ func convertService(raw *RawService) (*models.ServiceIR, error) {
 
 	// Env files
 	if raw.EnvFile.Kind != 0 {
-		files, err := parseStringOrList(&raw.EnvFile)
+		files, optional, err := parseEnvFiles(&raw.EnvFile)
 		if err != nil {
 			return nil, err
 		}
 		svc.EnvFiles = files
+		svc.EnvFilesOptional = optional
 	}
 
 	// Ports
@@ -168,6 +246,15 @@ func convertService(raw *RawService) (*models.ServiceIR, error) {
 		svc.Ports = ports
 	}
 
+	// Exposed ports
+	if raw.Expose.Kind != 0 {
+		expose, err := parseStringOrList(&raw.Expose)
+		if err != nil {
+			return nil, err
+		}
+		svc.Expose = normalizeExpose(expose)
+	}
+
 	// Volumes
 	if raw.Volumes.Kind != 0 {
 		volumes, err := parseVolumes(&raw.Volumes)
@@ -240,9 +327,292 @@ func convertService(raw *RawService) (*models.ServiceIR, error) {
 		svc.Labels = labels
 	}
 
+	// Stop grace period and signal
+	if raw.StopGracePeriod != "" {
+		grace := raw.StopGracePeriod // Create local copy
+		svc.StopGracePeriod = &grace
+	}
+	if raw.StopSignal != "" {
+		signal := raw.StopSignal // Create local copy
+		svc.StopSignal = &signal
+	}
+
+	// Deploy
+	if raw.Deploy.Kind != 0 {
+		deploy, err := parseDeploy(&raw.Deploy, u)
+		if err != nil {
+			return nil, err
+		}
+		svc.Deploy = deploy
+	}
+
+	// Legacy resource settings, normalized to bytes and CPU counts
+	svc.MemLimit = u.size("mem_limit", raw.MemLimit)
+	svc.MemReservation = u.size("mem_reservation", raw.MemReservation)
+	svc.CPUs = u.number("cpus", raw.CPUs)
+	svc.CPUShares = u.integer("cpu_shares", raw.CPUShares)
+
+	// Security
+	privileged := u.boolean("privileged", raw.Privileged)
+	if privileged || len(raw.CapAdd) > 0 || len(raw.CapDrop) > 0 || len(raw.SecurityOpt) > 0 {
+		svc.Security = &models.SecurityIR{
+			Privileged:  privileged,
+			CapAdd:      normalizeCapabilities(raw.CapAdd),
+			CapDrop:     normalizeCapabilities(raw.CapDrop),
+			SecurityOpt: raw.SecurityOpt,
+		}
+	}
+
+	// Process identity and container naming
+	svc.User = optionalString(raw.User)
+	svc.WorkingDir = optionalString(raw.WorkingDir)
+	if raw.Init != "" {
+		runInit := u.boolean("init", raw.Init)
+		if _, ok := u["init"]; !ok {
+			svc.Init = &runInit
+		}
+	}
+	svc.Hostname = optionalString(raw.Hostname)
+	svc.Domainname = optionalString(raw.Domainname)
+	svc.ContainerName = optionalString(raw.ContainerName)
+
+	// Devices
+	if raw.Devices.Kind != 0 {
+		devices, err := parseDevices(&raw.Devices)
+		if err != nil {
+			return nil, err
+		}
+		svc.Devices = devices
+	}
+	svc.DeviceCgroupRules = raw.DeviceCgroupRules
+
+	// Name resolution
+	if raw.ExtraHosts.Kind != 0 {
+		hosts, err := parseExtraHosts(&raw.ExtraHosts)
+		if err != nil {
+			return nil, err
+		}
+		svc.ExtraHosts = hosts
+	}
+	if raw.DNS.Kind != 0 {
+		dns, err := parseStringOrList(&raw.DNS)
+		if err != nil {
+			return nil, err
+		}
+		svc.DNS = dns
+	}
+	if raw.DNSSearch.Kind != 0 {
+		search, err := parseStringOrList(&raw.DNSSearch)
+		if err != nil {
+			return nil, err
+		}
+		svc.DNSSearch = search
+	}
+	svc.DNSOpt = raw.DNSOpt
+
+	// Runtime namespaces and in-memory filesystems
+	if raw.Tmpfs.Kind != 0 {
+		tmpfs, err := parseStringOrList(&raw.Tmpfs)
+		if err != nil {
+			return nil, err
+		}
+		svc.Tmpfs = tmpfs
+	}
+	svc.ShmSize = u.size("shm_size", raw.ShmSize)
+	svc.IPC = optionalString(raw.IPC)
+	svc.PID = optionalString(raw.PID)
+	svc.NetworkMode = optionalString(raw.NetworkMode)
+
+	svc.Unparsed = u.result()
 	return svc, nil
 }
 
+// parseExtraHosts parses extra_hosts ("host:ip" or "host=ip" list, or map
+// form) into hostname -> sorted, comma-separated IPs
+func parseExtraHosts(node *yaml.Node) (map[string]string, error) {
+	ips := make(map[string][]string)
+
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			// Hostnames can't contain ':' or '=', but IPv6 addresses can contain ':'
+			host, ip, found := strings.Cut(item.Value, "=")
+			if !found {
+				host, ip, found = strings.Cut(item.Value, ":")
+			}
+			if !found {
+				return nil, fmt.Errorf("invalid extra_hosts entry %q", item.Value)
+			}
+			ips[host] = append(ips[host], strings.Trim(ip, "[]"))
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			host := node.Content[i].Value
+			value := node.Content[i+1]
+			if value.Kind == yaml.SequenceNode {
+				for _, ip := range value.Content {
+					ips[host] = append(ips[host], ip.Value)
+				}
+			} else {
+				ips[host] = append(ips[host], value.Value)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("extra_hosts must be a list or map")
+	}
+
+	hosts := make(map[string]string, len(ips))
+	for host, list := range ips {
+		sort.Strings(list)
+		hosts[host] = strings.Join(list, ",")
+	}
+	return hosts, nil
+}
+
+// parseDevices parses the devices list (short "host[:container[:perms]]" or long syntax)
+func parseDevices(node *yaml.Node) ([]models.DeviceIR, error) {
+	if node.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("devices must be a list")
+	}
+
+	var devices []models.DeviceIR
+	for _, item := range node.Content {
+		var dev models.DeviceIR
+		switch item.Kind {
+		case yaml.ScalarNode:
+			parts := strings.SplitN(item.Value, ":", 3)
+			dev.Source = parts[0]
+			dev.Target = parts[0]
+			if len(parts) > 1 && parts[1] != "" {
+				dev.Target = parts[1]
+			}
+			if len(parts) > 2 {
+				dev.Permissions = parts[2]
+			}
+		case yaml.MappingNode:
+			var raw struct {
+				Source      string `yaml:"source"`
+				Target      string `yaml:"target"`
+				Permissions string `yaml:"permissions"`
+			}
+			if err := item.Decode(&raw); err != nil {
+				return nil, err
+			}
+			dev = models.DeviceIR{Source: raw.Source, Target: raw.Target, Permissions: raw.Permissions}
+			if dev.Target == "" {
+				dev.Target = dev.Source
+			}
+		default:
+			return nil, fmt.Errorf("invalid device entry")
+		}
+		devices = append(devices, dev)
+	}
+
+	return devices, nil
+}
+
+// optionalString returns a pointer to s, or nil if it is empty
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// normalizeCapabilities upper-cases capability names and strips the CAP_
+// prefix, since compose accepts both SYS_ADMIN and CAP_SYS_ADMIN
+func normalizeCapabilities(caps []string) []string {
+	if len(caps) == 0 {
+		return nil
+	}
+	result := make([]string, 0, len(caps))
+	for _, c := range caps {
+		result = append(result, strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(c)), "CAP_"))
+	}
+	return result
+}
+
+// parseDeploy parses the deploy section, keeping values it cannot convert
+// in u
+func parseDeploy(node *yaml.Node, u unparsed) (*models.DeployIR, error) {
+	deploy := &models.DeployIR{}
+
+	if node.Kind != yaml.MappingNode {
+		return deploy, nil
+	}
+
+	var raw struct {
+		RestartPolicy *struct {
+			Condition   string `yaml:"condition"`
+			Delay       string `yaml:"delay"`
+			MaxAttempts string `yaml:"max_attempts"`
+			Window      string `yaml:"window"`
+		} `yaml:"restart_policy"`
+		Resources struct {
+			Limits       *rawResourceSpec `yaml:"limits"`
+			Reservations *rawResourceSpec `yaml:"reservations"`
+		} `yaml:"resources"`
+	}
+	if err := node.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	if raw.Resources.Limits != nil || raw.Resources.Reservations != nil {
+		deploy.Resources = &models.ResourcesIR{
+			Limits:       convertResourceSpec(raw.Resources.Limits, "deploy.resources.limits", u),
+			Reservations: convertResourceSpec(raw.Resources.Reservations, "deploy.resources.reservations", u),
+		}
+	}
+
+	if raw.RestartPolicy != nil {
+		deploy.RestartPolicy = &models.RestartPolicyIR{
+			Condition:   raw.RestartPolicy.Condition,
+			Delay:       raw.RestartPolicy.Delay,
+			MaxAttempts: int(u.integer("deploy.restart_policy.max_attempts", raw.RestartPolicy.MaxAttempts)),
+			Window:      raw.RestartPolicy.Window,
+		}
+	}
+
+	return deploy, nil
+}
+
+// rawResourceSpec is deploy.resources.limits or .reservations as written
+type rawResourceSpec struct {
+	CPUs   string `yaml:"cpus"`
+	Memory string `yaml:"memory"`
+	Pids   string `yaml:"pids"`
+	Devices []struct {
+		Driver       string    `yaml:"driver"`
+		Count        yaml.Node `yaml:"count"`
+		DeviceIDs    []string  `yaml:"device_ids"`
+		Capabilities []string  `yaml:"capabilities"`
+	} `yaml:"devices"`
+}
+
+// convertResourceSpec normalizes memory to bytes and cpus to a number,
+// keeping values it cannot convert in u under path
+func convertResourceSpec(raw *rawResourceSpec, path string, u unparsed) *models.ResourceSpecIR {
+	if raw == nil {
+		return nil
+	}
+
+	spec := &models.ResourceSpecIR{
+		CPUs:   u.number(path+".cpus", raw.CPUs),
+		Memory: u.size(path+".memory", raw.Memory),
+		Pids:   u.integer(path+".pids", raw.Pids),
+	}
+	for _, d := range raw.Devices {
+		spec.Devices = append(spec.Devices, models.DeviceRequestIR{
+			Driver:       d.Driver,
+			Capabilities: d.Capabilities,
+			Count:        d.Count.Value,
+			DeviceIDs:    d.DeviceIDs,
+		})
+	}
+
+	return spec
+}
+
 // parseBuild parses the build configuration
 func parseBuild(node *yaml.Node) (*models.BuildIR, error) {
 	build := &models.BuildIR{}
@@ -256,23 +626,88 @@ func parseBuild(node *yaml.Node) (*models.BuildIR, error) {
 	// Map form
 	if node.Kind == yaml.MappingNode {
 		var raw struct {
-			Context    string            `yaml:"context"`
-			Dockerfile string            `yaml:"dockerfile"`
-			Args       map[string]string `yaml:"args"`
-			Target     string            `yaml:"target"`
+			Context    string    `yaml:"context"`
+			Dockerfile string    `yaml:"dockerfile"`
+			Args       yaml.Node `yaml:"args"`
+			Target     string    `yaml:"target"`
+			CacheFrom  []string  `yaml:"cache_from"`
+			Platforms  []string  `yaml:"platforms"`
+			SSH        yaml.Node `yaml:"ssh"`
+			Secrets    yaml.Node `yaml:"secrets"`
+			Labels     yaml.Node `yaml:"labels"`
 		}
 		if err := node.Decode(&raw); err != nil {
 			return nil, err
 		}
 		build.Context = raw.Context
 		build.Dockerfile = raw.Dockerfile
-		build.Args = raw.Args
 		build.Target = raw.Target
+		build.CacheFrom = raw.CacheFrom
+		build.Platforms = raw.Platforms
+
+		// args, ssh and labels accept both list ("KEY=VALUE") and map form
+		if raw.Args.Kind != 0 {
+			args, err := parseLabels(&raw.Args)
+			if err != nil {
+				return nil, err
+			}
+			build.Args = args
+		}
+		if raw.Labels.Kind != 0 {
+			labels, err := parseLabels(&raw.Labels)
+			if err != nil {
+				return nil, err
+			}
+			build.Labels = labels
+		}
+		if raw.SSH.Kind != 0 {
+			build.SSH = parseKeyValueList(&raw.SSH)
+		}
+		if raw.Secrets.Kind != 0 {
+			build.Secrets = parseSecretRefs(&raw.Secrets)
+		}
 	}
 
 	return build, nil
 }
 
+// parseKeyValueList parses a list of strings, or a map rendered as "key=value"
+func parseKeyValueList(node *yaml.Node) []string {
+	var result []string
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			result = append(result, item.Value)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			result = append(result, node.Content[i].Value+"="+node.Content[i+1].Value)
+		}
+	case yaml.ScalarNode:
+		result = append(result, node.Value)
+	}
+	return result
+}
+
+// parseSecretRefs returns the secret names of a short ("name") or long
+// ({source: name}) secrets list
+func parseSecretRefs(node *yaml.Node) []string {
+	var result []string
+	for _, item := range node.Content {
+		if item.Kind == yaml.MappingNode {
+			var ref struct {
+				Source string `yaml:"source"`
+			}
+			if err := item.Decode(&ref); err == nil && ref.Source != "" {
+				result = append(result, ref.Source)
+			}
+			continue
+		}
+		result = append(result, item.Value)
+	}
+	return result
+}
+
 // parseEnvironment parses environment variables (list or map form)
 func parseEnvironment(node *yaml.Node) (map[string]*string, error) {
 	env := make(map[string]*string)
@@ -331,26 +766,136 @@ func parsePorts(node *yaml.Node) ([]models.PortIR, error) {
 	}
 
 	for _, item := range node.Content {
+		var port *models.PortIR
+		var err error
 		if item.Kind == yaml.ScalarNode {
 			// String form: "8080:80" or "8080:80/udp"
-			port, err := parsePortString(item.Value)
-			if err != nil {
-				return nil, err
-			}
-			ports = append(ports, *port)
+			port, err = parsePortString(item.Value)
 		} else if item.Kind == yaml.MappingNode {
 			// Long form
-			port, err := parsePortMapping(item)
-			if err != nil {
-				return nil, err
-			}
-			ports = append(ports, *port)
+			port, err = parsePortMapping(item)
+		} else {
+			continue
 		}
+		if err != nil {
+			return nil, err
+		}
+
+		expanded, err := expandPortRange(*port)
+		if err != nil {
+			return nil, err
+		}
+		ports = append(ports, expanded...)
 	}
 
 	return ports, nil
 }
 
+// maxPortRange caps how many ports a single range entry expands into
+const maxPortRange = 1024
+
+// expandPortRange turns "8000-8010:8000-8010" into one PortIR per port, so
+// narrowing a range reports exactly the ports that were dropped. A host range
+// with a single container port ("8000-8010:80") means Docker picks one free
+// host port, so it is kept as written, as is a range whose ends are not both
+// literal numbers, such as "${HOST_LO}-${HOST_HI}:80-81".
+func expandPortRange(port models.PortIR) ([]models.PortIR, error) {
+	if !literalPorts(port.ContainerPort) || (port.HostPort != "" && !literalPorts(port.HostPort)) {
+		return []models.PortIR{port}, nil
+	}
+	cStart, cEnd, cRange, err := parsePortRange(port.ContainerPort)
+	if err != nil {
+		return nil, err
+	}
+	if !cRange {
+		return []models.PortIR{port}, nil
+	}
+
+	hStart, hEnd, hRange := 0, 0, false
+	if port.HostPort != "" {
+		if hStart, hEnd, hRange, err = parsePortRange(port.HostPort); err != nil {
+			return nil, err
+		}
+		if !hRange {
+			hEnd = hStart
+		}
+		if hEnd-hStart != cEnd-cStart {
+			return nil, fmt.Errorf("port range %s:%s: host and container ranges differ in size", port.HostPort, port.ContainerPort)
+		}
+	}
+
+	if cEnd-cStart+1 > maxPortRange {
+		return []models.PortIR{port}, nil
+	}
+
+	ports := make([]models.PortIR, 0, cEnd-cStart+1)
+	for i := 0; i <= cEnd-cStart; i++ {
+		p := port
+		p.ContainerPort = strconv.Itoa(cStart + i)
+		if port.HostPort != "" {
+			p.HostPort = strconv.Itoa(hStart + i)
+		}
+		ports = append(ports, p)
+	}
+	return ports, nil
+}
+
+// literalPorts reports whether a port or port range is written as numbers,
+// rather than with variables raw mode does not interpolate
+func literalPorts(s string) bool {
+	from, to, _ := strings.Cut(s, "-")
+	for _, part := range []string{from, to} {
+		if _, err := strconv.Atoi(part); err != nil && part != "" {
+			return false
+		}
+	}
+	return from != ""
+}
+
+// parsePortRange parses "8000" or "8000-8010"
+func parsePortRange(s string) (start, end int, isRange bool, err error) {
+	from, to, found := strings.Cut(s, "-")
+	if !found {
+		start, err = strconv.Atoi(s)
+		if err != nil {
+			// Not numeric (e.g. an unresolved variable); leave it alone
+			return 0, 0, false, nil
+		}
+		return start, start, false, nil
+	}
+
+	start, err1 := strconv.Atoi(from)
+	end, err2 := strconv.Atoi(to)
+	if err1 != nil || err2 != nil {
+		return 0, 0, false, nil // e.g. "${PORT:-80}"
+	}
+	if end < start {
+		return 0, 0, false, fmt.Errorf("invalid port range %q", s)
+	}
+	return start, end, true, nil
+}
+
+// normalizeExpose adds the default tcp protocol so "80" and "80/tcp" compare
+// equal, and expands ranges like ports
+func normalizeExpose(expose []string) []string {
+	result := make([]string, 0, len(expose))
+	for _, e := range expose {
+		port, proto, found := strings.Cut(e, "/")
+		if !found {
+			proto = "tcp"
+		}
+		start, end, isRange, err := parsePortRange(port)
+		if err != nil || !isRange || end-start+1 > maxPortRange {
+			result = append(result, port+"/"+proto)
+			continue
+		}
+		for p := start; p <= end; p++ {
+			result = append(result, strconv.Itoa(p)+"/"+proto)
+		}
+	}
+	return result
+}
+
 // parsePortString parses a port string like "8080:80" or "127.0.0.1:8080:80/udp"
 func parsePortString(s string) (*models.PortIR, error) {
 	port := &models.PortIR{Protocol: "tcp"}
@@ -456,14 +1001,30 @@ func parseVolumeString(s string) (*models.MountIR, error) {
 		mount.Source = parts[0]
 		mount.Target = parts[1]
 		mount.Type = inferMountType(parts[0])
-		if parts[2] == "ro" {
-			mount.ReadOnly = true
-		}
+		applyMountOptions(mount, parts[2])
 	}
 
 	return mount, nil
 }
 
+// applyMountOptions applies the comma-separated short-syntax mode, e.g. "ro,z,rslave"
+func applyMountOptions(mount *models.MountIR, mode string) {
+	for _, opt := range strings.Split(mode, ",") {
+		switch opt {
+		case "ro":
+			mount.ReadOnly = true
+		case "z", "Z":
+			mount.SELinux = opt
+		case "nocopy":
+			mount.NoCopy = true
+		case "cached", "delegated", "consistent":
+			mount.Consistency = opt
+		case "shared", "rshared", "slave", "rslave", "private", "rprivate":
+			mount.Propagation = opt
+		}
+	}
+}
+
 // inferMountType determines if a source is a bind mount or named volume
 func inferMountType(source string) string {
 	if strings.HasPrefix(source, "./") || strings.HasPrefix(source, "/") || strings.HasPrefix(source, "~") {
@@ -475,10 +1036,23 @@ func inferMountType(source string) string {
 // parseVolumeMapping parses long-form volume config
 func parseVolumeMapping(node *yaml.Node) (*models.MountIR, error) {
 	var raw struct {
-		Type     string `yaml:"type"`
-		Source   string `yaml:"source"`
-		Target   string `yaml:"target"`
-		ReadOnly bool   `yaml:"read_only"`
+		Type        string   `yaml:"type"`
+		Source      string   `yaml:"source"`
+		Target      string   `yaml:"target"`
+		ReadOnly    yamlBool `yaml:"read_only"`
+		Consistency string   `yaml:"consistency"`
+		Bind        struct {
+			Propagation string `yaml:"propagation"`
+			SELinux     string `yaml:"selinux"`
+		} `yaml:"bind"`
+		Volume struct {
+			NoCopy  yamlBool `yaml:"nocopy"`
+			Subpath string   `yaml:"subpath"`
+		} `yaml:"volume"`
+		Tmpfs struct {
+			Size string `yaml:"size"`
+			Mode string `yaml:"mode"`
+		} `yaml:"tmpfs"`
 	}
 	if err := node.Decode(&raw); err != nil {
 		return nil, err
@@ -489,12 +1063,40 @@ func parseVolumeMapping(node *yaml.Node) (*models.MountIR, error) {
 		mountType = inferMountType(raw.Source)
 	}
 
-	return &models.MountIR{
-		Type:     mountType,
-		Source:   raw.Source,
-		Target:   raw.Target,
-		ReadOnly: raw.ReadOnly,
-	}, nil
+	mount := &models.MountIR{
+		Type:        mountType,
+		Source:      raw.Source,
+		Target:      raw.Target,
+		ReadOnly:    bool(raw.ReadOnly),
+		Consistency: raw.Consistency,
+		Propagation: raw.Bind.Propagation,
+		SELinux:     raw.Bind.SELinux,
+		NoCopy:      bool(raw.Volume.NoCopy),
+		Subpath:     raw.Volume.Subpath,
+		TmpfsMode:   normalizeFileMode(raw.Tmpfs.Mode),
+	}
+	if raw.Tmpfs.Size != "" {
+		// An interpolated size such as ${TMPFS_SIZE} is compared as text
+		if size, err := units.ParseBytes(raw.Tmpfs.Size); err == nil {
+			mount.TmpfsSize = size
+		} else {
+			mount.TmpfsSizeText = raw.Tmpfs.Size
+		}
+	}
+
+	return mount, nil
+}
+
+// normalizeFileMode canonicalizes an octal mode so "01777", "0o1777" and 1777 compare equal
+func normalizeFileMode(mode string) string {
+	if mode == "" {
+		return ""
+	}
+	n, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(mode), "0o"), 8, 32)
+	if err != nil {
+		return mode
+	}
+	return strconv.FormatUint(n, 8)
 }
 
 // parseNetworksRef parses network references in a service
@@ -555,7 +1157,7 @@ func parseHealthcheck(node *yaml.Node) (*models.HealthcheckIR, error) {
 		Timeout     string    `yaml:"timeout"`
 		Retries     int       `yaml:"retries"`
 		StartPeriod string    `yaml:"start_period"`
-		Disable     bool      `yaml:"disable"`
+		Disable     yamlBool  `yaml:"disable"`
 	}
 	if err := node.Decode(&raw); err != nil {
 		return nil, err
@@ -566,7 +1168,7 @@ func parseHealthcheck(node *yaml.Node) (*models.HealthcheckIR, error) {
 		Timeout:     raw.Timeout,
 		Retries:     raw.Retries,
 		StartPeriod: raw.StartPeriod,
-		Disable:     raw.Disable,
+		Disable:     bool(raw.Disable),
 	}
 
 	// Parse test command
@@ -583,6 +1185,34 @@ func parseHealthcheck(node *yaml.Node) (*models.HealthcheckIR, error) {
 	return hc, nil
 }
 
+// parseEnvFiles parses env_file in string, list or long ({path, required})
+// form, returning all paths and the ones marked required: false
+func parseEnvFiles(node *yaml.Node) (files, optional []string, err error) {
+	if node.Kind != yaml.SequenceNode {
+		files, err = parseStringOrList(node)
+		return files, nil, err
+	}
+
+	for _, item := range node.Content {
+		if item.Kind != yaml.MappingNode {
+			files = append(files, item.Value)
+			continue
+		}
+		entry := struct {
+			Path     string    `yaml:"path"`
+			Required *yamlBool `yaml:"required"`
+		}{}
+		if err := item.Decode(&entry); err != nil {
+			return nil, nil, err
+		}
+		files = append(files, entry.Path)
+		if entry.Required != nil && !*entry.Required {
+			optional = append(optional, entry.Path)
+		}
+	}
+	return files, optional, nil
+}
+
 // parseStringOrList parses a value that can be string or list
 func parseStringOrList(node *yaml.Node) ([]string, error) {
 	var result []string
@@ -646,7 +1276,7 @@ func convertVolume(node *yaml.Node) (*models.VolumeIR, error) {
 		var raw struct {
 			Driver     string            `yaml:"driver"`
 			DriverOpts map[string]string `yaml:"driver_opts"`
-			External   bool              `yaml:"external"`
+			External   yamlBool          `yaml:"external"`
 			Name       string            `yaml:"name"`
 			Labels     map[string]string `yaml:"labels"`
 		}
@@ -655,7 +1285,7 @@ func convertVolume(node *yaml.Node) (*models.VolumeIR, error) {
 		}
 		vol.Driver = raw.Driver
 		vol.DriverOpts = raw.DriverOpts
-		vol.External = raw.External
+		vol.External = bool(raw.External)
 		vol.Name = raw.Name
 		vol.Labels = raw.Labels
 	}
@@ -677,18 +1307,43 @@ func convertNetwork(node *yaml.Node) (*models.NetworkIR, error) {
 		var raw struct {
 			Driver     string            `yaml:"driver"`
 			DriverOpts map[string]string `yaml:"driver_opts"`
-			External   bool              `yaml:"external"`
+			External   yamlBool          `yaml:"external"`
 			Name       string            `yaml:"name"`
 			Labels     map[string]string `yaml:"labels"`
+			IPAM       *struct {
+				Driver string `yaml:"driver"`
+				Config []struct {
+					Subnet       string            `yaml:"subnet"`
+					Gateway      string            `yaml:"gateway"`
+					IPRange      string            `yaml:"ip_range"`
+					AuxAddresses map[string]string `yaml:"aux_addresses"`
+				} `yaml:"config"`
+			} `yaml:"ipam"`
 		}
 		if err := node.Decode(&raw); err != nil {
 			return nil, err
 		}
 		net.Driver = raw.Driver
 		net.DriverOpts = raw.DriverOpts
-		net.External = raw.External
+		net.External = bool(raw.External)
 		net.Name = raw.Name
 		net.Labels = raw.Labels
+
+		if raw.IPAM != nil {
+			ipam := &models.IPAMIR{Driver: raw.IPAM.Driver}
+			for _, c := range raw.IPAM.Config {
+				ipam.Config = append(ipam.Config, models.IPAMPoolIR{
+					Subnet:       c.Subnet,
+					Gateway:      c.Gateway,
+					IPRange:      c.IPRange,
+					AuxAddresses: c.AuxAddresses,
+				})
+			}
+			sort.Slice(ipam.Config, func(i, j int) bool {
+				return ipam.Config[i].Subnet < ipam.Config[j].Subnet
+			})
+			net.IPAM = ipam
+		}
 	}
 
 	return net, nil
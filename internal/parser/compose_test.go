@@ -3,7 +3,10 @@ package parser
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
 )
 
 func TestParseComposeFile(t *testing.T) {
@@ -226,3 +229,597 @@ services:
 		t.Errorf("Volume 3 mismatch: %+v", v3)
 	}
 }
+
+func TestParseResourcesNormalizesUnits(t *testing.T) {
+	content := `
+services:
+  app:
+    image: app:latest
+    mem_limit: 512m
+    cpus: 0.5
+    deploy:
+      resources:
+        limits:
+          memory: 1g
+          cpus: "2"
+        reservations:
+          memory: 268435456
+`
+	tmpDir := t.TempDir()
+	composePath := filepath.Join(tmpDir, "docker-compose.yml")
+	if err := os.WriteFile(composePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	ir, err := ParseComposeFile(composePath)
+	if err != nil {
+		t.Fatalf("ParseComposeFile failed: %v", err)
+	}
+
+	app := ir.Services["app"]
+	if app.MemLimit != 536870912 {
+		t.Errorf("Expected mem_limit 536870912, got %d", app.MemLimit)
+	}
+	if app.CPUs != 0.5 {
+		t.Errorf("Expected cpus 0.5, got %v", app.CPUs)
+	}
+	if app.Deploy == nil || app.Deploy.Resources == nil {
+		t.Fatal("Expected deploy.resources to be parsed")
+	}
+	if limits := app.Deploy.Resources.Limits; limits == nil || limits.Memory != 1<<30 || limits.CPUs != 2 {
+		t.Errorf("Limits mismatch: %+v", limits)
+	}
+	if res := app.Deploy.Resources.Reservations; res == nil || res.Memory != 256<<20 {
+		t.Errorf("Reservations mismatch: %+v", res)
+	}
+}
+
+func TestParseUserAndInit(t *testing.T) {
+	content := `
+services:
+  app:
+    image: app:latest
+    user: 1000
+    working_dir: /srv/app
+    init: false
+    hostname: app01
+`
+	tmpDir := t.TempDir()
+	composePath := filepath.Join(tmpDir, "docker-compose.yml")
+	if err := os.WriteFile(composePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	ir, err := ParseComposeFile(composePath)
+	if err != nil {
+		t.Fatalf("ParseComposeFile failed: %v", err)
+	}
+
+	app := ir.Services["app"]
+	if app.User == nil || *app.User != "1000" {
+		t.Errorf("Expected user 1000, got %v", app.User)
+	}
+	if app.WorkingDir == nil || *app.WorkingDir != "/srv/app" {
+		t.Errorf("Expected working_dir /srv/app, got %v", app.WorkingDir)
+	}
+	// An explicit false must be kept so it differs from unset
+	if app.Init == nil || *app.Init {
+		t.Errorf("Expected init false, got %v", app.Init)
+	}
+	if app.Domainname != nil {
+		t.Errorf("Expected no domainname, got %v", *app.Domainname)
+	}
+}
+
+func TestParseBuildSection(t *testing.T) {
+	content := `
+services:
+  api:
+    build:
+      context: ./api
+      target: prod
+      args:
+        - NODE_ENV=production
+      platforms: [linux/arm64, linux/amd64]
+      ssh: {default: ~/.ssh/id_ed25519}
+      secrets:
+        - npmrc
+        - source: token
+          target: /run/secrets/tok
+      labels:
+        team: core
+`
+	tmpDir := t.TempDir()
+	composePath := filepath.Join(tmpDir, "docker-compose.yml")
+	if err := os.WriteFile(composePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	ir, err := ParseComposeFile(composePath)
+	if err != nil {
+		t.Fatalf("ParseComposeFile failed: %v", err)
+	}
+
+	expected := &models.BuildIR{
+		Context:   "./api",
+		Target:    "prod",
+		Args:      map[string]string{"NODE_ENV": "production"},
+		Platforms: []string{"linux/arm64", "linux/amd64"},
+		SSH:       []string{"default=~/.ssh/id_ed25519"},
+		Secrets:   []string{"npmrc", "token"},
+		Labels:    map[string]string{"team": "core"},
+	}
+	if got := ir.Services["api"].Build; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected build %+v, got %+v", expected, got)
+	}
+}
+
+func TestParseAnchorsMatchExpandedForm(t *testing.T) {
+	anchored := `
+x-logging: &logging
+  driver: json-file
+x-env: &common-env
+  LOG_LEVEL: info
+  REGION: eu-west-1
+x-defaults: &defaults
+  image: app:1
+  restart: unless-stopped
+  environment: *common-env
+  labels: &labels
+    team: core
+
+services:
+  api:
+    <<: *defaults
+    x-owner: payments
+    environment:
+      <<: *common-env
+      REGION: us-east-1
+  worker:
+    <<: [*defaults]
+    labels: *labels
+`
+	expanded := `
+x-logging:
+  driver: json-file
+x-env:
+  LOG_LEVEL: info
+  REGION: eu-west-1
+x-defaults:
+  image: app:1
+  restart: unless-stopped
+  environment:
+    LOG_LEVEL: info
+    REGION: eu-west-1
+  labels:
+    team: core
+
+services:
+  api:
+    image: app:1
+    restart: unless-stopped
+    x-owner: payments
+    labels:
+      team: core
+    environment:
+      LOG_LEVEL: info
+      REGION: us-east-1
+  worker:
+    image: app:1
+    restart: unless-stopped
+    labels:
+      team: core
+    environment:
+      LOG_LEVEL: info
+      REGION: eu-west-1
+`
+	tmpDir := t.TempDir()
+	parse := func(name, content string) *models.ComposeIR {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+		ir, err := ParseComposeFile(path)
+		if err != nil {
+			t.Fatalf("ParseComposeFile failed: %v", err)
+		}
+		return ir
+	}
+
+	a, b := parse("anchored.yml", anchored), parse("expanded.yml", expanded)
+	a.Lines, b.Lines = nil, nil // positions differ by construction
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("Anchored and expanded files parsed differently:\n%+v\n%+v", a.Services, b.Services)
+	}
+	if got := a.Services["api"].Extensions["x-owner"]; got != "payments" {
+		t.Errorf("Expected service extension x-owner, got %v", got)
+	}
+	if _, ok := a.Extensions["x-logging"]; !ok {
+		t.Error("Expected top-level extension x-logging")
+	}
+}
+
+func TestParseDevicesAndGPUs(t *testing.T) {
+	content := `
+services:
+  ml:
+    image: ml:latest
+    devices:
+      - /dev/ttyUSB0
+      - /dev/sda:/dev/xvda:rwm
+      - source: /dev/snd
+        target: /dev/snd
+    deploy:
+      resources:
+        reservations:
+          devices:
+            - driver: nvidia
+              count: all
+              capabilities: [utility, gpu]
+`
+	tmpDir := t.TempDir()
+	composePath := filepath.Join(tmpDir, "docker-compose.yml")
+	if err := os.WriteFile(composePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	ir, err := ParseComposeFile(composePath)
+	if err != nil {
+		t.Fatalf("ParseComposeFile failed: %v", err)
+	}
+	ml := Normalize(ir).Services["ml"]
+
+	expected := []models.DeviceIR{
+		{Source: "/dev/snd", Target: "/dev/snd"},
+		{Source: "/dev/ttyUSB0", Target: "/dev/ttyUSB0"},
+		{Source: "/dev/sda", Target: "/dev/xvda", Permissions: "rwm"},
+	}
+	if !reflect.DeepEqual(ml.Devices, expected) {
+		t.Errorf("Devices mismatch:\n got %+v\nwant %+v", ml.Devices, expected)
+	}
+
+	reqs := ml.Deploy.Resources.Reservations.Devices
+	if len(reqs) != 1 || reqs[0].Count != "all" || !reflect.DeepEqual(reqs[0].Capabilities, []string{"gpu", "utility"}) {
+		t.Errorf("Device requests mismatch: %+v", reqs)
+	}
+}
+
+func TestParseExtraHostsAndDNS(t *testing.T) {
+	content := `
+services:
+  list:
+    image: app:latest
+    extra_hosts:
+      - "db.internal:10.0.0.5"
+      - "cache=10.0.0.6"
+      - "v6host:::1"
+    dns: 8.8.8.8
+  mapped:
+    image: app:latest
+    extra_hosts:
+      db.internal: 10.0.0.5
+      multi: ["10.0.0.8", "10.0.0.7"]
+    dns:
+      - 1.1.1.1
+      - 8.8.8.8
+`
+	tmpDir := t.TempDir()
+	composePath := filepath.Join(tmpDir, "docker-compose.yml")
+	if err := os.WriteFile(composePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	ir, err := ParseComposeFile(composePath)
+	if err != nil {
+		t.Fatalf("ParseComposeFile failed: %v", err)
+	}
+
+	list := ir.Services["list"]
+	expected := map[string]string{"db.internal": "10.0.0.5", "cache": "10.0.0.6", "v6host": "::1"}
+	if !reflect.DeepEqual(list.ExtraHosts, expected) {
+		t.Errorf("List extra_hosts mismatch: %v", list.ExtraHosts)
+	}
+	if !reflect.DeepEqual(list.DNS, []string{"8.8.8.8"}) {
+		t.Errorf("Expected single dns entry, got %v", list.DNS)
+	}
+
+	mapped := ir.Services["mapped"]
+	expected = map[string]string{"db.internal": "10.0.0.5", "multi": "10.0.0.7,10.0.0.8"}
+	if !reflect.DeepEqual(mapped.ExtraHosts, expected) {
+		t.Errorf("Map extra_hosts mismatch: %v", mapped.ExtraHosts)
+	}
+}
+
+func TestParseRuntimeNamespaces(t *testing.T) {
+	content := `
+services:
+  app:
+    image: app:latest
+    tmpfs: /run
+    shm_size: 1gb
+    ipc: host
+    network_mode: "service:vpn"
+`
+	tmpDir := t.TempDir()
+	composePath := filepath.Join(tmpDir, "docker-compose.yml")
+	if err := os.WriteFile(composePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	ir, err := ParseComposeFile(composePath)
+	if err != nil {
+		t.Fatalf("ParseComposeFile failed: %v", err)
+	}
+
+	app := ir.Services["app"]
+	if !reflect.DeepEqual(app.Tmpfs, []string{"/run"}) {
+		t.Errorf("Expected tmpfs [/run], got %v", app.Tmpfs)
+	}
+	if app.ShmSize != 1<<30 {
+		t.Errorf("Expected shm_size 1GiB, got %d", app.ShmSize)
+	}
+	if app.IPC == nil || *app.IPC != "host" {
+		t.Errorf("Expected ipc host, got %v", app.IPC)
+	}
+	if app.NetworkMode == nil || *app.NetworkMode != "service:vpn" {
+		t.Errorf("Expected network_mode service:vpn, got %v", app.NetworkMode)
+	}
+	if app.PID != nil {
+		t.Errorf("Expected no pid, got %v", *app.PID)
+	}
+}
+
+func TestParsePortRanges(t *testing.T) {
+	content := `
+services:
+  app:
+    image: app:latest
+    ports:
+      - "8000-8002:9000-9002"
+      - "7000-7010:80"
+      - target: 5000-5001
+        published: "6000-6001"
+        protocol: udp
+    expose:
+      - "3000-3001"
+`
+	tmpDir := t.TempDir()
+	composePath := filepath.Join(tmpDir, "docker-compose.yml")
+	if err := os.WriteFile(composePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	ir, err := ParseComposeFile(composePath)
+	if err != nil {
+		t.Fatalf("ParseComposeFile failed: %v", err)
+	}
+
+	expected := []models.PortIR{
+		{HostPort: "8000", ContainerPort: "9000", Protocol: "tcp"},
+		{HostPort: "8001", ContainerPort: "9001", Protocol: "tcp"},
+		{HostPort: "8002", ContainerPort: "9002", Protocol: "tcp"},
+		// Docker picks one host port from the range
+		{HostPort: "7000-7010", ContainerPort: "80", Protocol: "tcp"},
+		{HostPort: "6000", ContainerPort: "5000", Protocol: "udp"},
+		{HostPort: "6001", ContainerPort: "5001", Protocol: "udp"},
+	}
+	app := ir.Services["app"]
+	if !reflect.DeepEqual(app.Ports, expected) {
+		t.Errorf("Ports mismatch:\n got %+v\nwant %+v", app.Ports, expected)
+	}
+	if !reflect.DeepEqual(app.Expose, []string{"3000/tcp", "3001/tcp"}) {
+		t.Errorf("Expose mismatch: %v", app.Expose)
+	}
+}
+
+func TestParsePortRangeSizeMismatch(t *testing.T) {
+	content := `
+services:
+  app:
+    image: app:latest
+    ports:
+      - "8000-8005:9000-9002"
+`
+	tmpDir := t.TempDir()
+	composePath := filepath.Join(tmpDir, "docker-compose.yml")
+	if err := os.WriteFile(composePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	if _, err := ParseComposeFile(composePath); err == nil {
+		t.Error("Expected error for mismatched range sizes")
+	}
+}
+
+func TestParsePortRangeInterpolated(t *testing.T) {
+	ir, err := parseContent(t, `
+services:
+  app:
+    image: app:latest
+    ports:
+      - "${HOST_LO}-${HOST_HI}:80-81"
+`)
+	if err != nil {
+		t.Fatalf("ParseComposeFile failed: %v", err)
+	}
+	ports := ir.Services["app"].Ports
+	if len(ports) != 1 || ports[0].HostPort != "${HOST_LO}-${HOST_HI}" || ports[0].ContainerPort != "80-81" {
+		t.Errorf("Expected the range kept as one mapping, got %+v", ports)
+	}
+}
+
+func TestParseMountOptions(t *testing.T) {
+	content := `
+services:
+  app:
+    image: app:latest
+    volumes:
+      - ./src:/src:ro,z,rslave
+      - data:/data:nocopy
+      - type: volume
+        source: shared
+        target: /shared
+        volume:
+          subpath: app
+          nocopy: true
+      - type: tmpfs
+        target: /scratch
+        tmpfs:
+          size: 64m
+          mode: 01777
+`
+	tmpDir := t.TempDir()
+	composePath := filepath.Join(tmpDir, "docker-compose.yml")
+	if err := os.WriteFile(composePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	ir, err := ParseComposeFile(composePath)
+	if err != nil {
+		t.Fatalf("ParseComposeFile failed: %v", err)
+	}
+
+	expected := []models.MountIR{
+		{Type: "bind", Source: "./src", Target: "/src", ReadOnly: true, SELinux: "z", Propagation: "rslave"},
+		{Type: "volume", Source: "data", Target: "/data", NoCopy: true},
+		{Type: "volume", Source: "shared", Target: "/shared", NoCopy: true, Subpath: "app"},
+		{Type: "tmpfs", Target: "/scratch", TmpfsSize: 64 << 20, TmpfsMode: "1777"},
+	}
+	if mounts := ir.Services["app"].Volumes; !reflect.DeepEqual(mounts, expected) {
+		t.Errorf("Mounts mismatch:\n got %+v\nwant %+v", mounts, expected)
+	}
+}
+
+func TestParseNetworkIPAM(t *testing.T) {
+	content := `
+services:
+  app:
+    image: app:latest
+networks:
+  back:
+    ipam:
+      driver: default
+      config:
+        - subnet: 172.28.0.0/16
+          ip_range: 172.28.5.0/24
+          gateway: 172.28.5.254
+        - subnet: 10.5.0.0/24
+`
+	tmpDir := t.TempDir()
+	composePath := filepath.Join(tmpDir, "docker-compose.yml")
+	if err := os.WriteFile(composePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	ir, err := ParseComposeFile(composePath)
+	if err != nil {
+		t.Fatalf("ParseComposeFile failed: %v", err)
+	}
+
+	expected := &models.IPAMIR{
+		Driver: "default",
+		Config: []models.IPAMPoolIR{
+			{Subnet: "10.5.0.0/24"},
+			{Subnet: "172.28.0.0/16", IPRange: "172.28.5.0/24", Gateway: "172.28.5.254"},
+		},
+	}
+	if ipam := ir.Networks["back"].IPAM; !reflect.DeepEqual(ipam, expected) {
+		t.Errorf("IPAM mismatch:\n got %+v\nwant %+v", ipam, expected)
+	}
+}
+
+func TestParseSourceLines(t *testing.T) {
+	content := `services:
+  api:
+    image: api:1
+    environment:
+      - DEBUG=1
+    ports:
+      - "8080:80"
+    volumes:
+      - ./data:/data:ro
+    labels:
+      com.example.team: core
+`
+	tmpFile := filepath.Join(t.TempDir(), "docker-compose.yml")
+	if err := os.WriteFile(tmpFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	ir, err := ParseComposeFile(tmpFile)
+	if err != nil {
+		t.Fatalf("ParseComposeFile failed: %v", err)
+	}
+
+	expected := map[string]int{
+		"services.api.image":                   3,
+		"services.api.environment.DEBUG":       5,
+		"services.api.ports.8080:80/tcp":       7,
+		"services.api.volumes./data":           9,
+		"services.api.labels.com.example.team": 11,
+		"services.api.environment.MISSING":     4, // falls back to the environment block
+		"services.api.healthcheck.test":        2,
+		"volumes.data":                         0,
+	}
+	for path, want := range expected {
+		if got := ir.Line(path); got != want {
+			t.Errorf("Line(%s) = %d, want %d", path, got, want)
+		}
+	}
+}
+
+func TestParseInterpolatedResources(t *testing.T) {
+	ir, err := parseContent(t, `
+services:
+  api:
+    image: api
+    mem_limit: ${API_MEM:-512m}
+    shm_size: ${SHM:-1g}
+    cpus: 0.5
+    cpu_shares: ${SHARES}
+    deploy:
+      restart_policy:
+        max_attempts: ${RETRIES}
+      resources:
+        limits:
+          memory: ${LIMIT}
+          pids: 100
+`)
+	if err != nil {
+		t.Fatalf("ParseComposeFile failed: %v", err)
+	}
+
+	api := ir.Services["api"]
+	want := map[string]string{
+		"mem_limit":                          "${API_MEM:-512m}",
+		"cpu_shares":                         "${SHARES}",
+		"shm_size":                           "${SHM:-1g}",
+		"deploy.resources.limits.memory":     "${LIMIT}",
+		"deploy.restart_policy.max_attempts": "${RETRIES}",
+	}
+	if !reflect.DeepEqual(api.Unparsed, want) {
+		t.Errorf("Unparsed = %v, want %v", api.Unparsed, want)
+	}
+	if api.CPUs != 0.5 || api.Deploy.Resources.Limits.Pids != 100 {
+		t.Errorf("Expected the literal values converted, got cpus %v and pids %d", api.CPUs, api.Deploy.Resources.Limits.Pids)
+	}
+}
+
+func TestParseInterpolatedTmpfsSize(t *testing.T) {
+	ir, err := parseContent(t, `
+services:
+  app:
+    image: app
+    volumes:
+      - type: tmpfs
+        target: /scratch
+        tmpfs:
+          size: ${TMPFS_SIZE:-64m}
+`)
+	if err != nil {
+		t.Fatalf("ParseComposeFile failed: %v", err)
+	}
+	want := []models.MountIR{{Type: "tmpfs", Target: "/scratch", TmpfsSizeText: "${TMPFS_SIZE:-64m}"}}
+	if got := ir.Services["app"].Volumes; !reflect.DeepEqual(got, want) {
+		t.Errorf("Volumes = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// directivePrefix starts a comment compose-diff reads
+const directivePrefix = "compose-diff:"
+
+// directiveIndex maps paths, like lineIndex, to the "# compose-diff:"
+// comment written on the key, after its value, or on the line above it.
+// Comments it cannot read are returned as diagnostics.
+func directiveIndex(doc *yaml.Node) (models.Directives, []models.Diagnostic) {
+	var idx directiveIndexer
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		idx.node(doc.Content[0], "")
+	}
+	return idx.directives, idx.diags
+}
+
+type directiveIndexer struct {
+	directives models.Directives
+	diags      []models.Diagnostic
+}
+
+func (idx *directiveIndexer) node(n *yaml.Node, path string) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			childPath := key.Value
+			if path != "" {
+				childPath = path + "." + key.Value
+			}
+			comments := []string{key.HeadComment, key.LineComment}
+			if value.Kind == yaml.ScalarNode {
+				comments = append(comments, value.LineComment)
+			}
+			idx.add([]string{childPath}, key.Line, comments...)
+			idx.node(value, childPath)
+		}
+	case yaml.SequenceNode:
+		for _, item := range n.Content {
+			keys := itemKeys(path, item)
+			paths := make([]string, len(keys))
+			for i, key := range keys {
+				paths[i] = path + "." + key
+			}
+			idx.add(paths, item.Line, item.HeadComment, item.LineComment)
+		}
+	}
+}
+
+// add records the directive among comments, if any, for paths
+func (idx *directiveIndexer) add(paths []string, line int, comments ...string) {
+	for _, comment := range comments {
+		for _, text := range strings.Split(comment, "\n") {
+			text = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text), "#"))
+			rest, ok := strings.CutPrefix(text, directivePrefix)
+			if !ok || len(paths) == 0 {
+				continue
+			}
+			d, err := parseDirective(rest)
+			if err != nil {
+				idx.diags = append(idx.diags, models.Diagnostic{Line: line, Path: paths[0], Message: err.Error()})
+				continue
+			}
+			d.Line = line
+			if idx.directives == nil {
+				idx.directives = make(models.Directives)
+			}
+			for _, path := range paths {
+				idx.directives[path] = d
+			}
+		}
+	}
+}
+
+// parseDirective reads the words after "compose-diff:": ignore,
+// severity=<level> and reason=<text to the end>
+func parseDirective(s string) (models.Directive, error) {
+	var d models.Directive
+	s = strings.TrimSpace(s)
+	for s != "" {
+		word, rest, _ := strings.Cut(s, " ")
+		switch {
+		case word == "ignore":
+			d.Ignore = true
+		case strings.HasPrefix(word, "severity="):
+			d.Severity = models.Severity(strings.TrimPrefix(word, "severity="))
+			if models.SeverityLevel(d.Severity) == 0 {
+				return d, fmt.Errorf("compose-diff comment: invalid severity %q (use info, warning or breaking)", d.Severity)
+			}
+		case strings.HasPrefix(word, "reason="):
+			d.Reason = strings.Trim(strings.TrimPrefix(s, "reason="), `"'`)
+			rest = ""
+		default:
+			return d, fmt.Errorf("compose-diff comment: unknown directive %q (use ignore, severity=... or reason=...)", word)
+		}
+		s = strings.TrimSpace(rest)
+	}
+	if !d.Ignore && d.Severity == "" {
+		return d, fmt.Errorf("compose-diff comment: expected ignore or severity=...")
+	}
+	if d.Ignore && d.Severity != "" {
+		return d, fmt.Errorf("compose-diff comment: ignore and severity cannot be combined")
+	}
+	return d, nil
+}
@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestParseDirective(t *testing.T) {
+	tests := []struct {
+		in   string
+		want models.Directive
+		err  string
+	}{
+		{"ignore", models.Directive{Ignore: true}, ""},
+		{"severity=info reason=moved to the new registry", models.Directive{Severity: models.SeverityInfo, Reason: "moved to the new registry"}, ""},
+		{`ignore reason="see OPS-12"`, models.Directive{Ignore: true, Reason: "see OPS-12"}, ""},
+		{"severity=low", models.Directive{}, "invalid severity"},
+		{"skip", models.Directive{}, "unknown directive"},
+		{"reason=why", models.Directive{}, "expected ignore"},
+		{"ignore severity=info", models.Directive{}, "cannot be combined"},
+	}
+	for _, tt := range tests {
+		got, err := parseDirective(tt.in)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("parseDirective(%q) error = %v, want %q", tt.in, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseDirective(%q) = %+v, %v, want %+v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseDirectives(t *testing.T) {
+	ir, err := parseContent(t, `
+services:
+  api:
+    # compose-diff: severity=info reason=rolled out behind a flag
+    image: api:2
+    user: root # compose-diff: ignore
+    environment: # compose-diff: severity=warning
+      DEBUG: "1"
+    ports:
+      - "8080:80" # compose-diff: ignore reason=temporary
+`)
+	if err != nil {
+		t.Fatalf("ParseComposeFile failed: %v", err)
+	}
+
+	for path, want := range map[string]models.Directive{
+		"services.api.image":             {Severity: models.SeverityInfo, Reason: "rolled out behind a flag", Line: 5},
+		"services.api.user":              {Ignore: true, Line: 6},
+		"services.api.environment.DEBUG": {Severity: models.SeverityWarning, Line: 7},
+		"services.api.ports.8080:80/tcp": {Ignore: true, Reason: "temporary", Line: 10},
+	} {
+		if got, ok := ir.Directives.For(path); !ok || got != want {
+			t.Errorf("Directives.For(%q) = %+v, %v, want %+v", path, got, ok, want)
+		}
+	}
+	if _, ok := ir.Directives.For("services.api.command"); ok {
+		t.Error("Expected no directive on services.api.command")
+	}
+
+	_, err = parseContent(t, "services:\n  api:\n    image: api # compose-diff: ignroe\n")
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Expected an error on line 3, got %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// ExpandEnvFiles merges the contents of each service's env_file entries into
+// its environment. Paths are resolved relative to baseDir, later files
+// override earlier ones, and the environment section overrides them all,
+// matching docker compose precedence. Where each variable is set is kept in
+// EnvSources. Missing files are an error unless marked required: false.
+func ExpandEnvFiles(ir *models.ComposeIR, baseDir string) (*models.ComposeIR, error) {
+	result := *ir
+	result.Services = make(map[string]models.ServiceIR, len(ir.Services))
+
+	for name, svc := range ir.Services {
+		if len(svc.EnvFiles) == 0 {
+			result.Services[name] = svc
+			continue
+		}
+
+		env := make(map[string]*string)
+		sources := make(map[string][]string)
+		for _, file := range svc.EnvFiles {
+			path := file
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(baseDir, path)
+			}
+			vars, err := readEnvFile(path)
+			if os.IsNotExist(err) && contains(svc.EnvFilesOptional, file) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("service %s: reading env_file: %w", name, err)
+			}
+			for k, v := range vars {
+				env[k] = v
+				sources[k] = append(sources[k], "env_file "+file)
+			}
+		}
+		for k, v := range svc.Env {
+			env[k] = v
+			sources[k] = append(sources[k], "environment")
+		}
+
+		svc.Env = env
+		svc.EnvSources = sources
+		result.Services[name] = svc
+	}
+
+	return &result, nil
+}
+
+// readEnvFile parses KEY=VALUE lines, skipping blanks and comments
+func readEnvFile(path string) (map[string]*string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	vars := make(map[string]*string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || value == "" {
+			vars[key] = nil
+			continue
+		}
+		value = envFileValue(strings.TrimSpace(value))
+		vars[key] = &value
+	}
+	return vars, scanner.Err()
+}
+
+// envFileValue unquotes a value, or strips a trailing " # comment" from an unquoted one
+func envFileValue(value string) string {
+	if len(value) >= 2 {
+		if q := value[0]; (q == '"' || q == '\'') && value[len(value)-1] == q {
+			return value[1 : len(value)-1]
+		}
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
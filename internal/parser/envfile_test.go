@@ -0,0 +1,58 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandEnvFilesLongForm(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env.production"), []byte("API_URL=https://api.example.com\nEMPTY=\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	composePath := filepath.Join(dir, "docker-compose.yml")
+	content := `
+services:
+  web:
+    image: web:1
+    env_file:
+      - path: .env.production
+      - path: .env.local
+        required: false
+`
+	if err := os.WriteFile(composePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	ir, err := ParseComposeFile(composePath)
+	if err != nil {
+		t.Fatalf("ParseComposeFile failed: %v", err)
+	}
+	expanded, err := ExpandEnvFiles(ir, dir)
+	if err != nil {
+		t.Fatalf("ExpandEnvFiles failed: %v", err)
+	}
+
+	env := expanded.Services["web"].Env
+	if v := env["API_URL"]; v == nil || *v != "https://api.example.com" {
+		t.Errorf("Expected API_URL from .env.production, got %v", v)
+	}
+	if v, ok := env["EMPTY"]; !ok || v != nil {
+		t.Errorf("Expected EMPTY to be present but empty, got %v", v)
+	}
+	if sources := expanded.Services["web"].EnvSources["API_URL"]; len(sources) != 1 || sources[0] != "env_file .env.production" {
+		t.Errorf("Expected API_URL to be set by .env.production, got %v", sources)
+	}
+	if len(ir.Services["web"].Env) != 0 {
+		t.Error("Expected ExpandEnvFiles to leave its input untouched")
+	}
+
+	// A required file that is missing is an error
+	svc := ir.Services["web"]
+	svc.EnvFilesOptional = nil
+	ir.Services["web"] = svc
+	if _, err := ExpandEnvFiles(ir, dir); err == nil {
+		t.Error("Expected an error for a missing required env_file")
+	}
+}
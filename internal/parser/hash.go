@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// ServiceHash fingerprints a service's normalized configuration as
+// sha256:<hex>. Like docker compose's own config hash it leaves out build,
+// depends_on and profiles, which do not change the running container, so
+// two services with the same hash need no recreation between them.
+func ServiceHash(svc models.ServiceIR) string {
+	svc = normalizeService(svc)
+	svc.Build = nil
+	svc.DependsOn = nil
+	svc.Profiles = nil
+
+	// encoding/json sorts map keys, so the encoding is stable
+	data, err := json.Marshal(svc)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// ServiceHashes hashes every service of both IRs by name; either IR may be
+// nil
+func ServiceHashes(old, new *models.ComposeIR) map[string]models.ServiceHash {
+	hashes := make(map[string]models.ServiceHash)
+	if old != nil {
+		for name, svc := range old.Services {
+			hashes[name] = models.ServiceHash{Old: ServiceHash(svc)}
+		}
+	}
+	if new != nil {
+		for name, svc := range new.Services {
+			h := hashes[name]
+			h.New = ServiceHash(svc)
+			hashes[name] = h
+		}
+	}
+	return hashes
+}
@@ -0,0 +1,52 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestServiceHash(t *testing.T) {
+	image := "api:1"
+	base := models.ServiceIR{
+		Image:     &image,
+		Ports:     []models.PortIR{{HostPort: "80", ContainerPort: "80", Protocol: "tcp"}, {HostPort: "443", ContainerPort: "443", Protocol: "tcp"}},
+		Command:   []string{"serve", "--port", "80"},
+		DependsOn: []string{"db"},
+	}
+	h := ServiceHash(base)
+	if len(h) != len("sha256:")+64 {
+		t.Fatalf("Unexpected hash %q", h)
+	}
+
+	same := base
+	same.Ports = []models.PortIR{base.Ports[1], base.Ports[0]}
+	same.DependsOn = []string{"db", "cache"}
+	if got := ServiceHash(same); got != h {
+		t.Errorf("Expected port order and depends_on not to change the hash")
+	}
+
+	changed := base
+	changed.Command = []string{"serve", "80", "--port"}
+	if ServiceHash(changed) == h {
+		t.Errorf("Expected command order to change the hash")
+	}
+}
+
+func TestServiceHashes(t *testing.T) {
+	image := "api:1"
+	old := models.NewComposeIR()
+	old.Services["api"] = models.ServiceIR{Image: &image}
+	old.Services["legacy"] = models.ServiceIR{Image: &image}
+	new := models.NewComposeIR()
+	new.Services["api"] = models.ServiceIR{Image: &image}
+	new.Services["web"] = models.ServiceIR{}
+
+	hashes := ServiceHashes(old, new)
+	if len(hashes) != 3 || hashes["api"].Old != hashes["api"].New {
+		t.Errorf("Expected api to hash the same in both files, got %+v", hashes)
+	}
+	if hashes["legacy"].New != "" || hashes["web"].Old != "" || hashes["web"].New == "" {
+		t.Errorf("Expected one-sided hashes for removed and added services, got %+v", hashes)
+	}
+}
@@ -0,0 +1,271 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// ParseComposeFileLenient is ParseComposeFile for files that may be
+// malformed, such as machine-generated ones. Whatever cannot be read (a
+// mapping where a list belongs, a value of the wrong type, a duplicate key,
+// a service that is not a mapping) is left out of the IR and reported as a
+// diagnostic instead of failing the file. Only a missing file or invalid
+// YAML syntax is an error.
+func ParseComposeFileLenient(filePath string) (*models.ComposeIR, []models.Diagnostic, error) {
+	doc, err := loadDocument(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ir, diags := parseDocumentLenient(doc)
+	for i := range diags {
+		diags[i].File = filePath
+	}
+	return ir, diags, nil
+}
+
+// parseDocumentLenient removes the parts of doc that cannot be converted,
+// then converts the rest. It never fails and never panics.
+func parseDocumentLenient(doc *yaml.Node) (*models.ComposeIR, []models.Diagnostic) {
+	var s sanitizer
+	s.document(doc)
+
+	var raw RawComposeFile
+	if err := recovered(func() error { return doc.Decode(&raw) }); err != nil {
+		// Decoding carries on past type errors, so raw holds everything else
+		s.add(doc, "", err)
+	}
+
+	var ir *models.ComposeIR
+	err := recovered(func() error {
+		var err error
+		ir, err = convertToIR(&raw)
+		return err
+	})
+	if err != nil {
+		s.add(doc, "", err)
+		ir = models.NewComposeIR()
+	}
+	ir.Lines = lineIndex(doc)
+	directives, diags := directiveIndex(doc)
+	ir.Directives = directives
+	s.diags = append(s.diags, diags...)
+
+	sort.SliceStable(s.diags, func(i, j int) bool { return s.diags[i].Line < s.diags[j].Line })
+	return ir, s.diags
+}
+
+// sanitizer prunes unreadable entries from a compose node tree, one field at
+// a time where possible, and records why each was dropped
+type sanitizer struct {
+	diags []models.Diagnostic
+}
+
+func (s *sanitizer) document(doc *yaml.Node) {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		if !isNull(root) {
+			s.add(root, "", fmt.Errorf("expected a mapping at the top level, got %s", describeNode(root)))
+		}
+		doc.Content[0] = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		return
+	}
+
+	s.uniqueKeys(root, "")
+	s.filter(root, "", func(key, value *yaml.Node) error {
+		switch key.Value {
+		case "services":
+			return s.section(value, "services", checkService)
+		case "volumes":
+			return s.section(value, "volumes", checkVolume)
+		case "networks":
+			return s.section(value, "networks", checkNetwork)
+		}
+		return recovered(func() error {
+			var raw RawComposeFile
+			return pair(key, value).Decode(&raw)
+		})
+	})
+}
+
+// section prunes the entries of services, volumes or networks
+func (s *sanitizer) section(n *yaml.Node, path string, check func(*yaml.Node) error) error {
+	if isNull(n) {
+		return nil
+	}
+	if n.Kind != yaml.MappingNode {
+		return fmt.Errorf("expected a mapping, got %s", describeNode(n))
+	}
+
+	s.uniqueKeys(n, path)
+	s.filter(n, path, func(key, value *yaml.Node) error {
+		if key.Kind != yaml.ScalarNode {
+			return fmt.Errorf("expected a name, got %s", describeNode(key))
+		}
+		return s.entry(value, path+"."+key.Value, check)
+	})
+	return nil
+}
+
+// entry drops the fields of a service, volume or network that fail check on
+// their own. It returns an error if the rest still fails.
+func (s *sanitizer) entry(n *yaml.Node, path string, check func(*yaml.Node) error) error {
+	if n.Kind == yaml.SequenceNode {
+		return fmt.Errorf("expected a mapping, got a list")
+	}
+	err := check(n)
+	if err == nil || n.Kind != yaml.MappingNode {
+		return err
+	}
+
+	s.uniqueKeys(n, path)
+	s.filter(n, path, func(key, value *yaml.Node) error {
+		return check(pair(key, value))
+	})
+	return check(n)
+}
+
+// filter removes the key/value pairs of mapping m for which check fails
+func (s *sanitizer) filter(m *yaml.Node, path string, check func(key, value *yaml.Node) error) {
+	kept := m.Content[:0]
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		key, value := m.Content[i], m.Content[i+1]
+		if err := check(key, value); err != nil {
+			s.add(key, joinPath(path, key.Value), err)
+			continue
+		}
+		kept = append(kept, key, value)
+	}
+	m.Content = kept
+}
+
+// uniqueKeys removes repeated keys of mapping m, keeping the first
+func (s *sanitizer) uniqueKeys(m *yaml.Node, path string) {
+	seen := make(map[string]int)
+	kept := m.Content[:0]
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		key, value := m.Content[i], m.Content[i+1]
+		if key.Kind == yaml.ScalarNode {
+			if line, ok := seen[key.Value]; ok {
+				s.add(key, joinPath(path, key.Value), fmt.Errorf("duplicate key, first defined on line %d", line))
+				continue
+			}
+			seen[key.Value] = key.Line
+		}
+		kept = append(kept, key, value)
+	}
+	m.Content = kept
+}
+
+var errorLinePattern = regexp.MustCompile(`^line (\d+): `)
+
+// add records err at node n. Type errors carry their own lines and become
+// one diagnostic each.
+func (s *sanitizer) add(n *yaml.Node, path string, err error) {
+	messages := []string{err.Error()}
+	if te, ok := err.(*yaml.TypeError); ok {
+		messages = te.Errors
+	}
+
+	for _, msg := range messages {
+		d := models.Diagnostic{Line: n.Line, Column: n.Column, Path: path, Message: msg}
+		if m := errorLinePattern.FindStringSubmatch(msg); m != nil {
+			d.Line, _ = strconv.Atoi(m[1])
+			d.Column = 0
+			d.Message = strings.TrimPrefix(msg, m[0])
+		}
+		s.diags = append(s.diags, d)
+	}
+}
+
+// serviceFieldKinds are the node kinds allowed for service fields that the
+// converter would otherwise skip without an error
+var serviceFieldKinds = map[string][]yaml.Kind{
+	"ports":      {yaml.SequenceNode},
+	"volumes":    {yaml.SequenceNode},
+	"depends_on": {yaml.SequenceNode, yaml.MappingNode},
+	"networks":   {yaml.SequenceNode, yaml.MappingNode},
+}
+
+func checkService(n *yaml.Node) error {
+	if n.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			value := n.Content[i+1]
+			kinds, ok := serviceFieldKinds[n.Content[i].Value]
+			if ok && !isNull(value) && !slices.Contains(kinds, value.Kind) {
+				return fmt.Errorf("expected a %s, got %s", kindNames[kinds[0]], describeNode(value))
+			}
+		}
+	}
+	return recovered(func() error {
+		var raw RawService
+		if err := n.Decode(&raw); err != nil {
+			return err
+		}
+		_, err := convertService(&raw)
+		return err
+	})
+}
+
+func checkVolume(n *yaml.Node) error {
+	return recovered(func() error {
+		_, err := convertVolume(n)
+		return err
+	})
+}
+
+func checkNetwork(n *yaml.Node) error {
+	return recovered(func() error {
+		_, err := convertNetwork(n)
+		return err
+	})
+}
+
+// recovered calls fn, turning a panic into an error
+func recovered(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("could not be read: %v", r)
+		}
+	}()
+	return fn()
+}
+
+// pair wraps one key/value pair in a mapping of its own
+func pair(key, value *yaml.Node) *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{key, value}}
+}
+
+func isNull(n *yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && n.Tag == "!!null"
+}
+
+var kindNames = map[yaml.Kind]string{yaml.SequenceNode: "list", yaml.MappingNode: "mapping"}
+
+func describeNode(n *yaml.Node) string {
+	if name, ok := kindNames[n.Kind]; ok {
+		return "a " + name
+	}
+	if n.Kind == yaml.ScalarNode {
+		return fmt.Sprintf("%q", n.Value)
+	}
+	return "nothing"
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseComposeFileLenient(t *testing.T) {
+	content := `services:
+  api:
+    image: node:18
+    ports:
+      web: 8080
+    environment:
+      DEBUG: "1"
+    restart: [always]
+  worker:
+    - not a service
+  1:
+    image: busybox
+  api:
+    image: duplicate
+volumes:
+  data:
+    driver_opts: [a, b]
+    driver: local
+networks: [front, back]
+`
+	path := filepath.Join(t.TempDir(), "compose.yml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	if _, err := ParseComposeFile(path); err == nil {
+		t.Fatal("Expected the strict parser to reject the file")
+	}
+
+	ir, diags, err := ParseComposeFileLenient(path)
+	if err != nil {
+		t.Fatalf("ParseComposeFileLenient failed: %v", err)
+	}
+
+	api, ok := ir.Services["api"]
+	if !ok || api.Image == nil || *api.Image != "node:18" || *api.Env["DEBUG"] != "1" {
+		t.Errorf("Expected the readable fields of api to be kept, got %+v", api)
+	}
+	if api.Ports != nil || api.Restart != nil {
+		t.Errorf("Expected the unreadable fields of api to be dropped, got %+v", api)
+	}
+	if _, ok := ir.Services["worker"]; ok {
+		t.Error("Expected worker to be dropped")
+	}
+	if _, ok := ir.Services["1"]; !ok {
+		t.Error("Expected a numeric service name to be read as a string")
+	}
+	if ir.Volumes["data"].Driver != "local" {
+		t.Errorf("Expected volume driver to be kept, got %+v", ir.Volumes["data"])
+	}
+
+	want := map[string]int{
+		"services.api.ports":       4,
+		"services.api.restart":     8,
+		"services.worker":          9,
+		"services.api":             13,
+		"volumes.data.driver_opts": 17,
+		"networks":                 19,
+	}
+	for _, d := range diags {
+		if d.File != path || d.Message == "" {
+			t.Errorf("Incomplete diagnostic %+v", d)
+		}
+		if line, ok := want[d.Path]; !ok || line != d.Line {
+			t.Errorf("Unexpected diagnostic %s", d)
+		}
+		delete(want, d.Path)
+	}
+	for path := range want {
+		t.Errorf("Missing diagnostic for %s", path)
+	}
+}
+
+func TestParseLenientTopLevel(t *testing.T) {
+	for _, content := range []string{"- a\n- b\n", "just text\n", "services: 3\n", "version: {a: b}\nservices: {}\n"} {
+		doc, err := ParseNode([]byte(content))
+		if err != nil {
+			t.Fatalf("ParseNode(%q) failed: %v", content, err)
+		}
+		ir, diags := parseDocumentLenient(doc)
+		if len(ir.Services) != 0 || len(diags) != 1 || !strings.Contains(diags[0].String(), "1") {
+			t.Errorf("Unexpected result for %q: %+v %v", content, ir, diags)
+		}
+	}
+}
+
+func TestParseRecursiveAnchor(t *testing.T) {
+	content := "services:\n  api: &api\n    image: x\n    x-self: *api\n  web:\n    <<: *api\n"
+	doc, err := ParseNode([]byte(content))
+	if err != nil {
+		t.Fatalf("ParseNode failed: %v", err)
+	}
+	if _, err := parseDocument(doc); err == nil {
+		t.Error("Expected the strict parser to reject a recursive anchor")
+	}
+
+	doc, _ = ParseNode([]byte(content))
+	ir, diags := parseDocumentLenient(doc)
+	if len(diags) == 0 || ir.Services["api"].Image == nil {
+		t.Errorf("Expected api without x-self and a diagnostic, got %+v %v", ir.Services, diags)
+	}
+}
+
+// FuzzParseLenient checks that the lenient parser never panics and keeps
+// every service of a file the strict parser accepts. It may still report
+// fields the strict parser skips without an error.
+func FuzzParseLenient(f *testing.F) {
+	f.Add([]byte("services:\n  a:\n    image: x\n    ports: [\"80:80\", {target: 81}]\n    environment: {A: b}\n    deploy: {resources: {limits: {cpus: '1'}}}\n"))
+	f.Add([]byte("services:\n  a:\n    healthcheck: {test: [CMD, x]}\n    build: {context: ., args: [A=1]}\n    volumes: [{type: bind, source: ., target: /a}]\nvolumes: {v: {}}\nnetworks: {n: {ipam: {config: [{subnet: 10.0.0.0/8}]}}}\n"))
+	f.Add([]byte("services:\n  a: &a\n    ports: {a: 1}\n    depends_on: 3\n  b:\n    <<: *a\n  ~: null\n  [x]: 1\n"))
+	f.Add([]byte("a: &x {y: {z: *x}}\nb: [*x, *x]\nc: {<<: *x}\n"))
+	f.Add([]byte("x-a: &x {a: 1}\nservices: [a]\nvolumes: 7\nnetworks:\n  n:\n    ipam: [1]\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		doc, err := ParseNode(data)
+		if err != nil {
+			return
+		}
+		strict, strictErr := parseDocument(doc)
+
+		doc, _ = ParseNode(data)
+		ir, diags := parseDocumentLenient(doc)
+		if ir == nil {
+			t.Fatal("Lenient parse returned no IR")
+		}
+		if strictErr == nil && len(ir.Services) != len(strict.Services) {
+			t.Errorf("Lenient parse of a valid file differs: %v", diags)
+		}
+	})
+}
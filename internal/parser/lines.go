@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// lineIndex maps the dotted paths the diff engine reports (services.api.image,
+// services.api.environment.DEBUG, services.api.ports.8080:80/tcp, ...) to the
+// line they are written on
+func lineIndex(doc *yaml.Node) map[string]int {
+	lines := make(map[string]int)
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		indexNode(lines, doc.Content[0], "")
+	}
+	return lines
+}
+
+func indexNode(lines map[string]int, n *yaml.Node, path string) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			childPath := key.Value
+			if path != "" {
+				childPath = path + "." + key.Value
+			}
+			lines[childPath] = key.Line
+			indexNode(lines, value, childPath)
+		}
+	case yaml.SequenceNode:
+		for _, item := range n.Content {
+			for _, key := range itemKeys(path, item) {
+				lines[path+"."+key] = item.Line
+			}
+		}
+	}
+}
+
+// itemKeys returns the keys the engine uses for a list item: the port or
+// mount target for service ports and volumes, the variable name for
+// KEY=value entries, and the value itself otherwise
+func itemKeys(path string, item *yaml.Node) []string {
+	parts := strings.Split(path, ".")
+	inService := len(parts) == 3 && parts[0] == "services"
+	list := &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{item}}
+
+	switch {
+	case inService && parts[2] == "ports":
+		ports, _ := parsePorts(list)
+		keys := make([]string, len(ports))
+		for i, p := range ports {
+			keys[i] = p.HostPort + ":" + p.ContainerPort + "/" + p.Protocol
+		}
+		return keys
+	case inService && parts[2] == "volumes":
+		mounts, _ := parseVolumes(list)
+		keys := make([]string, len(mounts))
+		for i, m := range mounts {
+			keys[i] = m.Target
+		}
+		return keys
+	case item.Kind != yaml.ScalarNode:
+		return nil
+	}
+
+	if key, _, ok := strings.Cut(item.Value, "="); ok {
+		return []string{item.Value, key}
+	}
+	return []string{item.Value}
+}
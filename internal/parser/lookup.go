@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// Lookup returns the value at a dotted path of an IR, such as
+// services.api.image or services.api.environment.DEBUG, as plain maps, lists
+// and scalars like the JSON IR. Keys may contain dots (labels such as
+// traefik.enable), list items are addressed by index, and x-* fields are
+// found among the extensions. ok is false if nothing is at the path.
+func Lookup(ir *models.ComposeIR, path string) (any, bool) {
+	if ir == nil || path == "" {
+		return nil, false
+	}
+	data, err := json.Marshal(ir)
+	if err != nil {
+		return nil, false
+	}
+	var root any
+	if json.Unmarshal(data, &root) != nil {
+		return nil, false
+	}
+	return lookup(root, strings.Split(path, "."))
+}
+
+func lookup(v any, parts []string) (any, bool) {
+	if len(parts) == 0 {
+		return v, true
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		// The longest key first, so labels.traefik.enable finds the
+		// traefik.enable label before a traefik label
+		for n := len(parts); n > 0; n-- {
+			if child, ok := v[strings.Join(parts[:n], ".")]; ok {
+				return lookup(child, parts[n:])
+			}
+		}
+		if ext, ok := v["extensions"].(map[string]any); ok && strings.HasPrefix(parts[0], "x-") {
+			return lookup(ext, parts)
+		}
+	case []any:
+		if i, err := strconv.Atoi(parts[0]); err == nil && i >= 0 && i < len(v) {
+			return lookup(v[i], parts[1:])
+		}
+	}
+	return nil, false
+}
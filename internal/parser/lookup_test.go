@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestLookup(t *testing.T) {
+	image := "acme/api:1.4"
+	ir := &models.ComposeIR{
+		Services: map[string]models.ServiceIR{
+			"api": {
+				Image:      &image,
+				Env:        map[string]*string{"DEBUG": nil},
+				Labels:     map[string]string{"traefik": "x", "traefik.enable": "true"},
+				Command:    []string{"run", "--fast"},
+				Extensions: map[string]any{"x-team": "web"},
+			},
+		},
+		Extensions: map[string]any{"x-common": map[string]any{"restart": "always"}},
+	}
+
+	tests := []struct {
+		path string
+		want any
+		ok   bool
+	}{
+		{"services.api.image", "acme/api:1.4", true},
+		{"services.api.environment", map[string]any{"DEBUG": nil}, true},
+		{"services.api.environment.DEBUG", nil, true},
+		{"services.api.labels.traefik.enable", "true", true},
+		{"services.api.labels.traefik", "x", true},
+		{"services.api.command.1", "--fast", true},
+		{"services.api.command.2", nil, false},
+		{"services.api.x-team", "web", true},
+		{"x-common.restart", "always", true},
+		{"services.web", nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := Lookup(ir, tt.path)
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Lookup(%s) = %v, %v, want %v, %v", tt.path, got, ok, tt.want, tt.ok)
+		}
+	}
+}
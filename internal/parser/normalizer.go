@@ -1,18 +1,52 @@
 package parser
 
 import (
+	"fmt"
+	"slices"
 	"sort"
 
 	"github.com/stackgen-cli/compose-diff/internal/models"
 )
 
-// Normalize normalizes a ComposeIR for consistent comparison
+// ListFields are the service list fields that can be compared either by
+// position or as sets
+var ListFields = []string{
+	"build.cache_from", "build.platforms", "build.secrets", "build.ssh",
+	"cap_add", "cap_drop", "command", "depends_on", "device_cgroup_rules",
+	"dns", "dns_opt", "dns_search", "entrypoint", "env_file", "expose",
+	"networks", "profiles", "security_opt", "tmpfs",
+}
+
+// DefaultOrderedLists are the list fields compared by position unless
+// configured otherwise: argv order, env_file precedence (later files win)
+// and the order search domains are tried in all matter
+var DefaultOrderedLists = []string{"command", "entrypoint", "env_file", "dns_search"}
+
+// CheckOrderedLists returns an error for a name that is not in ListFields
+func CheckOrderedLists(names []string) error {
+	for _, name := range names {
+		if !slices.Contains(ListFields, name) {
+			return fmt.Errorf("unknown list field %q (known: %v)", name, ListFields)
+		}
+	}
+	return nil
+}
+
+// Normalize normalizes a ComposeIR for consistent comparison: list fields are
+// sorted and equivalent values, such as 30s and 0.5m, spelled one way
 func Normalize(ir *models.ComposeIR) *models.ComposeIR {
+	return NormalizeOrdered(ir, DefaultOrderedLists)
+}
+
+// NormalizeOrdered is Normalize keeping the order of the given list fields,
+// so reordering them is a change
+func NormalizeOrdered(ir *models.ComposeIR, ordered []string) *models.ComposeIR {
 	result := models.NewComposeIR()
 
 	// Normalize services
+	n := newNormalizer(ordered)
 	for name, svc := range ir.Services {
-		result.Services[name] = normalizeService(svc)
+		result.Services[name] = n.service(svc)
 	}
 
 	// Copy volumes and networks as-is (already normalized during parsing)
@@ -22,27 +56,83 @@ func Normalize(ir *models.ComposeIR) *models.ComposeIR {
 	for name, net := range ir.Networks {
 		result.Networks[name] = net
 	}
+	result.Extensions = canonicalExtensions(ir.Extensions)
+	result.Lines = ir.Lines
+	result.Directives = ir.Directives
 
 	return result
 }
 
-// normalizeService normalizes a service for comparison
+// normalizer sorts the list fields that are not ordered
+type normalizer struct {
+	ordered map[string]bool
+}
+
+func newNormalizer(ordered []string) normalizer {
+	n := normalizer{ordered: make(map[string]bool, len(ordered))}
+	for _, field := range ordered {
+		n.ordered[field] = true
+	}
+	return n
+}
+
+// list returns a list field sorted, unless its order matters
+func (n normalizer) list(field string, s []string) []string {
+	if n.ordered[field] {
+		return s
+	}
+	return sortedStrings(s)
+}
+
+// normalizeService normalizes a service keeping the DefaultOrderedLists in order
 func normalizeService(svc models.ServiceIR) models.ServiceIR {
-	result := models.ServiceIR{
-		Image:       svc.Image,
-		Build:       svc.Build,
-		Env:         svc.Env,
-		EnvFiles:    sortedStrings(svc.EnvFiles),
-		Ports:       normalizePorts(svc.Ports),
-		Volumes:     normalizeVolumes(svc.Volumes),
-		Networks:    sortedStrings(svc.Networks),
-		DependsOn:   sortedStrings(svc.DependsOn),
-		Healthcheck: svc.Healthcheck,
-		Command:     svc.Command,
-		Entrypoint:  svc.Entrypoint,
-		Profiles:    sortedStrings(svc.Profiles),
-		Restart:     svc.Restart,
-		Labels:      svc.Labels,
+	return newNormalizer(DefaultOrderedLists).service(svc)
+}
+
+// service normalizes a service for comparison
+func (n normalizer) service(svc models.ServiceIR) models.ServiceIR {
+	svc = canonicalizeService(svc)
+	// Start from a copy so fields without ordering semantics carry over as-is
+	result := svc
+	result.Command = n.list("command", svc.Command)
+	result.Entrypoint = n.list("entrypoint", svc.Entrypoint)
+	result.EnvFiles = n.list("env_file", svc.EnvFiles)
+	result.EnvFilesOptional = n.list("env_file", svc.EnvFilesOptional)
+	result.Ports = normalizePorts(svc.Ports)
+	result.Expose = n.list("expose", svc.Expose)
+	if svc.Build != nil {
+		build := *svc.Build
+		build.CacheFrom = n.list("build.cache_from", build.CacheFrom)
+		build.Platforms = n.list("build.platforms", build.Platforms)
+		build.SSH = n.list("build.ssh", build.SSH)
+		build.Secrets = n.list("build.secrets", build.Secrets)
+		result.Build = &build
+	}
+	result.Volumes = normalizeVolumes(svc.Volumes)
+	result.Networks = n.list("networks", svc.Networks)
+	result.DependsOn = n.list("depends_on", svc.DependsOn)
+	result.Profiles = n.list("profiles", svc.Profiles)
+	if svc.Security != nil {
+		sec := *svc.Security
+		sec.CapAdd = n.list("cap_add", sec.CapAdd)
+		sec.CapDrop = n.list("cap_drop", sec.CapDrop)
+		sec.SecurityOpt = n.list("security_opt", sec.SecurityOpt)
+		result.Security = &sec
+	}
+	result.Devices = normalizeDevices(svc.Devices)
+	result.DeviceCgroupRules = n.list("device_cgroup_rules", svc.DeviceCgroupRules)
+	result.DNS = n.list("dns", svc.DNS)
+	result.DNSSearch = n.list("dns_search", svc.DNSSearch)
+	result.DNSOpt = n.list("dns_opt", svc.DNSOpt)
+	result.Tmpfs = n.list("tmpfs", svc.Tmpfs)
+	if res := svc.Deploy; res != nil && res.Resources != nil && res.Resources.Reservations != nil {
+		deploy := *svc.Deploy
+		resources := *deploy.Resources
+		reservations := *resources.Reservations
+		reservations.Devices = normalizeDeviceRequests(reservations.Devices)
+		resources.Reservations = &reservations
+		deploy.Resources = &resources
+		result.Deploy = &deploy
 	}
 
 	return result
@@ -84,6 +174,38 @@ func normalizeVolumes(volumes []models.MountIR) []models.MountIR {
 	return result
 }
 
+// normalizeDevices sorts device mappings by container path
+func normalizeDevices(devices []models.DeviceIR) []models.DeviceIR {
+	if len(devices) == 0 {
+		return devices
+	}
+
+	result := make([]models.DeviceIR, len(devices))
+	copy(result, devices)
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Target < result[j].Target
+	})
+
+	return result
+}
+
+// normalizeDeviceRequests sorts the capabilities and IDs of each device request
+func normalizeDeviceRequests(requests []models.DeviceRequestIR) []models.DeviceRequestIR {
+	if len(requests) == 0 {
+		return requests
+	}
+
+	result := make([]models.DeviceRequestIR, len(requests))
+	for i, r := range requests {
+		r.Capabilities = sortedStrings(r.Capabilities)
+		r.DeviceIDs = sortedStrings(r.DeviceIDs)
+		result[i] = r
+	}
+
+	return result
+}
+
 // sortedStrings returns a sorted copy of the string slice
 func sortedStrings(s []string) []string {
 	if len(s) == 0 {
@@ -0,0 +1,38 @@
+package parser
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestNormalizeOrdered(t *testing.T) {
+	ir := &models.ComposeIR{Services: map[string]models.ServiceIR{"api": {
+		Command:   []string{"serve", "--port", "80"},
+		EnvFiles:  []string{"prod.env", "base.env"},
+		DependsOn: []string{"redis", "db"},
+	}}}
+
+	api := Normalize(ir).Services["api"]
+	if !slices.Equal(api.Command, []string{"serve", "--port", "80"}) || !slices.Equal(api.EnvFiles, []string{"prod.env", "base.env"}) {
+		t.Errorf("Expected command and env_file in order, got %v and %v", api.Command, api.EnvFiles)
+	}
+	if !slices.Equal(api.DependsOn, []string{"db", "redis"}) {
+		t.Errorf("Expected depends_on sorted, got %v", api.DependsOn)
+	}
+
+	api = NormalizeOrdered(ir, []string{"depends_on"}).Services["api"]
+	if !slices.Equal(api.DependsOn, []string{"redis", "db"}) || !slices.Equal(api.EnvFiles, []string{"base.env", "prod.env"}) {
+		t.Errorf("Expected depends_on in order and env_file sorted, got %v and %v", api.DependsOn, api.EnvFiles)
+	}
+}
+
+func TestCheckOrderedLists(t *testing.T) {
+	if err := CheckOrderedLists([]string{"env_file", "build.cache_from"}); err != nil {
+		t.Errorf("Expected known fields to pass, got %v", err)
+	}
+	if err := CheckOrderedLists([]string{"ports"}); err == nil {
+		t.Error("Expected ports to be rejected")
+	}
+}
@@ -0,0 +1,37 @@
+package parser
+
+import "github.com/stackgen-cli/compose-diff/internal/models"
+
+// FilterProfiles keeps the services docker compose would start with the given
+// profiles enabled: services without profiles always run, others only when
+// one of their profiles is enabled. "*" enables every profile.
+func FilterProfiles(ir *models.ComposeIR, profiles []string) *models.ComposeIR {
+	enabled := make(map[string]bool, len(profiles))
+	for _, p := range profiles {
+		if p == "*" {
+			return ir
+		}
+		enabled[p] = true
+	}
+
+	result := *ir
+	result.Services = make(map[string]models.ServiceIR)
+	for name, svc := range ir.Services {
+		if serviceActive(svc.Profiles, enabled) {
+			result.Services[name] = svc
+		}
+	}
+	return &result
+}
+
+func serviceActive(profiles []string, enabled map[string]bool) bool {
+	if len(profiles) == 0 {
+		return true
+	}
+	for _, p := range profiles {
+		if enabled[p] {
+			return true
+		}
+	}
+	return false
+}
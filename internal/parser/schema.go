@@ -0,0 +1,108 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// irMigration upgrades a generic IR document from one schema version to the next
+type irMigration struct {
+	from  int
+	apply func(doc map[string]any) error
+}
+
+// irMigrations are applied in order to bring old documents up to date
+var irMigrations = []irMigration{
+	{from: 1, apply: migrateExpandPortRanges},
+}
+
+// MarshalIR serializes a ComposeIR with the current schema version
+func MarshalIR(ir *models.ComposeIR) ([]byte, error) {
+	return json.MarshalIndent(models.VersionedIR{
+		SchemaVersion: models.IRSchemaVersion,
+		ComposeIR:     *ir,
+	}, "", "  ")
+}
+
+// UnmarshalIR decodes a serialized ComposeIR, migrating older schema
+// versions. Documents without a version predate versioning and are treated
+// as version 1.
+func UnmarshalIR(data []byte) (*models.ComposeIR, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid IR document: %w", err)
+	}
+
+	version := 1
+	if v, ok := doc["schema_version"].(float64); ok {
+		version = int(v)
+	}
+	if version > models.IRSchemaVersion {
+		return nil, fmt.Errorf("IR schema version %d is newer than this compose-diff supports (%d); upgrade compose-diff", version, models.IRSchemaVersion)
+	}
+
+	for _, m := range irMigrations {
+		if m.from < version {
+			continue
+		}
+		if err := m.apply(doc); err != nil {
+			return nil, fmt.Errorf("migrating IR from version %d: %w", m.from, err)
+		}
+		version = m.from + 1
+	}
+
+	migrated, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var v models.VersionedIR
+	if err := json.Unmarshal(migrated, &v); err != nil {
+		return nil, fmt.Errorf("invalid IR document: %w", err)
+	}
+
+	ir := &v.ComposeIR
+	if ir.Services == nil {
+		ir.Services = make(map[string]models.ServiceIR)
+	}
+	if ir.Volumes == nil {
+		ir.Volumes = make(map[string]models.VolumeIR)
+	}
+	if ir.Networks == nil {
+		ir.Networks = make(map[string]models.NetworkIR)
+	}
+	return ir, nil
+}
+
+// migrateExpandPortRanges expands version 1 port ranges ("8000-8010") into
+// one port per entry, as the parser now does
+func migrateExpandPortRanges(doc map[string]any) error {
+	services, _ := doc["services"].(map[string]any)
+	for name, raw := range services {
+		svc, ok := raw.(map[string]any)
+		if !ok || svc["ports"] == nil {
+			continue
+		}
+
+		data, err := json.Marshal(svc["ports"])
+		if err != nil {
+			return err
+		}
+		var ports []models.PortIR
+		if err := json.Unmarshal(data, &ports); err != nil {
+			return fmt.Errorf("service %s: %w", name, err)
+		}
+
+		var expanded []models.PortIR
+		for _, p := range ports {
+			e, err := expandPortRange(p)
+			if err != nil {
+				return fmt.Errorf("service %s: %w", name, err)
+			}
+			expanded = append(expanded, e...)
+		}
+		svc["ports"] = expanded
+	}
+	return nil
+}
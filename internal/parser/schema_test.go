@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestMarshalIRRoundTrip(t *testing.T) {
+	image := "nginx:1.25"
+	ir := models.NewComposeIR()
+	ir.Services["web"] = models.ServiceIR{
+		Image: &image,
+		Ports: []models.PortIR{{HostPort: "8080", ContainerPort: "80", Protocol: "tcp"}},
+	}
+
+	data, err := MarshalIR(ir)
+	if err != nil {
+		t.Fatalf("MarshalIR failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"schema_version": 2`) {
+		t.Errorf("Expected schema version in output: %s", data)
+	}
+
+	got, err := UnmarshalIR(data)
+	if err != nil {
+		t.Fatalf("UnmarshalIR failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, ir) {
+		t.Errorf("Round trip mismatch:\n got %+v\nwant %+v", got, ir)
+	}
+}
+
+func TestUnmarshalIRMigratesUnversionedPortRanges(t *testing.T) {
+	v1 := `{"services": {"web": {"ports": [{"host_port": "8000-8001", "container_port": "8000-8001", "protocol": "tcp"}]}}}`
+
+	ir, err := UnmarshalIR([]byte(v1))
+	if err != nil {
+		t.Fatalf("UnmarshalIR failed: %v", err)
+	}
+
+	expected := []models.PortIR{
+		{HostPort: "8000", ContainerPort: "8000", Protocol: "tcp"},
+		{HostPort: "8001", ContainerPort: "8001", Protocol: "tcp"},
+	}
+	if ports := ir.Services["web"].Ports; !reflect.DeepEqual(ports, expected) {
+		t.Errorf("Expected expanded ports, got %+v", ports)
+	}
+}
+
+func TestUnmarshalIRRejectsNewerVersion(t *testing.T) {
+	if _, err := UnmarshalIR([]byte(`{"schema_version": 99, "services": {}}`)); err == nil {
+		t.Error("Expected error for a newer schema version")
+	}
+}
@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"strconv"
+
+	"github.com/stackgen-cli/compose-diff/internal/units"
+)
+
+// unparsed collects the values that cannot be converted to their type, by
+// path below the service. Raw mode does not interpolate, so a size written
+// as ${API_MEM:-512m} is kept as text and compared as such rather than
+// failing the whole file.
+type unparsed map[string]string
+
+// size converts a byte size such as 512m
+func (u unparsed) size(path, s string) int64 {
+	if s == "" {
+		return 0
+	}
+	n, err := units.ParseBytes(s)
+	if err != nil {
+		u[path] = s
+		return 0
+	}
+	return n
+}
+
+// number converts a CPU count such as 0.5
+func (u unparsed) number(path, s string) float64 {
+	if s == "" {
+		return 0
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		u[path] = s
+		return 0
+	}
+	return n
+}
+
+// integer converts a count or weight such as cpu_shares
+func (u unparsed) integer(path, s string) int64 {
+	if s == "" {
+		return 0
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		u[path] = s
+		return 0
+	}
+	return n
+}
+
+// boolean converts a compose boolean such as true or "yes"
+func (u unparsed) boolean(path, s string) bool {
+	if s == "" {
+		return false
+	}
+	b, ok := parseBool(s)
+	if !ok {
+		u[path] = s
+	}
+	return b
+}
+
+// result returns the collected values, or nil if there are none
+func (u unparsed) result() map[string]string {
+	if len(u) == 0 {
+		return nil
+	}
+	return u
+}
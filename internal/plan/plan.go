@@ -0,0 +1,128 @@
+// Package plan records a reviewed diff together with the hash of the new
+// compose file, so a later deploy step can check that the file it deploys
+// is the one that was reviewed. Plans are signed with an HMAC key shared by
+// the review and deploy jobs.
+package plan
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Version is the plan file format version
+const Version = "1"
+
+// ErrUnsigned is returned by Verify for a plan written without a key
+var ErrUnsigned = errors.New("plan is not signed")
+
+// Plan is a reviewed report and the hash of the compose file it approves
+type Plan struct {
+	Version   string          `json:"version"`
+	CreatedAt time.Time       `json:"created_at"`
+	OldFile   string          `json:"old_file"`
+	NewFile   string          `json:"new_file"`
+	NewSHA256 string          `json:"new_sha256"` // of the new file's bytes
+	Report    json.RawMessage `json:"report"`     // the JSON report
+	Signature string          `json:"signature,omitempty"`
+}
+
+// New creates an unsigned plan for a report and the new file's contents
+func New(report any, oldFile, newFile string, content []byte, now time.Time) (*Plan, error) {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+	return &Plan{
+		Version:   Version,
+		CreatedAt: now.UTC(),
+		OldFile:   oldFile,
+		NewFile:   newFile,
+		NewSHA256: Hash(content),
+		Report:    data,
+	}, nil
+}
+
+// Hash returns the hex SHA-256 of a compose file's contents
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Sign sets the plan's signature with key
+func (p *Plan) Sign(key []byte) error {
+	sig, err := p.mac(key)
+	if err != nil {
+		return err
+	}
+	p.Signature = sig
+	return nil
+}
+
+// Verify checks the signature with key, then that content is the approved
+// file. Unsigned plans fail with ErrUnsigned unless allowUnsigned.
+func (p *Plan) Verify(key, content []byte, allowUnsigned bool) error {
+	switch {
+	case p.Signature == "" && !allowUnsigned:
+		return ErrUnsigned
+	case p.Signature != "" && len(key) == 0:
+		return fmt.Errorf("plan is signed, but no key was given to verify it")
+	case p.Signature != "":
+		want, err := p.mac(key)
+		if err != nil {
+			return err
+		}
+		if !hmac.Equal([]byte(want), []byte(p.Signature)) {
+			return fmt.Errorf("plan signature does not match: it was modified or signed with another key")
+		}
+	}
+
+	if got := Hash(content); got != p.NewSHA256 {
+		return fmt.Errorf("file does not match the approved plan (sha256 %s, approved %s)", got[:12], p.NewSHA256[:min(12, len(p.NewSHA256))])
+	}
+	return nil
+}
+
+// mac signs every field but the signature. The report is compacted first so
+// re-indenting the file does not invalidate it.
+func (p *Plan) mac(key []byte) (string, error) {
+	var report bytes.Buffer
+	if err := json.Compact(&report, p.Report); err != nil {
+		return "", fmt.Errorf("plan report: %w", err)
+	}
+	h := hmac.New(sha256.New, key)
+	fmt.Fprintf(h, "%s\n%s\n%s\n%s\n%s\n", p.Version, p.CreatedAt.Format(time.RFC3339Nano), p.OldFile, p.NewFile, p.NewSHA256)
+	h.Write(report.Bytes())
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Write saves the plan as indented JSON
+func (p *Plan) Write(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Load reads a plan file
+func Load(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p Plan
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("%s is not a plan file: %w", path, err)
+	}
+	if p.Version != Version {
+		return nil, fmt.Errorf("%s: unsupported plan version %q", path, p.Version)
+	}
+	return &p, nil
+}
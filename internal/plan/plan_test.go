@@ -0,0 +1,50 @@
+package plan
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	content := []byte("services:\n  api:\n    image: api:1.5\n")
+	key := []byte("s3cret")
+	report := map[string]any{"summary": map[string]int{"breaking_count": 1}}
+
+	p, err := New(report, "old.yml", "new.yml", content, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := p.Verify(key, content, false); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("Expected ErrUnsigned, got %v", err)
+	}
+	if err := p.Sign(key); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	// Round trip through a file, which re-indents the report
+	path := filepath.Join(t.TempDir(), "plan.json")
+	if err := p.Write(path); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if p, err = Load(path); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if err := p.Verify(key, content, false); err != nil {
+		t.Errorf("Expected the approved file to verify, got %v", err)
+	}
+	if err := p.Verify(key, append(content, '#'), false); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("Expected a hash mismatch, got %v", err)
+	}
+	if err := p.Verify([]byte("other"), content, false); err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Errorf("Expected a signature mismatch, got %v", err)
+	}
+
+	p.NewSHA256 = Hash(append(content, '#'))
+	if err := p.Verify(key, append(content, '#'), false); err == nil {
+		t.Error("Expected a tampered hash to fail the signature check")
+	}
+}
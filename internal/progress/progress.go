@@ -0,0 +1,92 @@
+// Package progress shows spinners for slow steps such as docker compose
+// config or registry pulls. It only draws on an interactive terminal, so CI
+// logs and redirected output stay clean.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+var frames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// tickInterval is how often the spinner redraws
+const tickInterval = 100 * time.Millisecond
+
+// Reporter starts tasks on one output stream
+type Reporter struct {
+	out  io.Writer
+	live bool
+}
+
+// New returns a reporter drawing to f when enabled and f is a terminal
+func New(f *os.File, enabled bool) *Reporter {
+	return &Reporter{out: f, live: enabled && IsTerminal(f)}
+}
+
+// Task is a running step with a spinner
+type Task struct {
+	r     *Reporter
+	label string
+	start time.Time
+	stop  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// Start shows a spinner next to label until Done or Fail is called
+func (r *Reporter) Start(label string) *Task {
+	t := &Task{r: r, label: label, start: time.Now(), stop: make(chan struct{})}
+	if !r.live {
+		return t
+	}
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		ticker := time.NewTicker(tickInterval)
+		defer ticker.Stop()
+		for i := 0; ; i++ {
+			fmt.Fprintf(r.out, "\r\033[K%s %s", frames[i%len(frames)], label)
+			select {
+			case <-t.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return t
+}
+
+// Done replaces the spinner with a success line
+func (t *Task) Done() {
+	t.finish("✓", "")
+}
+
+// Fail replaces the spinner with a failure line
+func (t *Task) Fail(err error) {
+	t.finish("✗", ": "+err.Error())
+}
+
+func (t *Task) finish(mark, detail string) {
+	if !t.r.live {
+		return
+	}
+	close(t.stop)
+	t.wg.Wait()
+	fmt.Fprintf(t.r.out, "\r\033[K%s %s%s (%s)\n", mark, t.label, detail, time.Since(t.start).Round(100*time.Millisecond))
+}
+
+// IsTerminal reports whether f is an interactive terminal
+func IsTerminal(f *os.File) bool {
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
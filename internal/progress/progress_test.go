@@ -0,0 +1,50 @@
+package progress
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSilentWhenNotATerminal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stderr.log")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	defer f.Close()
+
+	r := New(f, true)
+	r.Start("Resolving docker-compose.yml").Done()
+	r.Start("Pulling bundle").Fail(errors.New("unauthorized"))
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if len(data) != 0 {
+		t.Errorf("Expected no output on a non-terminal, got %q", data)
+	}
+}
+
+func TestLiveTaskFinishes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tty.log")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	defer f.Close()
+
+	// Force drawing as if f were a terminal
+	r := &Reporter{out: f, live: true}
+	r.Start("Resolving docker-compose.yml").Done()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if got := string(data); len(got) == 0 || got[len(got)-1] != '\n' {
+		t.Errorf("Expected a final status line, got %q", got)
+	}
+}
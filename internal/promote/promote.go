@@ -0,0 +1,76 @@
+// Package promote checks that promoting a compose file from one environment
+// to the next (staging to prod, blue to green) changes only the fields the
+// release process allows, such as the image tag or feature flags.
+package promote
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// topLevel are the path prefixes that make an --allow pattern absolute
+var topLevel = []string{"services.", "volumes.", "networks.", "x-"}
+
+// Policy is a compiled list of allowed change paths
+type Policy struct {
+	patterns []*regexp.Regexp
+}
+
+// Compile builds a policy from glob patterns. Patterns are relative to each
+// service ("image", "environment.FEATURE_*") unless they start with
+// services., volumes., networks. or x-. A pattern also allows everything
+// below the path it names, so "deploy" covers deploy.replicas.
+func Compile(allow []string) (*Policy, error) {
+	p := &Policy{}
+	for _, pattern := range allow {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if !absolute(pattern) {
+			pattern = "services.*." + pattern
+		}
+
+		expr := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+		re, err := regexp.Compile("^" + expr + `(\..*)?$`)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --allow pattern %q: %w", pattern, err)
+		}
+		p.patterns = append(p.patterns, re)
+	}
+	return p, nil
+}
+
+func absolute(pattern string) bool {
+	for _, prefix := range topLevel {
+		if strings.HasPrefix(pattern, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows reports whether a change at path is allowed
+func (p *Policy) Allows(path string) bool {
+	for _, re := range p.patterns {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check splits changes into those the policy allows and those it blocks
+func (p *Policy) Check(changes []models.Change) (allowed, blocked []models.Change) {
+	for _, c := range changes {
+		if p.Allows(c.Path) {
+			allowed = append(allowed, c)
+		} else {
+			blocked = append(blocked, c)
+		}
+	}
+	return allowed, blocked
+}
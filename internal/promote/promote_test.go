@@ -0,0 +1,45 @@
+package promote
+
+import (
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestPolicyAllows(t *testing.T) {
+	p, err := Compile([]string{"image", "environment.FEATURE_*", "deploy", "volumes.cache"})
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"services.api.image", true},
+		{"services.api.environment.FEATURE_CHECKOUT", true},
+		{"services.api.environment.DATABASE_URL", false},
+		{"services.api.deploy.replicas", true},
+		{"services.api.deploy_extra", false},
+		{"services.api.ports.80:80/tcp", false},
+		{"services.api", false},
+		{"volumes.cache.driver", true},
+		{"volumes.data", false},
+	}
+	for _, tt := range tests {
+		if got := p.Allows(tt.path); got != tt.want {
+			t.Errorf("Allows(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	p, _ := Compile([]string{"image"})
+	allowed, blocked := p.Check([]models.Change{
+		{Path: "services.api.image"},
+		{Path: "services.api.environment.DEBUG"},
+	})
+	if len(allowed) != 1 || len(blocked) != 1 || blocked[0].Path != "services.api.environment.DEBUG" {
+		t.Errorf("Unexpected split: allowed %v, blocked %v", allowed, blocked)
+	}
+}
@@ -0,0 +1,100 @@
+// Package recreate classifies changes by what applying them with docker
+// compose up does to the running containers: most settings replace the
+// container, environment, resource limits and restart policy only need it
+// restarted with new settings, and metadata has no runtime effect.
+package recreate
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// reloadFields apply without a new image, mounts or network setup; docker
+// update can even change limits and restart policy in place
+var reloadFields = map[string]bool{
+	"environment":     true,
+	"env_file":        true,
+	"mem_limit":       true,
+	"mem_reservation": true,
+	"cpus":            true,
+	"cpu_shares":      true,
+	"deploy":          true,
+	"restart":         true,
+}
+
+// noneFields do not reach the container's runtime. build only takes effect
+// when the image is rebuilt, which then shows up as an image change.
+var noneFields = map[string]bool{
+	"labels":     true,
+	"profiles":   true,
+	"depends_on": true,
+	"build":      true,
+}
+
+// Classify returns the runtime effect of one service change. Changes to
+// volumes, networks and other top-level entries are RuntimeNone, since
+// their effect shows up in the services that use them.
+func Classify(c models.Change) models.RuntimeEffect {
+	if c.Scope != models.ScopeService {
+		return models.RuntimeNone
+	}
+	switch c.Kind {
+	case models.ChangeDangling, models.ChangeCycle, models.ChangeOrphaned:
+		// Problems with the new file rather than edits to a service
+		return models.RuntimeNone
+	}
+
+	field, _, _ := strings.Cut(strings.TrimPrefix(c.Path, "services."+c.Name+"."), ".")
+	switch {
+	case c.Path == "services."+c.Name:
+		return models.RuntimeRecreate
+	case noneFields[field] || strings.HasPrefix(field, "x-"):
+		return models.RuntimeNone
+	case reloadFields[field]:
+		return models.RuntimeReload
+	}
+	return models.RuntimeRecreate
+}
+
+// Analyze returns the strongest effect of each changed service's changes,
+// by service name
+func Analyze(changes []models.Change) []models.ServiceRuntime {
+	effects := make(map[string]models.RuntimeEffect)
+	for _, c := range changes {
+		if c.Scope != models.ScopeService {
+			continue
+		}
+		effect := Classify(c)
+		if current, ok := effects[c.Name]; !ok || rank(effect) > rank(current) {
+			effects[c.Name] = effect
+		}
+	}
+
+	result := make([]models.ServiceRuntime, 0, len(effects))
+	for name, effect := range effects {
+		result = append(result, models.ServiceRuntime{Service: name, Effect: effect})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Service < result[j].Service })
+	return result
+}
+
+// Effects maps each service of Analyze to its effect
+func Effects(runtime []models.ServiceRuntime) map[string]models.RuntimeEffect {
+	m := make(map[string]models.RuntimeEffect, len(runtime))
+	for _, r := range runtime {
+		m[r.Service] = r.Effect
+	}
+	return m
+}
+
+func rank(e models.RuntimeEffect) int {
+	switch e {
+	case models.RuntimeRecreate:
+		return 2
+	case models.RuntimeReload:
+		return 1
+	}
+	return 0
+}
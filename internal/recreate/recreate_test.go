@@ -0,0 +1,52 @@
+package recreate
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func change(name, path string) models.Change {
+	return models.Change{Kind: models.ChangeModified, Scope: models.ScopeService, Name: name, Path: path}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		path string
+		want models.RuntimeEffect
+	}{
+		{"services.api", models.RuntimeRecreate},
+		{"services.api.image", models.RuntimeRecreate},
+		{"services.api.ports.80:80/tcp", models.RuntimeRecreate},
+		{"services.api.environment.DEBUG", models.RuntimeReload},
+		{"services.api.deploy.resources.limits.memory", models.RuntimeReload},
+		{"services.api.labels.team", models.RuntimeNone},
+		{"services.api.profiles", models.RuntimeNone},
+		{"services.api.x-owner", models.RuntimeNone},
+	}
+	for _, tt := range tests {
+		if got := Classify(change("api", tt.path)); got != tt.want {
+			t.Errorf("Classify(%s) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	changes := []models.Change{
+		change("web", "services.web.labels.team"),
+		change("api", "services.api.environment.DEBUG"),
+		change("api", "services.api.image"),
+		change("api", "services.api.labels.team"),
+		change("worker", "services.worker.environment.QUEUE"),
+		{Kind: models.ChangeRemoved, Scope: models.ScopeVolume, Name: "data", Path: "volumes.data"},
+	}
+	want := []models.ServiceRuntime{
+		{Service: "api", Effect: models.RuntimeRecreate},
+		{Service: "web", Effect: models.RuntimeNone},
+		{Service: "worker", Effect: models.RuntimeReload},
+	}
+	if got := Analyze(changes); !reflect.DeepEqual(got, want) {
+		t.Errorf("Analyze = %+v, want %+v", got, want)
+	}
+}
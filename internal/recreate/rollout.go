@@ -0,0 +1,122 @@
+package recreate
+
+import (
+	"sort"
+	"time"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// defaultInterval is docker's healthcheck interval when none is set
+const defaultInterval = 30 * time.Second
+
+// Planner orders restarts the way docker compose up -d does: a service
+// starts after the services it depends on. It keeps only the new file's
+// depends_on and healthcheck timings, so the IR can be dropped once built.
+type Planner struct {
+	dependsOn map[string][]string
+	waits     map[string]time.Duration
+}
+
+// NewPlanner builds a planner from the new file's IR
+func NewPlanner(ir *models.ComposeIR) *Planner {
+	p := &Planner{dependsOn: make(map[string][]string), waits: make(map[string]time.Duration)}
+	if ir == nil {
+		return p
+	}
+	for name, svc := range ir.Services {
+		p.dependsOn[name] = svc.DependsOn
+		if wait := healthWait(svc.Healthcheck); wait > 0 {
+			p.waits[name] = wait
+		}
+	}
+	return p
+}
+
+// healthWait estimates how long a restarted container takes to report
+// healthy: the first check runs after the interval, and failures within
+// the start period do not count, so the longer of the two
+func healthWait(hc *models.HealthcheckIR) time.Duration {
+	if hc == nil || healthcheckDisabled(hc) {
+		return 0
+	}
+	interval := defaultInterval
+	if d, err := time.ParseDuration(hc.Interval); err == nil && d > 0 {
+		interval = d
+	}
+	if d, err := time.ParseDuration(hc.StartPeriod); err == nil && d > interval {
+		return d
+	}
+	return interval
+}
+
+func healthcheckDisabled(hc *models.HealthcheckIR) bool {
+	return hc.Disable || (len(hc.Test) > 0 && hc.Test[0] == "NONE")
+}
+
+// Plan groups the services that runtime says restart (recreate or reload)
+// into steps: each step waits for the restarted services it depends on,
+// directly or through unchanged services, in earlier steps. Removed
+// services and those with no runtime effect are left out.
+func (p *Planner) Plan(runtime []models.ServiceRuntime) []models.RolloutStep {
+	effects := make(map[string]models.RuntimeEffect)
+	for _, r := range runtime {
+		if _, ok := p.dependsOn[r.Service]; ok && r.Effect != models.RuntimeNone {
+			effects[r.Service] = r.Effect
+		}
+	}
+
+	// depth counts the restarted services on the longest depends_on chain
+	// below a service
+	depth := make(map[string]int)
+	visiting := make(map[string]bool)
+	var walk func(name string) int
+	walk = func(name string) int {
+		if d, ok := depth[name]; ok {
+			return d
+		}
+		if visiting[name] {
+			return 0 // cycles are reported as changes of their own
+		}
+		visiting[name] = true
+		d := 0
+		for _, dep := range p.dependsOn[name] {
+			below := walk(dep)
+			if _, restarted := effects[dep]; restarted {
+				below++
+			}
+			d = max(d, below)
+		}
+		visiting[name] = false
+		depth[name] = d
+		return d
+	}
+
+	var steps []models.RolloutStep
+	var waits []time.Duration
+	names := make([]string, 0, len(effects))
+	for name := range effects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		d := walk(name)
+		for len(steps) <= d {
+			steps = append(steps, models.RolloutStep{})
+			waits = append(waits, 0)
+		}
+		steps[d].Services = append(steps[d].Services, models.ServiceRuntime{Service: name, Effect: effects[name]})
+		waits[d] = max(waits[d], p.waits[name])
+	}
+	result := steps[:0]
+	for i, step := range steps {
+		if len(step.Services) == 0 {
+			continue // only possible when a depends_on cycle cut the walk short
+		}
+		if waits[i] > 0 {
+			step.HealthWait = waits[i].String()
+		}
+		result = append(result, step)
+	}
+	return result
+}
@@ -0,0 +1,34 @@
+package recreate
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestPlan(t *testing.T) {
+	ir := models.NewComposeIR()
+	ir.Services["db"] = models.ServiceIR{Healthcheck: &models.HealthcheckIR{Test: []string{"CMD", "pg_isready"}, Interval: "5s", StartPeriod: "20s"}}
+	ir.Services["cache"] = models.ServiceIR{Healthcheck: &models.HealthcheckIR{Test: []string{"CMD", "redis-cli", "ping"}}}
+	ir.Services["api"] = models.ServiceIR{DependsOn: []string{"db", "cache"}}
+	ir.Services["proxy"] = models.ServiceIR{DependsOn: []string{"api"}}
+	ir.Services["web"] = models.ServiceIR{DependsOn: []string{"proxy"}}
+
+	runtime := []models.ServiceRuntime{
+		{Service: "api", Effect: models.RuntimeReload},
+		{Service: "cache", Effect: models.RuntimeRecreate},
+		{Service: "db", Effect: models.RuntimeRecreate},
+		{Service: "legacy", Effect: models.RuntimeRecreate},
+		{Service: "proxy", Effect: models.RuntimeNone},
+		{Service: "web", Effect: models.RuntimeRecreate},
+	}
+	want := []models.RolloutStep{
+		{Services: []models.ServiceRuntime{{Service: "cache", Effect: models.RuntimeRecreate}, {Service: "db", Effect: models.RuntimeRecreate}}, HealthWait: "30s"},
+		{Services: []models.ServiceRuntime{{Service: "api", Effect: models.RuntimeReload}}},
+		{Services: []models.ServiceRuntime{{Service: "web", Effect: models.RuntimeRecreate}}},
+	}
+	if got := NewPlanner(ir).Plan(runtime); !reflect.DeepEqual(got, want) {
+		t.Errorf("Plan = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,66 @@
+package redact
+
+import (
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestSecretKey(t *testing.T) {
+	for key, want := range map[string]bool{
+		"POSTGRES_PASSWORD":     true,
+		"github_token":          true,
+		"AWS_SECRET_ACCESS_KEY": true,
+		"com.acme.api-key":      true,
+		"SECRET":                true,
+		"DATABASE_URL":          false,
+		"MONKEY":                false,
+		"TOKEN_TTL":             false,
+	} {
+		if got := SecretKey(key); got != want {
+			t.Errorf("SecretKey(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestValue(t *testing.T) {
+	for in, want := range map[string]string{
+		"postgres://app:s3cr3t@db:5432/app":                                       "postgres://app:[redacted]@db:5432/app",
+		"redis://:hunter2@cache:6379":                                             "redis://:[redacted]@cache:6379",
+		"http://api:8080/health":                                                  "http://api:8080/health",
+		"--api-key=Zk3pQ9vX2mL7wR4tY8nB1cD6":                                      "--api-key=[redacted]",
+		"ghcr.io/acme/api-gateway-service:1.2.3":                                  "ghcr.io/acme/api-gateway-service:1.2.3",
+		"sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08": "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+	} {
+		if got := Value(in); got != want {
+			t.Errorf("Value(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestChange(t *testing.T) {
+	c := Change(models.Change{
+		Kind: models.ChangeModified, Scope: models.ScopeService, Name: "db",
+		Path:   "services.db.environment.POSTGRES_PASSWORD",
+		Before: "old-password", After: "${POSTGRES_PASSWORD}",
+	})
+	if c.Before != Mask || c.After != "${POSTGRES_PASSWORD}" {
+		t.Errorf("Expected the old password masked and the reference kept, got %v -> %v", c.Before, c.After)
+	}
+
+	token := "abc123"
+	c = Change(models.Change{
+		Kind: models.ChangeAdded, Scope: models.ScopeService, Name: "token",
+		Path:  "services.token",
+		After: models.ServiceIR{Env: map[string]*string{"API_TOKEN": &token, "DATABASE_URL": ptr("mysql://root:pw@db/app")}},
+	})
+	env := c.After.(models.ServiceIR).Env
+	if *env["API_TOKEN"] != Mask || *env["DATABASE_URL"] != "mysql://root:[redacted]@db/app" {
+		t.Errorf("Unexpected environment of an added service: %s, %s", *env["API_TOKEN"], *env["DATABASE_URL"])
+	}
+	if token != "abc123" {
+		t.Error("Change modified the original service")
+	}
+}
+
+func ptr(s string) *string { return &s }
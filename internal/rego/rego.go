@@ -0,0 +1,131 @@
+// Package rego evaluates Rego policies against diff reports with the opa
+// CLI. A policy is a package compose_diff that gets the JSON report as
+// input and may define:
+//
+//	deny      a set of messages; any message fails the diff
+//	severity  an object of change path to info, warning or breaking
+//	annotate  a set of {"path": ..., "message": ...} notes shown with changes
+package rego
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// CheckDeny is the check of the findings a policy's deny rules produce
+const CheckDeny = "policy-deny"
+
+// Binary is the opa executable
+var Binary = "opa"
+
+// Result is what a policy decided about a report
+type Result struct {
+	Deny     []string                   `json:"deny"`
+	Severity map[string]models.Severity `json:"severity"`
+	Annotate []Annotation               `json:"annotate"`
+}
+
+// Annotation is a note on the change at Path
+type Annotation struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Evaluate runs the policy file against input, the JSON report
+func Evaluate(policy string, input any) (*Result, error) {
+	if _, err := exec.LookPath(Binary); err != nil {
+		return nil, fmt.Errorf("--policy needs the opa CLI on PATH (https://www.openpolicyagent.org/docs/latest/#running-opa)")
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(Binary, "eval", "--format", "json", "--stdin-input", "--data", policy, "data.compose_diff")
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// opa explains compile and evaluation errors on stdout
+		msg := strings.TrimSpace(stderr.String() + stdout.String())
+		return nil, fmt.Errorf("opa eval %s: %v: %s", policy, err, msg)
+	}
+	return parseOutput(stdout.Bytes())
+}
+
+// parseOutput reads the result of opa eval --format json
+func parseOutput(data []byte) (*Result, error) {
+	var out struct {
+		Result []struct {
+			Expressions []struct {
+				Value json.RawMessage `json:"value"`
+			} `json:"expressions"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("reading opa output: %w", err)
+	}
+	if len(out.Result) == 0 || len(out.Result[0].Expressions) == 0 {
+		return nil, errors.New("the policy defines nothing in package compose_diff")
+	}
+
+	var result Result
+	if err := json.Unmarshal(out.Result[0].Expressions[0].Value, &result); err != nil {
+		return nil, fmt.Errorf("policy result: %w (deny must be a set of strings, severity an object and annotate a set of objects)", err)
+	}
+	for path, s := range result.Severity {
+		if models.SeverityLevel(s) == 0 {
+			return nil, fmt.Errorf("policy severity for %s: unknown severity %q (use info, warning or breaking)", path, s)
+		}
+	}
+	sort.Strings(result.Deny)
+	return &result, nil
+}
+
+// Apply reclassifies and annotates the report's changes, recounting the
+// summary, and adds a breaking finding per deny message
+func (r *Result) Apply(report *models.DiffReport) {
+	notes := make(map[string][]string)
+	for _, a := range r.Annotate {
+		notes[a.Path] = append(notes[a.Path], a.Message)
+	}
+
+	report.Summary.BreakingCount, report.Summary.WarningCount, report.Summary.InfoCount = 0, 0, 0
+	for i := range report.Changes {
+		c := &report.Changes[i]
+		if s, ok := r.Severity[c.Path]; ok {
+			c.Severity = s
+		}
+		c.Notes = append(c.Notes, notes[c.Path]...)
+		switch c.Severity {
+		case models.SeverityBreaking:
+			report.Summary.BreakingCount++
+		case models.SeverityWarning:
+			report.Summary.WarningCount++
+		default:
+			report.Summary.InfoCount++
+		}
+	}
+
+	for _, msg := range r.Deny {
+		report.Findings = append(report.Findings, models.Finding{Check: CheckDeny, Severity: models.SeverityBreaking, Message: msg})
+	}
+}
+
+// Denied reports whether a policy denied the report
+func Denied(report *models.DiffReport) bool {
+	for _, f := range report.Findings {
+		if f.Check == CheckDeny {
+			return true
+		}
+	}
+	return false
+}
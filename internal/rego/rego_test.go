@@ -0,0 +1,97 @@
+package rego
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+const opaOutput = `{
+  "result": [{
+    "expressions": [{
+      "value": {
+        "deny": ["redis must stay pinned", "api lost its healthcheck"],
+        "severity": {"services.db.image": "info", "services.cache.image": "breaking"},
+        "annotate": [{"path": "services.db.image", "message": "minor bump, checked by DBA"}]
+      },
+      "text": "data.compose_diff",
+      "location": {"row": 1, "col": 1}
+    }]
+  }]
+}`
+
+func TestApply(t *testing.T) {
+	result, err := parseOutput([]byte(opaOutput))
+	if err != nil {
+		t.Fatalf("parseOutput failed: %v", err)
+	}
+
+	report := &models.DiffReport{Changes: []models.Change{
+		{Kind: models.ChangeModified, Path: "services.db.image", Severity: models.SeverityWarning},
+		{Kind: models.ChangeModified, Path: "services.cache.image", Severity: models.SeverityInfo},
+		{Kind: models.ChangeRemoved, Path: "services.api.environment.DEBUG", Severity: models.SeverityInfo},
+	}}
+	if Denied(report) {
+		t.Error("Expected the report not to be denied before the policy applies")
+	}
+	result.Apply(report)
+
+	if got := report.Changes[0]; got.Severity != models.SeverityInfo || !reflect.DeepEqual(got.Notes, []string{"minor bump, checked by DBA"}) {
+		t.Errorf("Expected db image reclassified to info with a note, got %+v", got)
+	}
+	if got := report.Changes[1].Severity; got != models.SeverityBreaking {
+		t.Errorf("Expected cache image reclassified to breaking, got %s", got)
+	}
+	if s := report.Summary; s.BreakingCount != 1 || s.WarningCount != 0 || s.InfoCount != 2 {
+		t.Errorf("Expected 1 breaking and 2 info after the policy, got %+v", s)
+	}
+	if !Denied(report) || len(report.Findings) != 2 || report.Findings[0].Message != "api lost its healthcheck" {
+		t.Errorf("Expected two sorted deny findings, got %+v", report.Findings)
+	}
+}
+
+func TestParseOutputErrors(t *testing.T) {
+	for output, want := range map[string]string{
+		`{"result": []}`: "defines nothing",
+		`{"result": [{"expressions": [{"value": {"deny": "no"}}]}]}`:                        "deny must be a set of strings",
+		`{"result": [{"expressions": [{"value": {"severity": {"services.a": "fatal"}}}]}]}`: "unknown severity",
+	} {
+		if _, err := parseOutput([]byte(output)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("parseOutput(%s) = %v, want an error containing %q", output, err, want)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	dir := t.TempDir()
+	defer func(b string) { Binary = b }(Binary)
+
+	Binary = filepath.Join(dir, "missing-opa")
+	if _, err := Evaluate("policy.rego", nil); err == nil || !strings.Contains(err.Error(), "opa CLI") {
+		t.Errorf("Expected an error naming the opa CLI, got %v", err)
+	}
+
+	// A stand-in for opa that checks its arguments and input
+	os.WriteFile(filepath.Join(dir, "output.json"), []byte(opaOutput), 0644)
+	Binary = filepath.Join(dir, "opa")
+	os.WriteFile(Binary, []byte(`#!/bin/sh
+[ "$*" = "eval --format json --stdin-input --data policy.rego data.compose_diff" ] || { echo "bad args: $*" >&2; exit 1; }
+grep -q '"new_file":"new.yml"' || { echo "bad input" >&2; exit 1; }
+cat "`+filepath.Join(dir, "output.json")+`"
+`), 0755)
+	result, err := Evaluate("policy.rego", map[string]string{"new_file": "new.yml"})
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if len(result.Deny) != 2 || result.Severity["services.db.image"] != models.SeverityInfo {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	if _, err := Evaluate("other.rego", nil); err == nil || !strings.Contains(err.Error(), "bad args") {
+		t.Errorf("Expected opa's error output in the error, got %v", err)
+	}
+}
@@ -77,6 +77,7 @@ func ToCategorySummary(report *models.DiffReport, oldFile, newFile string) strin
 		red(fmt.Sprintf("%d breaking", totalBreaking)),
 		yellow(fmt.Sprintf("%d warning", totalWarning)),
 		totalInfo))
+	sb.WriteString(fmt.Sprintf("Risk score: %d\n", report.Summary.RiskScore))
 
 	return sb.String()
 }
@@ -140,6 +141,16 @@ func ToCategoryDetail(report *models.DiffReport, oldFile, newFile string) string
 				sb.WriteString(fmt.Sprintf("  %s %s %s (removed)\n", icon, sevLabel, svcField))
 			case models.ChangeModified:
 				sb.WriteString(fmt.Sprintf("  %s %s %s: %v → %v\n", icon, sevLabel, svcField, formatValue(c.Before), formatValue(c.After)))
+			case models.ChangeReordered:
+				sb.WriteString(fmt.Sprintf("  %s %s %s (reordered): %v → %v\n", icon, sevLabel, svcField, formatValue(c.Before), formatValue(c.After)))
+			case models.ChangeRenamed:
+				sb.WriteString(fmt.Sprintf("  %s %s %s (renamed from %v)\n", icon, sevLabel, svcField, formatValue(c.Before)))
+			case models.ChangeDangling:
+				sb.WriteString(fmt.Sprintf("  %s %s %s (%s)\n", icon, sevLabel, svcField, danglingTarget(c)))
+			case models.ChangeCycle:
+				sb.WriteString(fmt.Sprintf("  %s %s %s (cycle: %v)\n", icon, sevLabel, svcField, c.After))
+			case models.ChangeOrphaned:
+				sb.WriteString(fmt.Sprintf("  %s %s %s (orphaned, no longer used by %s)\n", icon, sevLabel, svcField, nameList(c.Before)))
 			}
 		}
 	}
@@ -166,7 +177,7 @@ func groupByCategory(changes []models.Change) []CategorySummary {
 	categories := make(map[string]*CategorySummary)
 
 	for _, c := range changes {
-		cat := categorizeChange(c)
+		cat := Category(c)
 		if _, ok := categories[cat]; !ok {
 			categories[cat] = &CategorySummary{
 				Category: cat,
@@ -207,8 +218,12 @@ func groupByCategory(changes []models.Change) []CategorySummary {
 	return result
 }
 
-// categorizeChange determines the category of a change
-func categorizeChange(c models.Change) string {
+// Categories lists every category Category returns
+var Categories = []string{"environment", "ports", "images", "volumes", "networks", "deploy", "dependencies", "labels", "logging", "other"}
+
+// Category returns the category a change is summarized under, e.g.
+// environment, ports or images
+func Category(c models.Change) string {
 	path := c.Path
 
 	if strings.Contains(path, ".environment.") || strings.Contains(path, ".env_file") {
@@ -227,7 +242,8 @@ func categorizeChange(c models.Change) string {
 		return "networks"
 	}
 	if strings.Contains(path, ".deploy.") || strings.Contains(path, ".replicas") ||
-		strings.Contains(path, ".resources.") {
+		strings.Contains(path, ".resources.") || strings.Contains(path, ".mem_") ||
+		strings.HasSuffix(path, ".cpus") || strings.HasSuffix(path, ".cpu_shares") {
 		return "deploy"
 	}
 	if strings.Contains(path, ".depends_on") || strings.Contains(path, ".healthcheck") {
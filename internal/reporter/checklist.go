@@ -0,0 +1,32 @@
+package reporter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/hints"
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// ToChecklist renders breaking changes as a markdown review task list
+func ToChecklist(report *models.DiffReport) string {
+	breakingChanges := filterBySeverity(report.Changes, models.SeverityBreaking)
+	if len(breakingChanges) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("### ✅ Review Checklist\n\n")
+
+	seen := make(map[string]bool)
+	for _, c := range breakingChanges {
+		item := hints.ChecklistItem(c)
+		if seen[item] {
+			continue
+		}
+		seen[item] = true
+		sb.WriteString(fmt.Sprintf("- [ ] %s\n", item))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,37 @@
+package reporter
+
+import (
+	"encoding/csv"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// csvHeader names the columns of ToCSV
+var csvHeader = []string{"kind", "scope", "name", "path", "before", "after", "severity", "category"}
+
+// ToCSV generates one row per change, with a header row, for spreadsheets.
+// sep is ',' for CSV or '\t' for TSV. Values that are not scalars are
+// written as JSON.
+func ToCSV(report *models.DiffReport, sep rune) string {
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+	w.Comma = sep
+
+	w.Write(csvHeader)
+	for _, c := range report.Changes {
+		w.Write([]string{
+			string(c.Kind),
+			string(c.Scope),
+			c.Name,
+			c.Path,
+			formatTemplateValue(c.Before),
+			formatTemplateValue(c.After),
+			string(c.Severity),
+			Category(c),
+		})
+	}
+	w.Flush()
+
+	return sb.String()
+}
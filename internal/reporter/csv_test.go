@@ -0,0 +1,27 @@
+package reporter
+
+import (
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestToCSV(t *testing.T) {
+	report := models.NewDiffReport()
+	report.AddChange(models.Change{
+		Kind: models.ChangeModified, Scope: models.ScopeService, Name: "api",
+		Path: "services.api.environment.GREETING", Before: "hi", After: "hello, world", Severity: models.SeverityInfo,
+	})
+
+	want := "kind,scope,name,path,before,after,severity,category\n" +
+		"modified,service,api,services.api.environment.GREETING,hi,\"hello, world\",info,environment\n"
+	if got := ToCSV(report, ','); got != want {
+		t.Errorf("Unexpected CSV:\n%s", got)
+	}
+
+	want = "kind\tscope\tname\tpath\tbefore\tafter\tseverity\tcategory\n" +
+		"modified\tservice\tapi\tservices.api.environment.GREETING\thi\thello, world\tinfo\tenvironment\n"
+	if got := ToCSV(report, '\t'); got != want {
+		t.Errorf("Unexpected TSV:\n%s", got)
+	}
+}
@@ -0,0 +1,84 @@
+package reporter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// ToGitHubAnnotations generates GitHub Actions workflow commands, one per
+// change, so changes show up inline on the PR's file view. Breaking changes
+// are errors, warnings are warnings and the rest are notices. Annotations
+// point at the new file, where removed values are reported on their
+// enclosing key.
+func ToGitHubAnnotations(report *models.DiffReport, oldFile, newFile string) string {
+	var sb strings.Builder
+
+	for _, d := range report.Diagnostics {
+		props := []string{"file=" + escapeProperty(d.File), fmt.Sprintf("line=%d", d.Line), "title=" + escapeProperty("compose-diff: skipped")}
+		sb.WriteString(fmt.Sprintf("::warning %s::%s\n", strings.Join(props, ","), escapeData(fmt.Sprintf("%s: %s", d.Path, d.Message))))
+	}
+
+	for _, f := range report.Findings {
+		props := []string{"file=" + escapeProperty(newFile), "title=" + escapeProperty("compose-diff: "+f.Check)}
+		sb.WriteString(fmt.Sprintf("::%s %s::%s\n", annotationLevel(f.Severity), strings.Join(props, ","), escapeData(f.Message)))
+	}
+
+	for _, c := range report.Changes {
+		level := annotationLevel(c.Severity)
+		props := []string{"file=" + escapeProperty(newFile)}
+		if c.NewLine > 0 {
+			props = append(props, fmt.Sprintf("line=%d", c.NewLine))
+		}
+		props = append(props, "title="+escapeProperty(fmt.Sprintf("compose-diff: %s %s", c.Severity, c.Kind)))
+
+		sb.WriteString(fmt.Sprintf("::%s %s::%s\n", level, strings.Join(props, ","), escapeData(ChangeMessage(c))))
+	}
+
+	s := report.Summary
+	sb.WriteString(fmt.Sprintf("compose-diff: %s → %s: %d changes (%d breaking, %d warnings, %d info), risk score %d\n",
+		oldFile, newFile, s.TotalChanges, s.BreakingCount, s.WarningCount, s.InfoCount, s.RiskScore))
+	return sb.String()
+}
+
+// annotationLevel maps a severity to a workflow command
+func annotationLevel(s models.Severity) string {
+	switch s {
+	case models.SeverityBreaking:
+		return "error"
+	case models.SeverityWarning:
+		return "warning"
+	}
+	return "notice"
+}
+
+// ChangeLine describes a change on one line without markdown
+func ChangeLine(c models.Change) string {
+	if c.Path == fmt.Sprintf("%ss.%s", c.Scope, c.Name) {
+		// Whole services, volumes and networks are too large to print
+		if c.Kind == models.ChangeRenamed {
+			return fmt.Sprintf("%s %s renamed from %v", c.Scope, c.Name, c.Before)
+		}
+		if c.Kind == models.ChangeOrphaned {
+			return fmt.Sprintf("%s %s orphaned, no longer used by %s", c.Scope, c.Name, nameList(c.Before))
+		}
+		return fmt.Sprintf("%s %s %s", c.Scope, c.Name, c.Kind)
+	}
+	return fmt.Sprintf("%s: %s", c.Path, strings.ReplaceAll(formatChangeDescription(c), "`", ""))
+}
+
+// ChangeMessage is ChangeLine followed by the change's notes, one per line
+func ChangeMessage(c models.Change) string {
+	return strings.Join(append([]string{ChangeLine(c)}, c.Notes...), "\n")
+}
+
+// escapeData escapes a workflow command message
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a workflow command property value
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,72 @@
+package reporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestToGitHubAnnotations(t *testing.T) {
+	report := models.NewDiffReport()
+	report.AddChange(models.Change{
+		Kind:     models.ChangeRemoved,
+		Scope:    models.ScopeService,
+		Name:     "api",
+		Path:     "services.api.environment.DATABASE_URL",
+		Before:   "postgres://db:5432/app\nline2",
+		Severity: models.SeverityBreaking,
+		NewLine:  7,
+	})
+	report.AddChange(models.Change{
+		Kind:     models.ChangeAdded,
+		Scope:    models.ScopeService,
+		Name:     "worker",
+		Path:     "services.worker",
+		After:    models.ServiceIR{},
+		Severity: models.SeverityInfo,
+	})
+
+	lines := strings.Split(ToGitHubAnnotations(report, "old.yml", "deploy/compose,prod.yml"), "\n")
+
+	want := "::error file=deploy/compose%2Cprod.yml,line=7,title=compose-diff%3A breaking removed::" +
+		"services.api.environment.DATABASE_URL: Removed (was: postgres://db:5432/app%0Aline2)"
+	if lines[0] != want {
+		t.Errorf("Unexpected annotation:\n got %s\nwant %s", lines[0], want)
+	}
+	if want := "::notice file=deploy/compose%2Cprod.yml,title=compose-diff%3A info added::service worker added"; lines[1] != want {
+		t.Errorf("Unexpected annotation:\n got %s\nwant %s", lines[1], want)
+	}
+}
+
+func TestToGitHubAnnotationsDiagnostics(t *testing.T) {
+	report := models.NewDiffReport()
+	report.Diagnostics = []models.Diagnostic{
+		{File: "new.yml", Line: 4, Column: 5, Path: "services.api.ports", Message: "expected a list, got a mapping"},
+	}
+
+	lines := strings.Split(ToGitHubAnnotations(report, "old.yml", "new.yml"), "\n")
+	want := "::warning file=new.yml,line=4,title=compose-diff%3A skipped::services.api.ports: expected a list, got a mapping"
+	if lines[0] != want {
+		t.Errorf("Unexpected annotation:\n got %s\nwant %s", lines[0], want)
+	}
+}
+
+func TestChangeLineReorderedRenamed(t *testing.T) {
+	cases := map[string]models.Change{
+		"service web renamed from frontend": {
+			Kind: models.ChangeRenamed, Scope: models.ScopeService, Name: "web", Path: "services.web", Before: "frontend", After: "web",
+		},
+		"services.api.command: Reordered: [a b] → [b a]": {
+			Kind: models.ChangeReordered, Scope: models.ScopeService, Name: "api", Path: "services.api.command", Before: []string{"a", "b"}, After: []string{"b", "a"},
+		},
+		"services.api.environment.DB_URL: Renamed from DATABASE_URL": {
+			Kind: models.ChangeRenamed, Scope: models.ScopeService, Name: "api", Path: "services.api.environment.DB_URL", Before: "DATABASE_URL", After: "DB_URL",
+		},
+	}
+	for want, c := range cases {
+		if got := ChangeLine(c); got != want {
+			t.Errorf("ChangeLine = %q, want %q", got, want)
+		}
+	}
+}
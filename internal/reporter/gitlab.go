@@ -0,0 +1,77 @@
+package reporter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// CodeQualityIssue is one entry of a GitLab Code Quality report
+type CodeQualityIssue struct {
+	Description string              `json:"description"`
+	CheckName   string              `json:"check_name"`
+	Fingerprint string              `json:"fingerprint"`
+	Severity    string              `json:"severity"`
+	Location    CodeQualityLocation `json:"location"`
+}
+
+// CodeQualityLocation is where GitLab shows an issue in the merge request diff
+type CodeQualityLocation struct {
+	Path  string           `json:"path"`
+	Lines CodeQualityLines `json:"lines"`
+}
+
+// CodeQualityLines is the line range of an issue
+type CodeQualityLines struct {
+	Begin int `json:"begin"`
+}
+
+// codeQualitySeverity maps change severities to Code Quality ones
+var codeQualitySeverity = map[models.Severity]string{
+	models.SeverityBreaking: "critical",
+	models.SeverityWarning:  "major",
+	models.SeverityInfo:     "info",
+}
+
+// ToGitLabCodeQuality converts a report to GitLab's Code Quality format, so
+// changes show up in the merge request widget and diff. Issues point at the
+// new file. Fingerprints depend on the file, path and kind of change only,
+// so GitLab can tell which issues a later pipeline resolved.
+func ToGitLabCodeQuality(report *models.DiffReport, oldFile, newFile string) []CodeQualityIssue {
+	issues := make([]CodeQualityIssue, 0, len(report.Diagnostics)+len(report.Changes))
+
+	for _, d := range report.Diagnostics {
+		issues = append(issues, CodeQualityIssue{
+			Description: d.Path + ": " + d.Message,
+			CheckName:   "compose-diff/skipped",
+			Fingerprint: fingerprint(d.File, d.Path, "skipped"),
+			Severity:    "minor",
+			Location:    codeQualityLocation(d.File, d.Line),
+		})
+	}
+
+	for _, c := range report.Changes {
+		issues = append(issues, CodeQualityIssue{
+			Description: ChangeLine(c),
+			CheckName:   "compose-diff/" + string(c.Scope) + "-" + string(c.Kind),
+			Fingerprint: fingerprint(newFile, c.Path, string(c.Kind)),
+			Severity:    codeQualitySeverity[c.Severity],
+			Location:    codeQualityLocation(newFile, c.NewLine),
+		})
+	}
+	return issues
+}
+
+func codeQualityLocation(file string, line int) CodeQualityLocation {
+	return CodeQualityLocation{Path: file, Lines: CodeQualityLines{Begin: max(line, 1)}}
+}
+
+func fingerprint(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
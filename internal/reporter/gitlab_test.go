@@ -0,0 +1,45 @@
+package reporter
+
+import (
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestToGitLabCodeQuality(t *testing.T) {
+	report := models.NewDiffReport()
+	report.AddChange(models.Change{
+		Kind:     models.ChangeRemoved,
+		Scope:    models.ScopeService,
+		Name:     "api",
+		Path:     "services.api.ports.80:80/tcp",
+		Severity: models.SeverityBreaking,
+		NewLine:  4,
+	})
+	report.AddChange(models.Change{
+		Kind:     models.ChangeAdded,
+		Scope:    models.ScopeVolume,
+		Name:     "data",
+		Path:     "volumes.data",
+		Severity: models.SeverityInfo,
+	})
+
+	issues := ToGitLabCodeQuality(report, "old.yml", "compose.yml")
+	if len(issues) != 2 {
+		t.Fatalf("Expected 2 issues, got %d", len(issues))
+	}
+
+	first := issues[0]
+	if first.Severity != "critical" || first.CheckName != "compose-diff/service-removed" ||
+		first.Location.Path != "compose.yml" || first.Location.Lines.Begin != 4 {
+		t.Errorf("Unexpected issue %+v", first)
+	}
+	if issues[1].Description != "volume data added" || issues[1].Location.Lines.Begin != 1 {
+		t.Errorf("Unexpected issue %+v", issues[1])
+	}
+
+	again := ToGitLabCodeQuality(report, "other-old.yml", "compose.yml")
+	if first.Fingerprint == "" || again[0].Fingerprint != first.Fingerprint || issues[1].Fingerprint == first.Fingerprint {
+		t.Error("Expected fingerprints to be stable per change and distinct between changes")
+	}
+}
@@ -0,0 +1,209 @@
+package reporter
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+const htmlStyle = `
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2rem; color: #1f2328; }
+h1 { font-size: 1.5rem; }
+code { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; font-size: 0.85rem; }
+.summary { border-collapse: collapse; margin-bottom: 1.5rem; }
+.summary td { padding: 0.25rem 1rem 0.25rem 0; }
+details { border: 1px solid #d0d7de; border-radius: 6px; margin-bottom: 1rem; }
+summary { cursor: pointer; padding: 0.5rem 1rem; background: #f6f8fa; font-weight: 600; }
+table.changes { border-collapse: collapse; width: 100%; }
+table.changes th, table.changes td { border-top: 1px solid #d0d7de; padding: 0.4rem 0.75rem; text-align: left; vertical-align: top; }
+table.changes th { background: #fafbfc; }
+.before { color: #82071e; word-break: break-all; }
+.after { color: #116329; word-break: break-all; }
+.sev { font-weight: 600; border-radius: 4px; padding: 0 0.4rem; }
+.sev-breaking { background: #ffebe9; color: #cf222e; }
+.sev-warning { background: #fff8c5; color: #9a6700; }
+.sev-info { background: #ddf4ff; color: #0969da; }
+.badge { font-weight: normal; margin-left: 0.5rem; }
+.none { color: #116329; }
+.toolbar { position: sticky; top: 0; background: #fff; padding: 0.5rem 0; margin-bottom: 1rem; border-bottom: 1px solid #d0d7de; }
+.toolbar input[type=search] { width: 20rem; padding: 0.25rem 0.5rem; margin-right: 1rem; }
+.toolbar label { margin-right: 0.75rem; }
+a.anchor { color: #8c959f; text-decoration: none; margin-right: 0.25rem; }
+tr:target, p:target { background: #fff8c5; }
+`
+
+// htmlScript filters rows by the search box and severity toggles, and opens
+// the section holding the change a permalink points at
+const htmlScript = `
+(function () {
+  var search = document.getElementById("search");
+  var toggles = document.querySelectorAll(".toolbar input[type=checkbox]");
+  function apply() {
+    var q = search.value.toLowerCase();
+    var shown = {};
+    toggles.forEach(function (t) { shown[t.value] = t.checked; });
+    document.querySelectorAll("[data-sev]").forEach(function (el) {
+      var match = shown[el.dataset.sev] && el.textContent.toLowerCase().indexOf(q) >= 0;
+      el.style.display = match ? "" : "none";
+    });
+    document.querySelectorAll("details").forEach(function (d) {
+      var rows = d.querySelectorAll("tr[data-sev]");
+      var visible = Array.prototype.some.call(rows, function (r) { return r.style.display !== "none"; });
+      d.style.display = visible ? "" : "none";
+      if (q && visible) d.open = true;
+    });
+  }
+  function reveal() {
+    var el = location.hash && document.getElementById(location.hash.slice(1));
+    if (!el) return;
+    var d = el.closest("details");
+    if (d) d.open = true;
+    el.scrollIntoView();
+  }
+  search.addEventListener("input", apply);
+  toggles.forEach(function (t) { t.addEventListener("change", apply); });
+  window.addEventListener("hashchange", reveal);
+  reveal();
+})();
+`
+
+// changeAnchor is a permalink ID for a change that stays the same across
+// runs as long as the same path changes the same way
+func changeAnchor(c models.Change) string {
+	return "c-" + fingerprint(c.Path, string(c.Kind))[:12]
+}
+
+// findingAnchor is a permalink ID for a finding
+func findingAnchor(f models.Finding) string {
+	return "f-" + fingerprint(f.Check, strings.Join(f.Paths, ","))[:12]
+}
+
+// ToHTML generates a standalone HTML report with collapsible sections, a
+// search box, severity toggles and a permalink anchor per change and finding
+func ToHTML(report *models.DiffReport, oldFile, newFile string) string {
+	var sb strings.Builder
+
+	sb.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	sb.WriteString("<title>compose-diff report</title>\n")
+	sb.WriteString("<style>" + htmlStyle + "</style>\n</head>\n<body>\n")
+
+	sb.WriteString("<h1>Docker Compose Diff</h1>\n")
+	sb.WriteString(fmt.Sprintf("<p><strong>Comparing:</strong> <code>%s</code> &rarr; <code>%s</code></p>\n",
+		html.EscapeString(oldFile), html.EscapeString(newFile)))
+
+	// Summary
+	s := report.Summary
+	sb.WriteString("<table class=\"summary\">\n")
+	sb.WriteString(fmt.Sprintf("<tr><td>Services changed</td><td>%d</td></tr>\n", s.ServicesChanged))
+	sb.WriteString(fmt.Sprintf("<tr><td>Services added</td><td>%d</td></tr>\n", s.ServicesAdded))
+	sb.WriteString(fmt.Sprintf("<tr><td>Services removed</td><td>%d</td></tr>\n", s.ServicesRemoved))
+	if s.ServicesRenamed > 0 {
+		sb.WriteString(fmt.Sprintf("<tr><td>Services renamed</td><td>%d</td></tr>\n", s.ServicesRenamed))
+	}
+	sb.WriteString(fmt.Sprintf("<tr><td>Total changes</td><td>%d</td></tr>\n", s.TotalChanges))
+	sb.WriteString(fmt.Sprintf("<tr><td><span class=\"sev sev-breaking\">Breaking</span></td><td>%d</td></tr>\n", s.BreakingCount))
+	sb.WriteString(fmt.Sprintf("<tr><td><span class=\"sev sev-warning\">Warning</span></td><td>%d</td></tr>\n", s.WarningCount))
+	sb.WriteString(fmt.Sprintf("<tr><td><span class=\"sev sev-info\">Info</span></td><td>%d</td></tr>\n", s.InfoCount))
+	sb.WriteString(fmt.Sprintf("<tr><td>Risk score</td><td>%d</td></tr>\n", s.RiskScore))
+	sb.WriteString("</table>\n")
+
+	if report.Freeze != nil {
+		sb.WriteString("<p><span class=\"sev sev-breaking\">" + html.EscapeString(FreezeNotice(report.Freeze)) + "</span></p>\n")
+	}
+
+	if len(report.Diagnostics) > 0 {
+		sb.WriteString(fmt.Sprintf("<p><span class=\"sev sev-warning\">Skipped %d entries that could not be read</span></p>\n<ul>\n", len(report.Diagnostics)))
+		for _, d := range report.Diagnostics {
+			sb.WriteString("<li><code>" + html.EscapeString(d.String()) + "</code></li>\n")
+		}
+		sb.WriteString("</ul>\n")
+	}
+
+	if len(report.Changes) == 0 && len(report.Findings) == 0 {
+		sb.WriteString("<p class=\"none\">No differences found.</p>\n")
+		sb.WriteString("</body>\n</html>\n")
+		return sb.String()
+	}
+
+	sb.WriteString("<div class=\"toolbar\"><input type=\"search\" id=\"search\" placeholder=\"Filter by service, path or value\">")
+	for _, sev := range []models.Severity{models.SeverityBreaking, models.SeverityWarning, models.SeverityInfo} {
+		sb.WriteString(fmt.Sprintf("<label><input type=\"checkbox\" value=\"%s\" checked> %s</label>", sev, sev))
+	}
+	sb.WriteString("</div>\n")
+
+	for _, f := range report.Findings {
+		id := findingAnchor(f)
+		sb.WriteString(fmt.Sprintf("<p id=\"%s\" data-sev=\"%s\"><a class=\"anchor\" href=\"#%s\">#</a><span class=\"sev sev-%s\">%s</span> %s</p>\n",
+			id, f.Severity, id, f.Severity, html.EscapeString(f.Check), html.EscapeString(f.Message)))
+	}
+
+	// One collapsible section per service
+	byService := groupByService(report.Changes)
+	for _, svc := range sortedKeys(byService) {
+		writeHTMLSection(&sb, "Service: "+svc, byService[svc], extractField)
+	}
+
+	// Top-level volumes and networks
+	volNetChanges := filterVolNetChanges(report.Changes)
+	if len(volNetChanges) > 0 {
+		writeHTMLSection(&sb, "Top-level changes", volNetChanges, func(path string) string { return path })
+	}
+
+	sb.WriteString("<script>" + htmlScript + "</script>\n")
+	sb.WriteString("</body>\n</html>\n")
+	return sb.String()
+}
+
+// writeHTMLSection writes a collapsible table of changes
+func writeHTMLSection(sb *strings.Builder, title string, changes []models.Change, field func(string) string) {
+	var breaking, warning int
+	for _, c := range changes {
+		switch c.Severity {
+		case models.SeverityBreaking:
+			breaking++
+		case models.SeverityWarning:
+			warning++
+		}
+	}
+
+	// Sections with breaking or warning changes start expanded
+	open := ""
+	if breaking > 0 || warning > 0 {
+		open = " open"
+	}
+
+	sb.WriteString(fmt.Sprintf("<details%s>\n<summary>%s", open, html.EscapeString(title)))
+	sb.WriteString(fmt.Sprintf("<span class=\"badge\">%d changes", len(changes)))
+	if breaking > 0 {
+		sb.WriteString(fmt.Sprintf(", <span class=\"sev sev-breaking\">%d breaking</span>", breaking))
+	}
+	if warning > 0 {
+		sb.WriteString(fmt.Sprintf(", <span class=\"sev sev-warning\">%d warning</span>", warning))
+	}
+	sb.WriteString("</span></summary>\n")
+
+	sb.WriteString("<table class=\"changes\">\n")
+	sb.WriteString("<tr><th>Severity</th><th>Kind</th><th>Field</th><th>Before</th><th>After</th></tr>\n")
+	for _, c := range changes {
+		id := changeAnchor(c)
+		sb.WriteString(fmt.Sprintf("<tr id=\"%s\" data-sev=\"%s\"><td><a class=\"anchor\" href=\"#%s\" title=\"Link to this change\">#</a><span class=\"sev sev-%s\">%s</span></td><td>%s</td><td><code>%s</code></td><td class=\"before\"><code>%s</code></td><td class=\"after\"><code>%s</code></td></tr>\n",
+			id, html.EscapeString(string(c.Severity)), id,
+			html.EscapeString(string(c.Severity)),
+			html.EscapeString(string(c.Severity)),
+			html.EscapeString(string(c.Kind)),
+			html.EscapeString(field(c.Path)),
+			htmlValue(c.Before),
+			htmlValue(c.After)))
+	}
+	sb.WriteString("</table>\n</details>\n")
+}
+
+// htmlValue renders a change value for a table cell
+func htmlValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return html.EscapeString(fmt.Sprintf("%v", v))
+}
@@ -0,0 +1,36 @@
+package reporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestToHTMLAnchors(t *testing.T) {
+	change := models.Change{
+		Kind: models.ChangeModified, Scope: models.ScopeService, Name: "api",
+		Path: "services.api.image", Before: "api:1.4", After: "api:1.5", Severity: models.SeverityInfo,
+	}
+	report := models.NewDiffReport()
+	report.AddChange(change)
+
+	out := ToHTML(report, "old.yml", "new.yml")
+	id := changeAnchor(change)
+	for _, want := range []string{
+		`<tr id="` + id + `" data-sev="info">`,
+		`href="#` + id + `"`,
+		`<input type="search" id="search"`,
+		`<input type="checkbox" value="breaking" checked>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in the report", want)
+		}
+	}
+
+	// Anchors survive new values, so links stay valid across pushes
+	change.After = "api:1.6"
+	if changeAnchor(change) != id {
+		t.Error("Expected the anchor to depend only on the path and kind")
+	}
+}
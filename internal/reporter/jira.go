@@ -0,0 +1,56 @@
+package reporter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// jiraChangeLimit caps the changes listed in a Jira comment; the rest are
+// counted
+const jiraChangeLimit = 25
+
+// ToJira renders a short summary in Jira wiki markup, for issue comments:
+// the counts, then breaking changes and warnings in a table
+func ToJira(report *models.DiffReport, oldFile, newFile string) string {
+	var sb strings.Builder
+	s := report.Summary
+
+	sb.WriteString(fmt.Sprintf("h3. compose-diff: %s → %s\n", jiraEscape(oldFile), jiraEscape(newFile)))
+	if report.Freeze != nil {
+		sb.WriteString(fmt.Sprintf("(!) *%s*\n", jiraEscape(FreezeNotice(report.Freeze))))
+	}
+	if s.TotalChanges == 0 {
+		sb.WriteString("(/) No changes\n")
+		return sb.String()
+	}
+
+	icon := "(/)"
+	if s.BreakingCount > 0 {
+		icon = "(x)"
+	} else if s.WarningCount > 0 {
+		icon = "(!)"
+	}
+	sb.WriteString(fmt.Sprintf("%s *%d breaking*, %d warnings, %d info (%d changes, risk score %d)\n",
+		icon, s.BreakingCount, s.WarningCount, s.InfoCount, s.TotalChanges, s.RiskScore))
+
+	notable := append(filterBySeverity(report.Changes, models.SeverityBreaking), filterBySeverity(report.Changes, models.SeverityWarning)...)
+	if len(notable) == 0 {
+		return sb.String()
+	}
+	sb.WriteString("\n||Severity||Service||Change||\n")
+	for i, c := range notable {
+		if i == jiraChangeLimit {
+			sb.WriteString(fmt.Sprintf("\n_…and %d more_\n", len(notable)-i))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("|%s|%s|%s|\n", c.Severity, jiraEscape(c.Name), jiraEscape(ChangeLine(c))))
+	}
+	return sb.String()
+}
+
+// jiraEscape keeps table separators and markup characters in values literal
+func jiraEscape(s string) string {
+	return strings.NewReplacer("|", "\\|", "{", "\\{", "}", "\\}", "[", "\\[", "]", "\\]", "*", "\\*", "_", "\\_").Replace(s)
+}
@@ -0,0 +1,33 @@
+package reporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestToJira(t *testing.T) {
+	report := models.NewDiffReport()
+	report.AddChange(models.Change{
+		Kind: models.ChangeRemoved, Scope: models.ScopeService, Name: "api",
+		Path: "services.api.ports.80:80/tcp", Before: "80:80", Severity: models.SeverityBreaking,
+	})
+	report.AddChange(models.Change{
+		Kind: models.ChangeModified, Scope: models.ScopeService, Name: "api",
+		Path: "services.api.environment.MAX_CONN", Before: "10", After: "20", Severity: models.SeverityInfo,
+	})
+
+	out := ToJira(report, "old.yml", "new.yml")
+	for _, want := range []string{
+		"(x) *1 breaking*, 0 warnings, 1 info (2 changes, risk score 0)",
+		"|breaking|api|services.api.ports.80:80/tcp: Removed (was: 80:80)|",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "MAX\\_CONN") {
+		t.Errorf("Expected info changes to be left out:\n%s", out)
+	}
+}
@@ -4,11 +4,20 @@ import "github.com/stackgen-cli/compose-diff/internal/models"
 
 // JSONReport is the stable JSON output format
 type JSONReport struct {
-	SchemaVersion string          `json:"schema_version"`
-	OldFile       string          `json:"old_file"`
-	NewFile       string          `json:"new_file"`
-	Summary       JSONSummary     `json:"summary"`
-	Changes       []models.Change `json:"changes"`
+	SchemaVersion   string              `json:"schema_version"`
+	IRSchemaVersion int                 `json:"ir_schema_version"` // version of service definitions in before/after
+	OldFile         string              `json:"old_file"`
+	NewFile         string              `json:"new_file"`
+	Summary         JSONSummary         `json:"summary"`
+	Changes         []models.Change     `json:"changes"`
+	Diagnostics     []models.Diagnostic `json:"diagnostics,omitempty"` // entries skipped by --lenient
+	Freeze          *models.Freeze      `json:"freeze,omitempty"`      // change freeze in effect, if any
+	Findings        []models.Finding    `json:"findings,omitempty"`    // whole-file checks such as partial-env-update
+	Impacts         []models.Impact     `json:"impacts,omitempty"`     // services downstream of breaking changes
+
+	ServiceHashes map[string]models.ServiceHash `json:"service_hashes,omitempty"` // normalized configuration hash per service
+	Runtime       []models.ServiceRuntime       `json:"runtime,omitempty"`        // recreate, reload or none per changed service
+	Rollout       []models.RolloutStep          `json:"rollout,omitempty"`        // restart order of docker compose up -d
 }
 
 // JSONSummary is the summary section of JSON output
@@ -16,6 +25,7 @@ type JSONSummary struct {
 	ServicesAdded   int `json:"services_added"`
 	ServicesRemoved int `json:"services_removed"`
 	ServicesChanged int `json:"services_changed"`
+	ServicesRenamed int `json:"services_renamed"`
 	VolumesAdded    int `json:"volumes_added"`
 	VolumesRemoved  int `json:"volumes_removed"`
 	NetworksAdded   int `json:"networks_added"`
@@ -24,18 +34,21 @@ type JSONSummary struct {
 	BreakingCount   int `json:"breaking_count"`
 	WarningCount    int `json:"warning_count"`
 	InfoCount       int `json:"info_count"`
+	RiskScore       int `json:"risk_score"`
 }
 
 // ToJSON converts a DiffReport to the stable JSON format
 func ToJSON(report *models.DiffReport, oldFile, newFile string) *JSONReport {
 	return &JSONReport{
-		SchemaVersion: "1.0",
-		OldFile:       oldFile,
-		NewFile:       newFile,
+		SchemaVersion:   "1.0",
+		IRSchemaVersion: models.IRSchemaVersion,
+		OldFile:         oldFile,
+		NewFile:         newFile,
 		Summary: JSONSummary{
 			ServicesAdded:   report.Summary.ServicesAdded,
 			ServicesRemoved: report.Summary.ServicesRemoved,
 			ServicesChanged: report.Summary.ServicesChanged,
+			ServicesRenamed: report.Summary.ServicesRenamed,
 			VolumesAdded:    report.Summary.VolumesAdded,
 			VolumesRemoved:  report.Summary.VolumesRemoved,
 			NetworksAdded:   report.Summary.NetworksAdded,
@@ -44,7 +57,16 @@ func ToJSON(report *models.DiffReport, oldFile, newFile string) *JSONReport {
 			BreakingCount:   report.Summary.BreakingCount,
 			WarningCount:    report.Summary.WarningCount,
 			InfoCount:       report.Summary.InfoCount,
+			RiskScore:       report.Summary.RiskScore,
 		},
-		Changes: report.Changes,
+		Changes:     report.Changes,
+		Diagnostics: report.Diagnostics,
+		Freeze:      report.Freeze,
+		Findings:    report.Findings,
+		Impacts:     report.Impacts,
+
+		ServiceHashes: report.ServiceHashes,
+		Runtime:       report.Runtime,
+		Rollout:       report.Rollout,
 	}
 }
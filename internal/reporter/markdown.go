@@ -5,10 +5,122 @@ import (
 	"strings"
 
 	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/recreate"
 )
 
+// MarkdownOptions controls how much detail the Markdown report includes
+type MarkdownOptions struct {
+	CollapseInfo bool   // always fold info changes into <details>
+	OmitInfo     bool   // leave info changes out, keeping only their count
+	MaxRows      int    // truncate each table and list after this many rows (0 = no limit)
+	ArtifactURL  string // where the full report lives, linked from truncated tables
+}
+
 // ToMarkdown generates a Markdown report suitable for PR comments
 func ToMarkdown(report *models.DiffReport, oldFile, newFile string) string {
+	return ToMarkdownWithOptions(report, oldFile, newFile, MarkdownOptions{})
+}
+
+// FitMarkdown renders the Markdown report (plus an optional suffix section)
+// within maxBytes, shedding detail in stages: collapse info changes, shorten
+// tables, drop info changes, and finally cut the text
+func FitMarkdown(report *models.DiffReport, oldFile, newFile, suffix string, maxBytes int, artifactURL string) string {
+	render := func(opts MarkdownOptions) string {
+		opts.ArtifactURL = artifactURL
+		out := ToMarkdownWithOptions(report, oldFile, newFile, opts)
+		if suffix != "" {
+			out = strings.TrimRight(out, "\n") + "\n\n" + suffix
+		}
+		return out
+	}
+
+	out := render(MarkdownOptions{})
+	if maxBytes <= 0 || len(out) <= maxBytes {
+		return out
+	}
+
+	if out = render(MarkdownOptions{CollapseInfo: true}); len(out) <= maxBytes {
+		return out
+	}
+
+	longest := max(len(report.Changes), len(report.Findings), len(report.Impacts), len(report.Rollout))
+	for rows := longest / 2; rows >= 1; rows /= 2 {
+		if out = render(MarkdownOptions{CollapseInfo: true, MaxRows: rows}); len(out) <= maxBytes {
+			return out
+		}
+	}
+
+	if out = render(MarkdownOptions{OmitInfo: true, MaxRows: 1}); len(out) <= maxBytes {
+		return out
+	}
+
+	return truncateMarkdown(out, maxBytes, artifactURL)
+}
+
+// truncateMarkdown cuts text at a line boundary and appends a notice, never
+// exceeding maxBytes. Headings and table heads left without content by the
+// cut are dropped, and a <details> block left open is closed, so the rest
+// of the comment is not folded into it.
+func truncateMarkdown(out string, maxBytes int, artifactURL string) string {
+	notice := "\n\n_Report truncated._\n"
+	if artifactURL != "" {
+		notice = fmt.Sprintf("\n\n_Report truncated — see the [full report](%s)._\n", artifactURL)
+	}
+	if len(notice) >= maxBytes {
+		return strings.ToValidUTF8(out[:maxBytes], "")
+	}
+
+	budget := maxBytes - len(notice)
+	cut := out[:budget]
+	for {
+		if i := strings.LastIndex(cut, "\n"); i > 0 {
+			cut = cut[:i]
+		} else {
+			cut = ""
+		}
+		cut = trimDangling(cut)
+		closing := strings.Repeat("\n</details>", strings.Count(cut, "<details>")-strings.Count(cut, "</details>"))
+		if len(cut)+len(closing) <= budget {
+			return cut + closing + notice
+		}
+	}
+}
+
+// trimDangling drops trailing blank lines, headings, table heads and
+// <details> openers, which would be empty at the end of a cut report
+func trimDangling(out string) string {
+	for {
+		out = strings.TrimRight(out, "\n")
+		i := strings.LastIndex(out, "\n") + 1
+		last := out[i:]
+		switch {
+		case strings.HasPrefix(last, "|-"):
+			// the separator and the header row above it
+			out = out[:i]
+			if j := strings.LastIndex(strings.TrimRight(out, "\n"), "\n"); j >= 0 {
+				out = out[:j+1]
+			} else {
+				out = ""
+			}
+		case strings.HasPrefix(last, "#"), strings.HasPrefix(last, "<summary>"), last == "<details>":
+			out = out[:i]
+		default:
+			return out
+		}
+	}
+}
+
+// moreLine says how many rows a truncated table or list left out, linking
+// to the full report if there is one
+func moreLine(n int, opts MarkdownOptions) string {
+	if opts.ArtifactURL != "" {
+		return fmt.Sprintf("_[%d more…](%s)_", n, opts.ArtifactURL)
+	}
+	return fmt.Sprintf("_%d more…_", n)
+}
+
+// ToMarkdownWithOptions generates a Markdown report with the given level of detail
+func ToMarkdownWithOptions(report *models.DiffReport, oldFile, newFile string, opts MarkdownOptions) string {
 	var sb strings.Builder
 
 	// Header
@@ -23,7 +135,11 @@ func ToMarkdown(report *models.DiffReport, oldFile, newFile string) string {
 	sb.WriteString(fmt.Sprintf("| Services Changed | %d |\n", s.ServicesChanged))
 	sb.WriteString(fmt.Sprintf("| Services Added | %d |\n", s.ServicesAdded))
 	sb.WriteString(fmt.Sprintf("| Services Removed | %d |\n", s.ServicesRemoved))
+	if s.ServicesRenamed > 0 {
+		sb.WriteString(fmt.Sprintf("| Services Renamed | %d |\n", s.ServicesRenamed))
+	}
 	sb.WriteString(fmt.Sprintf("| Total Changes | %d |\n", s.TotalChanges))
+	sb.WriteString(fmt.Sprintf("| Risk Score | %d |\n", s.RiskScore))
 
 	if s.BreakingCount > 0 {
 		sb.WriteString(fmt.Sprintf("| ⚠️ **Breaking Changes** | **%d** |\n", s.BreakingCount))
@@ -34,6 +150,26 @@ func ToMarkdown(report *models.DiffReport, oldFile, newFile string) string {
 
 	sb.WriteString("\n")
 
+	if report.Freeze != nil {
+		sb.WriteString(fmt.Sprintf("> 🧊 **%s**\n\n", FreezeNotice(report.Freeze)))
+	}
+
+	if len(report.Diagnostics) > 0 {
+		sb.WriteString(fmt.Sprintf("> ⚠️ Skipped %d entries that could not be read, so they were not compared:\n", len(report.Diagnostics)))
+		for _, d := range report.Diagnostics {
+			sb.WriteString(fmt.Sprintf("> - `%s`\n", d))
+		}
+		sb.WriteString("\n")
+	}
+
+	for i, f := range report.Findings {
+		if opts.MaxRows > 0 && i >= opts.MaxRows {
+			sb.WriteString(fmt.Sprintf("> %s findings\n\n", moreLine(len(report.Findings)-i, opts)))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("> %s **%s:** %s\n\n", severityEmoji(f.Severity), f.Check, f.Message))
+	}
+
 	if s.TotalChanges == 0 {
 		sb.WriteString("✅ No differences found.\n")
 		return sb.String()
@@ -43,59 +179,103 @@ func ToMarkdown(report *models.DiffReport, oldFile, newFile string) string {
 	breakingChanges := filterBySeverity(report.Changes, models.SeverityBreaking)
 	if len(breakingChanges) > 0 {
 		sb.WriteString("### ⚠️ Breaking Changes\n\n")
-		sb.WriteString("| Service | Field | Change |\n")
-		sb.WriteString("|---------|-------|--------|\n")
-		for _, c := range breakingChanges {
-			field := extractField(c.Path)
-			change := formatChangeDescription(c)
-			sb.WriteString(fmt.Sprintf("| `%s` | `%s` | %s |\n", c.Name, field, change))
-		}
+		writeMarkdownTable(&sb, breakingChanges, opts)
 		sb.WriteString("\n")
+		writeImpactTable(&sb, report.Impacts, opts)
 	}
 
 	// Warnings
 	warningChanges := filterBySeverity(report.Changes, models.SeverityWarning)
 	if len(warningChanges) > 0 {
 		sb.WriteString("### ⚡ Warnings\n\n")
-		sb.WriteString("| Service | Field | Change |\n")
-		sb.WriteString("|---------|-------|--------|\n")
-		for _, c := range warningChanges {
-			field := extractField(c.Path)
-			change := formatChangeDescription(c)
-			sb.WriteString(fmt.Sprintf("| `%s` | `%s` | %s |\n", c.Name, field, change))
-		}
+		writeMarkdownTable(&sb, warningChanges, opts)
 		sb.WriteString("\n")
 	}
 
 	// Info changes (collapsed by default in long reports)
 	infoChanges := filterBySeverity(report.Changes, models.SeverityInfo)
-	if len(infoChanges) > 0 {
-		if len(infoChanges) > 5 {
+	if len(infoChanges) > 0 && opts.OmitInfo {
+		sb.WriteString(fmt.Sprintf("_ℹ️ %d info changes omitted", len(infoChanges)))
+		if opts.ArtifactURL != "" {
+			sb.WriteString(fmt.Sprintf(" — see the [full report](%s)", opts.ArtifactURL))
+		}
+		sb.WriteString("._\n")
+	} else if len(infoChanges) > 0 {
+		collapse := opts.CollapseInfo || len(infoChanges) > 5
+		if collapse {
 			sb.WriteString("<details>\n<summary>ℹ️ Info Changes (" + fmt.Sprintf("%d", len(infoChanges)) + ")</summary>\n\n")
 		} else {
 			sb.WriteString("### ℹ️ Info Changes\n\n")
 		}
 
-		sb.WriteString("| Service | Field | Change |\n")
-		sb.WriteString("|---------|-------|--------|\n")
-		for _, c := range infoChanges {
-			field := extractField(c.Path)
-			change := formatChangeDescription(c)
-			name := c.Name
-			if c.Scope != models.ScopeService {
-				name = fmt.Sprintf("(%s)", c.Scope)
-			}
-			sb.WriteString(fmt.Sprintf("| `%s` | `%s` | %s |\n", name, field, change))
-		}
+		writeMarkdownTable(&sb, infoChanges, opts)
 
-		if len(infoChanges) > 5 {
+		if collapse {
 			sb.WriteString("\n</details>\n")
 		}
 	}
 
+	if len(report.Rollout) > 0 {
+		sb.WriteString("\n### 🚦 Rollout Plan\n\n")
+		for i, step := range report.Rollout {
+			if opts.MaxRows > 0 && i >= opts.MaxRows {
+				sb.WriteString(fmt.Sprintf("\n%s steps\n", moreLine(len(report.Rollout)-i, opts)))
+				break
+			}
+			sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, RolloutStepLine(step, "`")))
+		}
+	}
+
 	return sb.String()
 }
 
+// writeMarkdownTable writes a change table, truncated to opts.MaxRows
+func writeMarkdownTable(sb *strings.Builder, changes []models.Change, opts MarkdownOptions) {
+	sb.WriteString("| Service | Field | Change | Runtime |\n")
+	sb.WriteString("|---------|-------|--------|---------|\n")
+	for i, c := range changes {
+		if opts.MaxRows > 0 && i >= opts.MaxRows {
+			sb.WriteString(fmt.Sprintf("| | | %s | |\n", moreLine(len(changes)-i, opts)))
+			break
+		}
+		field := extractField(c.Path)
+		change := formatChangeDescription(c)
+		for _, note := range c.Notes {
+			change += "<br>📝 " + strings.ReplaceAll(note, "|", "\\|")
+		}
+		name := fmt.Sprintf("`%s`", c.Name)
+		effect := ""
+		switch {
+		case c.Scope == models.ScopeService:
+			effect = string(recreate.Classify(c))
+		case c.Name != "":
+			name = fmt.Sprintf("`%s` (%s)", c.Name, c.Scope)
+		default:
+			name = fmt.Sprintf("(%s)", c.Scope)
+		}
+		sb.WriteString(fmt.Sprintf("| %s | `%s` | %s | %s |\n", name, field, change, effect))
+	}
+}
+
+// writeImpactTable lists the services downstream of each service with
+// breaking changes, truncated to opts.MaxRows
+func writeImpactTable(sb *strings.Builder, impacts []models.Impact, opts MarkdownOptions) {
+	if len(impacts) == 0 {
+		return
+	}
+	sb.WriteString("#### 💥 Blast Radius\n\n")
+	sb.WriteString("| Service | Breaking Changes | Downstream Services |\n")
+	sb.WriteString("|---------|------------------|---------------------|\n")
+	for i, imp := range impacts {
+		if opts.MaxRows > 0 && i >= opts.MaxRows {
+			sb.WriteString(fmt.Sprintf("| %s | | |\n", moreLine(len(impacts)-i, opts)))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("| `%s` | %d | `%s` |\n", imp.Service, len(imp.Changes), strings.Join(imp.Dependents, "`, `")))
+	}
+	sb.WriteString("\n")
+}
+
 func filterBySeverity(changes []models.Change, severity models.Severity) []models.Change {
 	var result []models.Change
 	for _, c := range changes {
@@ -106,6 +286,17 @@ func filterBySeverity(changes []models.Change, severity models.Severity) []model
 	return result
 }
 
+// severityEmoji matches the icons of the summary table
+func severityEmoji(s models.Severity) string {
+	switch s {
+	case models.SeverityBreaking:
+		return "⚠️"
+	case models.SeverityWarning:
+		return "⚡"
+	}
+	return "ℹ️"
+}
+
 func formatChangeDescription(c models.Change) string {
 	switch c.Kind {
 	case models.ChangeAdded:
@@ -114,6 +305,16 @@ func formatChangeDescription(c models.Change) string {
 		return fmt.Sprintf("Removed (was: `%v`)", truncateValue(c.Before))
 	case models.ChangeModified:
 		return fmt.Sprintf("`%v` → `%v`", truncateValue(c.Before), truncateValue(c.After))
+	case models.ChangeReordered:
+		return fmt.Sprintf("Reordered: `%v` → `%v`", truncateValue(c.Before), truncateValue(c.After))
+	case models.ChangeRenamed:
+		return fmt.Sprintf("Renamed from `%v`", truncateValue(c.Before))
+	case models.ChangeDangling:
+		return "Dangling: " + danglingTarget(c)
+	case models.ChangeCycle:
+		return fmt.Sprintf("Cycle: `%v`", c.After)
+	case models.ChangeOrphaned:
+		return "Orphaned, no longer used by " + nameList(c.Before)
 	}
 	return ""
 }
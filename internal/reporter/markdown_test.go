@@ -0,0 +1,123 @@
+package reporter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func largeReport(n int) *models.DiffReport {
+	report := models.NewDiffReport()
+	for i := 0; i < n; i++ {
+		sev := models.SeverityInfo
+		if i%10 == 0 {
+			sev = models.SeverityBreaking
+		}
+		report.AddChange(models.Change{
+			Kind:     models.ChangeAdded,
+			Scope:    models.ScopeService,
+			Name:     "api",
+			Path:     fmt.Sprintf("services.api.environment.VAR_%d", i),
+			After:    "value",
+			Severity: sev,
+		})
+	}
+	return report
+}
+
+func TestFitMarkdownWithinLimit(t *testing.T) {
+	report := largeReport(500)
+	full := ToMarkdown(report, "old.yml", "new.yml")
+
+	for _, limit := range []int{len(full) + 1, 8000, 2000, 600, 100} {
+		out := FitMarkdown(report, "old.yml", "new.yml", "", limit, "https://ci.example/report.html")
+		if len(out) > limit {
+			t.Errorf("limit %d: output is %d bytes", limit, len(out))
+		}
+	}
+}
+
+func TestFitMarkdownKeepsBreakingChanges(t *testing.T) {
+	report := largeReport(500)
+
+	out := FitMarkdown(report, "old.yml", "new.yml", "", 4000, "https://ci.example/report.html")
+
+	if !strings.Contains(out, "VAR_0") {
+		t.Error("Expected first breaking change to be kept")
+	}
+	if !strings.Contains(out, "more…](https://ci.example/report.html)") {
+		t.Error("Expected truncated table to link to the artifact")
+	}
+}
+
+func TestFitMarkdownCapsSections(t *testing.T) {
+	report := largeReport(50)
+	for i := 0; i < 200; i++ {
+		name := fmt.Sprintf("svc%d", i)
+		report.Rollout = append(report.Rollout, models.RolloutStep{Services: []models.ServiceRuntime{{Service: name, Effect: "recreate"}}})
+		report.Impacts = append(report.Impacts, models.Impact{Service: name, Changes: []string{"services.api.image"}, Dependents: []string{"web"}})
+		report.Findings = append(report.Findings, models.Finding{Check: "convention", Severity: models.SeverityWarning, Message: name + " has no healthcheck"})
+	}
+
+	for _, limit := range []int{5000, 2000} {
+		out := FitMarkdown(report, "old.yml", "new.yml", "", limit, "")
+		if len(out) > limit {
+			t.Errorf("limit %d: output is %d bytes", limit, len(out))
+		}
+		if !strings.Contains(out, "more…_ steps") || !strings.Contains(out, "more…_ findings") {
+			t.Errorf("limit %d: expected the rollout and findings to be capped in:\n%s", limit, out)
+		}
+	}
+}
+
+func TestTruncateMarkdownDropsEmptyHeadings(t *testing.T) {
+	out := "## Report\n\n" + strings.Repeat("> finding\n\n", 10) + "### 🚦 Rollout Plan\n\n" + strings.Repeat("1. `api` (recreate)\n", 50)
+
+	cut := truncateMarkdown(out, len("## Report\n\n")+len(strings.Repeat("> finding\n\n", 10))+60, "")
+	if strings.Contains(cut, "Rollout Plan") {
+		t.Errorf("Expected the empty heading to be dropped in:\n%s", cut)
+	}
+
+	out = "## Report\n\n### Warnings\n\n| Service | Field |\n|---------|-------|\n" + strings.Repeat("| `api` | `x` |\n", 50)
+	cut = truncateMarkdown(out, 90, "")
+	if strings.Contains(cut, "Warnings") || strings.Contains(cut, "|") {
+		t.Errorf("Expected the empty table and its heading to be dropped in:\n%s", cut)
+	}
+}
+
+func TestToMarkdownBlastRadius(t *testing.T) {
+	report := largeReport(1)
+	report.Impacts = []models.Impact{{Service: "api", Changes: []string{"services.api.environment.VAR_0"}, Dependents: []string{"nginx", "worker"}}}
+
+	out := ToMarkdown(report, "old.yml", "new.yml")
+	if !strings.Contains(out, "| `api` | 1 | `nginx`, `worker` |") {
+		t.Errorf("Expected a blast radius row in:\n%s", out)
+	}
+	if strings.Index(out, "Blast Radius") < strings.Index(out, "Breaking Changes") {
+		t.Error("Expected the blast radius to follow the breaking changes")
+	}
+}
+
+func TestTruncateMarkdownClosesDetails(t *testing.T) {
+	out := "## Report\n<details>\n<summary>Info</summary>\n\n" + strings.Repeat("| `api` | `x` | added | |\n", 50) + "\n</details>\n"
+
+	cut := truncateMarkdown(out, 300, "")
+	if len(cut) > 300 {
+		t.Errorf("Expected at most 300 bytes, got %d", len(cut))
+	}
+	if strings.Count(cut, "<details>") != strings.Count(cut, "</details>") {
+		t.Errorf("Expected <details> to be closed in:\n%s", cut)
+	}
+}
+
+func TestToMarkdownResourceName(t *testing.T) {
+	report := models.NewDiffReport()
+	report.AddChange(models.Change{Kind: models.ChangeRemoved, Scope: models.ScopeVolume, Name: "db-data", Path: "volumes.db-data", Severity: models.SeverityBreaking})
+
+	out := ToMarkdown(report, "old.yml", "new.yml")
+	if !strings.Contains(out, "| `db-data` (volume) |") {
+		t.Errorf("Expected the volume name in:\n%s", out)
+	}
+}
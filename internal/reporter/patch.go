@@ -0,0 +1,147 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// ToPatch renders the changes as a unified-diff-style YAML patch with one
+// hunk per changed service, volume, network or x- field. Only changed keys
+// are shown, nested under their parents as context lines; old values are -
+// lines and new values + lines. Values are the normalized ones that were
+// compared, so the patch reads as YAML but is not meant for patch(1).
+func ToPatch(report *models.DiffReport, oldFile, newFile string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", oldFile, newFile))
+
+	type hunk struct {
+		section, name string
+		changes       []models.Change
+	}
+	var hunks []*hunk
+	byEntity := make(map[string]*hunk)
+	for _, c := range report.Changes {
+		if c.Kind == models.ChangeDangling || c.Kind == models.ChangeCycle || c.Kind == models.ChangeOrphaned {
+			// Problems with the new file rather than edits to it
+			continue
+		}
+		section, name := patchEntity(c)
+		key := section + "\x00" + name
+		h, ok := byEntity[key]
+		if !ok {
+			h = &hunk{section: section, name: name}
+			byEntity[key] = h
+			hunks = append(hunks, h)
+		}
+		h.changes = append(h.changes, c)
+	}
+	sort.SliceStable(hunks, func(i, j int) bool {
+		if hunks[i].section != hunks[j].section {
+			return hunks[i].section < hunks[j].section
+		}
+		return hunks[i].name < hunks[j].name
+	})
+
+	for _, h := range hunks {
+		entityPath := joinPatchPath(h.section, h.name)
+		sb.WriteString(fmt.Sprintf("@@ %s @@\n", entityPath))
+
+		indent := ""
+		if h.section != "" {
+			sb.WriteString(" " + h.section + ":\n")
+			indent = "  "
+		}
+
+		sort.SliceStable(h.changes, func(i, j int) bool { return h.changes[i].Path < h.changes[j].Path })
+		lastField := ""
+		for _, c := range h.changes {
+			if c.Path == entityPath {
+				// The whole entity was added or removed
+				writePatchValue(&sb, c, indent, h.name)
+				lastField = ""
+				continue
+			}
+			if lastField == "" {
+				sb.WriteString(" " + indent + h.name + ":\n")
+			}
+
+			// Fields keep one level of nesting; the rest of the path is a
+			// single key, since keys like labels may themselves contain dots
+			rest := strings.TrimPrefix(c.Path, entityPath+".")
+			field, key, nested := strings.Cut(rest, ".")
+			if !nested {
+				writePatchValue(&sb, c, indent+"  ", field)
+				lastField = "\x00" + field
+				continue
+			}
+			if field != lastField {
+				sb.WriteString(" " + indent + "  " + field + ":\n")
+				lastField = field
+			}
+			writePatchValue(&sb, c, indent+"    ", key)
+		}
+	}
+
+	return sb.String()
+}
+
+// patchEntity returns the top-level section and entry a change belongs to
+func patchEntity(c models.Change) (section, name string) {
+	switch c.Scope {
+	case models.ScopeService:
+		return "services", c.Name
+	case models.ScopeVolume:
+		return "volumes", c.Name
+	case models.ScopeNetwork:
+		return "networks", c.Name
+	}
+	return "", c.Name
+}
+
+func joinPatchPath(section, name string) string {
+	if section == "" {
+		return name
+	}
+	return section + "." + name
+}
+
+// writePatchValue writes the - and + lines of a change to key. A rename
+// shows the old and new key, without the unchanged content.
+func writePatchValue(sb *strings.Builder, c models.Change, indent, key string) {
+	if c.Kind == models.ChangeRenamed {
+		sb.WriteString(fmt.Sprintf("-%s%v:\n+%s%v:\n", indent, c.Before, indent, c.After))
+		return
+	}
+	if c.Kind != models.ChangeAdded {
+		for _, line := range patchYAML(key, c.Before) {
+			sb.WriteString("-" + indent + line + "\n")
+		}
+	}
+	if c.Kind != models.ChangeRemoved {
+		for _, line := range patchYAML(key, c.After) {
+			sb.WriteString("+" + indent + line + "\n")
+		}
+	}
+}
+
+// patchYAML renders key: value as YAML lines. Values go through JSON first
+// so IR structs use their compose-style field names.
+func patchYAML(key string, value any) []string {
+	var plain any
+	if data, err := json.Marshal(value); err == nil {
+		json.Unmarshal(data, &plain)
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(map[string]any{key: plain}); err != nil {
+		return []string{fmt.Sprintf("%s: %v", key, value)}
+	}
+	return strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+}
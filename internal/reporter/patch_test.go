@@ -0,0 +1,48 @@
+package reporter
+
+import (
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestToPatch(t *testing.T) {
+	report := models.NewDiffReport()
+	report.AddChange(models.Change{
+		Kind: models.ChangeModified, Scope: models.ScopeService, Name: "api",
+		Path: "services.api.image", Before: "api:1.4", After: "api:1.5",
+	})
+	report.AddChange(models.Change{
+		Kind: models.ChangeModified, Scope: models.ScopeService, Name: "api",
+		Path: "services.api.labels.traefik.enable", Before: "true", After: "false",
+	})
+	report.AddChange(models.Change{
+		Kind: models.ChangeRemoved, Scope: models.ScopeService, Name: "api",
+		Path: "services.api.environment.DEBUG", Before: "1",
+	})
+	report.AddChange(models.Change{
+		Kind: models.ChangeAdded, Scope: models.ScopeVolume, Name: "data",
+		Path: "volumes.data", After: map[string]any{"driver": "local"},
+	})
+
+	want := `--- old.yml
++++ new.yml
+@@ services.api @@
+ services:
+   api:
+     environment:
+-      DEBUG: "1"
+-    image: api:1.4
++    image: api:1.5
+     labels:
+-      traefik.enable: "true"
++      traefik.enable: "false"
+@@ volumes.data @@
+ volumes:
++  data:
++    driver: local
+`
+	if got := ToPatch(report, "old.yml", "new.yml"); got != want {
+		t.Errorf("Unexpected patch:\n%s", got)
+	}
+}
@@ -0,0 +1,141 @@
+package reporter
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/hints"
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// ReviewFormatVersion is the version of the review-json contract. Fields
+// may be added within a version; renaming or removing one bumps it.
+const ReviewFormatVersion = 1
+
+// reviewValueLimit caps before/after values, in bytes
+const reviewValueLimit = 160
+
+// ReviewReport is a compact report for automated code-review assistants:
+// short keys, values as strings, identical changes to several entities
+// merged, and hint texts listed once
+type ReviewReport struct {
+	Format   string            `json:"format"` // always "compose-diff/review"
+	Version  int               `json:"version"`
+	Old      string            `json:"old"`
+	New      string            `json:"new"`
+	Counts   ReviewCounts      `json:"counts"`
+	Freeze   string            `json:"freeze,omitempty"`
+	Changes  []ReviewChange    `json:"changes"`
+	Findings []ReviewFinding   `json:"findings,omitempty"`
+	Hints    map[string]string `json:"hints,omitempty"` // hint ID -> remedy
+}
+
+// ReviewCounts are the change counts per severity
+type ReviewCounts struct {
+	Breaking int `json:"breaking"`
+	Warning  int `json:"warning"`
+	Info     int `json:"info"`
+	Risk     int `json:"risk"` // weighted risk score
+}
+
+// ReviewChange is one change, applied identically to every entity in Names
+type ReviewChange struct {
+	Severity models.Severity   `json:"sev"`
+	Op       models.ChangeKind `json:"op"`
+	Scope    models.Scope      `json:"scope,omitempty"` // omitted for services
+	Names    []string          `json:"names"`
+	Path     string            `json:"path,omitempty"` // below the entity; omitted for whole entities
+	From     string            `json:"from,omitempty"`
+	To       string            `json:"to,omitempty"`
+	Hint     string            `json:"hint,omitempty"` // key into ReviewReport.Hints
+}
+
+// ReviewFinding is a whole-file check result
+type ReviewFinding struct {
+	Severity models.Severity `json:"sev"`
+	Check    string          `json:"check"`
+	Message  string          `json:"msg"`
+	Paths    []string        `json:"paths,omitempty"`
+}
+
+// ToReview builds the review-json report, most severe changes first
+func ToReview(report *models.DiffReport, oldFile, newFile string) *ReviewReport {
+	r := &ReviewReport{
+		Format:  "compose-diff/review",
+		Version: ReviewFormatVersion,
+		Old:     oldFile,
+		New:     newFile,
+		Counts: ReviewCounts{
+			Breaking: report.Summary.BreakingCount,
+			Warning:  report.Summary.WarningCount,
+			Info:     report.Summary.InfoCount,
+			Risk:     report.Summary.RiskScore,
+		},
+		Changes: []ReviewChange{},
+		Hints:   make(map[string]string),
+	}
+	if report.Freeze != nil {
+		r.Freeze = FreezeNotice(report.Freeze)
+	}
+
+	byKey := make(map[string]int)
+	for _, c := range report.Changes {
+		rc := ReviewChange{
+			Severity: c.Severity,
+			Op:       c.Kind,
+			Path:     reviewPath(c),
+			From:     reviewValue(c.Before),
+			To:       reviewValue(c.After),
+		}
+		if c.Scope != models.ScopeService {
+			rc.Scope = c.Scope
+		}
+		if h, ok := hints.Lookup(c); ok && h.Remedy != "" {
+			rc.Hint = h.ID
+			r.Hints[h.ID] = h.Remedy
+		}
+
+		key := strings.Join([]string{string(rc.Severity), string(rc.Op), string(rc.Scope), rc.Path, rc.From, rc.To}, "\x00")
+		if i, ok := byKey[key]; ok {
+			r.Changes[i].Names = append(r.Changes[i].Names, c.Name)
+			continue
+		}
+		byKey[key] = len(r.Changes)
+		rc.Names = []string{c.Name}
+		r.Changes = append(r.Changes, rc)
+	}
+
+	rank := map[models.Severity]int{models.SeverityBreaking: 0, models.SeverityWarning: 1, models.SeverityInfo: 2}
+	sort.SliceStable(r.Changes, func(i, j int) bool {
+		a, b := r.Changes[i], r.Changes[j]
+		if rank[a.Severity] != rank[b.Severity] {
+			return rank[a.Severity] < rank[b.Severity]
+		}
+		return a.Path < b.Path
+	})
+	for i := range r.Changes {
+		sort.Strings(r.Changes[i].Names)
+	}
+
+	for _, f := range report.Findings {
+		r.Findings = append(r.Findings, ReviewFinding{Severity: f.Severity, Check: f.Check, Message: f.Message, Paths: f.Paths})
+	}
+	return r
+}
+
+// reviewPath is the change path below its service, volume, network or
+// extension
+func reviewPath(c models.Change) string {
+	section, name := patchEntity(c)
+	return strings.TrimPrefix(strings.TrimPrefix(c.Path, joinPatchPath(section, name)), ".")
+}
+
+// reviewValue formats a value like a CSV cell, shortened to
+// reviewValueLimit
+func reviewValue(v any) string {
+	s := formatTemplateValue(v)
+	if len(s) > reviewValueLimit {
+		s = strings.ToValidUTF8(s[:reviewValueLimit-3], "") + "..."
+	}
+	return s
+}
@@ -0,0 +1,41 @@
+package reporter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestToReview(t *testing.T) {
+	report := models.NewDiffReport()
+	for _, svc := range []string{"worker", "api"} {
+		report.AddChange(models.Change{
+			Kind: models.ChangeRemoved, Scope: models.ScopeService, Name: svc,
+			Path: "services." + svc + ".environment.LEGACY", Before: "1", Severity: models.SeverityBreaking,
+		})
+	}
+	report.AddChange(models.Change{
+		Kind: models.ChangeModified, Scope: models.ScopeService, Name: "api",
+		Path: "services.api.image", Before: "api:1.4", After: "api:1.5", Severity: models.SeverityInfo,
+	})
+	report.AddChange(models.Change{
+		Kind: models.ChangeAdded, Scope: models.ScopeVolume, Name: "data",
+		Path: "volumes.data", After: map[string]any{"driver": "local"}, Severity: models.SeverityInfo,
+	})
+
+	r := ToReview(report, "old.yml", "new.yml")
+	data, err := json.Marshal(r.Changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"sev":"breaking","op":"removed","names":["api","worker"],"path":"environment.LEGACY","from":"1","hint":"env-removed"},` +
+		`{"sev":"info","op":"added","scope":"volume","names":["data"],"to":"{\"driver\":\"local\"}"},` +
+		`{"sev":"info","op":"modified","names":["api"],"path":"image","from":"api:1.4","to":"api:1.5","hint":"image-changed"}]`
+	if string(data) != want {
+		t.Errorf("Unexpected changes:\n%s", data)
+	}
+	if r.Counts.Breaking != 2 || r.Hints["env-removed"] == "" {
+		t.Errorf("Unexpected counts or hints: %+v, %v", r.Counts, r.Hints)
+	}
+}
@@ -0,0 +1,87 @@
+package reporter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// TemplateData is what a user template is executed against: the report's
+// fields (.Summary, .Changes, .Diagnostics, .Findings, .Freeze) plus the
+// names of the compared files
+type TemplateData struct {
+	*models.DiffReport
+	OldFile string
+	NewFile string
+}
+
+// templateFuncs are available to user templates in addition to the
+// text/template builtins
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"csv": func(fields ...any) (string, error) {
+		record := make([]string, len(fields))
+		for i, f := range fields {
+			record[i] = formatTemplateValue(f)
+		}
+		var sb strings.Builder
+		w := csv.NewWriter(&sb)
+		w.Write(record)
+		w.Flush()
+		return strings.TrimSuffix(sb.String(), "\n"), w.Error()
+	},
+	"value":      formatTemplateValue,
+	"changeLine": ChangeLine,
+	"join":       strings.Join,
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"replace":    strings.ReplaceAll,
+	"field":      extractField,
+	"bySeverity": func(severity string, changes []models.Change) []models.Change {
+		return filterBySeverity(changes, models.Severity(severity))
+	},
+}
+
+// ParseTemplate reads a text/template file for ToTemplate
+func ParseTemplate(path string) (*template.Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return template.New(filepath.Base(path)).Funcs(templateFuncs).Option("missingkey=error").Parse(string(data))
+}
+
+// ToTemplate executes a user template against the report
+func ToTemplate(tmpl *template.Template, report *models.DiffReport, oldFile, newFile string) (string, error) {
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, TemplateData{DiffReport: report, OldFile: oldFile, NewFile: newFile}); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// formatTemplateValue prints a before/after value for a template or a CSV
+// cell: nil as an empty string, scalars as they are and anything else as
+// JSON
+func formatTemplateValue(v any) string {
+	switch v.(type) {
+	case nil:
+		return ""
+	case string, bool, int, int64, float64, models.Severity, models.ChangeKind, models.Scope:
+		return fmt.Sprint(v)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
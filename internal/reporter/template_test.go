@@ -0,0 +1,46 @@
+package reporter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestToTemplate(t *testing.T) {
+	report := models.NewDiffReport()
+	report.AddChange(models.Change{
+		Kind: models.ChangeModified, Scope: models.ScopeService, Name: "api",
+		Path: "services.api.image", Before: "app:1", After: "app:2, final", Severity: models.SeverityWarning,
+	})
+	report.AddChange(models.Change{
+		Kind: models.ChangeRemoved, Scope: models.ScopeService, Name: "api",
+		Path: "services.api.ports.80:80/tcp", Before: models.PortIR{HostPort: "80", ContainerPort: "80", Protocol: "tcp"}, Severity: models.SeverityBreaking,
+	})
+
+	path := filepath.Join(t.TempDir(), "report.gotmpl")
+	content := `{{.OldFile}} -> {{.NewFile}}: {{.Summary.TotalChanges}}
+{{range .Changes}}{{csv .Severity .Path .Before .After}}
+{{end}}{{range bySeverity "breaking" .Changes}}{{field .Path | upper}}{{end}}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	tmpl, err := ParseTemplate(path)
+	if err != nil {
+		t.Fatalf("ParseTemplate failed: %v", err)
+	}
+	out, err := ToTemplate(tmpl, report, "old.yml", "new.yml")
+	if err != nil {
+		t.Fatalf("ToTemplate failed: %v", err)
+	}
+
+	want := `old.yml -> new.yml: 2
+warning,services.api.image,app:1,"app:2, final"
+breaking,services.api.ports.80:80/tcp,"{""host_port"":""80"",""container_port"":""80"",""protocol"":""tcp""}",
+PORTS.80:80/TCP`
+	if out != want {
+		t.Errorf("Unexpected output:\n%s\nwant:\n%s", out, want)
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/recreate"
 )
 
 // ToText generates a human-readable text report
@@ -21,15 +22,40 @@ func ToText(report *models.DiffReport, oldFile, newFile string) string {
 	sb.WriteString(cyan("compose-diff\n\n"))
 	sb.WriteString(fmt.Sprintf("Comparing: %s → %s\n\n", oldFile, newFile))
 
+	if report.Freeze != nil {
+		sb.WriteString(red(FreezeNotice(report.Freeze)) + "\n\n")
+	}
+
+	if len(report.Diagnostics) > 0 {
+		sb.WriteString(yellow(fmt.Sprintf("Skipped %d entries that could not be read:\n", len(report.Diagnostics))))
+		for _, d := range report.Diagnostics {
+			sb.WriteString("  " + d.String() + "\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	for _, f := range report.Findings {
+		sb.WriteString(fmt.Sprintf("%s %s\n", severityLabel(f.Severity), f.Message))
+		for _, p := range f.Paths {
+			sb.WriteString("  " + p + "\n")
+		}
+		sb.WriteString("\n")
+	}
+
 	// Summary
 	s := report.Summary
-	sb.WriteString(fmt.Sprintf("Summary: %d services changed, %d added, %d removed\n",
+	sb.WriteString(fmt.Sprintf("Summary: %d services changed, %d added, %d removed",
 		s.ServicesChanged, s.ServicesAdded, s.ServicesRemoved))
-	sb.WriteString(fmt.Sprintf("         %d changes (%s, %s, %d info)\n\n",
+	if s.ServicesRenamed > 0 {
+		sb.WriteString(fmt.Sprintf(", %d renamed", s.ServicesRenamed))
+	}
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("         %d changes (%s, %s, %d info)\n",
 		s.TotalChanges,
 		red(fmt.Sprintf("%d breaking", s.BreakingCount)),
 		yellow(fmt.Sprintf("%d warnings", s.WarningCount)),
 		s.InfoCount))
+	sb.WriteString(fmt.Sprintf("         risk score %d\n\n", s.RiskScore))
 
 	if s.TotalChanges == 0 {
 		sb.WriteString(green("No differences found.\n"))
@@ -40,10 +66,15 @@ func ToText(report *models.DiffReport, oldFile, newFile string) string {
 
 	// Group changes by service/scope
 	byService := groupByService(report.Changes)
+	effects := recreate.Effects(report.Runtime)
 
 	for _, svc := range sortedKeys(byService) {
 		changes := byService[svc]
-		sb.WriteString(fmt.Sprintf("Service: %s\n", cyan(svc)))
+		if effect, ok := effects[svc]; ok {
+			sb.WriteString(fmt.Sprintf("Service: %s (%s)\n", cyan(svc), effect))
+		} else {
+			sb.WriteString(fmt.Sprintf("Service: %s\n", cyan(svc)))
+		}
 
 		for _, c := range changes {
 			icon := changeIcon(c.Kind, c.Severity)
@@ -52,12 +83,22 @@ func ToText(report *models.DiffReport, oldFile, newFile string) string {
 
 			switch c.Kind {
 			case models.ChangeAdded:
-				sb.WriteString(fmt.Sprintf("  %s %s %s = %v\n", icon, sevLabel, field, formatValue(c.After)))
+				sb.WriteString(fmt.Sprintf("  %s %s %s = %v", icon, sevLabel, field, formatValue(c.After)))
 			case models.ChangeRemoved:
-				sb.WriteString(fmt.Sprintf("  %s %s %s removed\n", icon, sevLabel, field))
+				sb.WriteString(fmt.Sprintf("  %s %s %s removed", icon, sevLabel, field))
 			case models.ChangeModified:
-				sb.WriteString(fmt.Sprintf("  %s %s %s changed: %v → %v\n", icon, sevLabel, field, formatValue(c.Before), formatValue(c.After)))
+				sb.WriteString(fmt.Sprintf("  %s %s %s changed: %v → %v", icon, sevLabel, field, formatValue(c.Before), formatValue(c.After)))
+			case models.ChangeReordered:
+				sb.WriteString(fmt.Sprintf("  %s %s %s reordered: %v → %v", icon, sevLabel, field, formatValue(c.Before), formatValue(c.After)))
+			case models.ChangeRenamed:
+				sb.WriteString(fmt.Sprintf("  %s %s %s renamed from %v", icon, sevLabel, field, formatValue(c.Before)))
+			case models.ChangeDangling:
+				sb.WriteString(fmt.Sprintf("  %s %s %s: %s", icon, sevLabel, field, danglingTarget(c)))
+			case models.ChangeCycle:
+				sb.WriteString(fmt.Sprintf("  %s %s %s cycle: %v", icon, sevLabel, field, c.After))
 			}
+			sb.WriteString(changeLocation(c, oldFile, newFile) + "\n")
+			sb.WriteString(changeNotes(c))
 		}
 		sb.WriteString("\n")
 	}
@@ -69,13 +110,99 @@ func ToText(report *models.DiffReport, oldFile, newFile string) string {
 		for _, c := range volNetChanges {
 			icon := changeIcon(c.Kind, c.Severity)
 			sevLabel := severityLabel(c.Severity)
-			sb.WriteString(fmt.Sprintf("  %s %s %s %s\n", icon, sevLabel, c.Scope, c.Name))
+			sb.WriteString(fmt.Sprintf("  %s %s %s %s", icon, sevLabel, c.Scope, c.Name))
+
+			// Definition changes name the field that changed
+			field := ""
+			switch {
+			case c.Scope == models.ScopeExtension && c.Path != c.Name:
+				field = strings.TrimPrefix(c.Path, c.Name+".")
+			case c.Scope != models.ScopeExtension && strings.Count(c.Path, ".") >= 2:
+				field = extractField(c.Path)
+			}
+			if field != "" {
+				switch c.Kind {
+				case models.ChangeAdded:
+					sb.WriteString(fmt.Sprintf(" %s = %v", field, formatValue(c.After)))
+				case models.ChangeRemoved:
+					sb.WriteString(fmt.Sprintf(" %s removed", field))
+				case models.ChangeModified:
+					sb.WriteString(fmt.Sprintf(" %s changed: %v → %v", field, formatValue(c.Before), formatValue(c.After)))
+				case models.ChangeReordered:
+					sb.WriteString(fmt.Sprintf(" %s reordered: %v → %v", field, formatValue(c.Before), formatValue(c.After)))
+				case models.ChangeRenamed:
+					sb.WriteString(fmt.Sprintf(" %s renamed from %v", field, formatValue(c.Before)))
+				}
+			} else if c.Kind == models.ChangeRenamed {
+				sb.WriteString(fmt.Sprintf(" renamed from %v", formatValue(c.Before)))
+			} else if c.Kind == models.ChangeOrphaned {
+				sb.WriteString(" orphaned, no longer used by " + nameList(c.Before))
+			}
+			sb.WriteString(changeLocation(c, oldFile, newFile) + "\n")
+			sb.WriteString(changeNotes(c))
+		}
+	}
+
+	if len(report.Impacts) > 0 {
+		if len(volNetChanges) > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("Blast radius of breaking changes:\n")
+		for _, imp := range report.Impacts {
+			sb.WriteString(fmt.Sprintf("  %s (%d breaking) → %s\n", cyan(imp.Service), len(imp.Changes), strings.Join(imp.Dependents, ", ")))
+		}
+	}
+
+	if len(report.Rollout) > 0 {
+		if len(volNetChanges) > 0 || len(report.Impacts) > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("Rollout plan (docker compose up -d):\n")
+		for i, step := range report.Rollout {
+			sb.WriteString(fmt.Sprintf("  %d. %s\n", i+1, RolloutStepLine(step, "")))
 		}
 	}
 
 	return sb.String()
 }
 
+// RolloutStepLine lists a rollout step's services and effects, each name
+// wrapped in quote, and the health wait before the next step
+func RolloutStepLine(step models.RolloutStep, quote string) string {
+	parts := make([]string, len(step.Services))
+	for i, s := range step.Services {
+		parts[i] = fmt.Sprintf("%s%s%s (%s)", quote, s.Service, quote, s.Effect)
+	}
+	line := strings.Join(parts, ", ")
+	if step.HealthWait != "" {
+		line += ", then about " + step.HealthWait + " until healthy"
+	}
+	return line
+}
+
+// changeLocation points at the line of a change: in the old file for
+// removals, in the new file otherwise. It is empty when the line is unknown.
+func changeLocation(c models.Change, oldFile, newFile string) string {
+	file, line := newFile, c.NewLine
+	if c.Kind == models.ChangeRemoved {
+		file, line = oldFile, c.OldLine
+	}
+	if line == 0 {
+		return ""
+	}
+	faint := color.New(color.Faint).SprintFunc()
+	return "  " + faint(fmt.Sprintf("(%s:%d)", file, line))
+}
+
+// changeNotes lists the notes a policy attached to a change, one per line
+func changeNotes(c models.Change) string {
+	var sb strings.Builder
+	for _, note := range c.Notes {
+		sb.WriteString("      ↳ " + note + "\n")
+	}
+	return sb.String()
+}
+
 func changeIcon(kind models.ChangeKind, severity models.Severity) string {
 	red := color.New(color.FgRed).SprintFunc()
 	yellow := color.New(color.FgYellow).SprintFunc()
@@ -97,6 +224,17 @@ func changeIcon(kind models.ChangeKind, severity models.Severity) string {
 			return yellow("⚡")
 		}
 		return "🔄"
+	case models.ChangeReordered:
+		return "🔀"
+	case models.ChangeRenamed:
+		if severity == models.SeverityBreaking {
+			return red("⚠️")
+		}
+		return "🏷️"
+	case models.ChangeDangling, models.ChangeCycle:
+		return red("⛓️")
+	case models.ChangeOrphaned:
+		return yellow("🗑️")
 	}
 	return "•"
 }
@@ -123,6 +261,34 @@ func extractField(path string) string {
 	return path
 }
 
+// danglingTarget says what a dangling reference names that does not exist
+func danglingTarget(c models.Change) string {
+	field, _, _ := strings.Cut(extractField(c.Path), ".")
+	switch field {
+	case "volumes":
+		return fmt.Sprintf("%v is not a declared volume", c.After)
+	case "networks":
+		return fmt.Sprintf("%v is not a declared network", c.After)
+	}
+	return fmt.Sprintf("%v is not a service", c.After)
+}
+
+// nameList joins a list of names, such as the former users of an orphaned
+// volume, which is []any once read back from JSON
+func nameList(v any) string {
+	switch names := v.(type) {
+	case []string:
+		return strings.Join(names, ", ")
+	case []any:
+		parts := make([]string, len(names))
+		for i, n := range names {
+			parts[i] = fmt.Sprint(n)
+		}
+		return strings.Join(parts, ", ")
+	}
+	return fmt.Sprint(v)
+}
+
 func formatValue(v interface{}) string {
 	if v == nil {
 		return "null"
@@ -155,7 +321,7 @@ func groupByService(changes []models.Change) map[string][]models.Change {
 func filterVolNetChanges(changes []models.Change) []models.Change {
 	var result []models.Change
 	for _, c := range changes {
-		if c.Scope == models.ScopeVolume || c.Scope == models.ScopeNetwork {
+		if c.Scope == models.ScopeVolume || c.Scope == models.ScopeNetwork || c.Scope == models.ScopeExtension {
 			result = append(result, c)
 		}
 	}
@@ -177,3 +343,13 @@ func sortedKeys(m map[string][]models.Change) []string {
 	}
 	return keys
 }
+
+// FreezeNotice describes a freeze window in effect, e.g. "Change freeze
+// year-end until 2027-01-04 00:00 UTC (holidays): breaking changes fail"
+func FreezeNotice(f *models.Freeze) string {
+	notice := fmt.Sprintf("Change freeze %s until %s", f.Name, f.End.Format("2006-01-02 15:04 MST"))
+	if f.Reason != "" {
+		notice += fmt.Sprintf(" (%s)", f.Reason)
+	}
+	return notice + ": breaking changes fail"
+}
@@ -0,0 +1,75 @@
+// Package risk condenses a diff into a single weighted score, so a release
+// can be gated on one number instead of a list of severities.
+package risk
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/reporter"
+)
+
+// Weights is the risk section of the rules file. Each change scores its
+// severity weight times its category weight; findings score their severity
+// weight. Missing entries fall back to the defaults.
+type Weights struct {
+	Severity   map[string]float64 `yaml:"severity"`   // breaking, warning, info
+	Categories map[string]float64 `yaml:"categories"` // e.g. images, ports, environment; default 1
+}
+
+// DefaultWeights scores a breaking change 10, a warning 3 and info 1, in
+// every category
+func DefaultWeights() Weights {
+	return Weights{
+		Severity: map[string]float64{
+			string(models.SeverityBreaking): 10,
+			string(models.SeverityWarning):  3,
+			string(models.SeverityInfo):     1,
+		},
+	}
+}
+
+// Compile validates configured weights and fills in the defaults
+func Compile(w Weights) (Weights, error) {
+	result := DefaultWeights()
+	for sev, weight := range w.Severity {
+		switch models.Severity(sev) {
+		case models.SeverityBreaking, models.SeverityWarning, models.SeverityInfo:
+		default:
+			return result, fmt.Errorf("risk: unknown severity %q (use breaking, warning or info)", sev)
+		}
+		if weight < 0 {
+			return result, fmt.Errorf("risk: weight of %s is negative", sev)
+		}
+		result.Severity[sev] = weight
+	}
+	result.Categories = make(map[string]float64, len(w.Categories))
+	for cat, weight := range w.Categories {
+		if weight < 0 {
+			return result, fmt.Errorf("risk: weight of category %s is negative", cat)
+		}
+		result.Categories[cat] = weight
+	}
+	return result, nil
+}
+
+// Score returns the weighted risk of a report's changes and findings,
+// rounded to the nearest integer
+func Score(report *models.DiffReport, w Weights) int {
+	total := 0.0
+	for _, c := range report.Changes {
+		total += w.Severity[string(c.Severity)] * w.category(reporter.Category(c))
+	}
+	for _, f := range report.Findings {
+		total += w.Severity[string(f.Severity)]
+	}
+	return int(math.Round(total))
+}
+
+func (w Weights) category(name string) float64 {
+	if weight, ok := w.Categories[name]; ok {
+		return weight
+	}
+	return 1
+}
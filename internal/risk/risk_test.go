@@ -0,0 +1,43 @@
+package risk
+
+import (
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestScore(t *testing.T) {
+	report := &models.DiffReport{
+		Changes: []models.Change{
+			{Scope: models.ScopeService, Name: "api", Path: "services.api.image", Severity: models.SeverityBreaking},
+			{Scope: models.ScopeService, Name: "api", Path: "services.api.environment.DEBUG", Severity: models.SeverityInfo},
+			{Scope: models.ScopeService, Name: "api", Path: "services.api.ports.0", Severity: models.SeverityWarning},
+		},
+		Findings: []models.Finding{{Check: "partial-env-update", Severity: models.SeverityWarning}},
+	}
+
+	if got := Score(report, DefaultWeights()); got != 17 {
+		t.Errorf("Expected default score 17, got %d", got)
+	}
+
+	w, err := Compile(Weights{
+		Severity:   map[string]float64{"info": 0.5},
+		Categories: map[string]float64{"images": 2.5, "ports": 0},
+	})
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	// 10*2.5 + 0.5*1 + 3*0 + 3 = 28.5, rounded
+	if got := Score(report, w); got != 29 {
+		t.Errorf("Expected weighted score 29, got %d", got)
+	}
+}
+
+func TestCompileRejectsBadWeights(t *testing.T) {
+	if _, err := Compile(Weights{Severity: map[string]float64{"critical": 20}}); err == nil {
+		t.Error("Expected an unknown severity to be rejected")
+	}
+	if _, err := Compile(Weights{Categories: map[string]float64{"images": -1}}); err == nil {
+		t.Error("Expected a negative weight to be rejected")
+	}
+}
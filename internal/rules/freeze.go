@@ -0,0 +1,230 @@
+package rules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// FreezeWindow is a period during which breaking changes fail the diff even
+// if a severity override downgrades them. It is either a fixed Start/End
+// range or a Cron schedule that opens a window of Duration at every match.
+type FreezeWindow struct {
+	Name     string `yaml:"name"`
+	Reason   string `yaml:"reason"`   // shown in reports
+	Start    string `yaml:"start"`    // RFC 3339 time or YYYY-MM-DD
+	End      string `yaml:"end"`      // RFC 3339 time or YYYY-MM-DD (inclusive)
+	Cron     string `yaml:"cron"`     // minute hour day-of-month month day-of-week
+	Duration string `yaml:"duration"` // length of each cron window, e.g. 62h
+}
+
+// maxCronWindow bounds cron window durations, which are searched minute by
+// minute
+const maxCronWindow = 31 * 24 * time.Hour
+
+type compiledFreeze struct {
+	window     FreezeWindow
+	start, end time.Time
+	cron       *cronSchedule
+	duration   time.Duration
+}
+
+func compileFreeze(w FreezeWindow) (compiledFreeze, error) {
+	cf := compiledFreeze{window: w}
+	name := w.Name
+	if name == "" {
+		name = "(unnamed)"
+	}
+
+	if w.Cron != "" {
+		if w.Start != "" || w.End != "" {
+			return cf, fmt.Errorf("freeze window %s: use either cron or start/end", name)
+		}
+		sched, err := parseCron(w.Cron)
+		if err != nil {
+			return cf, fmt.Errorf("freeze window %s: %w", name, err)
+		}
+		d, err := time.ParseDuration(w.Duration)
+		if err != nil || d <= 0 || d > maxCronWindow {
+			return cf, fmt.Errorf("freeze window %s: duration must be between 1m and 744h, got %q", name, w.Duration)
+		}
+		cf.cron, cf.duration = sched, d
+		return cf, nil
+	}
+
+	var err error
+	if cf.start, err = parseFreezeTime(w.Start, false); err != nil {
+		return cf, fmt.Errorf("freeze window %s: start: %w", name, err)
+	}
+	if cf.end, err = parseFreezeTime(w.End, true); err != nil {
+		return cf, fmt.Errorf("freeze window %s: end: %w", name, err)
+	}
+	if !cf.end.After(cf.start) {
+		return cf, fmt.Errorf("freeze window %s: end is before start", name)
+	}
+	return cf, nil
+}
+
+// parseFreezeTime parses an RFC 3339 time or a local date. A date used as an
+// end covers the whole day.
+func parseFreezeTime(s string, end bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, fmt.Errorf("missing (set start and end, or cron and duration)")
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a date (YYYY-MM-DD) or RFC 3339 time", s)
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// ParseTime parses a --now value the way freeze window times are parsed
+func ParseTime(s string) (time.Time, error) {
+	return parseFreezeTime(s, false)
+}
+
+// active returns the window's current occurrence containing now
+func (cf *compiledFreeze) active(now time.Time) (start, end time.Time, ok bool) {
+	if cf.cron == nil {
+		return cf.start, cf.end, !now.Before(cf.start) && now.Before(cf.end)
+	}
+	// Find the latest cron match that opened a window still open at now
+	t := now.Truncate(time.Minute)
+	for earliest := now.Add(-cf.duration); t.After(earliest); t = t.Add(-time.Minute) {
+		if cf.cron.matches(t) {
+			return t, t.Add(cf.duration), true
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// ActiveFreeze returns the first freeze window in effect at now, if any
+func (r *Rules) ActiveFreeze(now time.Time) (*models.Freeze, bool) {
+	for i := range r.freezeWindows {
+		cf := &r.freezeWindows[i]
+		if start, end, ok := cf.active(now); ok {
+			return &models.Freeze{Name: cf.window.Name, Reason: cf.window.Reason, Start: start, End: end}, true
+		}
+	}
+	return nil, false
+}
+
+// cronSchedule is a parsed five-field cron expression
+type cronSchedule struct {
+	minute, hour, dom, month, dow [64]bool
+	domAny, dowAny                bool
+}
+
+var (
+	monthNames = []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}
+	dayNames   = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+)
+
+func parseCron(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron %q: expected 5 fields (minute hour day-of-month month day-of-week)", expr)
+	}
+
+	s := &cronSchedule{domAny: fields[2] == "*", dowAny: fields[4] == "*"}
+	specs := []struct {
+		set      *[64]bool
+		min, max int
+		names    []string
+		nameBase int
+	}{
+		{&s.minute, 0, 59, nil, 0},
+		{&s.hour, 0, 23, nil, 0},
+		{&s.dom, 1, 31, nil, 0},
+		{&s.month, 1, 12, monthNames, 1},
+		{&s.dow, 0, 7, dayNames, 0},
+	}
+	for i, spec := range specs {
+		if err := parseCronField(fields[i], spec.set, spec.min, spec.max, spec.names, spec.nameBase); err != nil {
+			return nil, fmt.Errorf("cron %q: %w", expr, err)
+		}
+	}
+	if s.dow[7] {
+		s.dow[0] = true // both 0 and 7 are Sunday
+	}
+	return s, nil
+}
+
+// parseCronField parses a comma-separated list of *, N, A-B and their /step
+// forms into set
+func parseCronField(field string, set *[64]bool, min, max int, names []string, nameBase int) error {
+	value := func(s string) (int, error) {
+		for i, name := range names {
+			if strings.EqualFold(s, name) {
+				return i + nameBase, nil
+			}
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < min || n > max {
+			return 0, fmt.Errorf("%q is not between %d and %d", s, min, max)
+		}
+		return n, nil
+	}
+
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepStr); err != nil || step <= 0 {
+				return fmt.Errorf("invalid step %q", stepStr)
+			}
+		}
+
+		lo, hi := min, max
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = value(a); err != nil {
+				return err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = value(b); err != nil {
+					return err
+				}
+			} else if hasStep {
+				hi = max
+			}
+			if hi < lo {
+				return fmt.Errorf("range %q is backwards", rng)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return nil
+}
+
+// matches reports whether the schedule fires at t. As in cron, when both
+// day-of-month and day-of-week are restricted either one may match.
+func (s *cronSchedule) matches(t time.Time) bool {
+	if !s.minute[t.Minute()] || !s.hour[t.Hour()] || !s.month[t.Month()] {
+		return false
+	}
+	dom, dow := s.dom[t.Day()], s.dow[t.Weekday()]
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	}
+	return dom || dow
+}
@@ -0,0 +1,56 @@
+package rules
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActiveFreeze(t *testing.T) {
+	r, err := compileRules(&RulesConfig{FreezeWindows: []FreezeWindow{
+		{Name: "year-end", Start: "2026-12-20T00:00:00Z", End: "2027-01-04T00:00:00Z"},
+		{Name: "weekend", Cron: "0 18 * * FRI", Duration: "62h"},
+		{Name: "patch-tuesday", Cron: "0 9 8-14 * *", Duration: "8h"},
+	}})
+	if err != nil {
+		t.Fatalf("compileRules failed: %v", err)
+	}
+
+	tests := []struct {
+		now  string
+		want string
+	}{
+		{"2026-12-25T12:00:00Z", "year-end"},
+		{"2027-01-05T00:00:00Z", ""},
+		{"2026-10-16T17:59:00Z", ""},
+		{"2026-10-16T18:00:00Z", "weekend"},
+		{"2026-10-19T07:59:00Z", "weekend"},
+		{"2026-10-19T08:00:00Z", ""},
+		{"2026-10-13T16:59:00Z", "patch-tuesday"},
+		{"2026-10-15T09:00:00Z", ""},
+	}
+	for _, tt := range tests {
+		now, _ := time.Parse(time.RFC3339, tt.now)
+		got := ""
+		if f, ok := r.ActiveFreeze(now); ok {
+			got = f.Name
+		}
+		if got != tt.want {
+			t.Errorf("ActiveFreeze(%s) = %q, want %q", tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestFreezeWindowErrors(t *testing.T) {
+	for _, w := range []FreezeWindow{
+		{Name: "a", Start: "2026-12-20"},
+		{Name: "b", Start: "2026-12-20", End: "2026-12-01"},
+		{Name: "c", Cron: "0 18 * *", Duration: "1h"},
+		{Name: "d", Cron: "0 25 * * *", Duration: "1h"},
+		{Name: "e", Cron: "0 18 * * FRI", Duration: "1000h"},
+		{Name: "f", Cron: "0 18 * * FRI", Start: "2026-12-20", Duration: "1h"},
+	} {
+		if _, err := compileFreeze(w); err == nil {
+			t.Errorf("Expected an error for freeze window %s", w.Name)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package rules
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/imageref"
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// newImage returns the image a change sets: the new value of an image
+// change (including its registry, tag or digest), or the image of an added
+// service
+func newImage(c models.Change) (string, bool) {
+	if c.Scope != models.ScopeService || (c.Kind != models.ChangeModified && c.Kind != models.ChangeAdded) {
+		return "", false
+	}
+	switch {
+	case c.Path == "services."+c.Name+".image" || strings.HasPrefix(c.Path, "services."+c.Name+".image."):
+		image, ok := c.After.(string)
+		return image, ok
+	case c.Path == "services."+c.Name:
+		if svc, ok := c.After.(models.ServiceIR); ok && svc.Image != nil {
+			return *svc.Image, true
+		}
+	}
+	return "", false
+}
+
+// HasImageRules reports whether the rules set allowed_registries or forbid_tags
+func (r *Rules) HasImageRules() bool {
+	return len(r.config.AllowedRegistries) > 0 || len(r.config.ForbidTags) > 0
+}
+
+// ImageViolation checks the image a change sets against allowed_registries
+// and forbid_tags, and returns why it breaks them
+func (r *Rules) ImageViolation(c models.Change) (string, bool) {
+	allowed, forbidden := r.config.AllowedRegistries, r.config.ForbidTags
+	if len(allowed) == 0 && len(forbidden) == 0 {
+		return "", false
+	}
+	image, ok := newImage(c)
+	if !ok {
+		return "", false
+	}
+
+	ref := imageref.Parse(image)
+	if ref.Tag != "" && slices.Contains(forbidden, ref.Tag) {
+		return fmt.Sprintf("image %s uses forbidden tag %s", image, ref.Tag), true
+	}
+	if len(allowed) > 0 && !allowedRegistry(ref, allowed) {
+		return fmt.Sprintf("image %s is not from an allowed registry (%s)", image, strings.Join(allowed, ", ")), true
+	}
+	return "", false
+}
+
+// allowedRegistry reports whether an image is under one of the allowed
+// prefixes, e.g. ghcr.io/acme covers ghcr.io/acme/api. Official Docker Hub
+// images are under docker.io/library, whichever Docker Hub host they name.
+func allowedRegistry(ref imageref.Reference, allowed []string) bool {
+	name := ref.Name()
+	for _, prefix := range allowed {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if name == prefix || strings.HasPrefix(name, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
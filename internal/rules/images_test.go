@@ -0,0 +1,60 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestImageViolation(t *testing.T) {
+	r, err := compileRules(&RulesConfig{
+		AllowedRegistries: []string{"ghcr.io/acme/", "docker.io/library"},
+		ForbidTags:        []string{"latest"},
+	})
+	if err != nil {
+		t.Fatalf("compileRules failed: %v", err)
+	}
+
+	image := func(name, value string) models.Change {
+		return models.Change{Kind: models.ChangeModified, Scope: models.ScopeService, Name: name, Path: "services." + name + ".image", After: value}
+	}
+	redis := "redis"
+	tests := []struct {
+		change models.Change
+		want   string
+	}{
+		{image("api", "ghcr.io/acme/api:1.5"), ""},
+		{image("db", "postgres:16"), ""},
+		{image("db", "postgres:latest"), "image postgres:latest uses forbidden tag latest"},
+		{image("api", "ghcr.io/acmecorp/api:1"), "image ghcr.io/acmecorp/api:1 is not from an allowed registry (ghcr.io/acme/, docker.io/library)"},
+		{image("web", "index.docker.io/nginx:1"), ""},
+		{image("web", "docker.io/library/nginx"), "image docker.io/library/nginx uses forbidden tag latest"},
+		{image("web", "bitnami/nginx:1"), "image bitnami/nginx:1 is not from an allowed registry (ghcr.io/acme/, docker.io/library)"},
+		{image("api", "ghcr.io/acme/api@sha256:abc"), ""},
+		{models.Change{Kind: models.ChangeAdded, Scope: models.ScopeService, Name: "cache", Path: "services.cache", After: models.ServiceIR{Image: &redis}}, "image redis uses forbidden tag latest"},
+		{models.Change{Kind: models.ChangeRemoved, Scope: models.ScopeService, Name: "db", Path: "services.db.image", Before: "postgres:latest"}, ""},
+	}
+	for _, tt := range tests {
+		got, ok := r.ImageViolation(tt.change)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("ImageViolation(%s = %v) = %q, %v, want %q", tt.change.Path, tt.change.After, got, ok, tt.want)
+		}
+	}
+}
+
+func TestApplyImageViolation(t *testing.T) {
+	r, err := compileRules(&RulesConfig{
+		ForbidTags:        []string{"latest"},
+		SeverityOverrides: []SeverityRule{{Pattern: "services.*.image", Severity: "info"}},
+	})
+	if err != nil {
+		t.Fatalf("compileRules failed: %v", err)
+	}
+
+	// The override lowers the change, then the image rule makes it breaking
+	c := models.Change{Kind: models.ChangeModified, Scope: models.ScopeService, Name: "db", Path: "services.db.image", After: "postgres:latest", Severity: models.SeverityWarning}
+	out := r.Apply(c, nil, false)
+	if out.Change.Severity != models.SeverityBreaking || len(out.Change.Notes) != 1 || len(out.Matches) != 2 || out.Matches[1].Action != "image" {
+		t.Errorf("Expected the image rule to decide, got %+v", out)
+	}
+}
@@ -0,0 +1,282 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/stackgen-cli/compose-diff/internal/imageref"
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/reporter"
+)
+
+// Policy is a CEL expression that decides what happens to a change. It sees
+// the change as change (kind, scope, name, path, field, category, severity,
+// before, after) and the service before and after as old and new, null when
+// the service is missing on that side or the change is not a service's. It
+// returns "breaking", "warning", "info" or "ignore", or "" for no opinion.
+// registry(), repository() and tag() split an image reference.
+type Policy struct {
+	Name string `yaml:"name"`
+	Expr string `yaml:"expr"`
+	Line int    `yaml:"-"` // line in the rules file, if loaded from one
+}
+
+// String is the policy's name, or its expression if unnamed
+func (p Policy) String() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.Expr
+}
+
+type compiledPolicy struct {
+	policy  *Policy
+	program cel.Program
+}
+
+// Decision is the outcome of the first policy with an opinion on a change
+type Decision struct {
+	Severity models.Severity // empty when ignored
+	Ignore   bool
+	Policy   *Policy
+}
+
+// policyEnv declares the variables and functions policies can use
+func policyEnv() (*cel.Env, error) {
+	return celEnv(
+		cel.Variable("change", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("old", cel.DynType),
+		cel.Variable("new", cel.DynType),
+	)
+}
+
+// celEnv declares variables next to the image functions
+func celEnv(variables ...cel.EnvOption) (*cel.Env, error) {
+	image := func(name string, part func(imageref.Reference) string) cel.EnvOption {
+		return cel.Function(name, cel.Overload(name+"_string", []*cel.Type{cel.StringType}, cel.StringType,
+			cel.UnaryBinding(func(v ref.Val) ref.Val {
+				s, ok := v.(types.String)
+				if !ok {
+					return types.MaybeNoSuchOverloadErr(v)
+				}
+				return types.String(part(imageref.Parse(string(s))))
+			})))
+	}
+	return cel.NewEnv(append(variables,
+		image("registry", func(r imageref.Reference) string { return r.Registry }),
+		image("repository", func(r imageref.Reference) string { return r.Repository }),
+		image("tag", func(r imageref.Reference) string { return r.Tag }),
+	)...)
+}
+
+// compilePolicies type-checks the policies' expressions
+func compilePolicies(policies []Policy) ([]compiledPolicy, error) {
+	if len(policies) == 0 {
+		return nil, nil
+	}
+	env, err := policyEnv()
+	if err != nil {
+		return nil, err
+	}
+	compiled := make([]compiledPolicy, 0, len(policies))
+	for i := range policies {
+		p := &policies[i]
+		ast, issues := env.Compile(p.Expr)
+		if issues != nil && issues.Err() != nil {
+			return nil, fmt.Errorf("policies: %s: %w", p, issues.Err())
+		}
+		if out := ast.OutputType(); !out.IsAssignableType(cel.StringType) {
+			return nil, fmt.Errorf("policies: %s: returns %s, want a string", p, out)
+		}
+		program, err := env.Program(ast)
+		if err != nil {
+			return nil, fmt.Errorf("policies: %s: %w", p, err)
+		}
+		compiled = append(compiled, compiledPolicy{policy: p, program: program})
+	}
+	return compiled, nil
+}
+
+// HasPolicies reports whether the rules have any CEL policies
+func (r *Rules) HasPolicies() bool {
+	return len(r.policies) > 0
+}
+
+// CheckPolicyError is the Finding.Check of CEL policies that failed on
+// some changes
+const CheckPolicyError = "policy-error"
+
+// PolicyEvaluator runs the policies against the changes between two IRs,
+// converting each service for CEL once
+type PolicyEvaluator struct {
+	policies []compiledPolicy
+	old, new *models.ComposeIR
+	services map[string]any
+	file     string
+	failures map[*Policy]*policyFailure
+	err      error
+}
+
+// policyFailure counts the changes a policy failed on, keeping the first
+type policyFailure struct {
+	count int
+	path  string
+	err   error
+}
+
+// Policies returns an evaluator for changes between old and new; either IR
+// may be nil
+func (r *Rules) Policies(old, new *models.ComposeIR) *PolicyEvaluator {
+	return &PolicyEvaluator{policies: r.policies, old: old, new: new, services: make(map[string]any), file: r.path, failures: make(map[*Policy]*policyFailure)}
+}
+
+// Evaluate runs the policies in file order and returns the first decision.
+// A policy whose expression fails on the change, e.g. because it reads a
+// field the service does not set, has no opinion; Findings reports it. A
+// policy that returns a string other than a severity, "ignore" or "" has no
+// opinion either, and makes Err return an error. A nil evaluator has no
+// policies.
+func (e *PolicyEvaluator) Evaluate(c models.Change) (Decision, bool) {
+	if e == nil || len(e.policies) == 0 {
+		return Decision{}, false
+	}
+	vars := map[string]any{
+		"change": map[string]any{
+			"kind":     string(c.Kind),
+			"scope":    string(c.Scope),
+			"name":     c.Name,
+			"path":     c.Path,
+			"field":    changeField(c.Path),
+			"category": reporter.Category(c),
+			"severity": string(c.Severity),
+			"before":   plain(c.Before),
+			"after":    plain(c.After),
+		},
+		"old": nil,
+		"new": nil,
+	}
+	if c.Scope == models.ScopeService {
+		oldName := c.Name
+		if before, ok := c.Before.(string); ok && c.Kind == models.ChangeRenamed && c.Path == "services."+c.Name {
+			oldName = before
+		}
+		vars["old"] = e.service("old", e.old, oldName)
+		vars["new"] = e.service("new", e.new, c.Name)
+	}
+
+	for _, cp := range e.policies {
+		out, _, err := cp.program.Eval(vars)
+		if err != nil {
+			e.fail(cp.policy, c.Path, err)
+			continue
+		}
+		s, ok := out.Value().(string)
+		switch {
+		case !ok || s == "":
+			continue
+		case s == "ignore":
+			return Decision{Ignore: true, Policy: cp.policy}, true
+		case models.SeverityLevel(models.Severity(s)) > 0:
+			return Decision{Severity: models.Severity(s), Policy: cp.policy}, true
+		case e.err == nil:
+			e.err = fmt.Errorf("%s: policy %s returned %q for %s: use \"breaking\", \"warning\", \"info\", \"ignore\" or \"\"", e.location(cp.policy), cp.policy, s, c.Path)
+		}
+	}
+	return Decision{}, false
+}
+
+func (e *PolicyEvaluator) fail(p *Policy, path string, err error) {
+	f, ok := e.failures[p]
+	if !ok {
+		f = &policyFailure{path: path, err: err}
+		e.failures[p] = f
+	}
+	f.count++
+}
+
+// location is where a policy is defined, e.g. .compose-diff.yaml:12
+func (e *PolicyEvaluator) location(p *Policy) string {
+	if e.file == "" {
+		return "policies"
+	}
+	if p.Line > 0 {
+		return fmt.Sprintf("%s:%d", e.file, p.Line)
+	}
+	return e.file
+}
+
+// Err returns an error for the first policy that returned an unknown
+// string, such as "critical", which is most likely a typo
+func (e *PolicyEvaluator) Err() error {
+	if e == nil {
+		return nil
+	}
+	return e.err
+}
+
+// Findings returns a warning for each policy that failed on some changes
+// and so had no opinion on them
+func (e *PolicyEvaluator) Findings() []models.Finding {
+	if e == nil {
+		return nil
+	}
+	var findings []models.Finding
+	for _, cp := range e.policies {
+		f, ok := e.failures[cp.policy]
+		if !ok {
+			continue
+		}
+		findings = append(findings, models.Finding{
+			Check:    CheckPolicyError,
+			Severity: models.SeverityWarning,
+			Message:  fmt.Sprintf("%s: policy %s failed on %d changes and had no opinion on them, e.g. %s: %v", e.location(cp.policy), cp.policy, f.count, f.path, f.err),
+			Paths:    []string{f.path},
+		})
+	}
+	return findings
+}
+
+// service returns a service of one side as plain maps and lists, as in the
+// JSON IR, or nil if it is missing
+func (e *PolicyEvaluator) service(side string, ir *models.ComposeIR, name string) any {
+	key := side + "/" + name
+	if v, ok := e.services[key]; ok {
+		return v
+	}
+	var v any
+	if ir != nil {
+		if svc, ok := ir.Services[name]; ok {
+			v = plain(svc)
+		}
+	}
+	e.services[key] = v
+	return v
+}
+
+// plain converts a value to what encoding/json decodes it as, which CEL
+// handles natively
+func plain(v any) any {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	var out any
+	json.Unmarshal(data, &out)
+	return out
+}
+
+// changeField is the service field a path is under, e.g. environment
+func changeField(path string) string {
+	parts := strings.SplitN(path, ".", 4)
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[2]
+}
@@ -0,0 +1,99 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func image(s string) *string { return &s }
+
+func TestPolicies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	os.WriteFile(path, []byte(`policies:
+  - name: registry moved
+    expr: >
+      change.field == "image" && registry(old.image) != registry(new.image) && tag(old.image) == tag(new.image)
+      ? "breaking" : ""
+  - name: debug
+    expr: 'change.path.endsWith(".DEBUG") ? "ignore" : ""'
+  - expr: 'new.image.startsWith("redis") ? "warning" : ""'
+`), 0644)
+	r, err := LoadRules(path)
+	if err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+	if !r.HasPolicies() {
+		t.Fatal("Expected policies")
+	}
+
+	old := &models.ComposeIR{Services: map[string]models.ServiceIR{
+		"api": {Image: image("acme/api:1.4")},
+		"db":  {Image: image("postgres:15")},
+	}}
+	new := &models.ComposeIR{Services: map[string]models.ServiceIR{
+		"api":   {Image: image("ghcr.io/acme/api:1.4")},
+		"db":    {Image: image("postgres:16")},
+		"cache": {Image: image("redis:7")},
+	}}
+	e := r.Policies(old, new)
+
+	tests := []struct {
+		change models.Change
+		want   Decision
+		ok     bool
+	}{
+		{models.Change{Kind: models.ChangeModified, Scope: models.ScopeService, Name: "api", Path: "services.api.image"}, Decision{Severity: models.SeverityBreaking, Policy: &r.config.Policies[0]}, true},
+		{models.Change{Kind: models.ChangeModified, Scope: models.ScopeService, Name: "db", Path: "services.db.image"}, Decision{}, false},
+		{models.Change{Kind: models.ChangeRemoved, Scope: models.ScopeService, Name: "db", Path: "services.db.environment.DEBUG"}, Decision{Ignore: true, Policy: &r.config.Policies[1]}, true},
+		{models.Change{Kind: models.ChangeAdded, Scope: models.ScopeService, Name: "cache", Path: "services.cache"}, Decision{Severity: models.SeverityWarning, Policy: &r.config.Policies[2]}, true},
+		// old is null for an added service, so the first policy fails and has no opinion
+		{models.Change{Kind: models.ChangeAdded, Scope: models.ScopeVolume, Name: "data", Path: "volumes.data"}, Decision{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := e.Evaluate(tt.change)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("Evaluate(%s) = %+v, %v, want %+v, %v", tt.change.Path, got, ok, tt.want, tt.ok)
+		}
+	}
+	if line := r.config.Policies[1].Line; line != 6 {
+		t.Errorf("Expected the second policy on line 6, got %d", line)
+	}
+
+	findings := e.Findings()
+	if len(findings) != 1 || findings[0].Check != CheckPolicyError || !strings.Contains(findings[0].Message, ":8: policy new.image.startsWith") || !strings.Contains(findings[0].Message, "volumes.data") {
+		t.Errorf("Expected a finding for the policy that failed on volumes.data, got %+v", findings)
+	}
+	if err := e.Err(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestPoliciesUnknownSeverity(t *testing.T) {
+	r, err := compileRules(&RulesConfig{Policies: []Policy{{Name: "typo", Expr: `change.field == "environment" ? "Breaking" : ""`}}})
+	if err != nil {
+		t.Fatalf("compileRules failed: %v", err)
+	}
+	e := r.Policies(nil, nil)
+	if _, ok := e.Evaluate(models.Change{Kind: models.ChangeModified, Path: "services.api.environment.DEBUG"}); ok {
+		t.Error("Expected no decision for an unknown severity")
+	}
+	if err := e.Err(); err == nil || !strings.Contains(err.Error(), `policy typo returned "Breaking"`) {
+		t.Errorf("Expected an error naming the policy, got %v", err)
+	}
+}
+
+func TestPoliciesInvalid(t *testing.T) {
+	for _, expr := range []string{
+		`change.kind ==`,
+		`1 + 2`,
+		`unknown.image`,
+	} {
+		if _, err := compileRules(&RulesConfig{Policies: []Policy{{Expr: expr}}}); err == nil || !strings.Contains(err.Error(), "policies") {
+			t.Errorf("Expected %q to be rejected, got %v", expr, err)
+		}
+	}
+}
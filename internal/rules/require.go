@@ -0,0 +1,145 @@
+package rules
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/google/cel-go/cel"
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+// CheckRequirement is the Finding.Check of requirement violations
+const CheckRequirement = "requirement"
+
+// Requirement is an invariant of the services in the new file: a CEL
+// expression over service, the service as in the JSON IR, and name that
+// must be true. An expression that fails, e.g. on a field the service does
+// not set, counts as false.
+type Requirement struct {
+	Name     string   `yaml:"name"`     // what is required, e.g. "must define a healthcheck"
+	Services []string `yaml:"services"` // service name globs; default all
+	Expr     string   `yaml:"expr"`
+	Severity string   `yaml:"severity"` // of violations; default warning
+	Line     int      `yaml:"-"`        // line in the rules file, if loaded from one
+}
+
+// String is the requirement's name, or its expression if unnamed
+func (q Requirement) String() string {
+	if q.Name != "" {
+		return q.Name
+	}
+	return q.Expr
+}
+
+type compiledRequirement struct {
+	requirement *Requirement
+	severity    models.Severity
+	program     cel.Program
+}
+
+// compileRequirements type-checks the requirements' expressions
+func compileRequirements(requirements []Requirement) ([]compiledRequirement, error) {
+	if len(requirements) == 0 {
+		return nil, nil
+	}
+	env, err := celEnv(
+		cel.Variable("service", cel.DynType),
+		cel.Variable("name", cel.StringType),
+	)
+	if err != nil {
+		return nil, err
+	}
+	compiled := make([]compiledRequirement, 0, len(requirements))
+	for i := range requirements {
+		q := &requirements[i]
+		cq := compiledRequirement{requirement: q, severity: models.SeverityWarning}
+		if q.Severity != "" {
+			cq.severity = models.Severity(q.Severity)
+			if models.SeverityLevel(cq.severity) == 0 {
+				return nil, fmt.Errorf("require: %s: invalid severity %q (use info, warning or breaking)", q, q.Severity)
+			}
+		}
+		for _, pattern := range q.Services {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("require: %s: bad service pattern %q", q, pattern)
+			}
+		}
+		ast, issues := env.Compile(q.Expr)
+		if issues != nil && issues.Err() != nil {
+			return nil, fmt.Errorf("require: %s: %w", q, issues.Err())
+		}
+		if out := ast.OutputType(); !out.IsAssignableType(cel.BoolType) {
+			return nil, fmt.Errorf("require: %s: returns %s, want true or false", q, out)
+		}
+		if cq.program, err = env.Program(ast); err != nil {
+			return nil, fmt.Errorf("require: %s: %w", q, err)
+		}
+		compiled = append(compiled, cq)
+	}
+	return compiled, nil
+}
+
+// applies reports whether the requirement covers a service
+func (cq compiledRequirement) applies(name string) bool {
+	if len(cq.requirement.Services) == 0 {
+		return true
+	}
+	for _, pattern := range cq.requirement.Services {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// holds evaluates the requirement for a service
+func (cq compiledRequirement) holds(name string, service any) bool {
+	out, _, err := cq.program.Eval(map[string]any{"service": service, "name": name})
+	if err != nil {
+		return false
+	}
+	ok, _ := out.Value().(bool)
+	return ok
+}
+
+// CheckRequirements reports the services of new that violate a requirement,
+// by service name. A service that already violated it in old is left alone,
+// so adding a requirement does not flag every existing service.
+func (r *Rules) CheckRequirements(old, new *models.ComposeIR) []models.Finding {
+	if len(r.requirements) == 0 || new == nil {
+		return nil
+	}
+	names := make([]string, 0, len(new.Services))
+	for name := range new.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var findings []models.Finding
+	for _, name := range names {
+		service := plain(new.Services[name])
+		var before any
+		if old != nil {
+			if svc, ok := old.Services[name]; ok {
+				before = plain(svc)
+			}
+		}
+		for _, cq := range r.requirements {
+			if !cq.applies(name) || cq.holds(name, service) || (before != nil && !cq.holds(name, before)) {
+				continue
+			}
+			message := fmt.Sprintf("%s: %s", name, cq.requirement.Name)
+			if cq.requirement.Name == "" {
+				message = fmt.Sprintf("%s: does not satisfy %s", name, cq.requirement.Expr)
+			}
+			findings = append(findings, models.Finding{
+				Check:    CheckRequirement,
+				Severity: cq.severity,
+				Message:  message,
+				Paths:    []string{"services." + name},
+			})
+		}
+	}
+	return findings
+}
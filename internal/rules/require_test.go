@@ -0,0 +1,58 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestCheckRequirements(t *testing.T) {
+	r, err := compileRules(&RulesConfig{Requirements: []Requirement{
+		{Name: "must define a healthcheck", Services: []string{"db*"}, Expr: "has(service.healthcheck)", Severity: "breaking"},
+		{Name: "must set restart unless-stopped", Expr: `service.restart == "unless-stopped"`},
+		{Expr: `service.ports.all(p, p.host_ip == "127.0.0.1")`},
+	}})
+	if err != nil {
+		t.Fatalf("compileRules failed: %v", err)
+	}
+
+	restart := "unless-stopped"
+	old := &models.ComposeIR{Services: map[string]models.ServiceIR{
+		"db":  {Healthcheck: &models.HealthcheckIR{}},
+		"web": {Ports: []models.PortIR{{HostPort: "80", ContainerPort: "80"}}},
+	}}
+	new := &models.ComposeIR{Services: map[string]models.ServiceIR{
+		"db":    {Restart: &restart},
+		"web":   {Ports: []models.PortIR{{HostPort: "80", ContainerPort: "80"}}},
+		"api":   {Restart: &restart, Ports: []models.PortIR{{HostIP: "127.0.0.1", HostPort: "8080", ContainerPort: "80"}}},
+		"cache": {Restart: &restart, Ports: []models.PortIR{{HostPort: "6379", ContainerPort: "6379"}}},
+	}}
+
+	var got []string
+	for _, f := range r.CheckRequirements(old, new) {
+		got = append(got, string(f.Severity)+" "+f.Message)
+	}
+	// web and db already failed the port requirement and web the restart one, so
+	// only the new violations count
+	want := []string{
+		`warning cache: does not satisfy service.ports.all(p, p.host_ip == "127.0.0.1")`,
+		"breaking db: must define a healthcheck",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected findings:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestRequirementsInvalid(t *testing.T) {
+	for _, q := range []Requirement{
+		{Expr: `1 + 2`},
+		{Expr: `has(service.restart)`, Severity: "fatal"},
+		{Expr: `has(service.restart)`, Services: []string{"[db"}},
+		{Expr: `svc.restart == "no"`},
+	} {
+		if _, err := compileRules(&RulesConfig{Requirements: []Requirement{q}}); err == nil || !strings.Contains(err.Error(), "require") {
+			t.Errorf("Expected %+v to be rejected, got %v", q, err)
+		}
+	}
+}
@@ -1,12 +1,18 @@
 package rules
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 
+	"github.com/stackgen-cli/compose-diff/internal/conventions"
 	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/parser"
+	"github.com/stackgen-cli/compose-diff/internal/reporter"
+	"github.com/stackgen-cli/compose-diff/internal/risk"
 	"gopkg.in/yaml.v3"
 )
 
@@ -17,6 +23,17 @@ type RulesConfig struct {
 	// SeverityOverrides maps path patterns to severity levels
 	SeverityOverrides []SeverityRule `yaml:"severity_overrides"`
 
+	// SeverityPolicy sets or bounds the severity of whole categories and
+	// change kinds; severity_overrides still win for the paths they match
+	SeverityPolicy []PolicyRule `yaml:"severity_policy"`
+
+	// Policies are CEL expressions that ignore changes or set their
+	// severity, for what path patterns cannot express
+	Policies []Policy `yaml:"policies"`
+
+	// Requirements are invariants the services of the new file must satisfy
+	Requirements []Requirement `yaml:"require"`
+
 	// IgnorePatterns defines paths to completely ignore
 	IgnorePatterns []IgnoreRule `yaml:"ignore_patterns"`
 
@@ -25,6 +42,31 @@ type RulesConfig struct {
 
 	// Categories defines custom category mappings
 	Categories map[string][]string `yaml:"categories"`
+
+	// WarnLabelNamespaces lists label namespaces (e.g. "traefik.*") whose
+	// changes are warning rather than info
+	WarnLabelNamespaces []string `yaml:"warn_label_namespaces"`
+
+	// OrderedLists are the list fields compared by position rather than
+	// as sets, e.g. command and env_file; --ordered-lists overrides them
+	OrderedLists []string `yaml:"ordered_lists"`
+
+	// AllowedRegistries are the registries, or registry paths such as
+	// ghcr.io/acme, images may come from; a change to another is breaking
+	AllowedRegistries []string `yaml:"allowed_registries"`
+
+	// ForbidTags are image tags, such as latest, a change may not set
+	ForbidTags []string `yaml:"forbid_tags"`
+
+	// FreezeWindows are periods during which breaking changes always fail
+	FreezeWindows []FreezeWindow `yaml:"freeze_windows"`
+
+	// Conventions are the naming and port allocation rules that added or
+	// modified container names, labels and ports must follow
+	Conventions conventions.Config `yaml:"conventions"`
+
+	// Risk weighs changes by severity and category into the risk score
+	Risk risk.Weights `yaml:"risk"`
 }
 
 // SeverityRule maps a path pattern to a severity
@@ -32,6 +74,69 @@ type SeverityRule struct {
 	Pattern  string `yaml:"pattern"`  // glob or regex pattern
 	Severity string `yaml:"severity"` // info, warning, breaking
 	IsRegex  bool   `yaml:"regex"`    // if true, use regex matching
+	Line     int    `yaml:"-"`        // line in the rules file, if loaded from one
+}
+
+// PolicyRule sets, raises or caps the severity of the changes in a category
+// (environment, ports, images, ...) and of a kind (added, removed, ...).
+// An empty category or kind matches all.
+type PolicyRule struct {
+	Category string `yaml:"category"`
+	Kind     string `yaml:"kind"`
+	Severity string `yaml:"severity"` // set to this
+	Min      string `yaml:"min"`      // raise to at least this
+	Max      string `yaml:"max"`      // lower to at most this
+	Line     int    `yaml:"-"`        // line in the rules file, if loaded from one
+}
+
+// matches reports whether the rule applies to a change
+func (p PolicyRule) matches(c models.Change) bool {
+	return (p.Category == "" || p.Category == reporter.Category(c)) &&
+		(p.Kind == "" || models.ChangeKind(p.Kind) == c.Kind)
+}
+
+// apply returns severity after the rule
+func (p PolicyRule) apply(severity models.Severity) models.Severity {
+	if p.Severity != "" {
+		severity = models.Severity(p.Severity)
+	}
+	if p.Min != "" && models.SeverityLevel(severity) < models.SeverityLevel(models.Severity(p.Min)) {
+		severity = models.Severity(p.Min)
+	}
+	if p.Max != "" && models.SeverityLevel(severity) > models.SeverityLevel(models.Severity(p.Max)) {
+		severity = models.Severity(p.Max)
+	}
+	return severity
+}
+
+// String describes the rule as it reads in the rules file
+func (p PolicyRule) String() string {
+	var parts []string
+	for _, field := range [][2]string{{"category", p.Category}, {"kind", p.Kind}, {"severity", p.Severity}, {"min", p.Min}, {"max", p.Max}} {
+		if field[1] != "" {
+			parts = append(parts, field[0]+": "+field[1])
+		}
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// validate checks the rule's category, kind and severities
+func (p PolicyRule) validate() error {
+	if p.Category != "" && !slices.Contains(reporter.Categories, p.Category) {
+		return fmt.Errorf("severity_policy: unknown category %q (use %s)", p.Category, strings.Join(reporter.Categories, ", "))
+	}
+	if p.Kind != "" && !models.ValidChangeKind(models.ChangeKind(p.Kind)) {
+		return fmt.Errorf("severity_policy: unknown kind %q", p.Kind)
+	}
+	if p.Severity == "" && p.Min == "" && p.Max == "" {
+		return fmt.Errorf("severity_policy: %s sets none of severity, min or max", p)
+	}
+	for _, s := range []string{p.Severity, p.Min, p.Max} {
+		if s != "" && models.SeverityLevel(models.Severity(s)) == 0 {
+			return fmt.Errorf("severity_policy: unknown severity %q (use info, warning or breaking)", s)
+		}
+	}
+	return nil
 }
 
 // IgnoreRule defines what to ignore
@@ -39,6 +144,7 @@ type IgnoreRule struct {
 	Pattern string `yaml:"pattern"` // path pattern to ignore
 	IsRegex bool   `yaml:"regex"`   // if true, use regex matching
 	Reason  string `yaml:"reason"`  // why it's ignored (for reports)
+	Line    int    `yaml:"-"`       // line in the rules file, if loaded from one
 }
 
 // ServiceIgnoreRules defines ignores for a specific service
@@ -49,9 +155,15 @@ type ServiceIgnoreRules struct {
 
 // Rules holds the loaded rules configuration
 type Rules struct {
+	path             string
 	config           *RulesConfig
 	severityPatterns []compiledSeverity
 	ignorePatterns   []compiledIgnore
+	freezeWindows    []compiledFreeze
+	policies         []compiledPolicy
+	requirements     []compiledRequirement
+	conventions      *conventions.Checker
+	riskWeights      risk.Weights
 }
 
 type compiledSeverity struct {
@@ -59,6 +171,7 @@ type compiledSeverity struct {
 	literal  string
 	severity string
 	isRegex  bool
+	line     int
 }
 
 type compiledIgnore struct {
@@ -66,6 +179,17 @@ type compiledIgnore struct {
 	literal string
 	isRegex bool
 	reason  string
+	line    int
+}
+
+// Match describes the rule that decided what happens to a path
+type Match struct {
+	Action   string // "ignore", "service-ignore", "policy" (severity_policy), "cel" (policies), "severity" (severity_overrides) or "image" (allowed_registries, forbid_tags)
+	Pattern  string
+	Severity models.Severity
+	Reason   string
+	File     string
+	Line     int
 }
 
 // LoadRules loads rules from a file path
@@ -75,12 +199,78 @@ func LoadRules(path string) (*Rules, error) {
 		return nil, err
 	}
 
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
 	var config RulesConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	if err := root.Decode(&config); err != nil {
 		return nil, err
 	}
+	recordLines(&root, &config)
 
-	return compileRules(&config)
+	rules, err := compileRules(&config)
+	if err != nil {
+		return nil, err
+	}
+	rules.path = path
+	return rules, nil
+}
+
+// recordLines copies the source line of each list rule into the config
+func recordLines(root *yaml.Node, config *RulesConfig) {
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 {
+		return
+	}
+	doc := root.Content[0]
+	if doc.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		items := doc.Content[i+1]
+		if items.Kind != yaml.SequenceNode {
+			continue
+		}
+		switch doc.Content[i].Value {
+		case "severity_overrides":
+			for j, item := range items.Content {
+				if j < len(config.SeverityOverrides) {
+					config.SeverityOverrides[j].Line = item.Line
+				}
+			}
+		case "ignore_patterns":
+			for j, item := range items.Content {
+				if j < len(config.IgnorePatterns) {
+					config.IgnorePatterns[j].Line = item.Line
+				}
+			}
+		case "severity_policy":
+			for j, item := range items.Content {
+				if j < len(config.SeverityPolicy) {
+					config.SeverityPolicy[j].Line = item.Line
+				}
+			}
+		case "policies":
+			for j, item := range items.Content {
+				if j < len(config.Policies) {
+					config.Policies[j].Line = item.Line
+				}
+			}
+		case "require":
+			for j, item := range items.Content {
+				if j < len(config.Requirements) {
+					config.Requirements[j].Line = item.Line
+				}
+			}
+		}
+	}
+}
+
+// Path returns the file the rules were loaded from, empty if none
+func (r *Rules) Path() string {
+	return r.path
 }
 
 // LoadRulesFromDir finds and loads .compose-diff.yaml from directory
@@ -100,7 +290,7 @@ func LoadRulesFromDir(dir string) (*Rules, error) {
 	}
 
 	// Return empty rules if no file found
-	return &Rules{config: &RulesConfig{}}, nil
+	return &Rules{config: &RulesConfig{}, riskWeights: risk.DefaultWeights()}, nil
 }
 
 // compileRules compiles the patterns for efficient matching
@@ -116,6 +306,7 @@ func compileRules(config *RulesConfig) (*Rules, error) {
 		cs := compiledSeverity{
 			severity: sr.Severity,
 			isRegex:  sr.IsRegex,
+			line:     sr.Line,
 		}
 		if sr.IsRegex {
 			re, err := regexp.Compile(sr.Pattern)
@@ -129,11 +320,34 @@ func compileRules(config *RulesConfig) (*Rules, error) {
 		rules.severityPatterns = append(rules.severityPatterns, cs)
 	}
 
+	for _, pr := range config.SeverityPolicy {
+		if err := pr.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := parser.CheckOrderedLists(config.OrderedLists); err != nil {
+		return nil, fmt.Errorf("ordered_lists: %w", err)
+	}
+
+	policies, err := compilePolicies(config.Policies)
+	if err != nil {
+		return nil, err
+	}
+	rules.policies = policies
+
+	requirements, err := compileRequirements(config.Requirements)
+	if err != nil {
+		return nil, err
+	}
+	rules.requirements = requirements
+
 	// Compile ignore patterns
 	for _, ir := range config.IgnorePatterns {
 		ci := compiledIgnore{
 			isRegex: ir.IsRegex,
 			reason:  ir.Reason,
+			line:    ir.Line,
 		}
 		if ir.IsRegex {
 			re, err := regexp.Compile(ir.Pattern)
@@ -147,39 +361,154 @@ func compileRules(config *RulesConfig) (*Rules, error) {
 		rules.ignorePatterns = append(rules.ignorePatterns, ci)
 	}
 
+	// Compile freeze windows
+	for _, fw := range config.FreezeWindows {
+		cf, err := compileFreeze(fw)
+		if err != nil {
+			return nil, err
+		}
+		rules.freezeWindows = append(rules.freezeWindows, cf)
+	}
+
+	checker, err := conventions.Compile(config.Conventions)
+	if err != nil {
+		return nil, err
+	}
+	rules.conventions = checker
+
+	weights, err := risk.Compile(config.Risk)
+	if err != nil {
+		return nil, err
+	}
+	rules.riskWeights = weights
+
 	return rules, nil
 }
 
 // GetSeverityOverride returns custom severity if matched, empty otherwise
 func (r *Rules) GetSeverityOverride(path string) (models.Severity, bool) {
-	for _, sp := range r.severityPatterns {
+	if sp := r.matchSeverity(path); sp != nil {
+		return models.Severity(sp.severity), true
+	}
+	return "", false
+}
+
+// PolicySeverity applies the severity policy to a change, entries in file
+// order, and returns the resulting severity and the last entry that matched
+func (r *Rules) PolicySeverity(c models.Change) (models.Severity, *PolicyRule, bool) {
+	var last *PolicyRule
+	severity := c.Severity
+	for i, p := range r.config.SeverityPolicy {
+		if p.matches(c) {
+			severity = p.apply(severity)
+			last = &r.config.SeverityPolicy[i]
+		}
+	}
+	return severity, last, last != nil
+}
+
+// ShouldIgnore returns true if the path should be ignored
+func (r *Rules) ShouldIgnore(path string) (bool, string) {
+	if ip := r.matchIgnore(path); ip != nil {
+		return true, ip.reason
+	}
+	return false, ""
+}
+
+// Outcome is what the rules do to one change
+type Outcome struct {
+	Change  models.Change // with its final severity
+	Ignored bool
+	Matches []Match // the rules that applied, in order; the last one decided
+}
+
+// Apply runs the rules on a change in the order diff applies them. An
+// ignore pattern or a service ignore drops it; otherwise the severity
+// policy, the CEL policies evaluated by policies (which may be nil) and
+// the severity overrides each set its severity or, for a CEL policy,
+// drop it, except that while frozen a breaking change stays breaking.
+// Last, an image that breaks allowed_registries or forbid_tags makes it
+// breaking whatever the other rules said.
+func (r *Rules) Apply(c models.Change, policies *PolicyEvaluator, frozen bool) Outcome {
+	out := Outcome{Change: c}
+	if ip := r.matchIgnore(c.Path); ip != nil {
+		out.Ignored = true
+		out.Matches = append(out.Matches, Match{Action: "ignore", Pattern: ip.source(), Reason: ip.reason, File: r.path, Line: ip.line})
+		return out
+	}
+	if field := changeField(c.Path); r.ShouldIgnoreServiceField(c.Name, field) {
+		out.Ignored = true
+		out.Matches = append(out.Matches, Match{Action: "service-ignore", Pattern: c.Name + "." + field, File: r.path})
+		return out
+	}
+
+	locked := func() bool { return frozen && out.Change.Severity == models.SeverityBreaking }
+	if severity, rule, ok := r.PolicySeverity(out.Change); ok && !locked() {
+		out.Change.Severity = severity
+		out.Matches = append(out.Matches, Match{Action: "policy", Pattern: rule.String(), Severity: severity, File: r.path, Line: rule.Line})
+	}
+	if d, ok := policies.Evaluate(out.Change); ok && !locked() {
+		out.Matches = append(out.Matches, Match{Action: "cel", Pattern: d.Policy.String(), Severity: d.Severity, File: r.path, Line: d.Policy.Line})
+		if d.Ignore {
+			out.Ignored = true
+			return out
+		}
+		out.Change.Severity = d.Severity
+	}
+	if sp := r.matchSeverity(c.Path); sp != nil && !locked() {
+		out.Change.Severity = models.Severity(sp.severity)
+		out.Matches = append(out.Matches, Match{Action: "severity", Pattern: sp.source(), Severity: out.Change.Severity, File: r.path, Line: sp.line})
+	}
+	if reason, ok := r.ImageViolation(out.Change); ok {
+		out.Change.Severity = models.SeverityBreaking
+		out.Change.Notes = append(out.Change.Notes, reason)
+		out.Matches = append(out.Matches, Match{Action: "image", Severity: models.SeverityBreaking, Reason: reason, File: r.path})
+	}
+	return out
+}
+
+func (r *Rules) matchSeverity(path string) *compiledSeverity {
+	for i, sp := range r.severityPatterns {
 		if sp.isRegex {
 			if sp.pattern.MatchString(path) {
-				return models.Severity(sp.severity), true
+				return &r.severityPatterns[i]
 			}
 		} else {
-			if matchGlob(sp.literal, path) {
-				return models.Severity(sp.severity), true
+			if matchPattern(sp.literal, path) {
+				return &r.severityPatterns[i]
 			}
 		}
 	}
-	return "", false
+	return nil
 }
 
-// ShouldIgnore returns true if the path should be ignored
-func (r *Rules) ShouldIgnore(path string) (bool, string) {
-	for _, ip := range r.ignorePatterns {
+func (r *Rules) matchIgnore(path string) *compiledIgnore {
+	for i, ip := range r.ignorePatterns {
 		if ip.isRegex {
 			if ip.pattern.MatchString(path) {
-				return true, ip.reason
+				return &r.ignorePatterns[i]
 			}
 		} else {
-			if matchGlob(ip.literal, path) {
-				return true, ip.reason
+			if matchPattern(ip.literal, path) {
+				return &r.ignorePatterns[i]
 			}
 		}
 	}
-	return false, ""
+	return nil
+}
+
+func (cs *compiledSeverity) source() string {
+	if cs.isRegex {
+		return cs.pattern.String()
+	}
+	return cs.literal
+}
+
+func (ci *compiledIgnore) source() string {
+	if ci.isRegex {
+		return ci.pattern.String()
+	}
+	return ci.literal
 }
 
 // ShouldIgnoreServiceField returns true if the field should be ignored for service
@@ -232,6 +561,22 @@ func (r *Rules) GetCategory(path string) string {
 	return "other"
 }
 
+// OrderedLists returns the list fields compared by position, or nil for the
+// defaults
+func (r *Rules) OrderedLists() []string {
+	return r.config.OrderedLists
+}
+
+// WarnLabelNamespaces returns the label namespaces reported as warning
+func (r *Rules) WarnLabelNamespaces() []string {
+	return r.config.WarnLabelNamespaces
+}
+
+// Conventions returns the conventions checker, or nil if none are configured
+func (r *Rules) Conventions() *conventions.Checker {
+	return r.conventions
+}
+
 // GetCustomCategories returns custom category definitions
 func (r *Rules) GetCustomCategories() map[string][]string {
 	if r.config.Categories == nil {
@@ -245,7 +590,7 @@ func DefaultCategories() map[string][]string {
 	return map[string][]string{
 		"environment": {"*.environment.*"},
 		"ports":       {"*.ports.*", "*.expose.*"},
-		"images":      {"*.image", "*.build.*"},
+		"images":      {"*.image", "*.image.*", "*.build.*"},
 		"volumes":     {"*.volumes.*", "volumes.*"},
 		"networks":    {"*.networks.*", "networks.*"},
 		"deploy":      {"*.deploy.*", "*.replicas", "*.resources.*"},
@@ -253,6 +598,13 @@ func DefaultCategories() map[string][]string {
 	}
 }
 
+// matchPattern matches a change path against a severity or ignore glob. A
+// pattern naming an image, e.g. services.*.image, also covers the
+// image.registry, image.tag and image.digest changes below it.
+func matchPattern(pattern, path string) bool {
+	return matchGlob(pattern, path) || strings.HasSuffix(pattern, ".image") && matchGlob(pattern+".*", path)
+}
+
 // matchGlob does simple glob matching (* wildcards)
 func matchGlob(pattern, str string) bool {
 	// Simple glob matching
@@ -266,3 +618,8 @@ func matchGlob(pattern, str string) bool {
 	}
 	return re.MatchString(str)
 }
+
+// RiskWeights returns the weights of the risk score
+func (r *Rules) RiskWeights() risk.Weights {
+	return r.riskWeights
+}
@@ -0,0 +1,136 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestSeverityPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	os.WriteFile(path, []byte(`severity_policy:
+  - category: images
+    severity: breaking
+  - category: labels
+    max: info
+  - category: environment
+    kind: removed
+    min: warning
+severity_overrides:
+  - pattern: services.api.image
+    severity: info
+`), 0644)
+	r, err := LoadRules(path)
+	if err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+
+	tests := []struct {
+		change models.Change
+		want   models.Severity
+	}{
+		{models.Change{Kind: models.ChangeModified, Path: "services.web.image", Severity: models.SeverityWarning}, models.SeverityBreaking},
+		{models.Change{Kind: models.ChangeModified, Path: "services.web.labels.traefik.enable", Severity: models.SeverityWarning}, models.SeverityInfo},
+		{models.Change{Kind: models.ChangeRemoved, Path: "services.web.environment.DEBUG", Severity: models.SeverityInfo}, models.SeverityWarning},
+		{models.Change{Kind: models.ChangeRemoved, Path: "services.web.environment.DB", Severity: models.SeverityBreaking}, models.SeverityBreaking},
+		{models.Change{Kind: models.ChangeAdded, Path: "services.web.environment.DEBUG", Severity: models.SeverityInfo}, models.SeverityInfo},
+	}
+	for _, tt := range tests {
+		got, _, _ := r.PolicySeverity(tt.change)
+		if got != tt.want {
+			t.Errorf("PolicySeverity(%s %s) = %s, want %s", tt.change.Kind, tt.change.Path, got, tt.want)
+		}
+	}
+
+	// Path overrides win over the policy
+	out := r.Apply(models.Change{Kind: models.ChangeModified, Name: "api", Path: "services.api.image", Severity: models.SeverityWarning}, nil, false)
+	if match := out.Matches[len(out.Matches)-1]; out.Change.Severity != models.SeverityInfo || match.Action != "severity" {
+		t.Errorf("Expected the override to decide services.api.image, got %+v", out)
+	}
+	out = r.Apply(models.Change{Kind: models.ChangeModified, Name: "web", Path: "services.web.image", Severity: models.SeverityWarning}, nil, false)
+	if len(out.Matches) != 1 || out.Matches[0].Action != "policy" || out.Matches[0].Line != 2 || out.Matches[0].Pattern != "{category: images, severity: breaking}" {
+		t.Errorf("Expected the policy to decide services.web.image, got %+v", out)
+	}
+	// A freeze keeps a breaking change breaking
+	out = r.Apply(models.Change{Kind: models.ChangeModified, Name: "api", Path: "services.api.image", Severity: models.SeverityBreaking}, nil, true)
+	if out.Change.Severity != models.SeverityBreaking || len(out.Matches) != 0 {
+		t.Errorf("Expected the freeze to keep services.api.image breaking, got %+v", out)
+	}
+}
+
+func TestApplyPolicies(t *testing.T) {
+	r, err := compileRules(&RulesConfig{
+		IgnorePatterns: []IgnoreRule{{Pattern: "services.*.labels.*"}},
+		Policies: []Policy{
+			{Name: "debug", Expr: `change.path.endsWith(".DEBUG") ? "ignore" : ""`},
+			{Name: "env", Expr: `change.field == "environment" ? "info" : ""`},
+		},
+		SeverityOverrides: []SeverityRule{{Pattern: "services.api.environment.TOKEN", Severity: "breaking"}},
+	})
+	if err != nil {
+		t.Fatalf("compileRules failed: %v", err)
+	}
+	e := r.Policies(nil, nil)
+
+	tests := []struct {
+		path    string
+		ignored bool
+		want    models.Severity
+		actions string
+	}{
+		{"services.api.labels.team", true, models.SeverityWarning, "ignore"},
+		{"services.api.environment.DEBUG", true, models.SeverityWarning, "cel"},
+		{"services.api.environment.PORT", false, models.SeverityInfo, "cel"},
+		{"services.api.environment.TOKEN", false, models.SeverityBreaking, "cel severity"},
+	}
+	for _, tt := range tests {
+		out := r.Apply(models.Change{Kind: models.ChangeModified, Scope: models.ScopeService, Name: "api", Path: tt.path, Severity: models.SeverityWarning}, e, false)
+		var actions []string
+		for _, m := range out.Matches {
+			actions = append(actions, m.Action)
+		}
+		if out.Ignored != tt.ignored || out.Change.Severity != tt.want || strings.Join(actions, " ") != tt.actions {
+			t.Errorf("Apply(%s) = %+v, want ignored %v, %s by %s", tt.path, out, tt.ignored, tt.want, tt.actions)
+		}
+	}
+}
+
+func TestSeverityPolicyInvalid(t *testing.T) {
+	for _, rule := range []PolicyRule{
+		{Category: "image", Severity: "breaking"},
+		{Kind: "changed", Severity: "breaking"},
+		{Category: "ports"},
+		{Category: "ports", Max: "critical"},
+	} {
+		if _, err := compileRules(&RulesConfig{SeverityPolicy: []PolicyRule{rule}}); err == nil || !strings.Contains(err.Error(), "severity_policy") {
+			t.Errorf("Expected %s to be rejected, got %v", rule, err)
+		}
+	}
+}
+
+func TestImagePatternsCoverImageParts(t *testing.T) {
+	r, err := compileRules(&RulesConfig{
+		IgnorePatterns:    []IgnoreRule{{Pattern: "services.dev.image"}},
+		SeverityOverrides: []SeverityRule{{Pattern: "services.*.image", Severity: "info"}, {Pattern: `services\.db\.image`, IsRegex: true, Severity: "breaking"}},
+	})
+	if err != nil {
+		t.Fatalf("compileRules failed: %v", err)
+	}
+
+	for _, path := range []string{"services.dev.image", "services.dev.image.tag", "services.dev.image.registry"} {
+		if ignore, _ := r.ShouldIgnore(path); !ignore {
+			t.Errorf("Expected %s to be ignored", path)
+		}
+	}
+	for _, path := range []string{"services.api.image", "services.api.image.tag", "services.db.image.digest"} {
+		if severity, ok := r.GetSeverityOverride(path); !ok || severity != models.SeverityInfo {
+			t.Errorf("GetSeverityOverride(%s) = %s, %v, want info", path, severity, ok)
+		}
+	}
+	if _, ok := r.GetSeverityOverride("services.api.imagepull"); ok {
+		t.Error("Expected services.*.image not to match services.api.imagepull")
+	}
+}
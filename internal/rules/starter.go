@@ -0,0 +1,137 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultRulesFile is the file name written by `rules init`
+const DefaultRulesFile = ".compose-diff.yaml"
+
+// StarterConfig renders a commented starter rules file for the given services
+func StarterConfig(services []string) string {
+	names := make([]string, len(services))
+	copy(names, services)
+	sort.Strings(names)
+
+	var sb strings.Builder
+
+	sb.WriteString(`# compose-diff rules file
+# Docs: https://github.com/stackgen-cli/compose-diff#rules-file
+version: "1"
+
+# Severity overrides are checked in order; the first matching pattern wins.
+# Patterns are globs (* matches anything) unless regex: true is set.
+severity_overrides:
+  # Debug and log level toggles are rarely risky
+  - pattern: "services.*.environment.DEBUG"
+    severity: info
+  - pattern: "services.*.environment.LOG_LEVEL"
+    severity: info
+  # Treat every image change as something to look at
+  # - pattern: "services.*.image*"
+  #   severity: warning
+  # Removing a published port is always breaking
+  # - pattern: "services.*.ports.*"
+  #   severity: breaking
+
+# Severity by category (environment, ports, images, volumes, networks, deploy,
+# dependencies, labels, logging, other) and kind (added, removed, modified,
+# ...), applied in order before severity_overrides: set it with severity,
+# or bound it with min and max
+# severity_policy:
+#   - category: images
+#     severity: breaking
+#   - category: labels
+#     max: info
+#   - category: environment
+#     kind: removed
+#     min: warning
+
+# Image changes to other registries, or to these tags, are breaking
+# allowed_registries: ["ghcr.io/acme", "docker.io/library"]
+# forbid_tags: ["latest"]
+
+# CEL expressions over the change and the service before (old) and after
+# (new), returning breaking, warning, info, ignore, or "" for no opinion
+# policies:
+#   - name: registry moved but tag kept
+#     expr: >
+#       change.field == "image" &&
+#       registry(old.image) != registry(new.image) && tag(old.image) == tag(new.image)
+#       ? "breaking" : ""
+
+# Paths that are never reported
+ignore_patterns:
+  # Build metadata that changes on every run
+  # - pattern: "services.*.labels.build.*"
+  #   reason: "generated by CI"
+  # - pattern: ".*_TEST_.*"
+  #   regex: true
+  #   reason: "test-only variables"
+
+# Label namespaces whose changes are warning instead of info
+# warn_label_namespaces:
+#   - "traefik.*"
+
+# Periods during which breaking changes fail the diff, even if downgraded above
+# freeze_windows:
+#   - name: year-end
+#     start: "2026-12-20"
+#     end: "2027-01-03"
+#     reason: "holiday change freeze"
+#   - name: weekend
+#     cron: "0 18 * * FRI"   # opens Friday 18:00 ...
+#     duration: 62h          # ... and closes Monday 08:00
+
+# Naming and port conventions for added or changed container names, labels
+# and published ports; violations are reported as warnings
+# conventions:
+#   container_name_prefixes: ["acme-"]
+#   label_namespaces: ["com.acme", "traefik"]
+#   teams:
+#     - name: payments
+#       services: ["pay-*"]
+#       ports: ["8100-8199"]
+
+# Invariants of the new file: CEL expressions over each service that must be
+# true; violations a change introduces are reported as warnings
+# require:
+#   - name: must define a healthcheck
+#     services: ["db"]
+#     expr: has(service.healthcheck)
+#     severity: breaking
+
+# Weights of the risk score (severity weight x category weight per change),
+# used by --fail-on-score
+# risk:
+#   severity: {breaking: 10, warning: 3, info: 1}
+#   categories: {images: 2, ports: 1.5, labels: 0.5}
+`)
+
+	sb.WriteString("\n# Per-service ignores (fields: image, environment, ports, ...; paths: globs on the field name)\n")
+	if len(names) == 0 {
+		sb.WriteString("service_ignores: {}\n")
+		return sb.String()
+	}
+
+	sb.WriteString("service_ignores:\n")
+	for _, name := range names {
+		sb.WriteString(fmt.Sprintf("  %s:\n", yamlKey(name)))
+		sb.WriteString("    fields: []\n")
+		sb.WriteString("    paths: []\n")
+	}
+
+	return sb.String()
+}
+
+// yamlKey quotes a map key if it is not a plain YAML scalar
+func yamlKey(s string) string {
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') && r != '-' && r != '_' {
+			return fmt.Sprintf("%q", s)
+		}
+	}
+	return s
+}
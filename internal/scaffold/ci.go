@@ -0,0 +1,142 @@
+package scaffold
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CI providers supported by `init ci`
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+)
+
+// File is a generated file relative to the project root
+type File struct {
+	Path    string
+	Content string
+}
+
+// CIFiles returns the workflow files for a provider, comparing composeFile
+// on the target branch against the working tree
+func CIFiles(provider, composeFile, rulesFile string) ([]File, error) {
+	switch provider {
+	case ProviderGitHub:
+		return []File{{
+			Path:    ".github/workflows/compose-diff.yml",
+			Content: render(githubWorkflow, composeFile, rulesFile),
+		}}, nil
+	case ProviderGitLab:
+		return []File{{
+			Path:    ".gitlab/compose-diff.yml",
+			Content: render(gitlabJob, composeFile, rulesFile),
+		}}, nil
+	}
+	return nil, fmt.Errorf("unknown CI provider: %s", provider)
+}
+
+func render(tmpl, composeFile, rulesFile string) string {
+	r := strings.NewReplacer(
+		"{{COMPOSE_FILE}}", composeFile,
+		"{{RULES_FILE}}", rulesFile,
+	)
+	return r.Replace(tmpl)
+}
+
+// githubWorkflow posts the markdown report as a sticky PR comment and fails the
+// job only after the comment is posted, so breaking changes stay visible.
+const githubWorkflow = `# Generated by compose-diff init ci --github
+name: compose-diff
+
+on:
+  pull_request:
+    paths:
+      - "{{COMPOSE_FILE}}"
+      - "{{RULES_FILE}}"
+
+permissions:
+  contents: read
+  pull-requests: write
+
+jobs:
+  compose-diff:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+        with:
+          fetch-depth: 0
+
+      - uses: actions/setup-go@v5
+        with:
+          go-version: stable
+
+      - name: Install compose-diff
+        run: go install github.com/stackgen-cli/compose-diff@latest
+
+      - name: Diff against base branch
+        id: diff
+        run: |
+          git show "origin/${{ github.base_ref }}:{{COMPOSE_FILE}}" > "$RUNNER_TEMP/base-compose.yml" || echo "services: {}" > "$RUNNER_TEMP/base-compose.yml"
+          # Exit codes: 0 = ok, 1 = breaking changes (--strict), 2 = error.
+          # With --exit-code instead: 0 = no changes, 1 = changes, 2 = breaking,
+          # 3 = error; adjust the status checks below if you switch.
+          set +e
+          compose-diff diff --strict --format markdown --max-comment-bytes 65000 --rules "{{RULES_FILE}}" \
+            "$RUNNER_TEMP/base-compose.yml" "{{COMPOSE_FILE}}" > compose-diff.md
+          status=$?
+          set -e
+          echo "status=$status" >> "$GITHUB_OUTPUT"
+          if [ "$status" -ge 2 ]; then
+            cat compose-diff.md
+            exit "$status"
+          fi
+
+      - name: Comment on pull request
+        env:
+          GITHUB_TOKEN: ${{ github.token }}
+        run: compose-diff comment compose-diff.md
+
+      - name: Fail on breaking changes
+        if: steps.diff.outputs.status == '1'
+        run: |
+          echo "::error::compose-diff found breaking changes in {{COMPOSE_FILE}}"
+          exit 1
+`
+
+// gitlabJob is included from .gitlab-ci.yml, posts the report as a sticky MR
+// note and publishes a Code Quality report for the MR widget. It needs a
+// project access token with api scope in COMPOSE_DIFF_TOKEN.
+const gitlabJob = `# Generated by compose-diff init ci --gitlab
+# Add to .gitlab-ci.yml:
+#   include:
+#     - local: .gitlab/compose-diff.yml
+# and define a masked CI/CD variable COMPOSE_DIFF_TOKEN (project access token, api scope).
+
+compose-diff:
+  stage: test
+  image: golang:latest
+  rules:
+    - if: $CI_PIPELINE_SOURCE == "merge_request_event"
+      changes:
+        - "{{COMPOSE_FILE}}"
+        - "{{RULES_FILE}}"
+  script:
+    - go install github.com/stackgen-cli/compose-diff@latest
+    - git fetch origin "$CI_MERGE_REQUEST_TARGET_BRANCH_NAME"
+    - git show "origin/$CI_MERGE_REQUEST_TARGET_BRANCH_NAME:{{COMPOSE_FILE}}" > base-compose.yml || echo "services: {}" > base-compose.yml
+    # Exit codes: 0 = ok, 1 = breaking changes (--strict), 2 = error.
+    # With --exit-code instead: 0 = no changes, 1 = changes, 2 = breaking,
+    # 3 = error; adjust the status checks below if you switch.
+    - set +e
+    - compose-diff diff --strict --rules "{{RULES_FILE}}" -o compose-diff.md -o gitlab-codequality=gl-code-quality-report.json base-compose.yml "{{COMPOSE_FILE}}"; status=$?
+    - set -e
+    - if [ "$status" -ge 2 ]; then exit "$status"; fi
+    - GITLAB_TOKEN="$COMPOSE_DIFF_TOKEN" compose-diff comment --gitlab compose-diff.md
+    - exit "$status"
+  artifacts:
+    when: always
+    paths:
+      - compose-diff.md
+    reports:
+      codequality: gl-code-quality-report.json
+`
@@ -0,0 +1,88 @@
+// Package spill moves large change payloads (whole added or removed services,
+// big extension blocks) out of memory into a temporary directory. Spilled
+// values are read back only when a report is rendered.
+package spill
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/units"
+)
+
+// DefaultThreshold is the encoded size above which a payload is spilled
+const DefaultThreshold = 64 << 10
+
+// Spiller writes payloads above a size threshold to files in a temporary
+// directory. Close removes the directory.
+type Spiller struct {
+	dir       string
+	threshold int
+	count     int
+}
+
+// New creates a spiller with its own temporary directory
+func New(threshold int) (*Spiller, error) {
+	dir, err := os.MkdirTemp("", "compose-diff-spill-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spill directory: %w", err)
+	}
+	return &Spiller{dir: dir, threshold: threshold}, nil
+}
+
+// Change returns c with large Before/After values replaced by spilled Values
+func (s *Spiller) Change(c models.Change) (models.Change, error) {
+	var err error
+	if c.Before, err = s.value(c.Before); err != nil {
+		return c, err
+	}
+	c.After, err = s.value(c.After)
+	return c, err
+}
+
+func (s *Spiller) value(v any) (any, error) {
+	switch v.(type) {
+	case nil, string, bool, int, int64, float64:
+		return v, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil || len(data) <= s.threshold {
+		return v, nil
+	}
+
+	s.count++
+	path := filepath.Join(s.dir, fmt.Sprintf("%06d.json", s.count))
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to spill change payload: %w", err)
+	}
+	return Value{Path: path, Size: int64(len(data))}, nil
+}
+
+// Close deletes the spilled payloads
+func (s *Spiller) Close() error {
+	return os.RemoveAll(s.dir)
+}
+
+// Value is a payload stored on disk. It marshals to the original JSON and
+// prints as a size placeholder in text reports.
+type Value struct {
+	Path string
+	Size int64
+}
+
+// MarshalJSON reads the payload back from disk
+func (v Value) MarshalJSON() ([]byte, error) {
+	data, err := os.ReadFile(v.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spilled payload: %w", err)
+	}
+	return data, nil
+}
+
+func (v Value) String() string {
+	return fmt.Sprintf("<%s payload spilled to disk>", units.FormatBytes(v.Size))
+}
@@ -0,0 +1,51 @@
+package spill
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestSpillerChange(t *testing.T) {
+	sp, err := New(64)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	big := map[string]string{"command": strings.Repeat("x", 100)}
+	c, err := sp.Change(models.Change{Path: "services.api", Before: "small", After: big})
+	if err != nil {
+		t.Fatalf("Change failed: %v", err)
+	}
+
+	if c.Before != "small" {
+		t.Errorf("Expected small payload to stay in memory, got %v", c.Before)
+	}
+	v, ok := c.After.(Value)
+	if !ok {
+		t.Fatalf("Expected large payload to be spilled, got %T", c.After)
+	}
+	if !strings.Contains(v.String(), "spilled") {
+		t.Errorf("Unexpected placeholder %q", v.String())
+	}
+
+	// JSON output carries the original payload
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	want, _ := json.Marshal(big)
+	if !strings.Contains(string(data), `"after":`+string(want)) {
+		t.Errorf("Expected spilled payload in JSON, got %s", data)
+	}
+
+	if err := sp.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := os.Stat(v.Path); !os.IsNotExist(err) {
+		t.Errorf("Expected spill files to be removed, got %v", err)
+	}
+}
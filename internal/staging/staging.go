@@ -0,0 +1,324 @@
+// Package staging splits a risky change into a sequence of intermediate
+// compose files that can be applied one after another, expand and contract
+// style: first everything the change adds and the services it changes
+// without breaking anything, then the services with breaking changes in
+// depends_on order, dependencies first, and last the removals. Each step
+// leaves every service with what it needs, e.g. a new variable reaches api
+// before the old queue service goes away.
+package staging
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Step is one intermediate compose file
+type Step struct {
+	Name    string   // expand, update or contract
+	Actions []string // what the step does, e.g. "add service queue2"
+	Data    []byte   // the whole compose file after the step
+}
+
+// Plan works out the steps from old to new given their diff and the new
+// file's IR, for depends_on. The last step's file is new itself; the others
+// are old with parts of new swapped in, with YAML aliases expanded since an
+// anchor may not survive the swap. A change that needs no staging yields a
+// single step.
+func Plan(oldData, newData []byte, report *models.DiffReport, newIR *models.ComposeIR) ([]Step, error) {
+	oldRoot, err := parseRoot(oldData)
+	if err != nil {
+		return nil, fmt.Errorf("old file: %w", err)
+	}
+	newRoot, err := parseRoot(newData)
+	if err != nil {
+		return nil, fmt.Errorf("new file: %w", err)
+	}
+
+	added, removed, safe, breaking := classify(report.Changes)
+
+	var steps []Step
+	current := oldRoot
+
+	// Expand: new services, declarations and top-level keys, and services
+	// whose changes break nothing
+	expand := Step{Name: "expand"}
+	next := copyNode(current)
+	for i := 0; i < len(newRoot.Content); i += 2 {
+		key, value := newRoot.Content[i].Value, newRoot.Content[i+1]
+		switch {
+		case key == "services":
+			continue
+		case value.Kind == yaml.MappingNode && isDeclarationSection(key):
+			// Keep old declarations until the contract step
+			section := mapGet(next, key)
+			if section == nil {
+				section = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				mapSet(next, key, section)
+			}
+			for j := 0; j < len(value.Content); j += 2 {
+				name := value.Content[j].Value
+				old := mapGet(section, name)
+				if old == nil || !equalNodes(old, value.Content[j+1]) {
+					mapSet(section, name, copyNode(value.Content[j+1]))
+					expand.Actions = append(expand.Actions, fmt.Sprintf("%s %s %s", verb(old), singular(key), name))
+				}
+			}
+		default:
+			if old := mapGet(next, key); old == nil || !equalNodes(old, value) {
+				mapSet(next, key, copyNode(value))
+				expand.Actions = append(expand.Actions, fmt.Sprintf("%s %s", verb(old), key))
+			}
+		}
+	}
+	newServices := mapGet(newRoot, "services")
+	for _, name := range append(added, safe...) {
+		svc := mapGet(newServices, name)
+		if svc == nil {
+			continue
+		}
+		services := mapGet(next, "services")
+		if services == nil {
+			services = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			mapSet(next, "services", services)
+		}
+		action := "update service " + name
+		if mapGet(services, name) == nil {
+			action = "add service " + name
+		}
+		mapSet(services, name, copyNode(svc))
+		expand.Actions = append(expand.Actions, action)
+	}
+	if len(expand.Actions) > 0 {
+		if expand, err = withData(expand, next); err != nil {
+			return nil, err
+		}
+		steps = append(steps, expand)
+		current = next
+	}
+
+	// Update: breaking service changes, one depends_on level at a time
+	for _, level := range levels(breaking, newIR) {
+		update := Step{Name: "update"}
+		next = copyNode(current)
+		services := mapGet(next, "services")
+		for _, name := range level {
+			mapSet(services, name, copyNode(mapGet(newServices, name)))
+			update.Actions = append(update.Actions, "update service "+name)
+		}
+		if update, err = withData(update, next); err != nil {
+			return nil, err
+		}
+		steps = append(steps, update)
+		current = next
+	}
+
+	// Contract: whatever is left, which is the new file as written
+	contract := Step{Name: "contract", Data: newData}
+	for _, name := range removed {
+		contract.Actions = append(contract.Actions, "remove "+name)
+	}
+	if len(contract.Actions) > 0 || len(steps) == 0 {
+		return append(steps, contract), nil
+	}
+	steps[len(steps)-1].Data = newData
+	return steps, nil
+}
+
+// classify sorts the changed services into added, safe (changed without
+// breaking changes) and breaking, and lists removed entries as "service x",
+// "volume x" or "network x". A renamed service is added under its new name
+// and removed under its old one.
+func classify(changes []models.Change) (added, removed, safe, breaking []string) {
+	addedSet := make(map[string]bool)
+	removedSet := make(map[string]bool)
+	changed := make(map[string]bool)
+	breaks := make(map[string]bool)
+	for _, c := range changes {
+		whole := c.Path == fmt.Sprintf("%ss.%s", c.Scope, c.Name)
+		switch {
+		case c.Kind == models.ChangeDangling || c.Kind == models.ChangeCycle || c.Kind == models.ChangeOrphaned:
+			continue
+		case whole && c.Kind == models.ChangeAdded && c.Scope == models.ScopeService:
+			addedSet[c.Name] = true
+		case whole && c.Kind == models.ChangeRenamed && c.Scope == models.ScopeService:
+			addedSet[c.Name] = true
+			removedSet[fmt.Sprintf("service %v", c.Before)] = true
+		case whole && c.Kind == models.ChangeRemoved && c.Scope != models.ScopeExtension:
+			removedSet[fmt.Sprintf("%s %s", c.Scope, c.Name)] = true
+		case c.Scope == models.ScopeService:
+			changed[c.Name] = true
+			if c.Severity == models.SeverityBreaking {
+				breaks[c.Name] = true
+			}
+		}
+	}
+	for name := range changed {
+		if addedSet[name] {
+			continue
+		}
+		if breaks[name] {
+			breaking = append(breaking, name)
+		} else {
+			safe = append(safe, name)
+		}
+	}
+	for name := range addedSet {
+		added = append(added, name)
+	}
+	for name := range removedSet {
+		removed = append(removed, name)
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(safe)
+	sort.Strings(breaking)
+	return added, removed, safe, breaking
+}
+
+// levels groups services so that each group's dependencies among services
+// come in earlier groups
+func levels(services []string, ir *models.ComposeIR) [][]string {
+	in := make(map[string]bool, len(services))
+	for _, name := range services {
+		in[name] = true
+	}
+	depth := make(map[string]int)
+	visiting := make(map[string]bool)
+	var walk func(name string) int
+	walk = func(name string) int {
+		if d, ok := depth[name]; ok {
+			return d
+		}
+		if visiting[name] {
+			return 0
+		}
+		visiting[name] = true
+		d := 0
+		for _, dep := range ir.Services[name].DependsOn {
+			below := walk(dep)
+			if in[dep] {
+				below++
+			}
+			d = max(d, below)
+		}
+		visiting[name] = false
+		depth[name] = d
+		return d
+	}
+
+	var result [][]string
+	for _, name := range services {
+		d := walk(name)
+		for len(result) <= d {
+			result = append(result, nil)
+		}
+		result[d] = append(result[d], name)
+	}
+	compact := result[:0]
+	for _, level := range result {
+		if len(level) > 0 {
+			compact = append(compact, level)
+		}
+	}
+	return compact
+}
+
+func isDeclarationSection(key string) bool {
+	return key == "volumes" || key == "networks" || key == "configs" || key == "secrets"
+}
+
+func singular(section string) string {
+	return strings.TrimSuffix(section, "s")
+}
+
+func verb(old *yaml.Node) string {
+	if old == nil {
+		return "add"
+	}
+	return "update"
+}
+
+// withData sets a step's file to root
+func withData(step Step, root *yaml.Node) (Step, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}); err != nil {
+		return step, fmt.Errorf("%s step: %w", step.Name, err)
+	}
+	if err := enc.Close(); err != nil {
+		return step, fmt.Errorf("%s step: %w", step.Name, err)
+	}
+	step.Data = buf.Bytes()
+	return step, nil
+}
+
+// parseRoot returns the top-level mapping of a compose file with aliases
+// expanded
+func parseRoot(data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("not a compose file: expected a mapping")
+	}
+	return copyNode(doc.Content[0]), nil
+}
+
+// copyNode deep-copies a node, replacing aliases with copies of what they
+// point to and dropping anchors
+func copyNode(n *yaml.Node) *yaml.Node {
+	if n.Kind == yaml.AliasNode && n.Alias != nil {
+		return copyNode(n.Alias)
+	}
+	c := *n
+	c.Anchor = ""
+	if c.Tag == "!!merge" {
+		c.Tag = "" // so the key is written as a plain <<
+	}
+	c.Content = make([]*yaml.Node, len(n.Content))
+	for i, child := range n.Content {
+		c.Content[i] = copyNode(child)
+	}
+	return &c
+}
+
+// equalNodes compares two nodes by value, ignoring style and comments
+func equalNodes(a, b *yaml.Node) bool {
+	var va, vb any
+	if a.Decode(&va) != nil || b.Decode(&vb) != nil {
+		return false
+	}
+	da, _ := yaml.Marshal(va)
+	db, _ := yaml.Marshal(vb)
+	return bytes.Equal(da, db)
+}
+
+func mapGet(m *yaml.Node, key string) *yaml.Node {
+	if m == nil {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// mapSet replaces the value of key, or appends it
+func mapSet(m *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = value
+			return
+		}
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
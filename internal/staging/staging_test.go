@@ -0,0 +1,118 @@
+package staging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/diff"
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/parser"
+	"gopkg.in/yaml.v3"
+)
+
+func parse(t *testing.T, data []byte) *models.ComposeIR {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "docker-compose.yml")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	ir, err := parser.ParseComposeFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ir
+}
+
+const oldFile = `x-common: &common
+  restart: always
+services:
+  db:
+    <<: *common
+    image: pg:15
+  api:
+    <<: *common
+    image: api:1
+    depends_on: [db]
+    environment:
+      QUEUE_URL: amqp://queue
+  web:
+    image: web:1
+    depends_on: [api]
+  queue:
+    image: rabbitmq
+`
+
+const newFile = `x-common: &common
+  restart: always
+services:
+  db:
+    <<: *common
+    image: pg:15
+    ports: []
+  api:
+    <<: *common
+    image: api:1
+    depends_on: [db]
+    environment:
+      REDIS_URL: redis://cache
+  web:
+    image: web:1
+    depends_on: [api]
+    environment:
+      LEGACY: ""
+  cache:
+    image: redis
+volumes:
+  cachedata:
+`
+
+func TestPlan(t *testing.T) {
+	oldIR := parse(t, []byte(oldFile))
+	newIR := parse(t, []byte(newFile))
+	report := diff.Compare(oldIR, newIR)
+
+	steps, err := Plan([]byte(oldFile), []byte(newFile), report, newIR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range steps {
+		got = append(got, s.Name+": "+strings.Join(s.Actions, ", "))
+	}
+	want := []string{
+		"expand: add volume cachedata, add service cache, update service web",
+		"update: update service api",
+		"contract: remove service queue",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("Plan steps:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	expand := string(steps[0].Data)
+	if !strings.Contains(expand, "QUEUE_URL") || !strings.Contains(expand, "cache:") || strings.Contains(expand, "*common") {
+		t.Errorf("Expected the expand step to keep api's old variables, add cache and expand aliases:\n%s", expand)
+	}
+	if update := parse(t, steps[1].Data); *update.Services["api"].Env["REDIS_URL"] != "redis://cache" {
+		t.Errorf("Expected the update step to carry api's new variables")
+	}
+	if string(steps[2].Data) != newFile {
+		t.Errorf("Expected the last step to be the new file")
+	}
+}
+
+func TestPlanSingleStep(t *testing.T) {
+	ir := parse(t, []byte(oldFile))
+	steps, err := Plan([]byte(oldFile), []byte(oldFile), diff.Compare(ir, ir), ir)
+	if err != nil || len(steps) != 1 {
+		t.Errorf("Expected a single step for an unchanged file, got %d (%v)", len(steps), err)
+	}
+}
+
+func TestWithDataEncodeError(t *testing.T) {
+	root := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{{Kind: yaml.ScalarNode, Value: "services"}, {Kind: yaml.AliasNode}}}
+	if _, err := withData(Step{Name: "update"}, root); err == nil || !strings.Contains(err.Error(), "update step") {
+		t.Errorf("Expected an encode error for the update step, got %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FormatReport lists each group with its members' differences from the template
+func FormatReport(groups []Group) string {
+	var sb strings.Builder
+	for i, g := range groups {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(fmt.Sprintf("%s: %d services share %d fields\n", g.Name, len(g.Services), g.Fields))
+
+		width := 0
+		for _, name := range g.Services {
+			width = max(width, len(name))
+		}
+		for _, name := range g.Services {
+			var parts []string
+			for _, d := range g.Deviations[name] {
+				if d.Missing {
+					parts = append(parts, d.Path+" unset")
+				} else {
+					parts = append(parts, fmt.Sprintf("%s = %v", d.Path, d.Value))
+				}
+			}
+			if len(parts) == 0 {
+				parts = []string{"(matches the template)"}
+			}
+			sb.WriteString(fmt.Sprintf("  %-*s  %s\n", width, name, strings.Join(parts, ", ")))
+		}
+	}
+	return sb.String()
+}
+
+// Suggest renders the group as compose YAML: the template under an x-
+// extension with an anchor, and each service merging it (<<:) and
+// overriding only what differs. Mappings that members override a few keys of
+// get anchors of their own, since merge keys only merge one level deep.
+// Fields a member lacks are dropped with Compose's !reset tag.
+func Suggest(g Group) (string, error) {
+	var template yaml.Node
+	if err := template.Encode(g.Template); err != nil {
+		return "", err
+	}
+	template.Anchor = g.Name
+
+	services := &yaml.Node{Kind: yaml.MappingNode}
+	for _, name := range g.Services {
+		svc := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{mergeKey(), alias(&template)}}
+
+		for _, key := range overriddenKeys(g.Deviations[name]) {
+			value, err := override(g, name, key, &template)
+			if err != nil {
+				return "", err
+			}
+			svc.Content = append(svc.Content, scalar(key), value)
+		}
+		services.Content = append(services.Content, scalar(name), svc)
+	}
+
+	doc := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+		scalar("x-" + g.Name), &template,
+		scalar("services"), services,
+	}}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return "", err
+	}
+	return buf.String(), enc.Close()
+}
+
+// overriddenKeys returns the top-level keys a member differs in
+func overriddenKeys(devs []Deviation) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, d := range devs {
+		if !seen[d.path[0]] {
+			seen[d.path[0]] = true
+			keys = append(keys, d.path[0])
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// override returns the value a member sets for key on top of the template
+func override(g Group, name, key string, template *yaml.Node) (*yaml.Node, error) {
+	value, ok := g.raw[name][key]
+	if !ok {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!reset", Value: "null"}, nil
+	}
+
+	var node yaml.Node
+	tmplValue := lookup(template, key)
+	member, isMap := value.(map[string]any)
+	if tmplValue == nil || tmplValue.Kind != yaml.MappingNode || !isMap || removesKeys(g.Deviations[name], key) {
+		err := node.Encode(value)
+		return &node, err
+	}
+
+	// Merge the template's mapping and override the keys that differ
+	partial := make(map[string]any)
+	for _, d := range g.Deviations[name] {
+		if d.path[0] == key && len(d.path) > 1 {
+			partial[d.path[1]] = member[d.path[1]]
+		}
+	}
+	if len(partial)*2 >= len(tmplValue.Content) {
+		// Every key is overridden, merging would add nothing
+		err := node.Encode(value)
+		return &node, err
+	}
+	if err := node.Encode(partial); err != nil {
+		return nil, err
+	}
+	if tmplValue.Anchor == "" {
+		tmplValue.Anchor = g.Name + "-" + key
+	}
+	node.Content = append([]*yaml.Node{mergeKey(), alias(tmplValue)}, node.Content...)
+	return &node, nil
+}
+
+// removesKeys reports whether a member lacks part of the template's mapping
+// under key, which merging cannot express
+func removesKeys(devs []Deviation, key string) bool {
+	for _, d := range devs {
+		if d.path[0] == key && d.Missing {
+			return true
+		}
+	}
+	return false
+}
+
+func lookup(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+}
+
+func mergeKey() *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: "<<"}
+}
+
+func alias(target *yaml.Node) *yaml.Node {
+	return &yaml.Node{Kind: yaml.AliasNode, Value: target.Anchor, Alias: target}
+}
@@ -0,0 +1,225 @@
+// Package templates finds groups of near-identical services in a compose
+// file (a dozen workers that differ only by queue name), infers the template
+// they share and suggests YAML anchors that express them with less
+// duplication.
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Options controls how services are grouped
+type Options struct {
+	MinSimilarity float64 // fraction of fields two services must share to be grouped
+	MinServices   int     // smallest group worth reporting
+}
+
+// DefaultOptions groups services sharing 60% of their fields, three or more at a time
+func DefaultOptions() Options {
+	return Options{MinSimilarity: 0.6, MinServices: 3}
+}
+
+// Group is a set of services that differ from a common template in a few fields
+type Group struct {
+	Name       string                 // suggested anchor name
+	Services   []string               // sorted
+	Template   map[string]any         // fields shared by most members, as compose YAML
+	Fields     int                    // number of leaf fields in the template
+	Deviations map[string][]Deviation // per service, sorted by path
+	raw        map[string]map[string]any
+}
+
+// Deviation is a field where a service differs from its template
+type Deviation struct {
+	Path    string // dotted path, e.g. environment.QUEUE
+	Value   any    // the service's value; nil if Missing
+	Missing bool   // the template has the field but the service does not
+	path    []string
+}
+
+// leaf is a scalar or list at the end of a path through nested mappings
+type leaf struct {
+	path  []string
+	value any
+	key   string // canonical JSON of value, for comparison
+}
+
+type flatService map[string]leaf // keyed by path joined with "\x00"
+
+// Detect groups similar services. services is the services mapping of a
+// compose file as decoded from YAML.
+func Detect(services map[string]any, opts Options) []Group {
+	names := make([]string, 0, len(services))
+	flat := make(map[string]flatService, len(services))
+	raw := make(map[string]map[string]any, len(services))
+	for name, svc := range services {
+		m, ok := svc.(map[string]any)
+		if !ok {
+			continue
+		}
+		m = normalize(m)
+		names = append(names, name)
+		raw[name] = m
+		flat[name] = flatten(m)
+	}
+	sort.Strings(names)
+
+	// Greedy clustering: each service joins the most similar group seed
+	var clusters [][]string
+	for _, name := range names {
+		best, bestScore := -1, 0.0
+		for i, members := range clusters {
+			if score := similarity(flat[members[0]], flat[name]); score >= opts.MinSimilarity && score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 {
+			clusters = append(clusters, []string{name})
+		} else {
+			clusters[best] = append(clusters[best], name)
+		}
+	}
+
+	var groups []Group
+	for _, members := range clusters {
+		if len(members) < max(opts.MinServices, 2) {
+			continue
+		}
+		groups = append(groups, buildGroup(members, flat, raw))
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return len(groups[i].Services) > len(groups[j].Services) })
+	return groups
+}
+
+// buildGroup infers the template of a cluster by majority vote on each field
+func buildGroup(members []string, flat map[string]flatService, raw map[string]map[string]any) Group {
+	votes := make(map[string]map[string]int)
+	leaves := make(map[string]leaf)
+	for _, name := range members {
+		for k, l := range flat[name] {
+			if votes[k] == nil {
+				votes[k] = make(map[string]int)
+			}
+			votes[k][l.key]++
+			if votes[k][l.key]*2 > len(members) {
+				leaves[k] = l
+			}
+		}
+	}
+
+	template := make(map[string]any)
+	for _, l := range leaves {
+		set(template, l.path, l.value)
+	}
+
+	g := Group{
+		Name:       templateName(members),
+		Services:   members,
+		Template:   template,
+		Fields:     len(leaves),
+		Deviations: make(map[string][]Deviation),
+		raw:        make(map[string]map[string]any),
+	}
+	for _, name := range members {
+		g.raw[name] = raw[name]
+		var devs []Deviation
+		for k, l := range flat[name] {
+			if t, ok := leaves[k]; !ok || t.key != l.key {
+				devs = append(devs, Deviation{Path: strings.Join(l.path, "."), Value: l.value, path: l.path})
+			}
+		}
+		for k, t := range leaves {
+			if _, ok := flat[name][k]; !ok {
+				devs = append(devs, Deviation{Path: strings.Join(t.path, "."), Missing: true, path: t.path})
+			}
+		}
+		sort.Slice(devs, func(i, j int) bool { return devs[i].Path < devs[j].Path })
+		g.Deviations[name] = devs
+	}
+	return g
+}
+
+// normalize turns KEY=value lists under environment and labels into
+// mappings, so single variables can be compared
+func normalize(svc map[string]any) map[string]any {
+	result := make(map[string]any, len(svc))
+	for k, v := range svc {
+		if list, ok := v.([]any); ok && (k == "environment" || k == "labels") {
+			m := make(map[string]any, len(list))
+			for _, item := range list {
+				key, value, _ := strings.Cut(fmt.Sprint(item), "=")
+				m[key] = value
+			}
+			v = m
+		}
+		result[k] = v
+	}
+	return result
+}
+
+func flatten(svc map[string]any) flatService {
+	result := make(flatService)
+	var walk func(path []string, v any)
+	walk = func(path []string, v any) {
+		if m, ok := v.(map[string]any); ok && len(m) > 0 {
+			for k, child := range m {
+				walk(append(path[:len(path):len(path)], k), child)
+			}
+			return
+		}
+		data, _ := json.Marshal(v)
+		result[strings.Join(path, "\x00")] = leaf{path: path, value: v, key: string(data)}
+	}
+	for k, v := range svc {
+		walk([]string{k}, v)
+	}
+	return result
+}
+
+// similarity is the fraction of fields of either service that both have
+// with the same value
+func similarity(a, b flatService) float64 {
+	union, same := len(a), 0
+	for k, l := range b {
+		if al, ok := a[k]; !ok {
+			union++
+		} else if al.key == l.key {
+			same++
+		}
+	}
+	if union == 0 {
+		return 1
+	}
+	return float64(same) / float64(union)
+}
+
+func set(m map[string]any, path []string, v any) {
+	for _, k := range path[:len(path)-1] {
+		child, ok := m[k].(map[string]any)
+		if !ok {
+			child = make(map[string]any)
+			m[k] = child
+		}
+		m = child
+	}
+	m[path[len(path)-1]] = v
+}
+
+// templateName is the members' common name prefix without trailing
+// separators and digits, e.g. "worker" for worker-1 and worker-emails
+func templateName(members []string) string {
+	prefix := members[0]
+	for _, name := range members[1:] {
+		for !strings.HasPrefix(name, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	prefix = strings.TrimRight(prefix, "-_.0123456789")
+	if prefix == "" {
+		return members[0] + "-template"
+	}
+	return prefix
+}
@@ -0,0 +1,89 @@
+package templates
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const workers = `
+services:
+  worker-emails:
+    image: app:1.4
+    command: [worker]
+    environment: [LOG_LEVEL=info, REDIS_URL=redis://cache, QUEUE=emails]
+    deploy: {replicas: 2, resources: {limits: {memory: 256M}}}
+  worker-sms:
+    image: app:1.4
+    command: [worker]
+    environment: {LOG_LEVEL: info, REDIS_URL: redis://cache, QUEUE: sms}
+    deploy: {replicas: 2, resources: {limits: {memory: 256M}}}
+  worker-reports:
+    image: app:1.4
+    command: [worker]
+    environment: {LOG_LEVEL: info, REDIS_URL: redis://cache, QUEUE: reports}
+    deploy: {replicas: 2, resources: {limits: {memory: 1G}}}
+  worker-push:
+    image: app:1.4
+    command: [worker]
+    environment: {LOG_LEVEL: info, QUEUE: push}
+    deploy: {replicas: 2, resources: {limits: {memory: 256M}}}
+  web:
+    image: web:2
+    ports: ["80:80"]
+`
+
+func loadServices(t *testing.T, content string) map[string]any {
+	t.Helper()
+	var doc struct{ Services map[string]any }
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+	return doc.Services
+}
+
+func TestDetect(t *testing.T) {
+	groups := Detect(loadServices(t, workers), DefaultOptions())
+	if len(groups) != 1 {
+		t.Fatalf("Expected 1 group, got %d", len(groups))
+	}
+
+	g := groups[0]
+	if g.Name != "worker" || len(g.Services) != 4 || g.Fields != 6 {
+		t.Errorf("Unexpected group %s with %v sharing %d fields", g.Name, g.Services, g.Fields)
+	}
+
+	report := FormatReport(groups)
+	for _, want := range []string{
+		"worker-emails   environment.QUEUE = emails\n",
+		"worker-push     environment.QUEUE = push, environment.REDIS_URL unset\n",
+		"worker-reports  deploy.resources.limits.memory = 1G, environment.QUEUE = reports\n",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected report to contain %q, got:\n%s", want, report)
+		}
+	}
+}
+
+func TestSuggestIsEquivalent(t *testing.T) {
+	original := loadServices(t, workers)
+	g := Detect(original, DefaultOptions())[0]
+
+	snippet, err := Suggest(g)
+	if err != nil {
+		t.Fatalf("Suggest failed: %v", err)
+	}
+	if !strings.Contains(snippet, "x-worker: &worker\n") || !strings.Contains(snippet, "<<: *worker-environment") {
+		t.Errorf("Expected anchors for the template and its environment, got:\n%s", snippet)
+	}
+
+	rewritten := loadServices(t, snippet)
+	for _, name := range g.Services {
+		want := normalize(original[name].(map[string]any))
+		if got := rewritten[name]; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s changed meaning:\n got %v\nwant %v", name, got, want)
+		}
+	}
+}
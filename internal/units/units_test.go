@@ -0,0 +1,56 @@
+package units
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseBytes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"512m", 536870912},
+		{"512M", 536870912},
+		{"536870912", 536870912},
+		{"1g", 1073741824},
+		{"1gb", 1073741824},
+		{"1GiB", 1073741824},
+		{"1.5g", 1610612736},
+		{"64k", 65536},
+		{"100b", 100},
+		{"8191p", 8191 << 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ParseBytes(tt.input)
+			if err != nil {
+				t.Fatalf("ParseBytes(%q) failed: %v", tt.input, err)
+			}
+			if result != tt.expected {
+				t.Errorf("ParseBytes(%q) = %d, want %d", tt.input, result, tt.expected)
+			}
+		})
+	}
+
+	for _, bad := range []string{"", "abc", "12x", "8192p", "9223372036854775808", "99999999999999999999t", strings.Repeat("9", 400)} {
+		if _, err := ParseBytes(bad); err == nil {
+			t.Errorf("ParseBytes(%q) should fail", bad)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		536870912:  "512MiB",
+		1073741824: "1GiB",
+		1536:       "1536B",
+		100:        "100B",
+	}
+	for input, expected := range tests {
+		if result := FormatBytes(input); result != expected {
+			t.Errorf("FormatBytes(%d) = %q, want %q", input, result, expected)
+		}
+	}
+}
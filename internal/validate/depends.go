@@ -0,0 +1,101 @@
+package validate
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/stackgen-cli/compose-diff/internal/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// HealthDependencyWarnings reads a compose file and returns the warnings of
+// checkHealthDependencies for it
+func HealthDependencyWarnings(path string) ([]Diagnostic, error) {
+	actualPath, err := parser.ResolveComposePath(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(actualPath)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parser.ParseNode(data)
+	if err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	diags := checkHealthDependencies(doc.Content[0])
+	for i := range diags {
+		diags[i].File = actualPath
+	}
+	return diags, nil
+}
+
+// checkHealthDependencies warns about depends_on entries with condition
+// service_healthy whose service has no healthcheck in the file. Unless the
+// image defines a HEALTHCHECK, docker compose then fails when it starts the
+// stack rather than when the file is reviewed.
+func checkHealthDependencies(root *yaml.Node) []Diagnostic {
+	services := mappingValue(root, "services")
+	if services == nil || services.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	healthy := make(map[string]bool)
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		healthy[services.Content[i].Value] = hasHealthcheck(services.Content[i+1])
+	}
+
+	var diags []Diagnostic
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		name := services.Content[i].Value
+		deps := mappingValue(services.Content[i+1], "depends_on")
+		if deps == nil || deps.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 0; j+1 < len(deps.Content); j += 2 {
+			dep := deps.Content[j].Value
+			condition := mappingValue(deps.Content[j+1], "condition")
+			if condition == nil || condition.Value != "service_healthy" {
+				continue
+			}
+			if has, ok := healthy[dep]; ok && !has {
+				d := diagnostic(condition, fmt.Sprintf("services.%s.depends_on.%s.condition", name, dep),
+					"waits for %s to be healthy, but %s has no healthcheck (fine only if its image defines one)", dep, dep)
+				d.Warning = true
+				diags = append(diags, d)
+			}
+		}
+	}
+	return diags
+}
+
+// hasHealthcheck reports whether a service node defines a healthcheck that
+// is not disabled with disable: true or test: [NONE]
+func hasHealthcheck(svc *yaml.Node) bool {
+	hc := mappingValue(svc, "healthcheck")
+	if hc == nil || hc.Kind != yaml.MappingNode {
+		return false
+	}
+	if disable := mappingValue(hc, "disable"); disable != nil && disable.Value == "true" {
+		return false
+	}
+	test := mappingValue(hc, "test")
+	return test == nil || test.Kind != yaml.SequenceNode || len(test.Content) == 0 || test.Content[0].Value != "NONE"
+}
+
+// mappingValue returns the value of key in mapping n, or nil
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,489 @@
+// Package validate checks compose files against the Compose Specification
+// JSON schema, reporting each problem at its line and column in the file.
+//
+// compose-spec.json is the schema shipped with compose-go v1.20.2
+// (Apache License 2.0, https://github.com/compose-spec/compose-go).
+package validate
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/parser"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed compose-spec.json
+var composeSpec []byte
+
+// Diagnostic is a single schema violation
+type Diagnostic = models.Diagnostic
+
+// Schema is a parsed JSON schema. Only the keywords the compose-spec schema
+// uses are checked: $ref to local definitions, type, enum, pattern,
+// minimum/maximum, properties, patternProperties, additionalProperties,
+// required, items, uniqueItems, oneOf and anyOf. oneOf is treated like anyOf.
+type Schema struct {
+	root     map[string]any
+	patterns map[string]*regexp.Regexp
+}
+
+var (
+	defaultOnce   sync.Once
+	defaultSchema *Schema
+)
+
+// Default returns the bundled compose-spec schema
+func Default() *Schema {
+	defaultOnce.Do(func() {
+		s, err := Load(composeSpec)
+		if err != nil {
+			panic("validate: bundled schema: " + err.Error())
+		}
+		defaultSchema = s
+	})
+	return defaultSchema
+}
+
+// LoadFile reads a JSON schema from disk, e.g. a newer compose-spec.json
+func LoadFile(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+	return Load(data)
+}
+
+// Load parses a JSON schema document
+func Load(data []byte) (*Schema, error) {
+	var root map[string]any
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	s := &Schema{root: root, patterns: make(map[string]*regexp.Regexp)}
+	if err := s.compilePatterns(root); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// compilePatterns compiles every regular expression in the schema up front
+func (s *Schema) compilePatterns(node any) error {
+	var exprs []string
+	switch n := node.(type) {
+	case map[string]any:
+		if p, ok := n["pattern"].(string); ok {
+			exprs = append(exprs, p)
+		}
+		if props, ok := n["patternProperties"].(map[string]any); ok {
+			for p := range props {
+				exprs = append(exprs, p)
+			}
+		}
+		for _, child := range n {
+			if err := s.compilePatterns(child); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, child := range n {
+			if err := s.compilePatterns(child); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("invalid schema pattern %q: %w", expr, err)
+		}
+		s.patterns[expr] = re
+	}
+	return nil
+}
+
+// ValidateFile validates a compose file (or a directory containing one)
+func (s *Schema) ValidateFile(path string) ([]Diagnostic, error) {
+	actualPath, err := parser.ResolveComposePath(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(actualPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return s.Validate(actualPath, data)
+}
+
+// Validate checks YAML content against the schema, plus cross-field checks
+// that return warnings; file only labels the diagnostics. An error is
+// returned when the content is not valid YAML.
+func (s *Schema) Validate(file string, data []byte) ([]Diagnostic, error) {
+	doc, err := parser.ParseNode(data)
+	if err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return []Diagnostic{{File: file, Line: 1, Column: 1, Message: "empty compose file"}}, nil
+	}
+
+	diags := s.check(s.root, doc.Content[0], "")
+	diags = append(diags, checkHealthDependencies(doc.Content[0])...)
+	sort.Slice(diags, func(i, j int) bool {
+		if diags[i].Line != diags[j].Line {
+			return diags[i].Line < diags[j].Line
+		}
+		if diags[i].Column != diags[j].Column {
+			return diags[i].Column < diags[j].Column
+		}
+		return diags[i].Message < diags[j].Message
+	})
+	for i := range diags {
+		diags[i].File = file
+	}
+	return diags, nil
+}
+
+func diagnostic(n *yaml.Node, path, format string, args ...any) Diagnostic {
+	return Diagnostic{Line: n.Line, Column: n.Column, Path: path, Message: fmt.Sprintf(format, args...)}
+}
+
+// check validates a node against a schema (a map, or true/false)
+func (s *Schema) check(schema any, n *yaml.Node, path string) []Diagnostic {
+	switch sc := schema.(type) {
+	case bool:
+		if !sc {
+			return []Diagnostic{diagnostic(n, path, "not allowed")}
+		}
+		return nil
+	case map[string]any:
+		return s.checkMap(sc, n, path)
+	}
+	return nil
+}
+
+func (s *Schema) checkMap(sc map[string]any, n *yaml.Node, path string) []Diagnostic {
+	if t, ok := sc["type"]; ok && !anyTypeMatches(schemaTypes(t), n) {
+		return []Diagnostic{diagnostic(n, path, "expected %s, got %s", joinOr(schemaTypes(t)), kindName(n))}
+	}
+
+	var diags []Diagnostic
+	if ref, ok := sc["$ref"].(string); ok {
+		diags = append(diags, s.check(s.resolve(ref), n, path)...)
+	}
+	if enum, ok := sc["enum"].([]any); ok && n.Kind == yaml.ScalarNode && !inEnum(enum, n.Value) {
+		diags = append(diags, diagnostic(n, path, "must be one of %s", formatEnum(enum)))
+	}
+	if p, ok := sc["pattern"].(string); ok && n.Kind == yaml.ScalarNode && !s.patterns[p].MatchString(n.Value) {
+		diags = append(diags, diagnostic(n, path, "%q does not match %s", n.Value, p))
+	}
+	diags = append(diags, checkRange(sc, n, path)...)
+
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		if branches, ok := sc[keyword].([]any); ok {
+			diags = append(diags, s.checkAlternatives(branches, n, path)...)
+		}
+	}
+
+	switch n.Kind {
+	case yaml.MappingNode:
+		diags = append(diags, s.checkObject(sc, n, path)...)
+	case yaml.SequenceNode:
+		diags = append(diags, s.checkArray(sc, n, path)...)
+	}
+	return diags
+}
+
+// resolve looks up a local reference such as #/definitions/service
+func (s *Schema) resolve(ref string) any {
+	var node any = s.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		m, ok := node.(map[string]any)
+		if !ok {
+			return true
+		}
+		node = m[part]
+	}
+	if node == nil {
+		return true
+	}
+	return node
+}
+
+func (s *Schema) checkObject(sc map[string]any, n *yaml.Node, path string) []Diagnostic {
+	var diags []Diagnostic
+	props, _ := sc["properties"].(map[string]any)
+	patternProps, _ := sc["patternProperties"].(map[string]any)
+
+	present := make(map[string]bool)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		present[key.Value] = true
+		childPath := joinPath(path, key.Value)
+
+		matched := false
+		if prop, ok := props[key.Value]; ok {
+			matched = true
+			diags = append(diags, s.check(prop, value, childPath)...)
+		}
+		for p, prop := range patternProps {
+			if s.patterns[p].MatchString(key.Value) {
+				matched = true
+				diags = append(diags, s.check(prop, value, childPath)...)
+			}
+		}
+		if matched {
+			continue
+		}
+
+		switch additional := sc["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				diags = append(diags, diagnostic(key, childPath, "unknown key %q", key.Value))
+			}
+		case map[string]any:
+			diags = append(diags, s.check(additional, value, childPath)...)
+		}
+	}
+
+	if required, ok := sc["required"].([]any); ok {
+		for _, r := range required {
+			if name, ok := r.(string); ok && !present[name] {
+				diags = append(diags, diagnostic(n, path, "missing required key %q", name))
+			}
+		}
+	}
+	return diags
+}
+
+func (s *Schema) checkArray(sc map[string]any, n *yaml.Node, path string) []Diagnostic {
+	var diags []Diagnostic
+	if items, ok := sc["items"]; ok {
+		for i, item := range n.Content {
+			diags = append(diags, s.check(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+
+	if unique, _ := sc["uniqueItems"].(bool); unique {
+		seen := make(map[string]bool)
+		for i, item := range n.Content {
+			if item.Kind != yaml.ScalarNode {
+				continue
+			}
+			if seen[item.Value] {
+				diags = append(diags, diagnostic(item, fmt.Sprintf("%s[%d]", path, i), "duplicate item %q", item.Value))
+			}
+			seen[item.Value] = true
+		}
+	}
+	return diags
+}
+
+// checkAlternatives passes if any branch matches. Otherwise it reports the
+// errors of the branch whose type fits the node, or the expected types when
+// none does, rather than every branch's complaints.
+func (s *Schema) checkAlternatives(branches []any, n *yaml.Node, path string) []Diagnostic {
+	var best []Diagnostic
+	var expected []string
+	for _, branch := range branches {
+		diags := s.check(branch, n, path)
+		if len(diags) == 0 {
+			return nil
+		}
+
+		types := s.branchTypes(branch, 0)
+		expected = append(expected, types...)
+		if (len(types) == 0 || anyTypeMatches(types, n)) && (best == nil || len(diags) < len(best)) {
+			best = diags
+		}
+	}
+	if best != nil {
+		return best
+	}
+	return []Diagnostic{diagnostic(n, path, "expected %s, got %s", joinOr(expected), kindName(n))}
+}
+
+// branchTypes returns the types a schema accepts, following references and
+// nested alternatives; nil means any type
+func (s *Schema) branchTypes(schema any, depth int) []string {
+	sc, ok := schema.(map[string]any)
+	if !ok || depth > 10 {
+		return nil
+	}
+	if t, ok := sc["type"]; ok {
+		return schemaTypes(t)
+	}
+	if ref, ok := sc["$ref"].(string); ok {
+		return s.branchTypes(s.resolve(ref), depth+1)
+	}
+
+	var types []string
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		branches, _ := sc[keyword].([]any)
+		for _, branch := range branches {
+			bt := s.branchTypes(branch, depth+1)
+			if bt == nil {
+				return nil
+			}
+			types = append(types, bt...)
+		}
+	}
+	return types
+}
+
+func checkRange(sc map[string]any, n *yaml.Node, path string) []Diagnostic {
+	if n.Kind != yaml.ScalarNode || (n.Tag != "!!int" && n.Tag != "!!float") {
+		return nil
+	}
+	v, err := strconv.ParseFloat(n.Value, 64)
+	if err != nil {
+		return nil
+	}
+	if min, ok := sc["minimum"].(float64); ok && v < min {
+		return []Diagnostic{diagnostic(n, path, "must be at least %v", min)}
+	}
+	if max, ok := sc["maximum"].(float64); ok && v > max {
+		return []Diagnostic{diagnostic(n, path, "must be at most %v", max)}
+	}
+	return nil
+}
+
+// schemaTypes normalizes a type keyword, which is a string or a list
+func schemaTypes(t any) []string {
+	switch v := t.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		var types []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func anyTypeMatches(types []string, n *yaml.Node) bool {
+	for _, t := range types {
+		if typeMatches(t, n) {
+			return true
+		}
+	}
+	return false
+}
+
+// typeMatches reports whether a node has a JSON schema type. Strings that
+// become the expected type after interpolation or type casting, such as
+// "${PORT}" or a quoted "8080", are accepted the way docker compose does.
+func typeMatches(t string, n *yaml.Node) bool {
+	switch t {
+	case "object":
+		return n.Kind == yaml.MappingNode
+	case "array":
+		return n.Kind == yaml.SequenceNode
+	}
+	if n.Kind != yaml.ScalarNode {
+		return false
+	}
+
+	kind := kindName(n)
+	interpolated := kind == "string" && strings.Contains(n.Value, "$")
+	switch t {
+	case "string":
+		return kind == "string"
+	case "null":
+		return kind == "null"
+	case "boolean":
+		if kind == "string" {
+			_, err := strconv.ParseBool(n.Value)
+			return err == nil || interpolated
+		}
+		return kind == "boolean"
+	case "integer":
+		if kind == "string" {
+			_, err := strconv.ParseInt(n.Value, 10, 64)
+			return err == nil || interpolated
+		}
+		return kind == "integer"
+	case "number":
+		if kind == "string" {
+			_, err := strconv.ParseFloat(n.Value, 64)
+			return err == nil || interpolated
+		}
+		return kind == "integer" || kind == "number"
+	}
+	return false
+}
+
+// kindName returns the JSON type name of a node
+func kindName(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+	switch n.Tag {
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!bool":
+		return "boolean"
+	case "!!null":
+		return "null"
+	}
+	return "string"
+}
+
+func inEnum(enum []any, value string) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == value {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEnum(enum []any) string {
+	values := make([]string, len(enum))
+	for i, e := range enum {
+		values[i] = fmt.Sprintf("%q", fmt.Sprint(e))
+	}
+	return strings.Join(values, ", ")
+}
+
+// joinOr formats distinct type names as "a, b or c"
+func joinOr(types []string) string {
+	var distinct []string
+	seen := make(map[string]bool)
+	for _, t := range types {
+		if !seen[t] {
+			seen[t] = true
+			distinct = append(distinct, t)
+		}
+	}
+	if len(distinct) <= 1 {
+		return strings.Join(distinct, "")
+	}
+	return strings.Join(distinct[:len(distinct)-1], ", ") + " or " + distinct[len(distinct)-1]
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,162 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateAcceptsValidFile(t *testing.T) {
+	content := `
+name: shop
+x-defaults: &defaults
+  restart: unless-stopped
+  logging:
+    driver: json-file
+services:
+  api:
+    <<: *defaults
+    image: api:1
+    ports: ["8080:80", 9090]
+    environment:
+      DEBUG: "false"
+      WORKERS: 4
+    healthcheck:
+      test: ["CMD", "curl", "-f", "http://localhost"]
+      retries: "${RETRIES}"
+    deploy:
+      replicas: 2
+    x-team: payments
+  worker:
+    build:
+      context: .
+      args: [VERSION=1]
+    depends_on:
+      api:
+        condition: service_healthy
+volumes:
+  data: {}
+networks:
+  default:
+    external: true
+`
+	diags, err := Default().Validate("docker-compose.yml", []byte(content))
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	for _, d := range diags {
+		t.Errorf("Unexpected diagnostic: %s", d)
+	}
+}
+
+func TestValidateReportsPositions(t *testing.T) {
+	content := `services:
+  api:
+    image: api:1
+    prots: ["80:80"]
+    restart: 3
+    depends_on:
+      db:
+        condition: sometimes
+    healthcheck:
+      retries: many
+volumes: []
+`
+	diags, err := Default().Validate("docker-compose.yml", []byte(content))
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	expected := []string{
+		`docker-compose.yml:4:5: services.api.prots: unknown key "prots"`,
+		`docker-compose.yml:5:14: services.api.restart: expected string, got integer`,
+		`docker-compose.yml:8:20: services.api.depends_on.db.condition: must be one of`,
+		`docker-compose.yml:10:16: services.api.healthcheck.retries: expected number, got string`,
+		`docker-compose.yml:11:10: volumes: expected object, got array`,
+	}
+	if len(diags) != len(expected) {
+		t.Fatalf("Expected %d diagnostics, got %d: %v", len(expected), len(diags), diags)
+	}
+	for i, want := range expected {
+		if got := diags[i].String(); !strings.HasPrefix(got, want) {
+			t.Errorf("Diagnostic %d: expected %q, got %q", i, want, got)
+		}
+	}
+}
+
+func TestValidateAlternatives(t *testing.T) {
+	content := `services:
+  api:
+    image: api:1
+    command: 42
+    build:
+      context: .
+      bogus: true
+`
+	diags, err := Default().Validate("docker-compose.yml", []byte(content))
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if len(diags) != 2 {
+		t.Fatalf("Expected 2 diagnostics, got %v", diags)
+	}
+
+	// build is a string or an object: the object branch's error is the useful one
+	if diags[0].Path != "services.api.command" || !strings.Contains(diags[0].Message, "got integer") {
+		t.Errorf("Unexpected command diagnostic: %s", diags[0])
+	}
+	if diags[1].Path != "services.api.build.bogus" || diags[1].Message != `unknown key "bogus"` {
+		t.Errorf("Unexpected build diagnostic: %s", diags[1])
+	}
+}
+
+func TestValidateHealthDependencies(t *testing.T) {
+	content := `services:
+  api:
+    image: api:1
+    depends_on:
+      db:
+        condition: service_healthy
+      cache:
+        condition: service_healthy
+      queue:
+        condition: service_started
+  db:
+    image: postgres
+  cache:
+    image: redis
+    healthcheck:
+      test: ["CMD", "redis-cli", "ping"]
+  queue:
+    image: rabbitmq
+  worker:
+    image: api:1
+    depends_on:
+      disabled:
+        condition: service_healthy
+  disabled:
+    image: x
+    healthcheck:
+      test: ["NONE"]
+`
+	diags, err := Default().Validate("docker-compose.yml", []byte(content))
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if len(diags) != 2 {
+		t.Fatalf("Expected 2 warnings, got %v", diags)
+	}
+	for i, path := range []string{"services.api.depends_on.db.condition", "services.worker.depends_on.disabled.condition"} {
+		if !diags[i].Warning || diags[i].Path != path {
+			t.Errorf("Expected a warning at %s, got %s", path, diags[i])
+		}
+	}
+	if got := diags[0].String(); !strings.HasPrefix(got, "docker-compose.yml:6:20: warning: services.api.depends_on.db.condition: waits for db") {
+		t.Errorf("Unexpected warning %q", got)
+	}
+}
+
+func TestValidateInvalidYAML(t *testing.T) {
+	if _, err := Default().Validate("docker-compose.yml", []byte("services: [")); err == nil {
+		t.Error("Expected an error for malformed YAML")
+	}
+}
@@ -0,0 +1,249 @@
+// Package workspace diffs several compose projects together, for stacks that
+// share external networks and volumes, and finds changes in one project that
+// break another.
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/stackgen-cli/compose-diff/internal/diff"
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile is the workspace file used when none is given
+const DefaultFile = "compose-workspace.yaml"
+
+// CheckSharedResource is the Finding.Check of changes to a network or volume
+// that another project of the workspace uses
+const CheckSharedResource = "shared-resource"
+
+// Project is one compose project of a workspace, compared old to new
+type Project struct {
+	Name string `yaml:"name"` // compose project name; default the directory of new
+	Old  string `yaml:"old"`
+	New  string `yaml:"new"`
+}
+
+// Workspace lists the projects that are deployed to the same Docker host
+type Workspace struct {
+	Projects []Project `yaml:"projects"`
+}
+
+// Load reads a workspace file. Relative paths are relative to the file.
+func Load(path string) (*Workspace, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var ws Workspace
+	if err := yaml.Unmarshal(data, &ws); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(ws.Projects) == 0 {
+		return nil, fmt.Errorf("%s: no projects", path)
+	}
+
+	dir := filepath.Dir(path)
+	seen := make(map[string]bool)
+	for i := range ws.Projects {
+		p := &ws.Projects[i]
+		if p.Old == "" || p.New == "" {
+			return nil, fmt.Errorf("%s: project %d needs old and new", path, i+1)
+		}
+		if !filepath.IsAbs(p.Old) {
+			p.Old = filepath.Join(dir, p.Old)
+		}
+		if !filepath.IsAbs(p.New) {
+			p.New = filepath.Join(dir, p.New)
+		}
+		if p.Name == "" {
+			p.Name = defaultProjectName(p.New)
+		}
+		p.Name = strings.ToLower(p.Name)
+		if seen[p.Name] {
+			return nil, fmt.Errorf("%s: project %s is listed twice", path, p.Name)
+		}
+		seen[p.Name] = true
+	}
+	return &ws, nil
+}
+
+// defaultProjectName is the name docker compose gives a project: the
+// directory of its compose file
+func defaultProjectName(path string) string {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return filepath.Base(path)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return filepath.Base(filepath.Dir(path))
+}
+
+// resource is a volume or network as Docker knows it, by its name on the
+// host rather than its key in a compose file
+type resource struct {
+	scope models.Scope
+	name  string
+}
+
+func (r resource) String() string {
+	return fmt.Sprintf("%s %s", r.scope, r.name)
+}
+
+// created maps the volumes and networks a project creates to their paths.
+// Docker names them project_key unless they set a name.
+func created(project string, ir *models.ComposeIR) map[resource]string {
+	out := make(map[resource]string)
+	if ir == nil {
+		return out
+	}
+	for key, vol := range ir.Volumes {
+		if !vol.External {
+			out[resource{models.ScopeVolume, hostName(project, key, vol.Name)}] = "volumes." + key
+		}
+	}
+	for key, net := range ir.Networks {
+		if !net.External {
+			out[resource{models.ScopeNetwork, hostName(project, key, net.Name)}] = "networks." + key
+		}
+	}
+	return out
+}
+
+// external maps the external volumes and networks a project uses to their
+// paths. Docker looks them up by name, or by key if they set none.
+func external(ir *models.ComposeIR) map[resource]string {
+	out := make(map[resource]string)
+	if ir == nil {
+		return out
+	}
+	for key, vol := range ir.Volumes {
+		if vol.External {
+			out[resource{models.ScopeVolume, valueOr(vol.Name, key)}] = "volumes." + key
+		}
+	}
+	for key, net := range ir.Networks {
+		if net.External {
+			out[resource{models.ScopeNetwork, valueOr(net.Name, key)}] = "networks." + key
+		}
+	}
+	return out
+}
+
+func hostName(project, key, name string) string {
+	if name != "" {
+		return name
+	}
+	return project + "_" + key
+}
+
+func valueOr(s, fallback string) string {
+	if s != "" {
+		return s
+	}
+	return fallback
+}
+
+// Check finds changes that break the workspace as a whole: a project that
+// no longer creates a volume or network another project uses as external,
+// which is breaking, and a project that starts using an external one no
+// project creates, which is a warning since it may be created by hand. old
+// and new hold each project's IR by name; paths are prefixed with the
+// project, as in app:networks.shared.
+func Check(old, new map[string]*models.ComposeIR) []models.Finding {
+	names := make([]string, 0, len(new))
+	for name := range new {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	createdNow := make(map[resource]bool)
+	for _, name := range names {
+		for r := range created(name, new[name]) {
+			createdNow[r] = true
+		}
+	}
+
+	var findings []models.Finding
+	for _, owner := range names {
+		createdBefore := created(owner, old[owner])
+		createdAfter := created(owner, new[owner])
+		for _, r := range sortedResources(createdBefore) {
+			if _, ok := createdAfter[r]; ok || createdNow[r] {
+				continue
+			}
+			for _, user := range names {
+				path, ok := external(new[user])[r]
+				if !ok || user == owner {
+					continue
+				}
+				findings = append(findings, models.Finding{
+					Check:    CheckSharedResource,
+					Severity: models.SeverityBreaking,
+					Message:  fmt.Sprintf("%s is no longer created by %s, but %s uses it as external", r, owner, user),
+					Paths:    []string{owner + ":" + createdBefore[r], user + ":" + path},
+				})
+			}
+		}
+	}
+
+	for _, user := range names {
+		before, after := external(old[user]), external(new[user])
+		for _, r := range sortedResources(after) {
+			if _, ok := before[r]; ok || createdNow[r] {
+				continue
+			}
+			findings = append(findings, models.Finding{
+				Check:    CheckSharedResource,
+				Severity: models.SeverityWarning,
+				Message:  fmt.Sprintf("%s now uses external %s, which no project in the workspace creates", user, r),
+				Paths:    []string{user + ":" + after[r]},
+			})
+		}
+	}
+	return findings
+}
+
+// Merge combines the projects' reports, by project name, and the workspace
+// findings into one report for the whole workspace. A change two projects
+// make alike, e.g. the same image bump, is listed once with both projects as
+// its sources.
+func Merge(reports map[string]*models.DiffReport, findings []models.Finding) *models.DiffReport {
+	merged := diff.MergeReports(reports)
+	merged.Findings = findings
+	return merged
+}
+
+// Breaking reports whether a merged workspace report has a breaking change
+// or finding
+func Breaking(report *models.DiffReport) bool {
+	if report.Summary.BreakingCount > 0 {
+		return true
+	}
+	for _, f := range report.Findings {
+		if f.Severity == models.SeverityBreaking {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedResources(m map[resource]string) []resource {
+	out := make([]resource, 0, len(m))
+	for r := range m {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].scope != out[j].scope {
+			return out[i].scope < out[j].scope
+		}
+		return out[i].name < out[j].name
+	})
+	return out
+}
@@ -0,0 +1,104 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, DefaultFile)
+	content := `
+projects:
+  - old: edge/prod.yml
+    new: edge/docker-compose.yml
+  - name: App
+    old: /srv/app/prod.yml
+    new: app/docker-compose.yml
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ws, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	edge, app := ws.Projects[0], ws.Projects[1]
+	if edge.Name != "edge" || edge.Old != filepath.Join(dir, "edge/prod.yml") {
+		t.Errorf("Unexpected first project: %+v", edge)
+	}
+	if app.Name != "app" || app.Old != "/srv/app/prod.yml" {
+		t.Errorf("Unexpected second project: %+v", app)
+	}
+
+	os.WriteFile(path, []byte("projects:\n  - {old: a.yml, new: x/a.yml}\n  - {old: b.yml, new: x/b.yml}\n"), 0644)
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "twice") {
+		t.Errorf("Expected a duplicate project error, got %v", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	old := map[string]*models.ComposeIR{
+		"edge": {Networks: map[string]models.NetworkIR{"public": {Name: "shared"}}},
+		"app": {
+			Networks: map[string]models.NetworkIR{"public": {External: true, Name: "shared"}},
+			Volumes:  map[string]models.VolumeIR{"data": {}},
+		},
+	}
+	new := map[string]*models.ComposeIR{
+		"edge": {},
+		"app": {
+			Networks: map[string]models.NetworkIR{"public": {External: true, Name: "shared"}},
+			Volumes:  map[string]models.VolumeIR{"data": {}, "certs": {External: true}},
+		},
+	}
+
+	var got []string
+	for _, f := range Check(old, new) {
+		got = append(got, string(f.Severity)+" "+f.Message+" "+strings.Join(f.Paths, ","))
+	}
+	want := []string{
+		"breaking network shared is no longer created by edge, but app uses it as external edge:networks.public,app:networks.public",
+		"warning app now uses external volume certs, which no project in the workspace creates app:volumes.certs",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected findings:\n%s", strings.Join(got, "\n"))
+	}
+
+	// A network moved to another project of the workspace is still created
+	new["edge"] = &models.ComposeIR{Networks: map[string]models.NetworkIR{}}
+	new["app"].Networks["public"] = models.NetworkIR{Name: "shared"}
+	new["app"].Volumes = map[string]models.VolumeIR{"data": {}}
+	if findings := Check(old, new); len(findings) != 0 {
+		t.Errorf("Expected no findings, got %v", findings)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	bump := models.Change{Kind: models.ChangeModified, Scope: models.ScopeService, Name: "api", Path: "services.api.image", Before: "app:1", After: "app:2", Severity: models.SeverityInfo}
+	edge, shop := models.NewDiffReport(), models.NewDiffReport()
+	edge.AddChange(bump)
+	shop.AddChange(bump)
+	shop.AddChange(models.Change{Kind: models.ChangeModified, Scope: models.ScopeService, Name: "api", Path: "services.api.user", Before: "app", After: "root", Severity: models.SeverityWarning})
+
+	merged := Merge(map[string]*models.DiffReport{"edge": edge, "shop": shop}, nil)
+	if merged.Summary.TotalChanges != 2 || merged.Summary.WarningCount != 1 {
+		t.Errorf("Unexpected summary: %+v", merged.Summary)
+	}
+	if got := strings.Join(merged.Changes[0].Sources, ","); got != "edge,shop" {
+		t.Errorf("Expected the shared change from edge and shop, got %s", got)
+	}
+	if Breaking(merged) {
+		t.Error("Expected no breaking changes")
+	}
+
+	merged = Merge(map[string]*models.DiffReport{"edge": edge}, []models.Finding{{Check: CheckSharedResource, Severity: models.SeverityBreaking}})
+	if !Breaking(merged) {
+		t.Error("Expected a breaking finding to break the workspace")
+	}
+}
@@ -0,0 +1,173 @@
+// Package composediff is the embeddable API of compose-diff: load compose
+// files into the canonical IR and compare them with the same engine the CLI
+// uses. Everything that changes how a comparison behaves is set through
+// Options rather than global state.
+package composediff
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+
+	"github.com/stackgen-cli/compose-diff/internal/diff"
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/internal/parser"
+)
+
+// Types shared with the engine
+type (
+	ComposeIR  = models.ComposeIR
+	ServiceIR  = models.ServiceIR
+	Change     = models.Change
+	Report     = models.DiffReport
+	Severity   = models.Severity
+	Diagnostic = models.Diagnostic
+)
+
+// Severity levels
+const (
+	SeverityInfo     = models.SeverityInfo
+	SeverityWarning  = models.SeverityWarning
+	SeverityBreaking = models.SeverityBreaking
+)
+
+// Comparator reports extra changes for a service present in both files,
+// for fields or conventions the built-in comparison does not cover
+type Comparator func(name string, old, new *ServiceIR) []Change
+
+// Options configures loading and comparison. The zero value compares the IRs
+// exactly as given.
+type Options struct {
+	// IgnoreOrdering sorts list fields first so reordering is not a change,
+	// and spells equivalent values one way (30s and 0.5m, a shell-form
+	// command and the same argv as a list)
+	IgnoreOrdering bool
+	// OrderedLists are the list fields IgnoreOrdering keeps in order, so
+	// reordering them is a change (see parser.ListFields); nil means command,
+	// entrypoint, env_file and dns_search
+	OrderedLists []string
+	// ExpandEnvFiles merges env_file contents into the environment when loading
+	ExpandEnvFiles bool
+	// Profiles limits both sides to services active under these profiles
+	Profiles []string
+	// WarnLabelNamespaces reports label changes under these namespaces
+	// (e.g. "traefik.*") as warning instead of info
+	WarnLabelNamespaces []string
+	// SeverityResolver returns the severity for a change; c.Severity holds the
+	// built-in heuristic's verdict
+	SeverityResolver func(c Change) Severity
+	// Comparators run after the built-in comparison
+	Comparators []Comparator
+	// Redactor rewrites a change before it is reported, e.g. to mask secrets
+	Redactor func(c Change) Change
+}
+
+// LoadFile parses a compose file (or a directory containing one) into the IR
+func LoadFile(path string, opts Options) (*ComposeIR, error) {
+	ir, err := parser.ParseComposeFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return loaded(ir, path, opts)
+}
+
+// LoadFileLenient is LoadFile for files that may be malformed, such as
+// machine-generated ones. Entries that cannot be read are left out of the IR
+// and returned as diagnostics; attach them to the report's Diagnostics so
+// readers know what was not compared.
+func LoadFileLenient(path string, opts Options) (*ComposeIR, []Diagnostic, error) {
+	ir, diags, err := parser.ParseComposeFileLenient(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	ir, err = loaded(ir, path, opts)
+	return ir, diags, err
+}
+
+// loaded applies the load-time options to a parsed IR
+func loaded(ir *ComposeIR, path string, opts Options) (*ComposeIR, error) {
+	if !opts.ExpandEnvFiles {
+		return ir, nil
+	}
+
+	composePath, err := parser.ResolveComposePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return parser.ExpandEnvFiles(ir, filepath.Dir(composePath))
+}
+
+// Compare computes the differences between two IRs. The inputs are not modified.
+func Compare(old, new *ComposeIR, opts Options) *Report {
+	report := models.NewDiffReport()
+	_ = CompareStream(context.Background(), old, new, opts, func(c Change) error {
+		report.AddChange(c)
+		return nil
+	})
+	diff.SummarizeEntities(report)
+	return report
+}
+
+// CompareStream calls fn with each change as soon as it is found, after the
+// severity resolver and redactor have been applied. Changes from custom
+// comparators follow the built-in ones. It stops at the first error from fn
+// or when ctx is done and returns that error, so callers can bail out once a
+// policy has already failed.
+func CompareStream(ctx context.Context, old, new *ComposeIR, opts Options, fn func(Change) error) error {
+	if opts.Profiles != nil {
+		old = parser.FilterProfiles(old, opts.Profiles)
+		new = parser.FilterProfiles(new, opts.Profiles)
+	}
+	if opts.IgnoreOrdering {
+		ordered := opts.OrderedLists
+		if ordered == nil {
+			ordered = parser.DefaultOrderedLists
+		}
+		old = parser.NormalizeOrdered(old, ordered)
+		new = parser.NormalizeOrdered(new, ordered)
+	}
+
+	emit := func(c Change) error {
+		if len(opts.WarnLabelNamespaces) > 0 {
+			c.Severity = diff.LabelNamespaceSeverity(c, opts.WarnLabelNamespaces)
+		}
+		if opts.SeverityResolver != nil {
+			c.Severity = opts.SeverityResolver(c)
+		}
+		if opts.Redactor != nil {
+			c = opts.Redactor(c)
+		}
+		return fn(c)
+	}
+
+	if err := diff.CompareStream(ctx, old, new, emit); err != nil {
+		return err
+	}
+	if len(opts.Comparators) == 0 {
+		return nil
+	}
+
+	var common []string
+	for name := range old.Services {
+		if _, ok := new.Services[name]; ok {
+			common = append(common, name)
+		}
+	}
+	sort.Strings(common)
+
+	for _, compare := range opts.Comparators {
+		for _, name := range common {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			oldSvc, newSvc := old.Services[name], new.Services[name]
+			for _, c := range compare(name, &oldSvc, &newSvc) {
+				diff.AttachLines(&c, old, new)
+				if err := emit(c); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package composediff
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLoadFileExpandEnvFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "base.env", "# shared\nLOG_LEVEL=info\nREGION='eu-west-1'\nexport WORKERS=4 # per node\n")
+	writeFile(t, dir, "prod.env", "LOG_LEVEL=warn\n")
+	path := writeFile(t, dir, "docker-compose.yml", `
+services:
+  api:
+    image: api:1
+    env_file: [base.env, prod.env]
+    environment:
+      WORKERS: "8"
+`)
+
+	ir, err := LoadFile(path, Options{ExpandEnvFiles: true})
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+
+	env := ir.Services["api"].Env
+	expected := map[string]string{"LOG_LEVEL": "warn", "REGION": "eu-west-1", "WORKERS": "8"}
+	for key, want := range expected {
+		if got := env[key]; got == nil || *got != want {
+			t.Errorf("Expected %s=%s, got %v", key, want, got)
+		}
+	}
+
+	if ir, err = LoadFile(path, Options{}); err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if _, ok := ir.Services["api"].Env["LOG_LEVEL"]; ok {
+		t.Error("Expected env_file to be left unread without ExpandEnvFiles")
+	}
+}
+
+func TestLoadFileLenient(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "docker-compose.yml", `
+services:
+  api:
+    image: api:1
+    ports: {http: 80}
+`)
+
+	if _, err := LoadFile(path, Options{}); err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	ir, diags, err := LoadFileLenient(path, Options{})
+	if err != nil {
+		t.Fatalf("LoadFileLenient failed: %v", err)
+	}
+	if ir.Services["api"].Image == nil || len(diags) != 1 || diags[0].Path != "services.api.ports" || diags[0].Line != 5 {
+		t.Errorf("Expected api with a diagnostic for its ports, got %+v %v", ir.Services["api"], diags)
+	}
+}
+
+func TestCompareOptions(t *testing.T) {
+	dir := t.TempDir()
+	oldIR, err := LoadFile(writeFile(t, dir, "old.yml", `
+services:
+  api:
+    image: api:1
+    environment: {DB_PASSWORD: hunter2}
+  debug:
+    image: debug:1
+    profiles: [dev]
+`), Options{})
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	newIR, err := LoadFile(writeFile(t, dir, "new.yml", `
+services:
+  api:
+    image: api:2
+    environment: {DB_PASSWORD: swordfish}
+`), Options{})
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+
+	opts := Options{
+		Profiles: []string{"prod"},
+		SeverityResolver: func(c Change) Severity {
+			if strings.HasSuffix(c.Path, ".image.tag") {
+				return SeverityBreaking
+			}
+			return c.Severity
+		},
+		Comparators: []Comparator{func(name string, old, new *ServiceIR) []Change {
+			return []Change{{Kind: "modified", Scope: "service", Name: name, Path: "services." + name + ".x-custom", Severity: SeverityInfo}}
+		}},
+		Redactor: func(c Change) Change {
+			if strings.Contains(c.Path, "PASSWORD") {
+				c.Before, c.After = "***", "***"
+			}
+			return c
+		},
+	}
+	report := Compare(oldIR, newIR, opts)
+
+	// The dev-only service is out of scope, so its removal is not reported
+	paths := make(map[string]Change)
+	for _, c := range report.Changes {
+		paths[c.Path] = c
+	}
+	if _, ok := paths["services.debug"]; ok {
+		t.Error("Expected services outside the enabled profiles to be skipped")
+	}
+	if c := paths["services.api.image.tag"]; c.Severity != SeverityBreaking {
+		t.Errorf("Expected resolver to make image change breaking, got %s", c.Severity)
+	}
+	if c := paths["services.api.image.tag"]; c.OldLine != 4 || c.NewLine != 4 {
+		t.Errorf("Expected image change on line 4 of both files, got %d/%d", c.OldLine, c.NewLine)
+	}
+	if c := paths["services.api.environment.DB_PASSWORD"]; c.After != "***" {
+		t.Errorf("Expected redacted value, got %v", c.After)
+	}
+	if _, ok := paths["services.api.x-custom"]; !ok {
+		t.Error("Expected custom comparator change")
+	}
+	if report.Summary.TotalChanges != len(report.Changes) || report.Summary.ServicesChanged != 1 {
+		t.Errorf("Unexpected summary: %+v", report.Summary)
+	}
+
+	if old := oldIR.Services["api"].Env["DB_PASSWORD"]; old == nil || *old != "hunter2" {
+		t.Error("Expected Compare to leave its inputs untouched")
+	}
+}
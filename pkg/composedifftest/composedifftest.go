@@ -0,0 +1,204 @@
+// Package composedifftest helps application repositories test their compose
+// files with the compose-diff engine, e.g. that the diff between two fixtures
+// has no breaking changes, or still matches a reviewed golden report:
+//
+//	func TestComposeUpgrade(t *testing.T) {
+//		report := composedifftest.Diff(t, "testdata/prod.yml", "docker-compose.yml", composediff.Options{IgnoreOrdering: true})
+//		composedifftest.AssertNoBreaking(t, report)
+//		composedifftest.AssertGolden(t, report, "testdata/upgrade.golden")
+//	}
+//
+// Golden files are rewritten instead of compared when the UpdateEnv
+// environment variable is set, e.g. COMPOSEDIFF_UPDATE_GOLDEN=1 go test ./...
+package composedifftest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/internal/models"
+	"github.com/stackgen-cli/compose-diff/pkg/composediff"
+)
+
+// UpdateEnv is the environment variable that makes AssertGolden write the
+// golden file instead of comparing against it
+const UpdateEnv = "COMPOSEDIFF_UPDATE_GOLDEN"
+
+// Diff loads two compose files and compares them, failing the test if
+// either cannot be loaded
+func Diff(t testing.TB, oldPath, newPath string, opts composediff.Options) *composediff.Report {
+	t.Helper()
+	oldIR, err := composediff.LoadFile(oldPath, opts)
+	if err != nil {
+		t.Fatalf("composedifftest: loading %s: %v", oldPath, err)
+		return nil
+	}
+	newIR, err := composediff.LoadFile(newPath, opts)
+	if err != nil {
+		t.Fatalf("composedifftest: loading %s: %v", newPath, err)
+		return nil
+	}
+	return composediff.Compare(oldIR, newIR, opts)
+}
+
+// AssertNoChanges fails the test if the report has any change
+func AssertNoChanges(t testing.TB, report *composediff.Report) {
+	t.Helper()
+	if len(report.Changes) > 0 {
+		t.Errorf("expected no changes, got %d:\n%s", len(report.Changes), Lines(report.Changes))
+	}
+}
+
+// AssertNoBreaking fails the test if the report has a breaking change
+func AssertNoBreaking(t testing.TB, report *composediff.Report) {
+	t.Helper()
+	AssertMaxSeverity(t, report, composediff.SeverityWarning)
+}
+
+// AssertMaxSeverity fails the test if any change is more severe than max
+func AssertMaxSeverity(t testing.TB, report *composediff.Report, max composediff.Severity) {
+	t.Helper()
+	var over []composediff.Change
+	for _, c := range report.Changes {
+		if models.SeverityLevel(c.Severity) > models.SeverityLevel(max) {
+			over = append(over, c)
+		}
+	}
+	if len(over) > 0 {
+		t.Errorf("expected no changes above %s, got %d:\n%s", max, len(over), Lines(over))
+	}
+}
+
+// AssertChanged fails the test unless a change's path matches pattern, a
+// path.Match glob such as services.api.environment.*
+func AssertChanged(t testing.TB, report *composediff.Report, pattern string) {
+	t.Helper()
+	if len(Matching(report, pattern)) == 0 {
+		t.Errorf("expected a change matching %s, got:\n%s", pattern, Lines(report.Changes))
+	}
+}
+
+// AssertNotChanged fails the test if a change's path matches pattern
+func AssertNotChanged(t testing.TB, report *composediff.Report, pattern string) {
+	t.Helper()
+	if matched := Matching(report, pattern); len(matched) > 0 {
+		t.Errorf("expected no change matching %s, got:\n%s", pattern, Lines(matched))
+	}
+}
+
+// Matching returns the changes whose path matches a path.Match glob
+func Matching(report *composediff.Report, pattern string) []composediff.Change {
+	var matched []composediff.Change
+	for _, c := range report.Changes {
+		if ok, _ := path.Match(pattern, c.Path); ok {
+			matched = append(matched, c)
+		}
+	}
+	return matched
+}
+
+// AssertGolden compares the report's changes, one line each, with a golden
+// file. With UpdateEnv set the file is written instead.
+func AssertGolden(t testing.TB, report *composediff.Report, goldenPath string) {
+	t.Helper()
+	got := Lines(report.Changes)
+
+	if os.Getenv(UpdateEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(goldenPath), 0755); err != nil {
+			t.Fatalf("composedifftest: %v", err)
+			return
+		}
+		if err := os.WriteFile(goldenPath, []byte(got), 0644); err != nil {
+			t.Fatalf("composedifftest: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("composedifftest: %v (set %s=1 to create it)", err, UpdateEnv)
+		return
+	}
+	if got == string(want) {
+		return
+	}
+	t.Errorf("changes differ from %s (set %s=1 to update it):\n%s", goldenPath, UpdateEnv, lineDiff(string(want), got))
+}
+
+// Lines renders changes as sorted lines of severity, kind, path and values,
+// the format of golden files. Whole services, volumes and networks show
+// only their path.
+func Lines(changes []composediff.Change) string {
+	lines := make([]string, 0, len(changes))
+	for _, c := range changes {
+		line := string(c.Severity) + " " + string(c.Kind) + " " + c.Path
+		if c.Path != string(c.Scope)+"s."+c.Name || c.Kind == models.ChangeOrphaned {
+			switch c.Kind {
+			case models.ChangeAdded:
+				line += " = " + value(c.After)
+			case models.ChangeRemoved:
+				line += " (was " + value(c.Before) + ")"
+			case models.ChangeRenamed:
+				line += " (renamed from " + value(c.Before) + ")"
+			case models.ChangeDangling, models.ChangeCycle:
+				line += ": " + value(c.After)
+			case models.ChangeOrphaned:
+				line += " (was used by " + value(c.Before) + ")"
+			default:
+				line += ": " + value(c.Before) + " → " + value(c.After)
+			}
+		}
+		lines = append(lines, line)
+	}
+	sort.Strings(lines)
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// value renders strings as they are and anything else as compact JSON
+func value(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// lineDiff lists the lines only in want (-) or only in got (+)
+func lineDiff(want, got string) string {
+	count := func(s string) map[string]int {
+		m := make(map[string]int)
+		for _, line := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+			if line != "" {
+				m[line]++
+			}
+		}
+		return m
+	}
+	wantLines, gotLines := count(want), count(got)
+
+	var out []string
+	for line, n := range wantLines {
+		for i := gotLines[line]; i < n; i++ {
+			out = append(out, "- "+line)
+		}
+	}
+	for line, n := range gotLines {
+		for i := wantLines[line]; i < n; i++ {
+			out = append(out, "+ "+line)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i][2:] < out[j][2:] })
+	return strings.Join(out, "\n")
+}
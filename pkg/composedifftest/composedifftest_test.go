@@ -0,0 +1,84 @@
+package composedifftest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/compose-diff/pkg/composediff"
+)
+
+// recorder captures failures instead of failing the test
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+}
+
+func fixtures(t *testing.T) (oldPath, newPath string) {
+	dir := t.TempDir()
+	oldPath = filepath.Join(dir, "old.yml")
+	newPath = filepath.Join(dir, "new.yml")
+	os.WriteFile(oldPath, []byte("services:\n  api:\n    image: api:1\n    ports: [\"80:80\"]\n    environment:\n      DEBUG: \"1\"\n"), 0644)
+	os.WriteFile(newPath, []byte("services:\n  api:\n    image: api:1\n    environment:\n      DEBUG: \"0\"\n"), 0644)
+	return oldPath, newPath
+}
+
+func TestAssertions(t *testing.T) {
+	oldPath, newPath := fixtures(t)
+	report := Diff(t, oldPath, newPath, composediff.Options{})
+
+	r := &recorder{TB: t}
+	AssertChanged(r, report, "services.api.environment.*")
+	AssertNotChanged(r, report, "services.api.image")
+	AssertMaxSeverity(r, report, composediff.SeverityBreaking)
+	if len(r.errors) > 0 {
+		t.Fatalf("Expected assertions to pass, got %v", r.errors)
+	}
+
+	AssertNoBreaking(r, report)
+	AssertNoChanges(r, report)
+	AssertChanged(r, report, "services.db.*")
+	if len(r.errors) != 3 {
+		t.Fatalf("Expected 3 failures, got %v", r.errors)
+	}
+	if !strings.Contains(r.errors[0], "breaking removed services.api.ports") {
+		t.Errorf("Expected the failure to list the breaking change, got %s", r.errors[0])
+	}
+
+	r = &recorder{TB: t}
+	Diff(r, oldPath, filepath.Join(t.TempDir(), "missing.yml"), composediff.Options{})
+	if len(r.errors) != 1 {
+		t.Errorf("Expected a load failure, got %v", r.errors)
+	}
+}
+
+func TestAssertGolden(t *testing.T) {
+	oldPath, newPath := fixtures(t)
+	report := Diff(t, oldPath, newPath, composediff.Options{})
+	golden := filepath.Join(t.TempDir(), "testdata", "diff.golden")
+
+	t.Setenv(UpdateEnv, "1")
+	AssertGolden(t, report, golden)
+	t.Setenv(UpdateEnv, "")
+
+	AssertGolden(t, report, golden)
+
+	same := Diff(t, oldPath, oldPath, composediff.Options{})
+	r := &recorder{TB: t}
+	AssertGolden(r, same, golden)
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], "- breaking removed services.api.ports") {
+		t.Errorf("Expected the golden mismatch to show the missing lines, got %v", r.errors)
+	}
+}